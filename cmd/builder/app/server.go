@@ -30,6 +30,8 @@ func Run(logger *zap.Logger, shareVolume string) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", builder.Handler)
 	mux.HandleFunc("/version", builder.VersionHandler)
+	mux.HandleFunc("/cache", builder.CacheHandler)
+	mux.HandleFunc("/workspace", builder.WorkspaceHandler)
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
@@ -23,12 +23,14 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 
 	"contrib.go.opencensus.io/exporter/jaeger"
 	"go.opencensus.io/plugin/ochttp"
 	"go.opencensus.io/trace"
 	"go.uber.org/zap"
 
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/fetcher"
 )
 
@@ -62,6 +64,8 @@ func Run(logger *zap.Logger) {
 	specializePayload := flag.String("specialize-request", "", "JSON payload for specialize request")
 	secretDir := flag.String("secret-dir", "", "Path to shared secrets directory")
 	configDir := flag.String("cfgmap-dir", "", "Path to shared configmap directory")
+	fetchPayload := flag.String("fetch-request", "", "JSON payload for a fetch request of type url to run before exiting")
+	putPayload := flag.String("put-request", "", "JSON payload for a put request to run before exiting")
 
 	flag.Parse()
 	if flag.NArg() == 0 {
@@ -88,6 +92,13 @@ func Run(logger *zap.Logger) {
 		logger.Fatal("error making fetcher", zap.Error(err))
 	}
 
+	// build step pods run the fetcher to get the workspace of the step
+	// before it, and to put it back after, instead of serving requests
+	if len(*fetchPayload) > 0 || len(*putPayload) > 0 {
+		runOnce(logger, f, dir, *fetchPayload, *putPayload)
+		return
+	}
+
 	readyToServe := false
 
 	// do specialization in other goroutine to prevent blocking in newdeploy
@@ -139,6 +150,46 @@ func Run(logger *zap.Logger) {
 	})
 }
 
+// runOnce runs the fetch request, then the put request, if any, exiting on
+// the first failure.
+func runOnce(logger *zap.Logger, f *fetcher.Fetcher, dir string, fetchPayload string, putPayload string) {
+	ctx := context.Background()
+
+	if len(fetchPayload) > 0 {
+		var fetchReq fetcher.FunctionFetchRequest
+		err := json.Unmarshal([]byte(fetchPayload), &fetchReq)
+		if err != nil {
+			logger.Fatal("error decoding fetch request", zap.Error(err))
+		}
+		// there is no package to fetch archives of
+		if fetchReq.FetchType != fv1.FETCH_URL {
+			logger.Fatal("only fetch requests of type url can be run before exiting", zap.Any("fetch_type", fetchReq.FetchType))
+		}
+		_, err = f.Fetch(ctx, nil, fetchReq)
+		if err != nil {
+			logger.Fatal("error fetching", zap.Error(err))
+		}
+		// the workspace of a build step is a zip archive, unzipped into a
+		// directory, anything else is an error page
+		fi, err := os.Stat(filepath.Join(dir, fetchReq.Filename))
+		if err != nil || !fi.IsDir() {
+			logger.Fatal("fetched url is not a zip archive", zap.String("url", fetchReq.Url))
+		}
+	}
+
+	if len(putPayload) > 0 {
+		var putReq fetcher.ArchivePutRequest
+		err := json.Unmarshal([]byte(putPayload), &putReq)
+		if err != nil {
+			logger.Fatal("error decoding put request", zap.Error(err))
+		}
+		err = f.Put(ctx, putReq)
+		if err != nil {
+			logger.Fatal("error putting", zap.Error(err))
+		}
+	}
+}
+
 func fetcherUsage() {
-	fmt.Println("Usage: fetcher [-specialize-on-startup] [-specialize-request <json>] [-secret-dir <string>] [-cfgmap-dir <string>] <shared volume path>")
+	fmt.Println("Usage: fetcher [-specialize-on-startup] [-specialize-request <json>] [-fetch-request <json>] [-put-request <json>] [-secret-dir <string>] [-cfgmap-dir <string>] <shared volume path>")
 }
@@ -41,6 +41,7 @@ const (
 
 const (
 	BuildStatusPending   = "pending"
+	BuildStatusQueued    = "queued"
 	BuildStatusRunning   = "running"
 	BuildStatusSucceeded = "succeeded"
 	BuildStatusFailed    = "failed"
@@ -52,9 +53,17 @@ const (
 	AllowedFunctionsPerContainerInfinite = "infinite"
 )
 
+const (
+	PodSelectionRandom          PodSelectionStrategy = "random"
+	PodSelectionSpread          PodSelectionStrategy = "spread"
+	PodSelectionPackageAffinity PodSelectionStrategy = "package-affinity"
+	PodSelectionLeastLoaded     PodSelectionStrategy = "least-loaded"
+)
+
 const (
 	ExecutorTypePoolmgr   ExecutorType = "poolmgr"
 	ExecutorTypeNewdeploy ExecutorType = "newdeploy"
+	ExecutorTypeContainer ExecutorType = "container"
 )
 
 const (
@@ -87,6 +96,29 @@ const (
 	//   Set of function references (recursively), by percentage of traffic
 )
 
+const (
+	TriggerProtocolHTTP TriggerProtocol = "http"
+	TriggerProtocolH2C  TriggerProtocol = "h2c"
+)
+
+const (
+	RateLimitKeyTypeTrigger  RateLimitKeyType = "trigger"
+	RateLimitKeyTypeClientIP RateLimitKeyType = "clientip"
+	RateLimitKeyTypeHeader   RateLimitKeyType = "header"
+)
+
+const (
+	TriggerAuthTypeJWT    TriggerAuthType = "jwt"
+	TriggerAuthTypeAPIKey TriggerAuthType = "apikey"
+	TriggerAuthTypeHMAC   TriggerAuthType = "hmac"
+)
+
+const (
+	RoutingRuleMatchTypeHeader RoutingRuleMatchType = "header"
+	RoutingRuleMatchTypeCookie RoutingRuleMatchType = "cookie"
+	RoutingRuleMatchTypeQuery  RoutingRuleMatchType = "query"
+)
+
 const (
 	// failure type currently supported is http status code. This could be extended
 	// in the future.
@@ -125,7 +157,8 @@ const (
 )
 
 const (
-	ANNOTATION_SVC_HOST = "svcHost"
+	ANNOTATION_SVC_HOST        = "svcHost"
+	ANNOTATION_EXECUTOR_MEMBER = "executorMember"
 )
 
 const (
@@ -226,6 +226,66 @@ type (
 		Checksum Checksum `json:"checksum,omitempty"`
 	}
 
+	// BuildStep is a named step of a package build. Steps run in the environment
+	// builder, or in a pod of their own if they have an image, on the same source
+	// and deployment package directories.
+	BuildStep struct {
+		// Name of the step, unique among the build steps of a package.
+		Name string `json:"name"`
+
+		// (Optional) Image to run the step in instead of the environment builder.
+		// The step runs in a pod of its own, with the source and deployment packages
+		// of the build copied in before and back after the step. The command of the
+		// step is required, and cache directories are not supported.
+		Image string `json:"image,omitempty"`
+
+		// (Optional) Command to run for the step, with the same environment variables
+		// as a build command. Defaults to the build command of the package.
+		Command string `json:"command,omitempty"`
+
+		// (Optional) Args are the arguments of the command.
+		Args []string `json:"args,omitempty"`
+
+		// (Optional) Env is the additional environment variables of the command.
+		Env map[string]string `json:"env,omitempty"`
+
+		// (Optional) CacheDirs are directories relative to the source package, such as
+		// downloaded dependencies, kept by the environment builder across builds of the package.
+		// They are in the source package only while the step runs, copy their content into the
+		// deployment package if needed. They are deleted with the package.
+		CacheDirs []string `json:"cacheDirs,omitempty"`
+	}
+
+	// BuildStepStatus is the status and log of a build step.
+	BuildStepStatus struct {
+		// Name of the build step.
+		Name string `json:"name"`
+
+		// Status of the build step: pending, running, succeeded or failed.
+		Status BuildStatus `json:"status,omitempty"`
+
+		// Log is the output of the build step.
+		Log string `json:"log,omitempty"`
+
+		StartTimestamp      metav1.Time `json:"startTimestamp,omitempty"`
+		CompletionTimestamp metav1.Time `json:"completionTimestamp,omitempty"`
+	}
+
+	// PackageRevision is an immutable record of a successful package build.
+	PackageRevision struct {
+		// Revision number, increasing with each successful build of the package.
+		Revision int `json:"revision"`
+
+		// Deployment is the deployable archive built for the revision.
+		Deployment Archive `json:"deployment"`
+
+		// BuildLog is the end of the output of the build of the revision.
+		BuildLog string `json:"buildlog,omitempty"`
+
+		// Timestamp is the time the revision was built.
+		Timestamp metav1.Time `json:"timestamp,omitempty"`
+	}
+
 	// EnvironmentReference is a reference to a environment.
 	EnvironmentReference struct {
 		Namespace string `json:"namespace"`
@@ -263,6 +323,15 @@ type (
 		// BuildCommand is a custom build command that builder used to build the source archive.
 		BuildCommand string `json:"buildcmd,omitempty"`
 
+		// (Optional) BuildTimeout is the maximum duration in seconds a build of the package
+		// may run, overriding the build timeout of the environment builder.
+		BuildTimeout int `json:"buildTimeout,omitempty"`
+
+		// (Optional) BuildSteps are run in order by the environment builder, or in a pod
+		// of their own for steps with an image, instead of a single build command.
+		// A build stops at the first step failing.
+		BuildSteps []BuildStep `json:"buildSteps,omitempty"`
+
 		// In the future, we can have a debug build here too
 	}
 
@@ -277,6 +346,13 @@ type (
 		// BuildLog stores build log during the compilation.
 		BuildLog string `json:"buildlog,omitempty"` // output of the build (errors etc)
 
+		// Steps is the status and log of each build step, if the package has build steps.
+		Steps []BuildStepStatus `json:"steps,omitempty"`
+
+		// Revisions are the retained revisions of the package, oldest first.
+		// A function may be pinned to one of them with its package reference.
+		Revisions []PackageRevision `json:"revisions,omitempty"`
+
 		// LastUpdateTimestamp will store the timestamp the package was last updated
 		// metav1.Time is a wrapper around time.Time which supports correct marshaling to YAML and JSON.
 		// https://github.com/kubernetes/apimachinery/blob/44bd77c24ef93cd3a5eb6fef64e514025d10d44e/pkg/apis/meta/v1/time.go#L26-L35
@@ -291,6 +367,10 @@ type (
 		// Including resource version in the reference forces the function to be updated on
 		// package update, making it possible to cache the function based on its metadata.
 		ResourceVersion string `json:"resourceversion,omitempty"`
+
+		// (Optional) Revision pins the function to a revision of the package
+		// instead of its latest deployment archive.
+		Revision int `json:"revision,omitempty"`
 	}
 
 	// FunctionPackageRef includes the reference to the package also the entrypoint of package.
@@ -353,6 +433,36 @@ type (
 		// Maximum number of pods to be specialized which will serve requests
 		// This is optional. If not specified default value will be taken as 5
 		Concurrency int `json:"concurrency,omitempty"`
+
+		// (Optional) CircuitBreaker makes router fail fast with 503 when the function,
+		// or one of its service addresses, keeps failing, instead of retrying every request.
+		CircuitBreaker *CircuitBreaker `json:"circuitBreaker,omitempty"`
+
+		// PodSpec is the pod running the function, for executor type container only.
+		// The first container serves the function on its first container port
+		// (8888 if no port is given); Environment and Package are not used.
+		PodSpec *apiv1.PodSpec `json:"podspec,omitempty"`
+	}
+
+	// CircuitBreaker is the setting of the circuit breakers router keeps for a
+	// function and for each service address of it. A breaker opens after
+	// consecutive failures (network errors, 502, 503 or 504 responses), rejects
+	// requests while open, and lets a few trial requests through once the open
+	// duration passes (half-open). It closes again if all trial requests succeed.
+	CircuitBreaker struct {
+		// ConsecutiveFailures is the number of consecutive failures to open the breaker.
+		// (Optional) defaults to 5.
+		ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+
+		// OpenDuration is the time in seconds a breaker stays open before
+		// letting trial requests through.
+		// (Optional) defaults to 30 seconds.
+		OpenDuration int `json:"openDuration,omitempty"`
+
+		// HalfOpenRequests is the number of trial requests that must succeed
+		// to close a half-open breaker.
+		// (Optional) defaults to 1.
+		HalfOpenRequests int `json:"halfOpenRequests,omitempty"`
 	}
 
 	// InvokeStrategy is a set of controls over how the function executes.
@@ -392,6 +502,7 @@ type (
 		// Available value:
 		//  - poolmgr
 		//  - newdeploy
+		//  - container
 		ExecutorType ExecutorType
 
 		// This is only for newdeploy to set up minimum replicas of deployment.
@@ -403,8 +514,40 @@ type (
 		// This is only for newdeploy to set up target CPU utilization of HPA.
 		TargetCPUPercent int
 
+		// This is only for newdeploy to scale the deployment by the number
+		// of in-flight requests per pod reported by routers instead of by
+		// CPU utilization. No HPA is created if set.
+		TargetConcurrency int
+
+		// This is only for newdeploy to scale the deployment by the number
+		// of requests per second per pod reported by routers instead of by
+		// CPU utilization. No HPA is created if set.
+		TargetRPS int
+
 		// This is the timeout setting for executor to wait for pod specialization.
 		SpecializationTimeout int
+
+		// This is only for poolmgr to keep the number of specialized pods of the
+		// function at all times, so requests don't hit a cold start.
+		WarmPods int
+
+		// This is only for poolmgr to specialize pods of the function ahead of
+		// predictable traffic, see WarmupSchedule.
+		WarmupSchedules []WarmupSchedule
+	}
+
+	// WarmupSchedule keeps specialized pods of a poolmgr function during a
+	// time window that starts at each activation of the cron schedule.
+	WarmupSchedule struct {
+		// Cron schedule of the starts of the window, in the same format as
+		// TimeTriggerSpec.Cron, e.g. "0 0 8 * * 1-5".
+		Cron string
+
+		// Length of the window in seconds.
+		Duration int
+
+		// Number of specialized pods to keep during the window.
+		WarmPods int
 	}
 
 	FunctionReferenceType string
@@ -426,6 +569,47 @@ type (
 		// Function Reference by weight. this map contains function name as key and its weight
 		// as the value. This is for canary upgrade purpose.
 		FunctionWeights map[string]int `json:"functionweights"`
+
+		// (Optional) Rules route requests to a specific function of FunctionWeights
+		// by request headers, cookies or query parameters. Rules are evaluated in
+		// order and the first matched rule wins. Requests matching no rule are
+		// routed by StickySession if set, or by function weights otherwise.
+		// Only applies to HTTP triggers with "function-weights" reference type.
+		Rules []RoutingRule `json:"rules,omitempty"`
+
+		// (Optional) StickySession routes requests with the same header value to
+		// the same function, following the function weights.
+		// Only applies to HTTP triggers with "function-weights" reference type.
+		StickySession *StickySession `json:"stickySession,omitempty"`
+	}
+
+	// RoutingRuleMatchType is the part of request a routing rule looks into.
+	RoutingRuleMatchType string
+
+	// RoutingRule routes requests matching the rule to a function.
+	RoutingRule struct {
+		// Match is the part of request to match.
+		// Available value:
+		//  - header
+		//  - cookie
+		//  - query
+		Match RoutingRuleMatchType `json:"match"`
+
+		// Key is the name of header, cookie or query parameter.
+		Key string `json:"key"`
+
+		// (Optional) Value is the exact value to match. If empty, any
+		// request carrying the key matches the rule.
+		Value string `json:"value,omitempty"`
+
+		// FunctionName is the function to route matched requests to.
+		FunctionName string `json:"functionName"`
+	}
+
+	// StickySession is the setting of session affinity of canary routing.
+	StickySession struct {
+		// Header is the name of request header identifying a session, e.g. a user id.
+		Header string `json:"header"`
 	}
 
 	//
@@ -499,6 +683,11 @@ type (
 
 		// PodSpec will store the spec of the pod that will be applied to the pod created for the builder
 		PodSpec *apiv1.PodSpec `json:"podspec,omitempty"`
+
+		// (Optional) BuildTimeout is the maximum duration in seconds a package build may run.
+		// A build exceeding it is canceled and the package marked as failed.
+		// If not specified, the default build timeout of builder manager is used.
+		BuildTimeout int `json:"buildTimeout,omitempty"`
 	}
 
 	// EnvironmentSpec contains with builder, runtime and some other related environment settings.
@@ -555,10 +744,41 @@ type (
 		// ImagePullSecret is the secret for Kubernetes to pull an image from a
 		// private registry.
 		ImagePullSecret string `json:"imagepullsecret"`
+
+		// (Optional) PodSelection is the strategy for poolmanager to choose a pod
+		// from the pre-warm pool to specialize for a function.
+		// Available value:
+		// - random (default)
+		// - spread: spread the pods of a function across zones and nodes
+		// - package-affinity: prefer nodes that recently fetched the function package
+		// - least-loaded: prefer nodes running the fewest specialized pods of the pool
+		PodSelection PodSelectionStrategy `json:"podSelection,omitempty"`
+
+		// (Optional) Autoscale sizes the pre-warm pool by the recent rate of
+		// specializations instead of the fixed Poolsize, which is ignored
+		// when this is set.
+		Autoscale *PoolAutoscale `json:"autoscale,omitempty"`
 	}
 
 	AllowedFunctionsPerContainer string
 
+	// PodSelectionStrategy is the strategy to choose a pod from the pre-warm pool.
+	PodSelectionStrategy string
+
+	// PoolAutoscale is the setting for poolmanager to size the pre-warm pool.
+	// The pool is sized to hold the pods specialized in the last minute plus
+	// TargetFreePods, within MinPoolsize and MaxPoolsize.
+	PoolAutoscale struct {
+		// Minimum number of pods in the pool, must be greater than 0.
+		MinPoolsize int `json:"minPoolsize"`
+
+		// Maximum number of pods in the pool.
+		MaxPoolsize int `json:"maxPoolsize"`
+
+		// Number of pods to keep free on top of the recent specializations.
+		TargetFreePods int `json:"targetFreePods"`
+	}
+
 	//
 	// Triggers
 	//
@@ -585,6 +805,145 @@ type (
 		// TODO: make IngressConfig a independent Fission resource
 		// IngressConfig for router to set up Ingress.
 		IngressConfig IngressConfig `json:"ingressconfig"`
+
+		// (Optional) RateLimit limits the request rate and the number of
+		// in-flight requests that router forwards through this trigger.
+		RateLimit *RateLimit `json:"ratelimit,omitempty"`
+
+		// (Optional) Protocol is the protocol router uses to talk to the function pod.
+		// Available value:
+		//  - http (default): HTTP/1.1, request path is rewritten to "/"
+		//  - h2c: HTTP/2 over cleartext with the original request path, trailers
+		//    and streaming bodies kept intact. Use it to expose gRPC services.
+		Protocol TriggerProtocol `json:"protocol,omitempty"`
+
+		// (Optional) Streaming enables long-lived connections (WebSocket
+		// upgrades and server-sent events) through this trigger. Requests
+		// are not bound by function timeout and router keeps the function
+		// pod from being recycled until the connection closes.
+		Streaming *StreamingConfig `json:"streaming,omitempty"`
+
+		// (Optional) Mirror sends a copy of sampled requests to a shadow function.
+		// Responses of the shadow function are discarded and only recorded in metrics.
+		Mirror *MirrorConfig `json:"mirror,omitempty"`
+
+		// (Optional) Cache enables router to cache successful responses of GET
+		// requests. Only set it for functions returning the same response for
+		// the same request, e.g. pure lookups.
+		Cache *ResponseCache `json:"cache,omitempty"`
+
+		// (Optional) Auth makes router authenticate requests before
+		// invoking the function. Requests failing authentication are
+		// rejected with 401 or 403.
+		Auth *TriggerAuth `json:"auth,omitempty"`
+	}
+
+	// TriggerAuthType is the authentication method of an HTTP trigger.
+	TriggerAuthType string
+
+	// TriggerAuth is the authentication setting of an HTTP trigger.
+	// The credentials are kept in a Secret in the namespace of the trigger:
+	//  - jwt: the "jwks" key holds the JSON Web Key Set to verify tokens
+	//  - apikey: each key is a client name and its value is the API key of the client
+	//  - hmac: the "key" key holds the shared key to verify request signatures
+	//
+	// Verified identity is passed to the function in X-Fission-Auth-* headers.
+	// The internal route of the function requires the credentials of one of its
+	// triggers too, including for requests of other triggers such as timers.
+	TriggerAuth struct {
+		// Type is the authentication method.
+		// Available value:
+		//  - jwt
+		//  - apikey
+		//  - hmac
+		Type TriggerAuthType `json:"type"`
+
+		// SecretName is the name of the Secret holding the credentials.
+		SecretName string `json:"secretName"`
+
+		// (Optional) Header is the request header carrying the credential.
+		// Defaults to "Authorization" for jwt, "X-Api-Key" for apikey and
+		// "X-Fission-Signature" for hmac.
+		Header string `json:"header,omitempty"`
+
+		// (Optional) Issuer is the required "iss" claim of JWTs.
+		Issuer string `json:"issuer,omitempty"`
+
+		// (Optional) Audience is the required "aud" claim of JWTs.
+		Audience string `json:"audience,omitempty"`
+
+		// (Optional) Claims is the list of JWT claims passed to the function
+		// in X-Fission-Auth-Claim-* headers.
+		Claims []string `json:"claims,omitempty"`
+	}
+
+	// ResponseCache is the setting of response caching of an HTTP trigger.
+	// Cache-Control headers of both requests and responses are respected, e.g.
+	// a response with "Cache-Control: no-store" is never cached and max-age of
+	// a response shortens the TTL. Responses of triggers requiring
+	// authentication are never cached.
+	ResponseCache struct {
+		// TTL is the time in seconds a response is cached.
+		TTL int `json:"ttl"`
+
+		// (Optional) Headers is the list of request headers that are part of
+		// the cache key, e.g. "Accept-Language". Responses varying by other
+		// request headers, as listed in their Vary header, are not cached.
+		Headers []string `json:"headers,omitempty"`
+
+		// (Optional) QueryParams is the list of query parameters that are part
+		// of the cache key. If empty, the whole query string is part of the key.
+		QueryParams []string `json:"queryParams,omitempty"`
+	}
+
+	// MirrorConfig is the setting of traffic mirroring of an HTTP trigger.
+	MirrorConfig struct {
+		// FunctionName is the shadow function receiving mirrored requests.
+		// It must be in the same namespace as the trigger.
+		FunctionName string `json:"functionName"`
+
+		// Percentage of requests to mirror, from 1 to 100.
+		Percentage int `json:"percentage"`
+	}
+
+	// StreamingConfig is the setting of streaming connections of an HTTP trigger.
+	StreamingConfig struct {
+		// PingInterval is the interval in seconds for router to ping an idle
+		// connection, to prevent load balancers from closing it.
+		// (Optional) defaults to 30 seconds.
+		PingInterval int `json:"pingInterval,omitempty"`
+	}
+
+	// TriggerProtocol is the protocol between router and function pod.
+	TriggerProtocol string
+
+	// RateLimitKeyType decides which requests share the same rate limit bucket.
+	RateLimitKeyType string
+
+	// RateLimit is the request rate limit and concurrency quota of an HTTP trigger.
+	RateLimit struct {
+		// RequestsPerSecond is the sustained request rate allowed for each key.
+		// (Optional) defaults to 0, which disables request rate limiting.
+		RequestsPerSecond int `json:"requestsPerSecond,omitempty"`
+
+		// Burst is the maximum number of requests allowed at once for each key.
+		// (Optional) defaults to RequestsPerSecond.
+		Burst int `json:"burst,omitempty"`
+
+		// KeyType decides which requests share the same rate limit bucket.
+		// Available value:
+		//  - trigger (default)
+		//  - clientip
+		//  - header
+		KeyType RateLimitKeyType `json:"keyType,omitempty"`
+
+		// KeyHeader is the name of request header used as the rate limit key
+		// when KeyType is "header".
+		KeyHeader string `json:"keyHeader,omitempty"`
+
+		// MaxInFlight is the maximum number of concurrent requests of the trigger.
+		// (Optional) defaults to 0, which means no limitation.
+		MaxInFlight int `json:"maxInFlight,omitempty"`
 	}
 
 	// IngressConfig is for router to set up Ingress.
@@ -731,3 +1090,23 @@ type (
 func (a Archive) IsEmpty() bool {
 	return len(a.Literal) == 0 && len(a.URL) == 0
 }
+
+// GetRevision returns the retained revision of the package with the given
+// number, nil if there is none.
+func (status *PackageStatus) GetRevision(revision int) *PackageRevision {
+	for i := range status.Revisions {
+		if status.Revisions[i].Revision == revision {
+			return &status.Revisions[i]
+		}
+	}
+	return nil
+}
+
+// LatestRevision returns the number of the latest revision of the package,
+// 0 if it has none.
+func (status *PackageStatus) LatestRevision() int {
+	if len(status.Revisions) == 0 {
+		return 0
+	}
+	return status.Revisions[len(status.Revisions)-1].Revision
+}
@@ -19,6 +19,8 @@ package v1
 import (
 	"fmt"
 	"net/http"
+	"path"
+	"reflect"
 	"regexp"
 	"strings"
 
@@ -224,6 +226,51 @@ func (spec PackageSpec) Validate() error {
 		}
 	}
 
+	if spec.BuildTimeout < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "PackageSpec.BuildTimeout", spec.BuildTimeout, "build timeout must be greater than or equal to 0"))
+	}
+
+	names := make(map[string]bool)
+	for _, step := range spec.BuildSteps {
+		result = multierror.Append(result, step.Validate())
+		if names[step.Name] {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "PackageSpec.BuildSteps.Name", step.Name, "build step names must be unique"))
+		}
+		names[step.Name] = true
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (step BuildStep) Validate() error {
+	result := &multierror.Error{}
+
+	if len(step.Name) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BuildStep.Name", step.Name, "build step name must not be empty"))
+	}
+
+	for name := range step.Env {
+		if len(name) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BuildStep.Env", name, "environment variable name must not be empty"))
+		}
+	}
+
+	if len(step.Image) > 0 {
+		if len(step.Command) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BuildStep.Command", step.Command, "build step with an image must have a command"))
+		}
+		if len(step.CacheDirs) > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BuildStep.CacheDirs", step.CacheDirs, "build step with an image cannot have cache directories"))
+		}
+	}
+
+	for _, dir := range step.CacheDirs {
+		clean := path.Clean(dir)
+		if len(dir) == 0 || path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "BuildStep.CacheDirs", dir, "cache directory must be a sub directory of the source package"))
+		}
+	}
+
 	return result.ErrorOrNil()
 }
 
@@ -231,7 +278,7 @@ func (sts PackageStatus) Validate() error {
 	result := &multierror.Error{}
 
 	switch sts.BuildStatus {
-	case BuildStatusPending, BuildStatusRunning, BuildStatusSucceeded, BuildStatusFailed, BuildStatusNone: // no op
+	case BuildStatusPending, BuildStatusQueued, BuildStatusRunning, BuildStatusSucceeded, BuildStatusFailed, BuildStatusNone: // no op
 	default:
 		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "PackageStatus.BuildStatus", sts.BuildStatus, "not a valid build status"))
 	}
@@ -242,6 +289,9 @@ func (sts PackageStatus) Validate() error {
 func (ref PackageRef) Validate() error {
 	result := &multierror.Error{}
 	result = multierror.Append(result, ValidateKubeReference("PackageRef", ref.Name, ref.Namespace))
+	if ref.Revision < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "PackageRef.Revision", ref.Revision, "revision must be greater than or equal to 0"))
+	}
 	return result.ErrorOrNil()
 }
 
@@ -269,10 +319,24 @@ func (spec FunctionSpec) Validate() error {
 		result = multierror.Append(result, c.Validate())
 	}
 
-	if spec.InvokeStrategy != (InvokeStrategy{}) {
+	if !reflect.DeepEqual(spec.InvokeStrategy, InvokeStrategy{}) {
 		result = multierror.Append(result, spec.InvokeStrategy.Validate())
 	}
 
+	if spec.CircuitBreaker != nil {
+		result = multierror.Append(result, spec.CircuitBreaker.Validate())
+	}
+
+	if spec.InvokeStrategy.ExecutionStrategy.ExecutorType == ExecutorTypeContainer {
+		if spec.PodSpec == nil || len(spec.PodSpec.Containers) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidObject, "FunctionSpec.PodSpec", nil, "pod spec with at least one container is required for executor type container"))
+		} else if len(spec.PodSpec.Containers[0].Image) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionSpec.PodSpec.Containers.Image", "", "image of the function container is required"))
+		}
+	} else if spec.PodSpec != nil {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionSpec.PodSpec", spec.InvokeStrategy.ExecutionStrategy.ExecutorType, "pod spec is only supported by executor type container"))
+	}
+
 	// TODO Add below validation warning
 	/*if spec.FunctionTimeout <= 0 {
 		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionTimeout value", spec.FunctionTimeout, "not a valid value. Should always be more than 0"))
@@ -281,6 +345,24 @@ func (spec FunctionSpec) Validate() error {
 	return result.ErrorOrNil()
 }
 
+func (cb CircuitBreaker) Validate() error {
+	result := &multierror.Error{}
+
+	if cb.ConsecutiveFailures < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionSpec.CircuitBreaker.ConsecutiveFailures", cb.ConsecutiveFailures, "must be greater than or equal to 0"))
+	}
+
+	if cb.OpenDuration < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionSpec.CircuitBreaker.OpenDuration", cb.OpenDuration, "must be greater than or equal to 0"))
+	}
+
+	if cb.HalfOpenRequests < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionSpec.CircuitBreaker.HalfOpenRequests", cb.HalfOpenRequests, "must be greater than or equal to 0"))
+	}
+
+	return result.ErrorOrNil()
+}
+
 func (is InvokeStrategy) Validate() error {
 	result := &multierror.Error{}
 
@@ -299,12 +381,12 @@ func (es ExecutionStrategy) Validate() error {
 	result := &multierror.Error{}
 
 	switch es.ExecutorType {
-	case ExecutorTypeNewdeploy, ExecutorTypePoolmgr: // no op
+	case ExecutorTypeNewdeploy, ExecutorTypePoolmgr, ExecutorTypeContainer: // no op
 	default:
 		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "ExecutionStrategy.ExecutorType", es.ExecutorType, "not a valid executor type"))
 	}
 
-	if es.ExecutorType == ExecutorTypeNewdeploy {
+	if es.ExecutorType == ExecutorTypeNewdeploy || es.ExecutorType == ExecutorTypeContainer {
 		if es.MinScale < 0 {
 			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.MinScale", es.MinScale, "minimum scale must be greater than or equal to 0"))
 		}
@@ -321,12 +403,58 @@ func (es ExecutionStrategy) Validate() error {
 			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.TargetCPUPercent", es.TargetCPUPercent, "TargetCPUPercent must be a value between 1 - 100"))
 		}
 
+		if es.TargetConcurrency < 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.TargetConcurrency", es.TargetConcurrency, "target concurrency must be greater than or equal to 0"))
+		}
+
+		if es.TargetRPS < 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.TargetRPS", es.TargetRPS, "target RPS must be greater than or equal to 0"))
+		}
+
+		if es.TargetConcurrency > 0 && es.TargetRPS > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.TargetRPS", es.TargetRPS, "only one of target concurrency and target RPS can be set"))
+		}
+
 		// TODO Add validation warning
 		//if es.SpecializationTimeout < 120 {
 		//	result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.SpecializationTimeout", es.SpecializationTimeout, "SpecializationTimeout must be a value equal to or greater than 120"))
 		//}
 	}
 
+	if es.ExecutorType == ExecutorTypePoolmgr && (es.TargetConcurrency != 0 || es.TargetRPS != 0) {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.TargetConcurrency", es.TargetConcurrency, "request-driven autoscaling is not supported by executor type poolmgr"))
+	}
+
+	if es.WarmPods < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.WarmPods", es.WarmPods, "warm pods must be greater than or equal to 0"))
+	}
+
+	if es.ExecutorType != ExecutorTypePoolmgr && (es.WarmPods > 0 || len(es.WarmupSchedules) > 0) {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "ExecutionStrategy.WarmPods", es.WarmPods, "warm pods are only supported by executor type poolmgr"))
+	}
+
+	for _, ws := range es.WarmupSchedules {
+		result = multierror.Append(result, ws.Validate())
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (ws WarmupSchedule) Validate() error {
+	result := &multierror.Error{}
+
+	if err := IsValidCronSpec(ws.Cron); err != nil {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WarmupSchedule.Cron", ws.Cron, "not a valid cron spec"))
+	}
+
+	if ws.Duration <= 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WarmupSchedule.Duration", ws.Duration, "duration must be greater than 0"))
+	}
+
+	if ws.WarmPods <= 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "WarmupSchedule.WarmPods", ws.WarmPods, "warm pods must be greater than 0"))
+	}
+
 	return result.ErrorOrNil()
 }
 
@@ -344,6 +472,37 @@ func (ref FunctionReference) Validate() error {
 		result = multierror.Append(result, ValidateKubeName("FunctionReference.Name", ref.Name))
 	}
 
+	if ref.Type != FunctionReferenceTypeFunctionWeights && (len(ref.Rules) > 0 || ref.StickySession != nil) {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionReference.Type", ref.Type, "routing rules and sticky session require function-weights reference type"))
+	}
+
+	for _, rule := range ref.Rules {
+		result = multierror.Append(result, rule.Validate())
+		if _, ok := ref.FunctionWeights[rule.FunctionName]; !ok {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionReference.Rules.FunctionName", rule.FunctionName, "function must be listed in function weights"))
+		}
+	}
+
+	if ref.StickySession != nil && len(ref.StickySession.Header) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionReference.StickySession.Header", ref.StickySession.Header, "header name is required"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (rule RoutingRule) Validate() error {
+	result := &multierror.Error{}
+
+	switch rule.Match {
+	case RoutingRuleMatchTypeHeader, RoutingRuleMatchTypeCookie, RoutingRuleMatchTypeQuery: // no op
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "FunctionReference.Rules.Match", rule.Match, "not a valid match type"))
+	}
+
+	if len(rule.Key) == 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "FunctionReference.Rules.Key", rule.Key, "key is required"))
+	}
+
 	return result.ErrorOrNil()
 }
 
@@ -362,8 +521,13 @@ func (runtime Runtime) Validate() error {
 }
 
 func (builder Builder) Validate() error {
-	// do nothing for now
-	return nil
+	result := &multierror.Error{}
+
+	if builder.BuildTimeout < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "Builder.BuildTimeout", builder.BuildTimeout, "build timeout must be greater than or equal to 0"))
+	}
+
+	return result.ErrorOrNil()
 }
 
 func (spec EnvironmentSpec) Validate() error {
@@ -387,6 +551,12 @@ func (spec EnvironmentSpec) Validate() error {
 		}
 	}
 
+	switch spec.PodSelection {
+	case "", PodSelectionRandom, PodSelectionSpread, PodSelectionPackageAffinity, PodSelectionLeastLoaded: // no op
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "EnvironmentSpec.PodSelection", spec.PodSelection, "not a valid pod selection strategy"))
+	}
+
 	if spec.Poolsize < 0 {
 		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "EnvironmentSpec.Poolsize", spec.Poolsize, "must be greater than or equal to 0"))
 	}
@@ -395,6 +565,28 @@ func (spec EnvironmentSpec) Validate() error {
 		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "EnvironmentSpec.TerminationGracePeriod", spec.TerminationGracePeriod, "must be greater than or equal to 0"))
 	}
 
+	if spec.Autoscale != nil {
+		result = multierror.Append(result, spec.Autoscale.Validate())
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (pa PoolAutoscale) Validate() error {
+	result := &multierror.Error{}
+
+	if pa.MinPoolsize <= 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "PoolAutoscale.MinPoolsize", pa.MinPoolsize, "must be greater than 0"))
+	}
+
+	if pa.MaxPoolsize < pa.MinPoolsize {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "PoolAutoscale.MaxPoolsize", pa.MaxPoolsize, "must be greater than or equal to minimum pool size"))
+	}
+
+	if pa.TargetFreePods < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "PoolAutoscale.TargetFreePods", pa.TargetFreePods, "must be greater than or equal to 0"))
+	}
+
 	return result.ErrorOrNil()
 }
 
@@ -419,6 +611,125 @@ func (spec HTTPTriggerSpec) Validate() error {
 
 	result = multierror.Append(result, spec.IngressConfig.Validate())
 
+	if spec.RateLimit != nil {
+		result = multierror.Append(result, spec.RateLimit.Validate())
+	}
+
+	switch spec.Protocol {
+	case "", TriggerProtocolHTTP, TriggerProtocolH2C: // no op
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "HTTPTriggerSpec.Protocol", spec.Protocol, "not a valid protocol"))
+	}
+
+	if spec.Streaming != nil {
+		result = multierror.Append(result, spec.Streaming.Validate())
+	}
+
+	if spec.Mirror != nil {
+		result = multierror.Append(result, spec.Mirror.Validate())
+	}
+
+	if spec.Cache != nil {
+		result = multierror.Append(result, spec.Cache.Validate())
+	}
+
+	if spec.Auth != nil {
+		result = multierror.Append(result, spec.Auth.Validate())
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (config MirrorConfig) Validate() error {
+	result := &multierror.Error{}
+
+	result = multierror.Append(result, ValidateKubeName("HTTPTriggerSpec.Mirror.FunctionName", config.FunctionName))
+
+	if config.Percentage <= 0 || config.Percentage > 100 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Mirror.Percentage", config.Percentage, "must be between 1 and 100"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (auth TriggerAuth) Validate() error {
+	result := &multierror.Error{}
+
+	switch auth.Type {
+	case TriggerAuthTypeJWT: // no op
+	case TriggerAuthTypeAPIKey, TriggerAuthTypeHMAC:
+		if len(auth.Issuer) > 0 || len(auth.Audience) > 0 || len(auth.Claims) > 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Auth.Type", auth.Type, "issuer, audience and claims only apply to jwt authentication"))
+		}
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "HTTPTriggerSpec.Auth.Type", auth.Type, "not a supported authentication type"))
+	}
+
+	result = multierror.Append(result, ValidateKubeName("HTTPTriggerSpec.Auth.SecretName", auth.SecretName))
+
+	for _, c := range auth.Claims {
+		if len(c) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Auth.Claims", c, "claim name cannot be empty"))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (config ResponseCache) Validate() error {
+	result := &multierror.Error{}
+
+	if config.TTL <= 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Cache.TTL", config.TTL, "must be greater than 0"))
+	}
+
+	for _, h := range config.Headers {
+		if len(h) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Cache.Headers", h, "header name cannot be empty"))
+		}
+	}
+
+	for _, q := range config.QueryParams {
+		if len(q) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Cache.QueryParams", q, "query parameter name cannot be empty"))
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+func (config StreamingConfig) Validate() error {
+	if config.PingInterval < 0 {
+		return MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.Streaming.PingInterval", config.PingInterval, "must be greater than or equal to 0")
+	}
+	return nil
+}
+
+func (rl RateLimit) Validate() error {
+	result := &multierror.Error{}
+
+	if rl.RequestsPerSecond < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.RateLimit.RequestsPerSecond", rl.RequestsPerSecond, "must be greater than or equal to 0"))
+	}
+
+	if rl.Burst < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.RateLimit.Burst", rl.Burst, "must be greater than or equal to 0"))
+	}
+
+	if rl.MaxInFlight < 0 {
+		result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.RateLimit.MaxInFlight", rl.MaxInFlight, "must be greater than or equal to 0"))
+	}
+
+	switch rl.KeyType {
+	case "", RateLimitKeyTypeTrigger, RateLimitKeyTypeClientIP: // no op
+	case RateLimitKeyTypeHeader:
+		if len(rl.KeyHeader) == 0 {
+			result = multierror.Append(result, MakeValidationErr(ErrorInvalidValue, "HTTPTriggerSpec.RateLimit.KeyHeader", rl.KeyHeader, "header name is required for key type header"))
+		}
+	default:
+		result = multierror.Append(result, MakeValidationErr(ErrorUnsupportedType, "HTTPTriggerSpec.RateLimit.KeyType", rl.KeyType, "not a valid rate limit key type"))
+	}
+
 	return result.ErrorOrNil()
 }
 
@@ -18,6 +18,7 @@ package builder
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -34,6 +35,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/info"
 )
 
@@ -41,6 +43,10 @@ const (
 	// supported environment variables
 	envSrcPkg    = "SRC_PKG"
 	envDeployPkg = "DEPLOY_PKG"
+
+	// StreamContentType is accepted by clients reading build output as it is
+	// produced, replied with a stream of newline delimited PackageBuildEvent.
+	StreamContentType = "application/x-ndjson"
 )
 
 type (
@@ -52,11 +58,39 @@ type (
 		// 1. SRC_PKG: path to source package directory
 		// 2. DEPLOY_PKG: path to deployment package directory
 		BuildCommand string `json:"command"`
+		// Steps to run in order instead of the build command, which is
+		// the default command of steps.
+		Steps []fv1.BuildStep `json:"steps,omitempty"`
+		// CacheKey identifies the cache directories of build steps, kept
+		// across builds with the same key.
+		CacheKey string `json:"cacheKey,omitempty"`
+		// DeployPkgFilename is the deployment package to build into, such as
+		// the one of build steps run before, instead of a new one.
+		DeployPkgFilename string `json:"deployPkgFilename,omitempty"`
 	}
 
 	PackageBuildResponse struct {
-		ArtifactFilename string `json:"artifactFilename"`
-		BuildLogs        string `json:"buildLogs"`
+		ArtifactFilename string                `json:"artifactFilename"`
+		BuildLogs        string                `json:"buildLogs"`
+		Steps            []fv1.BuildStepStatus `json:"steps,omitempty"`
+	}
+
+	// PackageBuildEvent is either a line of build output, of a build step if
+	// any, a change of build step status, or the result of the build along
+	// with the status code it would have been replied with.
+	PackageBuildEvent struct {
+		Log        string                `json:"log,omitempty"`
+		Step       string                `json:"step,omitempty"`
+		StepStatus *fv1.BuildStepStatus  `json:"stepStatus,omitempty"`
+		Result     *PackageBuildResponse `json:"result,omitempty"`
+		StatusCode int                   `json:"statusCode,omitempty"`
+	}
+
+	// buildStream writes build events to a streaming client.
+	buildStream struct {
+		logger  *zap.Logger
+		w       http.ResponseWriter
+		encoder *json.Encoder
 	}
 
 	Builder struct {
@@ -81,7 +115,7 @@ func (builder *Builder) Handler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
 		e := "method not allowed"
 		builder.logger.Error(e, zap.String("http_method", r.Method))
-		builder.reply(w, "", fmt.Sprintf("%s: %s", e, r.Method), http.StatusMethodNotAllowed)
+		builder.reply(w, &PackageBuildResponse{BuildLogs: fmt.Sprintf("%s: %s", e, r.Method)}, http.StatusMethodNotAllowed)
 		return
 	}
 
@@ -96,7 +130,7 @@ func (builder *Builder) Handler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		e := "error reading request body"
 		builder.logger.Error(e, zap.Error(err))
-		builder.reply(w, "", fmt.Sprintf("%s: %s", e, err.Error()), http.StatusInternalServerError)
+		builder.reply(w, &PackageBuildResponse{BuildLogs: fmt.Sprintf("%s: %s", e, err.Error())}, http.StatusInternalServerError)
 		return
 	}
 	var req PackageBuildRequest
@@ -104,40 +138,124 @@ func (builder *Builder) Handler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		e := "error parsing json body"
 		builder.logger.Error(e, zap.Error(err))
-		builder.reply(w, "", fmt.Sprintf("%s: %s", e, err.Error()), http.StatusBadRequest)
+		builder.reply(w, &PackageBuildResponse{BuildLogs: fmt.Sprintf("%s: %s", e, err.Error())}, http.StatusBadRequest)
 		return
 	}
 	builder.logger.Info("builder received request", zap.Any("request", req))
 
+	reply := builder.reply
+	var onEvent func(*PackageBuildEvent)
+	if strings.Contains(r.Header.Get("Accept"), StreamContentType) {
+		stream := builder.startStream(w)
+		reply = stream.reply
+		onEvent = stream.send
+	}
+
+	deployPkgFilename := req.DeployPkgFilename
+	if len(deployPkgFilename) == 0 {
+		deployPkgFilename = fmt.Sprintf("%v-%v", req.SrcPkgFilename, strings.ToLower(uniuri.NewLen(6)))
+	} else if !isBaseName(deployPkgFilename) {
+		e := "invalid deployment package filename"
+		builder.logger.Error(e, zap.String("deployment_package", deployPkgFilename))
+		reply(w, &PackageBuildResponse{BuildLogs: fmt.Sprintf("%s: %q\n", e, deployPkgFilename)}, http.StatusBadRequest)
+		return
+	}
+
 	builder.logger.Info("starting build")
 	srcPkgPath := filepath.Join(builder.sharedVolumePath, req.SrcPkgFilename)
-	deployPkgFilename := fmt.Sprintf("%v-%v", req.SrcPkgFilename, strings.ToLower(uniuri.NewLen(6)))
 	deployPkgPath := filepath.Join(builder.sharedVolumePath, deployPkgFilename)
 	buildCmd := req.BuildCommand
 	if len(buildCmd) == 0 {
 		// use default build command
 		buildCmd = "/build"
 	}
-	buildLogs, err := builder.build(buildCmd, srcPkgPath, deployPkgPath)
+	resp := &PackageBuildResponse{
+		ArtifactFilename: deployPkgFilename,
+	}
+	// the build command is killed if the client goes away
+	if len(req.Steps) > 0 {
+		resp.BuildLogs, resp.Steps, err = builder.buildSteps(r.Context(), &req, srcPkgPath, deployPkgPath, onEvent)
+	} else {
+		resp.BuildLogs, err = builder.build(r.Context(), buildCmd, nil, nil, srcPkgPath, deployPkgPath, logEvents(onEvent, ""))
+	}
 	if err != nil {
 		e := "error building source package"
 		builder.logger.Error(e, zap.Error(err))
 
 		// append error at the end of build logs
-		buildLogs += fmt.Sprintf("%s: %s\n", e, err.Error())
-		builder.reply(w, deployPkgFilename, buildLogs, http.StatusInternalServerError)
+		resp.BuildLogs += fmt.Sprintf("%s: %s\n", e, err.Error())
+		reply(w, resp, http.StatusInternalServerError)
 		return
 	}
 
-	builder.reply(w, deployPkgFilename, buildLogs, http.StatusOK)
+	reply(w, resp, http.StatusOK)
 }
 
-func (builder *Builder) reply(w http.ResponseWriter, pkgFilename string, buildLogs string, statusCode int) {
-	resp := PackageBuildResponse{
-		ArtifactFilename: pkgFilename,
-		BuildLogs:        buildLogs,
+// CacheHandler deletes the build cache directories kept for the cache key
+// in the "key" query parameter, once the package they were kept for is gone.
+func (builder *Builder) CacheHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		e := "method not allowed"
+		builder.logger.Error(e, zap.String("http_method", r.Method))
+		http.Error(w, fmt.Sprintf("%s: %s", e, r.Method), http.StatusMethodNotAllowed)
+		return
 	}
 
+	cacheKey := r.URL.Query().Get("key")
+	err := builder.deleteCacheDirs(cacheKey)
+	if err != nil {
+		e := "error deleting build cache"
+		builder.logger.Error(e, zap.String("cache_key", cacheKey), zap.Error(err))
+		http.Error(w, fmt.Sprintf("%s: %s", e, err.Error()), http.StatusBadRequest)
+		return
+	}
+	builder.logger.Info("deleted build cache", zap.String("cache_key", cacheKey))
+	w.WriteHeader(http.StatusOK)
+}
+
+// logEvents returns a func sending lines of build output of a step as build
+// events, nil if events are not sent.
+func logEvents(onEvent func(*PackageBuildEvent), step string) func(string) {
+	if onEvent == nil {
+		return nil
+	}
+	return func(line string) {
+		onEvent(&PackageBuildEvent{Log: line, Step: step})
+	}
+}
+
+// startStream replies to the client right away, the status of the build is
+// sent with its result at the end of the stream.
+func (builder *Builder) startStream(w http.ResponseWriter) *buildStream {
+	w.Header().Set("Content-Type", StreamContentType)
+	w.WriteHeader(http.StatusOK)
+	return &buildStream{
+		logger:  builder.logger,
+		w:       w,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (stream *buildStream) send(event *PackageBuildEvent) {
+	err := stream.encoder.Encode(event)
+	if err != nil {
+		// the client may be gone, the build goes on regardless
+		stream.logger.Debug("error sending build event", zap.Error(err))
+		return
+	}
+	if f, ok := stream.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (stream *buildStream) reply(w http.ResponseWriter, resp *PackageBuildResponse, statusCode int) {
+	stream.send(&PackageBuildEvent{
+		Result:     resp,
+		StatusCode: statusCode,
+	})
+}
+
+func (builder *Builder) reply(w http.ResponseWriter, resp *PackageBuildResponse, statusCode int) {
 	rBody, err := json.Marshal(resp)
 	if err != nil {
 		e := errors.Wrap(err, "error encoding response body")
@@ -152,8 +270,12 @@ func (builder *Builder) reply(w http.ResponseWriter, pkgFilename string, buildLo
 	w.Write(rBody)
 }
 
-func (builder *Builder) build(command string, srcPkgPath string, deployPkgPath string) (string, error) {
-	cmd := exec.Command(command)
+// build runs the build command with the arguments and additional environment
+// variables until completion or the context is done, passing each line of its
+// output to onLog if not nil, and returns the whole output.
+func (builder *Builder) build(ctx context.Context, command string, args []string, env map[string]string,
+	srcPkgPath string, deployPkgPath string, onLog func(string)) (string, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
 
 	fi, err := os.Stat(srcPkgPath)
 	if err != nil {
@@ -166,7 +288,11 @@ func (builder *Builder) build(command string, srcPkgPath string, deployPkgPath s
 	}
 
 	// set env variables for build command
-	cmd.Env = append(os.Environ(),
+	cmd.Env = os.Environ()
+	for name, value := range env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%v=%v", name, value))
+	}
+	cmd.Env = append(cmd.Env,
 		fmt.Sprintf("%v=%v", envSrcPkg, srcPkgPath),
 		fmt.Sprintf("%v=%v", envDeployPkg, deployPkgPath),
 	)
@@ -201,6 +327,9 @@ func (builder *Builder) build(command string, srcPkgPath string, deployPkgPath s
 		output := scanner.Text()
 		fmt.Println(output)
 		buildLogs += fmt.Sprintf("%v\n", output)
+		if onLog != nil {
+			onLog(output)
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -18,9 +18,13 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -45,7 +49,10 @@ func MakeClient(logger *zap.Logger, builderUrl string) *Client {
 	}
 }
 
-func (c *Client) Build(req *builder.PackageBuildRequest) (*builder.PackageBuildResponse, error) {
+// Build sends a build request to the builder. If the builder streams build
+// events, such as lines of output, they are passed to onEvent, if not nil, as
+// they happen. Canceling the context stops the build.
+func (c *Client) Build(ctx context.Context, req *builder.PackageBuildRequest, onEvent func(*builder.PackageBuildEvent)) (*builder.PackageBuildResponse, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "error marshaling json")
@@ -55,7 +62,7 @@ func (c *Client) Build(req *builder.PackageBuildRequest) (*builder.PackageBuildR
 	var resp *http.Response
 
 	for i := 0; i < maxRetries; i++ {
-		resp, err = http.Post(c.url, "application/json", bytes.NewReader(body))
+		resp, err = c.post(ctx, body)
 
 		if err == nil {
 			if resp.StatusCode == 200 {
@@ -64,6 +71,10 @@ func (c *Client) Build(req *builder.PackageBuildRequest) (*builder.PackageBuildR
 			err = ferror.MakeErrorFromHTTP(resp)
 		}
 
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
 		if i < maxRetries-1 {
 			time.Sleep(50 * time.Duration(2*i) * time.Millisecond)
 			c.logger.Error("error building package, retrying", zap.Error(err))
@@ -75,6 +86,11 @@ func (c *Client) Build(req *builder.PackageBuildRequest) (*builder.PackageBuildR
 
 	defer resp.Body.Close()
 
+	// builders of older releases reply with the result only
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), builder.StreamContentType) {
+		return c.readStream(resp.Body, onEvent)
+	}
+
 	rBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		c.logger.Error("error reading resp body", zap.Error(err))
@@ -90,3 +106,62 @@ func (c *Client) Build(req *builder.PackageBuildRequest) (*builder.PackageBuildR
 
 	return &pkgBuildResp, ferror.MakeErrorFromHTTP(resp)
 }
+
+// DeleteCache deletes the build cache directories the builder keeps for the
+// cache key.
+func (c *Client) DeleteCache(ctx context.Context, cacheKey string) error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%v/cache?key=%v", c.url, url.QueryEscape(cacheKey)), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "error deleting build cache")
+	}
+	defer resp.Body.Close()
+	return ferror.MakeErrorFromHTTP(resp)
+}
+
+// WorkspaceUrl returns the URL a build step run in a pod of its own gets the
+// source and deployment packages from, and puts them back to once done.
+func (c *Client) WorkspaceUrl(srcPkgFilename string, deployPkgFilename string) string {
+	return fmt.Sprintf("%v/workspace?src=%v&deploy=%v", c.url, url.QueryEscape(srcPkgFilename), url.QueryEscape(deployPkgFilename))
+}
+
+func (c *Client) post(ctx context.Context, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", fmt.Sprintf("%v, application/json", builder.StreamContentType))
+	return http.DefaultClient.Do(req)
+}
+
+// readStream reads build events until the result of the build.
+func (c *Client) readStream(body io.Reader, onEvent func(*builder.PackageBuildEvent)) (*builder.PackageBuildResponse, error) {
+	decoder := json.NewDecoder(body)
+	for {
+		event := builder.PackageBuildEvent{}
+		err := decoder.Decode(&event)
+		if err == io.EOF {
+			return nil, errors.New("build stream ended without build result")
+		} else if err != nil {
+			c.logger.Error("error reading build stream", zap.Error(err))
+			return nil, errors.Wrap(err, "error reading build stream")
+		}
+
+		if event.Result != nil {
+			if event.StatusCode != http.StatusOK {
+				return event.Result, ferror.MakeError(ferror.ErrorInternal,
+					fmt.Sprintf("builder responded with status %v", event.StatusCode))
+			}
+			return event.Result, nil
+		}
+
+		if onEvent != nil {
+			onEvent(&event)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mholt/archiver"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/builder"
+)
+
+func TestBuildStreamsLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "builder")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "src"), 0755))
+	script := filepath.Join(dir, "build.sh")
+	assert.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho compiling\necho linking\n"), 0755))
+
+	logger := zap.NewNop()
+	ts := httptest.NewServer(http.HandlerFunc(builder.MakeBuilder(logger, dir).Handler))
+	defer ts.Close()
+
+	var lines []string
+	resp, err := MakeClient(logger, ts.URL).Build(context.Background(), &builder.PackageBuildRequest{
+		SrcPkgFilename: "src",
+		BuildCommand:   script,
+	}, func(event *builder.PackageBuildEvent) {
+		lines = append(lines, event.Log)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"compiling", "linking"}, lines)
+	assert.Equal(t, "compiling\nlinking\n", resp.BuildLogs)
+
+	// a failed build is not retried and keeps its logs
+	assert.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\necho broken\nexit 1\n"), 0755))
+	lines = nil
+	resp, err = MakeClient(logger, ts.URL).Build(context.Background(), &builder.PackageBuildRequest{
+		SrcPkgFilename: "src",
+		BuildCommand:   script,
+	}, func(event *builder.PackageBuildEvent) {
+		lines = append(lines, event.Log)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"broken"}, lines)
+	assert.Contains(t, resp.BuildLogs, "broken\n")
+}
+
+func TestBuildSteps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "builder")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "src"), 0755))
+	install := filepath.Join(dir, "install.sh")
+	assert.NoError(t, ioutil.WriteFile(install, []byte("#!/bin/sh\necho installing $MODE\ntouch deps/installed\n"), 0755))
+	test := filepath.Join(dir, "test.sh")
+	assert.NoError(t, ioutil.WriteFile(test, []byte("#!/bin/sh\necho testing $1\nexit 1\n"), 0755))
+
+	logger := zap.NewNop()
+	ts := httptest.NewServer(http.HandlerFunc(builder.MakeBuilder(logger, dir).Handler))
+	defer ts.Close()
+
+	stepLogs := make(map[string][]string)
+	var statuses []fv1.BuildStepStatus
+	resp, err := MakeClient(logger, ts.URL).Build(context.Background(), &builder.PackageBuildRequest{
+		SrcPkgFilename: "src",
+		Steps: []fv1.BuildStep{
+			{Name: "install", Command: install, Env: map[string]string{"MODE": "production"}, CacheDirs: []string{"deps"}},
+			{Name: "test", Command: test, Args: []string{"unit"}},
+			{Name: "bundle", Command: install},
+		},
+		CacheKey: "pkg-uid",
+	}, func(event *builder.PackageBuildEvent) {
+		if event.StepStatus != nil {
+			statuses = append(statuses, *event.StepStatus)
+		} else if len(event.Step) > 0 {
+			stepLogs[event.Step] = append(stepLogs[event.Step], event.Log)
+		}
+	})
+
+	// the failed step stops the build
+	assert.Error(t, err)
+	assert.Equal(t, []string{"installing production"}, stepLogs["install"])
+	assert.Equal(t, []string{"testing unit"}, stepLogs["test"][:1])
+	assert.Empty(t, stepLogs["bundle"])
+	if assert.Len(t, resp.Steps, 3) {
+		assert.Equal(t, fv1.BuildStatus(fv1.BuildStatusSucceeded), resp.Steps[0].Status)
+		assert.Equal(t, fv1.BuildStatus(fv1.BuildStatusFailed), resp.Steps[1].Status)
+		assert.Equal(t, fv1.BuildStatus(fv1.BuildStatusPending), resp.Steps[2].Status)
+	}
+	assert.Contains(t, resp.BuildLogs, "=== build step test ===")
+	assert.NotEmpty(t, statuses)
+
+	// the cache directory is kept for the next builds with the same key
+	_, err = os.Stat(filepath.Join(dir, ".build-cache", "pkg-uid", "deps", "installed"))
+	assert.NoError(t, err)
+	_, err = os.Lstat(filepath.Join(dir, "src", "deps"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDeleteCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "builder")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cached := filepath.Join(dir, ".build-cache", "pkg-uid", "deps")
+	assert.NoError(t, os.MkdirAll(cached, 0755))
+
+	logger := zap.NewNop()
+	ts := httptest.NewServer(http.HandlerFunc(builder.MakeBuilder(logger, dir).CacheHandler))
+	defer ts.Close()
+
+	client := MakeClient(logger, ts.URL)
+	assert.Error(t, client.DeleteCache(context.Background(), ".."))
+	assert.NoError(t, client.DeleteCache(context.Background(), "pkg-uid"))
+	_, err = os.Stat(filepath.Join(dir, ".build-cache", "pkg-uid"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWorkspace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "builder")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "src"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main\n"), 0644))
+	script := filepath.Join(dir, "build.sh")
+	assert.NoError(t, ioutil.WriteFile(script, []byte("#!/bin/sh\nmkdir -p $DEPLOY_PKG\ncat $DEPLOY_PKG/step.txt\n"), 0755))
+
+	logger := zap.NewNop()
+	b := builder.MakeBuilder(logger, dir)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", b.Handler)
+	mux.HandleFunc("/workspace", b.WorkspaceHandler)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	client := MakeClient(logger, ts.URL)
+
+	resp, err := http.Get(client.WorkspaceUrl("src", "../src"))
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// the step gets the source package, without any deployment package yet
+	resp, err = http.Get(client.WorkspaceUrl("src", "src-deploy"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	stepDir := filepath.Join(dir, "step")
+	assert.NoError(t, archiver.Zip.Read(resp.Body, stepDir))
+	resp.Body.Close()
+	content, err := ioutil.ReadFile(filepath.Join(stepDir, "src", "main.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+	_, err = os.Stat(filepath.Join(stepDir, "src-deploy"))
+	assert.True(t, os.IsNotExist(err))
+
+	// and puts both packages back
+	assert.NoError(t, os.Remove(filepath.Join(stepDir, "src", "main.go")))
+	assert.NoError(t, os.Mkdir(filepath.Join(stepDir, "src-deploy"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(stepDir, "src-deploy", "step.txt"), []byte("from step\n"), 0644))
+	archive := filepath.Join(dir, "step.zip")
+	assert.NoError(t, archiver.Zip.Make(archive, []string{filepath.Join(stepDir, "src"), filepath.Join(stepDir, "src-deploy")}))
+	f, err := os.Open(archive)
+	assert.NoError(t, err)
+	defer f.Close()
+	req, err := http.NewRequest(http.MethodPut, client.WorkspaceUrl("src", "src-deploy"), f)
+	assert.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = os.Stat(filepath.Join(dir, "src", "main.go"))
+	assert.True(t, os.IsNotExist(err))
+
+	// following steps build into the same deployment package
+	buildResp, err := client.Build(context.Background(), &builder.PackageBuildRequest{
+		SrcPkgFilename:    "src",
+		DeployPkgFilename: "src-deploy",
+		BuildCommand:      script,
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "src-deploy", buildResp.ArtifactFilename)
+	assert.Equal(t, "from step\n", buildResp.BuildLogs)
+}
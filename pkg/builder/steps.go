@@ -0,0 +1,222 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// buildCacheDir is the directory of the shared volume keeping the cache
+// directories of build steps.
+const buildCacheDir = ".build-cache"
+
+// buildSteps runs the build steps of the request in order, until one fails.
+// It returns the build logs of all steps, with a header line per step, and
+// the status of each step. Step status changes and lines of output are sent
+// to onEvent if not nil.
+func (builder *Builder) buildSteps(ctx context.Context, req *PackageBuildRequest, srcPkgPath string, deployPkgPath string,
+	onEvent func(*PackageBuildEvent)) (string, []fv1.BuildStepStatus, error) {
+
+	statuses := make([]fv1.BuildStepStatus, len(req.Steps))
+	for i, step := range req.Steps {
+		statuses[i] = fv1.BuildStepStatus{
+			Name:   step.Name,
+			Status: fv1.BuildStatusPending,
+		}
+	}
+
+	sendStatus := func(status *fv1.BuildStepStatus) {
+		if onEvent != nil {
+			s := *status
+			onEvent(&PackageBuildEvent{Step: s.Name, StepStatus: &s})
+		}
+	}
+
+	for i := range statuses {
+		sendStatus(&statuses[i])
+	}
+
+	var buildLogs string
+	for i, step := range req.Steps {
+		status := &statuses[i]
+		status.Status = fv1.BuildStatusRunning
+		status.StartTimestamp = metav1.Time{Time: time.Now().UTC()}
+		sendStatus(status)
+
+		onLog := logEvents(onEvent, step.Name)
+		logf := func(format string, args ...interface{}) {
+			line := fmt.Sprintf(format, args...)
+			status.Log += line + "\n"
+			if onLog != nil {
+				onLog(line)
+			}
+		}
+
+		// the header is part of the build logs only
+		header := fmt.Sprintf("=== build step %v ===", step.Name)
+		buildLogs += header + "\n"
+		if onEvent != nil {
+			onEvent(&PackageBuildEvent{Log: header})
+		}
+
+		command := step.Command
+		if len(command) == 0 {
+			command = req.BuildCommand
+		}
+		if len(command) == 0 {
+			command = "/build"
+		}
+
+		cacheDirs, err := builder.restoreCacheDirs(req.CacheKey, srcPkgPath, step.CacheDirs, logf)
+		if err == nil {
+			var stepLogs string
+			stepLogs, err = builder.build(ctx, command, step.Args, step.Env, srcPkgPath, deployPkgPath, onLog)
+			status.Log += stepLogs
+		}
+		builder.saveCacheDirs(cacheDirs, logf)
+
+		status.CompletionTimestamp = metav1.Time{Time: time.Now().UTC()}
+		if err != nil {
+			status.Status = fv1.BuildStatusFailed
+			logf("error running build step %v: %v", step.Name, err)
+			sendStatus(status)
+			buildLogs += status.Log
+			return buildLogs, statuses, errors.Wrapf(err, "build step %q failed", step.Name)
+		}
+
+		status.Status = fv1.BuildStatusSucceeded
+		sendStatus(status)
+		buildLogs += status.Log
+	}
+
+	return buildLogs, statuses, nil
+}
+
+// cacheDir is a cache directory of a build step, moved into the source
+// package while the step runs.
+type cacheDir struct {
+	name   string
+	target string // in the source package
+	cached string // on the shared volume
+}
+
+// restoreCacheDirs moves the cache directories of a build step, kept on the
+// shared volume across builds with the same cache key, into the source
+// package. They are moved rather than linked, so that build scripts copying
+// the source package to the deployment package copy their content. Cache
+// directories already in the source package are not cached. The directories
+// moved are returned even on error, to be saved by saveCacheDirs.
+func (builder *Builder) restoreCacheDirs(cacheKey string, srcPkgPath string, dirs []string, logf func(string, ...interface{})) ([]cacheDir, error) {
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+	if !isValidCacheKey(cacheKey) {
+		logf("no valid build cache key, cache directories are not kept")
+		return nil, nil
+	}
+
+	srcDir := srcPkgPath
+	fi, err := os.Stat(srcPkgPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not find srcPkgPath: '%s'", srcPkgPath)
+	}
+	if !fi.IsDir() {
+		srcDir = path.Dir(srcPkgPath)
+	}
+
+	restored := make([]cacheDir, 0, len(dirs))
+	for _, dir := range dirs {
+		clean := path.Clean(dir)
+		if path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			return restored, errors.Errorf("cache directory %q is not a sub directory of the source package", dir)
+		}
+
+		target := filepath.Join(srcDir, clean)
+		if _, err := os.Lstat(target); err == nil {
+			logf("cache directory %v is in the source package, not caching it", dir)
+			continue
+		}
+
+		err = os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return restored, errors.Wrapf(err, "error creating parent of cache directory %q", dir)
+		}
+
+		cached := filepath.Join(builder.sharedVolumePath, buildCacheDir, cacheKey, clean)
+		err = os.Rename(cached, target)
+		if os.IsNotExist(err) {
+			// first build with the cache directory
+			err = os.Mkdir(target, 0755)
+		}
+		if err != nil {
+			return restored, errors.Wrapf(err, "error restoring cache directory %q", dir)
+		}
+		restored = append(restored, cacheDir{name: dir, target: target, cached: cached})
+		builder.logger.Debug("restored build cache directory", zap.String("directory", dir), zap.String("cache_key", cacheKey))
+	}
+	return restored, nil
+}
+
+// saveCacheDirs moves the cache directories of a build step back to the
+// shared volume. Errors are logged only, as the build itself succeeded.
+func (builder *Builder) saveCacheDirs(dirs []cacheDir, logf func(string, ...interface{})) {
+	for _, dir := range dirs {
+		// a concurrent build of the same package may have saved it meanwhile
+		err := os.RemoveAll(dir.cached)
+		if err == nil {
+			err = os.MkdirAll(filepath.Dir(dir.cached), 0755)
+		}
+		if err == nil {
+			err = os.Rename(dir.target, dir.cached)
+		}
+		if err != nil {
+			logf("error saving cache directory %v: %v", dir.name, err)
+			continue
+		}
+		builder.logger.Debug("saved build cache directory", zap.String("directory", dir.name))
+	}
+}
+
+// deleteCacheDirs removes the cache directories kept for the cache key.
+func (builder *Builder) deleteCacheDirs(cacheKey string) error {
+	if !isValidCacheKey(cacheKey) {
+		return errors.Errorf("invalid build cache key %q", cacheKey)
+	}
+	return os.RemoveAll(filepath.Join(builder.sharedVolumePath, buildCacheDir, cacheKey))
+}
+
+// isValidCacheKey returns whether the cache key can name a directory.
+func isValidCacheKey(cacheKey string) bool {
+	return isBaseName(cacheKey)
+}
+
+// isBaseName returns whether name is a file name without any directory.
+func isBaseName(name string) bool {
+	return len(name) > 0 && !strings.ContainsAny(name, `/\`) && name != "." && name != ".."
+}
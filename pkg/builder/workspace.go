@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dchest/uniuri"
+	"github.com/mholt/archiver"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// WorkspaceHandler serves the workspace of a build step run in a pod of its
+// own, that is the source package in the "src" query parameter and the
+// deployment package in the "deploy" one. A GET replies with a zip archive of
+// both, a PUT replaces them with the ones of the zip archive in the body once
+// the step is done.
+func (builder *Builder) WorkspaceHandler(w http.ResponseWriter, r *http.Request) {
+	srcPkgFilename := r.URL.Query().Get("src")
+	deployPkgFilename := r.URL.Query().Get("deploy")
+	if !isBaseName(srcPkgFilename) || !isBaseName(deployPkgFilename) {
+		e := "invalid package filename"
+		builder.logger.Error(e, zap.String("source_package", srcPkgFilename), zap.String("deployment_package", deployPkgFilename))
+		http.Error(w, e, http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/zip")
+		err = builder.writeWorkspace(w, srcPkgFilename, deployPkgFilename)
+	case http.MethodPut:
+		err = builder.readWorkspace(r.Body, srcPkgFilename, deployPkgFilename)
+		if err == nil {
+			w.WriteHeader(http.StatusOK)
+		}
+	default:
+		e := "method not allowed"
+		builder.logger.Error(e, zap.String("http_method", r.Method))
+		http.Error(w, fmt.Sprintf("%s: %s", e, r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err != nil {
+		e := "error transferring build step workspace"
+		builder.logger.Error(e, zap.Error(err), zap.String("http_method", r.Method), zap.String("source_package", srcPkgFilename))
+		// a GET failing once the archive is partly written leaves the client
+		// with an archive it fails to unzip
+		http.Error(w, fmt.Sprintf("%s: %s", e, err.Error()), http.StatusInternalServerError)
+		return
+	}
+	builder.logger.Info("transferred build step workspace", zap.String("http_method", r.Method), zap.String("source_package", srcPkgFilename))
+}
+
+// writeWorkspace writes a zip archive of the source package and of the
+// deployment package, if any, named after their filenames.
+func (builder *Builder) writeWorkspace(w io.Writer, srcPkgFilename string, deployPkgFilename string) error {
+	srcPkgPath := filepath.Join(builder.sharedVolumePath, srcPkgFilename)
+	if _, err := os.Stat(srcPkgPath); err != nil {
+		return errors.Wrap(err, "error finding source package")
+	}
+	files := []string{srcPkgPath}
+
+	deployPkgPath := filepath.Join(builder.sharedVolumePath, deployPkgFilename)
+	if _, err := os.Stat(deployPkgPath); err == nil {
+		files = append(files, deployPkgPath)
+	}
+	return archiver.Zip.Write(w, files)
+}
+
+// readWorkspace replaces the source and deployment packages with the ones of
+// a zip archive written by writeWorkspace and changed by a build step. The
+// deployment package is removed if the archive has none.
+func (builder *Builder) readWorkspace(r io.Reader, srcPkgFilename string, deployPkgFilename string) error {
+	tmpName := fmt.Sprintf(".workspace-%v", strings.ToLower(uniuri.NewLen(6)))
+	tmpFile := filepath.Join(builder.sharedVolumePath, tmpName+".zip")
+	tmpDir := filepath.Join(builder.sharedVolumePath, tmpName)
+	defer os.RemoveAll(tmpDir)
+	defer os.Remove(tmpFile)
+
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		return errors.Wrap(err, "error creating workspace archive")
+	}
+	_, err = io.Copy(f, r)
+	f.Close()
+	if err != nil {
+		return errors.Wrap(err, "error reading workspace archive")
+	}
+
+	err = archiver.Zip.Open(tmpFile, tmpDir)
+	if err != nil {
+		return errors.Wrap(err, "error unzipping workspace archive")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, srcPkgFilename)); err != nil {
+		return errors.Wrap(err, "error finding source package in workspace archive")
+	}
+
+	for _, filename := range []string{srcPkgFilename, deployPkgFilename} {
+		target := filepath.Join(builder.sharedVolumePath, filename)
+		err = os.RemoveAll(target)
+		if err != nil {
+			return errors.Wrapf(err, "error removing %v", filename)
+		}
+		err = os.Rename(filepath.Join(tmpDir, filename), target)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "error moving %v", filename)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fetcher"
+	storageSvcClient "github.com/fission/fission/pkg/storagesvc/client"
+)
+
+// BUILD_CACHE_KEY_ANNOTATION is set on packages built successfully, to the
+// key of the inputs of the build. It is informational only, cached builds are
+// looked up in the build cache index.
+const BUILD_CACHE_KEY_ANNOTATION = "fission.io/build-cache-key"
+
+const (
+	// BUILD_CACHE_CONFIGMAP is the ConfigMap of the build cache index, in
+	// the namespace set by BUILD_CACHE_NAMESPACE. Only the builder manager
+	// writes it, packages are never trusted to tell how they were built.
+	BUILD_CACHE_CONFIGMAP = "fission-build-cache"
+
+	// maxBuildCacheEntries keeps the index well below the ConfigMap size
+	// limit, the oldest builds are dropped beyond it.
+	maxBuildCacheEntries = 2000
+)
+
+type (
+	// buildCacheIndex maps build cache keys to the deployment archives
+	// built with them.
+	buildCacheIndex struct {
+		kubernetesClient kubernetes.Interface
+		namespace        string
+		name             string
+		lock             sync.Mutex
+	}
+
+	// buildCacheEntry is a deployment archive of the build cache index.
+	buildCacheEntry struct {
+		// Package is the namespace/name of the package built.
+		Package   string       `json:"package"`
+		URL       string       `json:"url"`
+		Checksum  fv1.Checksum `json:"checksum"`
+		Timestamp time.Time    `json:"timestamp"`
+	}
+)
+
+// makeBuildCacheIndex returns the build cache index kept in the namespace,
+// nil if the namespace is empty.
+func makeBuildCacheIndex(kubernetesClient kubernetes.Interface, namespace string) *buildCacheIndex {
+	if len(namespace) == 0 {
+		return nil
+	}
+	return &buildCacheIndex{
+		kubernetesClient: kubernetesClient,
+		namespace:        namespace,
+		name:             BUILD_CACHE_CONFIGMAP,
+	}
+}
+
+// get returns the entry of the cache key, nil if there is none.
+func (index *buildCacheIndex) get(key string) (*buildCacheEntry, error) {
+	cm, err := index.kubernetesClient.CoreV1().ConfigMaps(index.namespace).Get(index.name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	data, ok := cm.Data[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := &buildCacheEntry{}
+	err = json.Unmarshal([]byte(data), entry)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing build cache entry %q", key)
+	}
+	return entry, nil
+}
+
+// set sets the entry of the cache key, or deletes it if the entry is nil.
+func (index *buildCacheIndex) set(key string, entry *buildCacheEntry) error {
+	var data []byte
+	if entry != nil {
+		var err error
+		data, err = json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	index.lock.Lock()
+	defer index.lock.Unlock()
+
+	var err error
+	for i := 0; i < 3; i++ {
+		err = index.update(key, data)
+		if !k8serrors.IsConflict(err) && !k8serrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (index *buildCacheIndex) update(key string, data []byte) error {
+	configMaps := index.kubernetesClient.CoreV1().ConfigMaps(index.namespace)
+	cm, err := configMaps.Get(index.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		if data == nil {
+			return nil
+		}
+		cm = &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: index.name, Namespace: index.namespace},
+			Data:       map[string]string{key: string(data)},
+		}
+		_, err = configMaps.Create(cm)
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if data == nil {
+		if _, ok := cm.Data[key]; !ok {
+			return nil
+		}
+		delete(cm.Data, key)
+	} else {
+		if cm.Data == nil {
+			cm.Data = make(map[string]string)
+		}
+		cm.Data[key] = string(data)
+		pruneBuildCacheEntries(cm.Data, maxBuildCacheEntries)
+	}
+	_, err = configMaps.Update(cm)
+	return err
+}
+
+// pruneBuildCacheEntries drops the oldest entries until at most max are left.
+func pruneBuildCacheEntries(data map[string]string, max int) {
+	if len(data) <= max {
+		return
+	}
+	keys := make([]string, 0, len(data))
+	timestamps := make(map[string]time.Time, len(data))
+	for key, value := range data {
+		entry := buildCacheEntry{}
+		// unparsable entries have a zero timestamp and go first
+		json.Unmarshal([]byte(value), &entry)
+		keys = append(keys, key)
+		timestamps[key] = entry.Timestamp
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return timestamps[keys[i]].Before(timestamps[keys[j]])
+	})
+	for _, key := range keys[:len(keys)-max] {
+		delete(data, key)
+	}
+}
+
+// buildCacheKey returns the content-addressed key of a package build, made
+// of the source archive checksum, the builder image, the build command and
+// the build steps.
+// False is returned if the source archive has no known checksum.
+func buildCacheKey(pkg *fv1.Package, env *fv1.Environment) (string, bool) {
+	var srcSum string
+	switch pkg.Spec.Source.Type {
+	case fv1.ArchiveTypeLiteral:
+		if len(pkg.Spec.Source.Literal) == 0 {
+			return "", false
+		}
+		sum := sha256.Sum256(pkg.Spec.Source.Literal)
+		srcSum = hex.EncodeToString(sum[:])
+	case fv1.ArchiveTypeUrl:
+		// Content behind the URL may change, only its checksum identifies it.
+		if pkg.Spec.Source.Checksum.Type != fv1.ChecksumTypeSHA256 || len(pkg.Spec.Source.Checksum.Sum) == 0 {
+			return "", false
+		}
+		srcSum = strings.ToLower(pkg.Spec.Source.Checksum.Sum)
+	default:
+		return "", false
+	}
+
+	buildCmd := pkg.Spec.BuildCommand
+	if len(buildCmd) == 0 {
+		buildCmd = env.Spec.Builder.Command
+	}
+
+	var steps string
+	if len(pkg.Spec.BuildSteps) > 0 {
+		// maps are encoded with sorted keys
+		b, err := json.Marshal(pkg.Spec.BuildSteps)
+		if err != nil {
+			return "", false
+		}
+		steps = string(b)
+	}
+
+	h := sha256.New()
+	for _, v := range []string{srcSum, env.Spec.Builder.Image, buildCmd, fmt.Sprint(env.Spec.KeepArchive), steps} {
+		// length-prefix each input so that different inputs never collide
+		fmt.Fprintf(h, "%d:%s;", len(v), v)
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// findCachedBuild looks up the deployment archive built with the given cache
+// key by another package in the build cache index, and returns it with its
+// upload response if it is still in the storage service. The key covers all
+// the inputs of a build, so an archive is reused across namespaces.
+func (pkgw *packageWatcher) findCachedBuild(ctx context.Context, pkg *fv1.Package, key string) (*buildCacheEntry, *fetcher.ArchiveUploadResponse) {
+	if pkgw.buildCache == nil {
+		return nil, nil
+	}
+
+	entry, err := pkgw.buildCache.get(key)
+	if err != nil {
+		pkgw.logger.Error("error reading build cache index", zap.Error(err))
+		return nil, nil
+	}
+	if entry == nil || entry.Package == fmt.Sprintf("%s/%s", pkg.ObjectMeta.Namespace, pkg.ObjectMeta.Name) {
+		return nil, nil
+	}
+
+	ssClient := storageSvcClient.MakeClient(pkgw.storageSvcUrl)
+	var id string
+	if strings.HasPrefix(entry.URL, ssClient.GetUrl("")) {
+		if u, err := url.Parse(entry.URL); err == nil {
+			id = u.Query().Get("id")
+		}
+	}
+	if len(id) == 0 {
+		return nil, nil
+	}
+
+	// The archive may have been pruned since it was built
+	exists, err := ssClient.Exists(ctx, id)
+	if err != nil {
+		pkgw.logger.Error("error checking cached deployment archive in storage service",
+			zap.Error(err), zap.String("archive_id", id))
+		return nil, nil
+	}
+	if !exists {
+		err = pkgw.buildCache.set(key, nil)
+		if err != nil {
+			pkgw.logger.Error("error deleting pruned build from build cache index", zap.Error(err))
+		}
+		return nil, nil
+	}
+
+	return entry, &fetcher.ArchiveUploadResponse{
+		ArchiveDownloadUrl: entry.URL,
+		Checksum:           entry.Checksum,
+	}
+}
+
+// addCachedBuild adds the deployment archive built for the package with the
+// given cache key to the build cache index.
+func (pkgw *packageWatcher) addCachedBuild(pkg *fv1.Package, key string, uploadResp *fetcher.ArchiveUploadResponse) {
+	if pkgw.buildCache == nil {
+		return
+	}
+	err := pkgw.buildCache.set(key, &buildCacheEntry{
+		Package:   fmt.Sprintf("%s/%s", pkg.ObjectMeta.Namespace, pkg.ObjectMeta.Name),
+		URL:       uploadResp.ArchiveDownloadUrl,
+		Checksum:  uploadResp.Checksum,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		pkgw.logger.Error("error adding build to build cache index", zap.Error(err),
+			zap.String("package_name", pkg.ObjectMeta.Name), zap.String("package_namespace", pkg.ObjectMeta.Namespace))
+	}
+}
@@ -0,0 +1,142 @@
+package buildermgr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fetcher"
+	storageSvcClient "github.com/fission/fission/pkg/storagesvc/client"
+)
+
+func makeCacheTestPackage(name string, src fv1.Archive) *fv1.Package {
+	return &fv1.Package{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       fv1.PackageSpec{Source: src},
+	}
+}
+
+func TestBuildCacheKey(t *testing.T) {
+	env := &fv1.Environment{}
+	env.Spec.Builder.Image = "fission/python-builder"
+	env.Spec.Builder.Command = "build"
+
+	literal := fv1.Archive{Type: fv1.ArchiveTypeLiteral, Literal: []byte("print('hello')")}
+	key, ok := buildCacheKey(makeCacheTestPackage("a", literal), env)
+	assert.True(t, ok)
+
+	// same inputs, same key
+	other, ok := buildCacheKey(makeCacheTestPackage("b", literal), env)
+	assert.True(t, ok)
+	assert.Equal(t, key, other)
+
+	// the package build command overrides the environment one
+	pkg := makeCacheTestPackage("a", literal)
+	pkg.Spec.BuildCommand = "build --release"
+	other, _ = buildCacheKey(pkg, env)
+	assert.NotEqual(t, key, other)
+
+	env2 := env.DeepCopy()
+	env2.Spec.Builder.Image = "fission/python-builder:1.1"
+	other, _ = buildCacheKey(makeCacheTestPackage("a", literal), env2)
+	assert.NotEqual(t, key, other)
+
+	// URL sources are only cacheable with a checksum
+	url := fv1.Archive{Type: fv1.ArchiveTypeUrl, URL: "http://example.com/src.zip"}
+	_, ok = buildCacheKey(makeCacheTestPackage("a", url), env)
+	assert.False(t, ok)
+
+	url.Checksum = fv1.Checksum{Type: fv1.ChecksumTypeSHA256, Sum: "abc"}
+	_, ok = buildCacheKey(makeCacheTestPackage("a", url), env)
+	assert.True(t, ok)
+
+	// build steps are part of the key
+	pkg = makeCacheTestPackage("a", literal)
+	pkg.Spec.BuildSteps = []fv1.BuildStep{{Name: "install", Env: map[string]string{"A": "1", "B": "2"}}}
+	stepsKey, _ := buildCacheKey(pkg, env)
+	assert.NotEqual(t, key, stepsKey)
+	pkg.Spec.BuildSteps[0].Env = map[string]string{"B": "2", "A": "1"}
+	other, _ = buildCacheKey(pkg, env)
+	assert.Equal(t, stepsKey, other)
+}
+
+func TestFindCachedBuild(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		if r.URL.Query().Get("id") != "exists" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	ssClient := storageSvcClient.MakeClient(ts.URL)
+	store := k8sCache.NewStore(k8sCache.MetaNamespaceKeyFunc)
+	pkgw := &packageWatcher{
+		logger:        zap.NewNop(),
+		pkgStore:      store,
+		storageSvcUrl: ts.URL,
+		buildCache:    makeBuildCacheIndex(fake.NewSimpleClientset(), "fission"),
+	}
+
+	addBuilt := func(name, namespace, key, archiveID string) {
+		pkg := makeCacheTestPackage(name, fv1.Archive{})
+		pkg.ObjectMeta.Namespace = namespace
+		pkgw.addCachedBuild(pkg, key, &fetcher.ArchiveUploadResponse{ArchiveDownloadUrl: ssClient.GetUrl(archiveID)})
+	}
+
+	// packages claiming a cached build are not trusted
+	forged := makeCacheTestPackage("forged", fv1.Archive{})
+	forged.ObjectMeta.Annotations = map[string]string{BUILD_CACHE_KEY_ANNOTATION: "k"}
+	forged.Status.BuildStatus = fv1.BuildStatusSucceeded
+	forged.Spec.Deployment = fv1.Archive{Type: fv1.ArchiveTypeUrl, URL: ssClient.GetUrl("exists")}
+	store.Add(forged)
+	pkg := makeCacheTestPackage("pkg", fv1.Archive{})
+	cached, _ := pkgw.findCachedBuild(context.Background(), pkg, "k")
+	assert.Nil(t, cached)
+
+	// pruned archives are dropped from the index
+	addBuilt("pruned", "default", "k", "missing")
+	cached, _ = pkgw.findCachedBuild(context.Background(), pkg, "k")
+	assert.Nil(t, cached)
+	entry, err := pkgw.buildCache.get("k")
+	assert.NoError(t, err)
+	assert.Nil(t, entry)
+
+	// the package itself is not a cached build
+	addBuilt("pkg", "default", "k", "exists")
+	cached, _ = pkgw.findCachedBuild(context.Background(), pkg, "k")
+	assert.Nil(t, cached)
+
+	// builds of other namespaces are reused
+	addBuilt("built", "other", "k", "exists")
+	cached, uploadResp := pkgw.findCachedBuild(context.Background(), pkg, "k")
+	if assert.NotNil(t, cached) {
+		assert.Equal(t, "other/built", cached.Package)
+		assert.Equal(t, ssClient.GetUrl("exists"), uploadResp.ArchiveDownloadUrl)
+	}
+}
+
+func TestPruneBuildCacheEntries(t *testing.T) {
+	now := time.Now()
+	data := make(map[string]string)
+	for i, key := range []string{"a", "b", "c"} {
+		b, _ := json.Marshal(buildCacheEntry{Timestamp: now.Add(time.Duration(i) * time.Minute)})
+		data[key] = string(b)
+	}
+	data["broken"] = "{"
+
+	pruneBuildCacheEntries(data, 2)
+	assert.Len(t, data, 2)
+	assert.Contains(t, data, "b")
+	assert.Contains(t, data, "c")
+}
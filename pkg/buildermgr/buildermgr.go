@@ -17,7 +17,10 @@ limitations under the License.
 package buildermgr
 
 import (
+	"net/http"
+
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 
 	"github.com/fission/fission/pkg/crd"
@@ -47,8 +50,19 @@ func Start(logger *zap.Logger, storageSvcUrl string, envBuilderNamespace string)
 	go envWatcher.watchEnvironments()
 
 	pkgWatcher := makePackageWatcher(bmLogger, fissionClient,
-		kubernetesClient, envBuilderNamespace, storageSvcUrl)
+		kubernetesClient, fetcherConfig, envBuilderNamespace, storageSvcUrl)
 	go pkgWatcher.watchPackages()
 
+	go serveMetric(bmLogger)
+
 	select {}
 }
+
+func serveMetric(logger *zap.Logger) {
+	// Expose the registered metrics via HTTP.
+	metricAddr := ":8080"
+	http.Handle("/metrics", promhttp.Handler())
+	err := http.ListenAndServe(metricAddr, nil)
+
+	logger.Fatal("done listening on metrics endpoint", zap.Error(err))
+}
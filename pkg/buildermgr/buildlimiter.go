@@ -0,0 +1,159 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// buildLimiter caps the number of concurrent builds, in total and per
+	// environment. Builds beyond the caps wait in a queue, and are started in
+	// order as soon as their environment is under the caps.
+	buildLimiter struct {
+		// zero for no limit
+		maxBuilds       int
+		maxBuildsPerEnv int
+
+		lock         sync.Mutex
+		running      int
+		runningByEnv map[string]int
+		queue        []*buildWaiter
+	}
+
+	buildWaiter struct {
+		env     string
+		readyCh chan struct{}
+	}
+)
+
+func makeBuildLimiter(maxBuilds int, maxBuildsPerEnv int) *buildLimiter {
+	return &buildLimiter{
+		maxBuilds:       maxBuilds,
+		maxBuildsPerEnv: maxBuildsPerEnv,
+		runningByEnv:    make(map[string]int),
+	}
+}
+
+// tryAcquire takes a build slot for the environment without waiting, and
+// returns whether it did.
+func (limiter *buildLimiter) tryAcquire(env string) bool {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	// builds of the environment already waiting go first
+	for _, w := range limiter.queue {
+		if w.env == env {
+			return false
+		}
+	}
+	if !limiter.available(env) {
+		return false
+	}
+	limiter.take(env)
+	return true
+}
+
+// acquire takes a build slot for the environment, waiting in the queue until
+// one is free or the context is done.
+func (limiter *buildLimiter) acquire(ctx context.Context, env string) error {
+	if limiter.tryAcquire(env) {
+		return nil
+	}
+
+	w := &buildWaiter{
+		env:     env,
+		readyCh: make(chan struct{}),
+	}
+	limiter.lock.Lock()
+	limiter.queue = append(limiter.queue, w)
+	// a slot may have been released since tryAcquire
+	limiter.dispatch()
+	limiter.lock.Unlock()
+
+	select {
+	case <-w.readyCh:
+		return nil
+	case <-ctx.Done():
+		limiter.lock.Lock()
+		defer limiter.lock.Unlock()
+
+		select {
+		case <-w.readyCh:
+			// granted meanwhile, give it to the next build
+			limiter.releaseLocked(env)
+		default:
+			for i, qw := range limiter.queue {
+				if qw == w {
+					limiter.queue = append(limiter.queue[:i], limiter.queue[i+1:]...)
+					break
+				}
+			}
+			limiter.observe()
+		}
+		return ctx.Err()
+	}
+}
+
+// release returns a build slot of the environment.
+func (limiter *buildLimiter) release(env string) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+	limiter.releaseLocked(env)
+}
+
+func (limiter *buildLimiter) releaseLocked(env string) {
+	limiter.running--
+	limiter.runningByEnv[env]--
+	if limiter.runningByEnv[env] <= 0 {
+		delete(limiter.runningByEnv, env)
+	}
+	limiter.dispatch()
+}
+
+// dispatch starts queued builds in order, skipping those whose environment
+// is at its cap.
+func (limiter *buildLimiter) dispatch() {
+	queue := limiter.queue[:0]
+	for _, w := range limiter.queue {
+		if limiter.available(w.env) {
+			limiter.take(w.env)
+			close(w.readyCh)
+			continue
+		}
+		queue = append(queue, w)
+	}
+	limiter.queue = queue
+	limiter.observe()
+}
+
+func (limiter *buildLimiter) available(env string) bool {
+	return (limiter.maxBuilds <= 0 || limiter.running < limiter.maxBuilds) &&
+		(limiter.maxBuildsPerEnv <= 0 || limiter.runningByEnv[env] < limiter.maxBuildsPerEnv)
+}
+
+func (limiter *buildLimiter) take(env string) {
+	limiter.running++
+	limiter.runningByEnv[env]++
+	limiter.observe()
+}
+
+func (limiter *buildLimiter) observe() {
+	buildsRunning.Set(float64(limiter.running))
+	buildsQueued.Set(float64(len(limiter.queue)))
+}
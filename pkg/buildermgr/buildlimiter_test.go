@@ -0,0 +1,54 @@
+package buildermgr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildLimiter(t *testing.T) {
+	limiter := makeBuildLimiter(2, 1)
+
+	assert.True(t, limiter.tryAcquire("a"))
+	// environment a is at its cap
+	assert.False(t, limiter.tryAcquire("a"))
+	assert.True(t, limiter.tryAcquire("b"))
+	// total cap reached
+	assert.False(t, limiter.tryAcquire("c"))
+
+	acquired := make(chan string, 2)
+	go func() {
+		assert.NoError(t, limiter.acquire(context.Background(), "a"))
+		acquired <- "a"
+	}()
+	time.Sleep(50 * time.Millisecond)
+	go func() {
+		assert.NoError(t, limiter.acquire(context.Background(), "c"))
+		acquired <- "c"
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// a queued build of environment a does not block environment c
+	limiter.release("b")
+	assert.Equal(t, "c", <-acquired)
+
+	limiter.release("a")
+	assert.Equal(t, "a", <-acquired)
+
+	// a canceled build leaves the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() {
+		errCh <- limiter.acquire(ctx, "d")
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-errCh)
+
+	limiter.lock.Lock()
+	assert.Empty(t, limiter.queue)
+	assert.Equal(t, 2, limiter.running)
+	limiter.lock.Unlock()
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/builder"
+	"github.com/fission/fission/pkg/crd"
+)
+
+// buildLogRelayInterval is how often the build log of a running package is
+// updated with the build output streamed by the builder.
+const buildLogRelayInterval = 2 * time.Second
+
+type (
+	// buildLogRelay writes build output and build step statuses to the
+	// status of a running package as the builder streams them, so that the
+	// build can be followed before it completes.
+	buildLogRelay struct {
+		logger        *zap.Logger
+		fissionClient *crd.FissionClient
+
+		// pkg is only accessed by the relay goroutine until stopped
+		pkg *fv1.Package
+
+		lock  sync.Mutex
+		logs  strings.Builder
+		steps []fv1.BuildStepStatus
+		dirty bool
+
+		stopCh chan struct{}
+		doneCh chan struct{}
+	}
+)
+
+func startBuildLogRelay(logger *zap.Logger, fissionClient *crd.FissionClient, pkg *fv1.Package) *buildLogRelay {
+	relay := &buildLogRelay{
+		logger:        logger,
+		fissionClient: fissionClient,
+		pkg:           pkg,
+		stopCh:        make(chan struct{}),
+		doneCh:        make(chan struct{}),
+	}
+	go relay.run()
+	return relay
+}
+
+// handle adds a line of build output to the build log, and to the log of its
+// build step if any, or updates the status of a build step.
+func (relay *buildLogRelay) handle(event *builder.PackageBuildEvent) {
+	relay.lock.Lock()
+	defer relay.lock.Unlock()
+	relay.dirty = true
+
+	if event.StepStatus != nil {
+		for i := range relay.steps {
+			if relay.steps[i].Name == event.StepStatus.Name {
+				relay.steps[i] = *event.StepStatus
+				return
+			}
+		}
+		relay.steps = append(relay.steps, *event.StepStatus)
+		return
+	}
+
+	relay.logs.WriteString(event.Log)
+	relay.logs.WriteString("\n")
+	if len(event.Step) > 0 {
+		for i := range relay.steps {
+			if relay.steps[i].Name == event.Step {
+				relay.steps[i].Log += event.Log + "\n"
+				break
+			}
+		}
+	}
+}
+
+// stop stops updating the package and returns its latest version, with the
+// build step statuses, for the final status update not to conflict with the
+// relayed ones.
+func (relay *buildLogRelay) stop() *fv1.Package {
+	close(relay.stopCh)
+	<-relay.doneCh
+
+	relay.lock.Lock()
+	defer relay.lock.Unlock()
+	relay.pkg.Status.Steps = relay.copySteps()
+	return relay.pkg
+}
+
+func (relay *buildLogRelay) copySteps() []fv1.BuildStepStatus {
+	if relay.steps == nil {
+		return nil
+	}
+	steps := make([]fv1.BuildStepStatus, len(relay.steps))
+	copy(steps, relay.steps)
+	return steps
+}
+
+func (relay *buildLogRelay) run() {
+	defer close(relay.doneCh)
+
+	ticker := time.NewTicker(buildLogRelayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			relay.flush()
+		case <-relay.stopCh:
+			return
+		}
+	}
+}
+
+func (relay *buildLogRelay) flush() {
+	relay.lock.Lock()
+	if !relay.dirty {
+		relay.lock.Unlock()
+		return
+	}
+	logs := relay.logs.String()
+	pkg := relay.pkg.DeepCopy()
+	pkg.Status.Steps = relay.copySteps()
+	relay.dirty = false
+	relay.lock.Unlock()
+
+	pkg, err := updatePackage(relay.logger, relay.fissionClient, pkg, fv1.BuildStatusRunning, logs, nil)
+	if err != nil {
+		relay.logger.Warn("error relaying build logs to package", zap.Error(err),
+			zap.String("package_name", relay.pkg.ObjectMeta.Name),
+			zap.String("package_namespace", relay.pkg.ObjectMeta.Namespace))
+		return
+	}
+	relay.pkg = pkg
+}
@@ -0,0 +1,440 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/builder"
+	builderClient "github.com/fission/fission/pkg/builder/client"
+	"github.com/fission/fission/pkg/executor/util"
+	"github.com/fission/fission/pkg/fetcher"
+	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
+)
+
+const (
+	// buildStepContainer is the container of a build step in its pod.
+	buildStepContainer = "step"
+
+	// buildStepPollInterval is the interval of checks of build step pods.
+	buildStepPollInterval = time.Second
+)
+
+// buildStepPodStartErrors are the reasons a container of a build step pod
+// is waiting for which it is not going to start.
+var buildStepPodStartErrors = map[string]bool{
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+type (
+	// stepBuild runs the build steps of a package, some with an image in a pod
+	// of their own, on the source and deployment packages kept by the
+	// environment builder.
+	stepBuild struct {
+		logger           *zap.Logger
+		kubernetesClient kubernetes.Interface
+		fetcherConfig    *fetcherConfig.Config
+		namespace        string // of the environment builder
+		env              *fv1.Environment
+		builderC         *builderClient.Client
+		req              *builder.PackageBuildRequest
+		onEvent          func(*builder.PackageBuildEvent)
+
+		resp *builder.PackageBuildResponse
+	}
+)
+
+// hasImageSteps returns whether any of the build steps has an image.
+func hasImageSteps(steps []fv1.BuildStep) bool {
+	for _, step := range steps {
+		if len(step.Image) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// splitBuildSteps splits build steps in runs of consecutive steps without an
+// image, run by a single request to the environment builder, and steps with
+// an image, run alone.
+func splitBuildSteps(steps []fv1.BuildStep) [][]fv1.BuildStep {
+	var runs [][]fv1.BuildStep
+	for _, step := range steps {
+		last := len(runs) - 1
+		if len(step.Image) == 0 && last >= 0 && len(runs[last][0].Image) == 0 {
+			runs[last] = append(runs[last], step)
+			continue
+		}
+		runs = append(runs, []fv1.BuildStep{step})
+	}
+	return runs
+}
+
+// run runs the build steps in order until one fails, into a deployment
+// package named by the build. The response has the build logs and step
+// statuses so far even on error.
+func (sb *stepBuild) run(ctx context.Context) (*builder.PackageBuildResponse, error) {
+	sb.resp = &builder.PackageBuildResponse{
+		ArtifactFilename: fmt.Sprintf("%v-%v", sb.req.SrcPkgFilename, strings.ToLower(uniuri.NewLen(6))),
+	}
+	for _, step := range sb.req.Steps {
+		sb.resp.Steps = append(sb.resp.Steps, fv1.BuildStepStatus{
+			Name:   step.Name,
+			Status: fv1.BuildStatusPending,
+		})
+	}
+	// the environment builder reports the steps it runs only, report all
+	// of them first to keep their order
+	for i := range sb.resp.Steps {
+		sb.sendStatus(&sb.resp.Steps[i])
+	}
+
+	for _, steps := range splitBuildSteps(sb.req.Steps) {
+		var err error
+		if len(steps[0].Image) > 0 {
+			err = sb.runImageStep(ctx, steps[0])
+		} else {
+			err = sb.runBuilderSteps(ctx, steps)
+		}
+		if err != nil {
+			return sb.resp, err
+		}
+	}
+	return sb.resp, nil
+}
+
+// runBuilderSteps runs build steps without an image in the environment
+// builder.
+func (sb *stepBuild) runBuilderSteps(ctx context.Context, steps []fv1.BuildStep) error {
+	req := *sb.req
+	req.Steps = steps
+	req.DeployPkgFilename = sb.resp.ArtifactFilename
+
+	resp, err := sb.builderC.Build(ctx, &req, sb.onEvent)
+	if resp != nil {
+		sb.resp.BuildLogs += resp.BuildLogs
+		for _, status := range resp.Steps {
+			if s := sb.status(status.Name); s != nil {
+				*s = status
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// builders of older releases ignore build steps, or the deployment
+	// package to build into
+	if len(resp.Steps) == 0 || resp.ArtifactFilename != sb.resp.ArtifactFilename {
+		return errors.New("environment builder does not support build steps with an image, an environment builder image of a newer release is required")
+	}
+	return nil
+}
+
+// runImageStep runs a build step with an image in a pod of its own, sending
+// its status and output as build events like the environment builder does.
+func (sb *stepBuild) runImageStep(ctx context.Context, step fv1.BuildStep) error {
+	status := sb.status(step.Name)
+	status.Status = fv1.BuildStatusRunning
+	status.StartTimestamp = metav1.Time{Time: time.Now().UTC()}
+	sb.sendStatus(status)
+
+	onLog := func(line string) {
+		status.Log += line + "\n"
+		if sb.onEvent != nil {
+			sb.onEvent(&builder.PackageBuildEvent{Step: step.Name, Log: line})
+		}
+	}
+
+	// the header is part of the build logs only
+	header := fmt.Sprintf("=== build step %v ===", step.Name)
+	sb.resp.BuildLogs += header + "\n"
+	if sb.onEvent != nil {
+		sb.onEvent(&builder.PackageBuildEvent{Log: header})
+	}
+
+	err := sb.runPod(ctx, step, onLog)
+
+	status.CompletionTimestamp = metav1.Time{Time: time.Now().UTC()}
+	if err != nil {
+		status.Status = fv1.BuildStatusFailed
+		onLog(fmt.Sprintf("error running build step %v: %v", step.Name, err))
+		sb.sendStatus(status)
+		sb.resp.BuildLogs += status.Log
+		return errors.Wrapf(err, "build step %q failed", step.Name)
+	}
+
+	status.Status = fv1.BuildStatusSucceeded
+	sb.sendStatus(status)
+	sb.resp.BuildLogs += status.Log
+	return nil
+}
+
+// runPod runs a build step with an image in a pod getting the source and
+// deployment packages from the environment builder before the step, and
+// putting them back after it. Lines of output of the step are passed to
+// onLog.
+func (sb *stepBuild) runPod(ctx context.Context, step fv1.BuildStep, onLog func(string)) error {
+	workspace := fmt.Sprintf("step-%v", strings.ToLower(uniuri.NewLen(6)))
+	workspacePath := filepath.Join(sb.fetcherConfig.SharedMountPath(), workspace)
+	srcPkgPath := filepath.Join(workspacePath, sb.req.SrcPkgFilename)
+	deployPkgPath := filepath.Join(workspacePath, sb.resp.ArtifactFilename)
+	workspaceUrl := sb.builderC.WorkspaceUrl(sb.req.SrcPkgFilename, sb.resp.ArtifactFilename)
+
+	container := apiv1.Container{
+		Name:    buildStepContainer,
+		Image:   step.Image,
+		Command: []string{step.Command},
+		Args:    step.Args,
+		Env:     buildStepEnv(step, srcPkgPath, deployPkgPath),
+		// like in the environment builder, the step runs in the source
+		// package directory
+		WorkingDir: srcPkgPath,
+	}
+
+	pod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%v-step-", sb.env.ObjectMeta.Name),
+			Namespace:    sb.namespace,
+			Labels: map[string]string{
+				LABEL_DEPLOYMENT_OWNER: BUILDER_MGR,
+			},
+			// a sidecar would keep the pod from completing
+			Annotations: map[string]string{
+				"sidecar.istio.io/inject": "false",
+			},
+		},
+		Spec: apiv1.PodSpec{
+			RestartPolicy:      apiv1.RestartPolicyNever,
+			ServiceAccountName: fv1.FissionBuilderSA,
+		},
+	}
+	err := sb.fetcherConfig.AddBuildStepToPodSpec(&pod.Spec, container,
+		fetcher.FunctionFetchRequest{
+			FetchType: fv1.FETCH_URL,
+			Url:       workspaceUrl,
+			Filename:  workspace,
+		},
+		fetcher.ArchivePutRequest{
+			Filename: workspace,
+			Url:      workspaceUrl,
+		})
+	if err != nil {
+		return errors.Wrap(err, "error adding fetcher to build step pod")
+	}
+	pod.Spec = *(util.ApplyImagePullSecret(sb.env.Spec.ImagePullSecret, pod.Spec))
+
+	pods := sb.kubernetesClient.CoreV1().Pods(sb.namespace)
+	pod, err = pods.Create(pod)
+	if err != nil {
+		return errors.Wrap(err, "error creating build step pod")
+	}
+	logger := sb.logger.With(zap.String("pod", pod.ObjectMeta.Name), zap.String("step", step.Name))
+	logger.Info("created build step pod")
+	defer func() {
+		err := pods.Delete(pod.ObjectMeta.Name, &metav1.DeleteOptions{})
+		if err != nil {
+			logger.Error("error deleting build step pod", zap.Error(err))
+		}
+	}()
+
+	// the output of the step can be followed once it runs
+	pod, err = sb.waitForPod(ctx, pod.ObjectMeta.Name, func(pod *apiv1.Pod) bool {
+		cStatus := findContainerStatus(pod.Status.InitContainerStatuses, buildStepContainer)
+		return isPodDone(pod) || (cStatus != nil && (cStatus.State.Running != nil || cStatus.State.Terminated != nil))
+	})
+	if err != nil {
+		return err
+	}
+	err = sb.followLogs(ctx, pod.ObjectMeta.Name, onLog)
+	if err != nil {
+		logger.Error("error following build step logs", zap.Error(err))
+	}
+
+	pod, err = sb.waitForPod(ctx, pod.ObjectMeta.Name, isPodDone)
+	if err != nil {
+		return err
+	}
+	return buildStepPodError(pod)
+}
+
+// waitForPod polls the pod until the condition is met, the pod cannot start
+// or the context is done.
+func (sb *stepBuild) waitForPod(ctx context.Context, name string, condition func(*apiv1.Pod) bool) (*apiv1.Pod, error) {
+	ticker := time.NewTicker(buildStepPollInterval)
+	defer ticker.Stop()
+
+	for {
+		pod, err := sb.kubernetesClient.CoreV1().Pods(sb.namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "error getting build step pod")
+		}
+		if condition(pod) {
+			return pod, nil
+		}
+		err = buildStepPodStartError(pod)
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// followLogs passes lines of output of the build step to onLog until the
+// step is done or the context is.
+func (sb *stepBuild) followLogs(ctx context.Context, name string, onLog func(string)) error {
+	stream, err := sb.kubernetesClient.CoreV1().Pods(sb.namespace).GetLogs(name, &apiv1.PodLogOptions{
+		Container: buildStepContainer,
+		Follow:    true,
+	}).Stream()
+	if err != nil {
+		return errors.Wrap(err, "error streaming build step logs")
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		stream.Close()
+	}()
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		onLog(scanner.Text())
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+// status returns the status of the named build step.
+func (sb *stepBuild) status(name string) *fv1.BuildStepStatus {
+	for i := range sb.resp.Steps {
+		if sb.resp.Steps[i].Name == name {
+			return &sb.resp.Steps[i]
+		}
+	}
+	return nil
+}
+
+func (sb *stepBuild) sendStatus(status *fv1.BuildStepStatus) {
+	if sb.onEvent != nil {
+		s := *status
+		sb.onEvent(&builder.PackageBuildEvent{Step: s.Name, StepStatus: &s})
+	}
+}
+
+// buildStepEnv returns the environment variables of a build step run in a
+// pod, the same as in the environment builder.
+func buildStepEnv(step fv1.BuildStep, srcPkgPath string, deployPkgPath string) []apiv1.EnvVar {
+	names := make([]string, 0, len(step.Env))
+	for name := range step.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]apiv1.EnvVar, 0, len(names)+2)
+	for _, name := range names {
+		env = append(env, apiv1.EnvVar{Name: name, Value: step.Env[name]})
+	}
+	return append(env,
+		apiv1.EnvVar{Name: "SRC_PKG", Value: srcPkgPath},
+		apiv1.EnvVar{Name: "DEPLOY_PKG", Value: deployPkgPath},
+	)
+}
+
+func isPodDone(pod *apiv1.Pod) bool {
+	return pod.Status.Phase == apiv1.PodSucceeded || pod.Status.Phase == apiv1.PodFailed
+}
+
+// podContainerStatuses returns the statuses of the init containers and of
+// the containers of the pod.
+func podContainerStatuses(pod *apiv1.Pod) []apiv1.ContainerStatus {
+	statuses := make([]apiv1.ContainerStatus, 0, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+	return append(statuses, pod.Status.ContainerStatuses...)
+}
+
+func findContainerStatus(statuses []apiv1.ContainerStatus, name string) *apiv1.ContainerStatus {
+	for i := range statuses {
+		if statuses[i].Name == name {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+// buildStepPodStartError returns an error if a container of the build step
+// pod is not going to start, such as if its image cannot be pulled.
+func buildStepPodStartError(pod *apiv1.Pod) error {
+	for _, cStatus := range podContainerStatuses(pod) {
+		waiting := cStatus.State.Waiting
+		if waiting != nil && buildStepPodStartErrors[waiting.Reason] {
+			return errors.Errorf("container %v of build step pod cannot start: %v: %v", cStatus.Name, waiting.Reason, waiting.Message)
+		}
+	}
+	return nil
+}
+
+// buildStepPodError returns the error of a build step pod done, if it failed.
+func buildStepPodError(pod *apiv1.Pod) error {
+	if pod.Status.Phase == apiv1.PodSucceeded {
+		return nil
+	}
+
+	for _, cStatus := range podContainerStatuses(pod) {
+		terminated := cStatus.State.Terminated
+		if terminated == nil || terminated.ExitCode == 0 {
+			continue
+		}
+		switch cStatus.Name {
+		case buildStepContainer:
+			return errors.Errorf("build step exited with code %v", terminated.ExitCode)
+		case "fetcher":
+			return errors.Errorf("error getting the packages from the environment builder: %v", terminated.Message)
+		default:
+			return errors.Errorf("error putting the packages back to the environment builder: %v", terminated.Message)
+		}
+	}
+	return errors.Errorf("build step pod failed: %v %v", pod.Status.Reason, pod.Status.Message)
+}
@@ -0,0 +1,81 @@
+package buildermgr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestSplitBuildSteps(t *testing.T) {
+	names := func(runs [][]fv1.BuildStep) [][]string {
+		var result [][]string
+		for _, steps := range runs {
+			var run []string
+			for _, step := range steps {
+				run = append(run, step.Name)
+			}
+			result = append(result, run)
+		}
+		return result
+	}
+
+	assert.Nil(t, splitBuildSteps(nil))
+	assert.Equal(t, [][]string{{"install", "test"}}, names(splitBuildSteps([]fv1.BuildStep{
+		{Name: "install"}, {Name: "test"},
+	})))
+	// steps with an image run alone, even one after another
+	assert.Equal(t, [][]string{{"lint"}, {"scan"}, {"install", "test"}, {"push"}}, names(splitBuildSteps([]fv1.BuildStep{
+		{Name: "lint", Image: "linter"},
+		{Name: "scan", Image: "scanner"},
+		{Name: "install"},
+		{Name: "test"},
+		{Name: "push", Image: "pusher"},
+	})))
+}
+
+func TestBuildStepEnv(t *testing.T) {
+	env := buildStepEnv(fv1.BuildStep{Env: map[string]string{"MODE": "production", "DEBUG": "false"}}, "/packages/step/src", "/packages/step/deploy")
+	assert.Equal(t, []apiv1.EnvVar{
+		{Name: "DEBUG", Value: "false"},
+		{Name: "MODE", Value: "production"},
+		{Name: "SRC_PKG", Value: "/packages/step/src"},
+		{Name: "DEPLOY_PKG", Value: "/packages/step/deploy"},
+	}, env)
+}
+
+func TestBuildStepPodError(t *testing.T) {
+	terminated := func(name string, exitCode int32) apiv1.ContainerStatus {
+		return apiv1.ContainerStatus{
+			Name: name,
+			State: apiv1.ContainerState{
+				Terminated: &apiv1.ContainerStateTerminated{ExitCode: exitCode, Message: "connection refused"},
+			},
+		}
+	}
+
+	pod := &apiv1.Pod{Status: apiv1.PodStatus{Phase: apiv1.PodSucceeded}}
+	assert.NoError(t, buildStepPodError(pod))
+
+	pod.Status.Phase = apiv1.PodFailed
+	pod.Status.InitContainerStatuses = []apiv1.ContainerStatus{terminated("fetcher", 0), terminated(buildStepContainer, 2)}
+	assert.EqualError(t, buildStepPodError(pod), "build step exited with code 2")
+
+	pod.Status.InitContainerStatuses = []apiv1.ContainerStatus{terminated("fetcher", 1)}
+	assert.EqualError(t, buildStepPodError(pod), "error getting the packages from the environment builder: connection refused")
+
+	pod.Status.InitContainerStatuses = []apiv1.ContainerStatus{terminated("fetcher", 0), terminated(buildStepContainer, 0)}
+	pod.Status.ContainerStatuses = []apiv1.ContainerStatus{terminated("uploader", 1)}
+	assert.EqualError(t, buildStepPodError(pod), "error putting the packages back to the environment builder: connection refused")
+
+	// an image which cannot be pulled fails the step before it runs
+	pod.Status.Phase = apiv1.PodPending
+	pod.Status.InitContainerStatuses = []apiv1.ContainerStatus{terminated("fetcher", 0), {
+		Name:  buildStepContainer,
+		State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "not found"}},
+	}}
+	pod.Status.ContainerStatuses = nil
+	assert.EqualError(t, buildStepPodStartError(pod), "container step of build step pod cannot start: ImagePullBackOff: not found")
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/builder"
@@ -35,17 +36,25 @@ import (
 	ferror "github.com/fission/fission/pkg/error"
 	"github.com/fission/fission/pkg/fetcher"
 	fetcherClient "github.com/fission/fission/pkg/fetcher/client"
+	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
 )
 
+// builderSvcName returns the name of the builder service of the environment.
+func builderSvcName(env *fv1.Environment, envBuilderNamespace string) string {
+	return fmt.Sprintf("%v-%v.%v", env.ObjectMeta.Name, env.ObjectMeta.ResourceVersion, envBuilderNamespace)
+}
+
 // buildPackage helps to build source package into deployment package.
 // Following is the steps buildPackage function takes to complete the whole process.
 // 1. Send fetch request to fetcher to fetch source package.
-// 2. Send build request to builder to start a build.
+// 2. Send build request to builder to start a build, passing build events such as lines of output to onEvent,
+// running build steps with an image in a pod of their own in between requests for the other steps.
 // 3. Send upload request to fetcher to upload deployment package.
 // 4. Return upload response and build logs.
 // *. Return build logs and error if any one of steps above failed.
-func buildPackage(ctx context.Context, logger *zap.Logger, fissionClient *crd.FissionClient, envBuilderNamespace string,
-	storageSvcUrl string, pkg *fv1.Package) (uploadResp *fetcher.ArchiveUploadResponse, buildLogs string, err error) {
+func buildPackage(ctx context.Context, logger *zap.Logger, fissionClient *crd.FissionClient, kubernetesClient kubernetes.Interface,
+	fetcherConfig *fetcherConfig.Config, envBuilderNamespace string, storageSvcUrl string, pkg *fv1.Package,
+	onEvent func(*builder.PackageBuildEvent)) (uploadResp *fetcher.ArchiveUploadResponse, buildLogs string, err error) {
 
 	env, err := fissionClient.CoreV1().Environments(pkg.Spec.Environment.Namespace).Get(pkg.Spec.Environment.Name, metav1.GetOptions{})
 	if err != nil {
@@ -55,7 +64,7 @@ func buildPackage(ctx context.Context, logger *zap.Logger, fissionClient *crd.Fi
 		return nil, e, ferror.MakeError(http.StatusInternalServerError, e)
 	}
 
-	svcName := fmt.Sprintf("%v-%v.%v", env.ObjectMeta.Name, env.ObjectMeta.ResourceVersion, envBuilderNamespace)
+	svcName := builderSvcName(env, envBuilderNamespace)
 	srcPkgFilename := fmt.Sprintf("%v-%v", pkg.ObjectMeta.Name, strings.ToLower(uniuri.NewLen(6)))
 	fetcherC := fetcherClient.MakeClient(logger, fmt.Sprintf("http://%v:8000", svcName))
 	builderC := builderClient.MakeClient(logger, fmt.Sprintf("http://%v:8001", svcName))
@@ -84,11 +93,29 @@ func buildPackage(ctx context.Context, logger *zap.Logger, fissionClient *crd.Fi
 	pkgBuildReq := &builder.PackageBuildRequest{
 		SrcPkgFilename: srcPkgFilename,
 		BuildCommand:   buildCmd,
+		Steps:          pkg.Spec.BuildSteps,
+		// cache directories are kept per package
+		CacheKey: string(pkg.ObjectMeta.UID),
 	}
 
 	logger.Info("started building with source package", zap.String("source_package", srcPkgFilename))
 	// send build request to builder
-	buildResp, err := builderC.Build(pkgBuildReq)
+	var buildResp *builder.PackageBuildResponse
+	if hasImageSteps(pkg.Spec.BuildSteps) {
+		sb := &stepBuild{
+			logger:           logger,
+			kubernetesClient: kubernetesClient,
+			fetcherConfig:    fetcherConfig,
+			namespace:        envBuilderNamespace,
+			env:              env,
+			builderC:         builderC,
+			req:              pkgBuildReq,
+			onEvent:          onEvent,
+		}
+		buildResp, err = sb.run(ctx)
+	} else {
+		buildResp, err = builderC.Build(ctx, pkgBuildReq, onEvent)
+	}
 	if err != nil {
 		e := fmt.Sprintf("Error building deployment package: %v", err)
 		var buildLogs string
@@ -99,6 +126,13 @@ func buildPackage(ctx context.Context, logger *zap.Logger, fissionClient *crd.Fi
 		return nil, buildLogs, ferror.MakeError(http.StatusInternalServerError, e)
 	}
 
+	// builders of older releases ignore build steps and run the build command
+	if len(pkg.Spec.BuildSteps) > 0 && len(buildResp.Steps) == 0 {
+		e := "environment builder does not support build steps, an environment builder image of a newer release is required"
+		buildResp.BuildLogs += fmt.Sprintf("%v\n", e)
+		return nil, buildResp.BuildLogs, ferror.MakeError(http.StatusInternalServerError, e)
+	}
+
 	logger.Info("build succeed", zap.String("source_package", srcPkgFilename), zap.String("deployment_package", buildResp.ArtifactFilename))
 
 	archivePackage := !env.Spec.KeepArchive
@@ -125,18 +159,17 @@ func updatePackage(logger *zap.Logger, fissionClient *crd.FissionClient,
 	pkg *fv1.Package, status fv1.BuildStatus, buildLogs string,
 	uploadResp *fetcher.ArchiveUploadResponse) (*fv1.Package, error) {
 
+	// the build step statuses and revisions are set on the package by the caller
 	pkg.Status = fv1.PackageStatus{
 		BuildStatus:         status,
 		BuildLog:            buildLogs,
+		Steps:               pkg.Status.Steps,
+		Revisions:           pkg.Status.Revisions,
 		LastUpdateTimestamp: metav1.Time{Time: time.Now().UTC()},
 	}
 
 	if uploadResp != nil {
-		pkg.Spec.Deployment = fv1.Archive{
-			Type:     fv1.ArchiveTypeUrl,
-			URL:      uploadResp.ArchiveDownloadUrl,
-			Checksum: uploadResp.Checksum,
-		}
+		pkg.Spec.Deployment = deploymentArchive(uploadResp)
 	}
 
 	// update package spec
@@ -150,3 +183,12 @@ func updatePackage(logger *zap.Logger, fissionClient *crd.FissionClient,
 	// return resource version for function to update function package ref
 	return pkg, nil
 }
+
+// deploymentArchive returns the archive of an uploaded deployment package.
+func deploymentArchive(uploadResp *fetcher.ArchiveUploadResponse) fv1.Archive {
+	return fv1.Archive{
+		Type:     fv1.ArchiveTypeUrl,
+		URL:      uploadResp.ArchiveDownloadUrl,
+		Checksum: uploadResp.Checksum,
+	}
+}
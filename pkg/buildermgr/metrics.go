@@ -0,0 +1,45 @@
+package buildermgr
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// results of looking up the build cache, used as metric label
+	buildCacheHit         = "hit"
+	buildCacheMiss        = "miss"
+	buildCacheUncacheable = "uncacheable"
+)
+
+var (
+	// result: hit | miss | uncacheable
+	buildCacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_build_cache_lookups_total",
+			Help: "Number of package builds looked up in the build cache, by result.",
+		},
+		[]string{"result"},
+	)
+	buildsRunning = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fission_builds_running",
+			Help: "Number of package builds running.",
+		},
+	)
+	buildsQueued = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "fission_builds_queued",
+			Help: "Number of package builds waiting for the concurrent build limits.",
+		},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(buildCacheLookups)
+	prometheus.MustRegister(buildsRunning)
+	prometheus.MustRegister(buildsQueued)
+}
+
+func observeBuildCache(result string) {
+	buildCacheLookups.WithLabelValues(result).Inc()
+}
@@ -19,8 +19,12 @@ package buildermgr
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	apiv1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -30,8 +34,11 @@ import (
 	k8sCache "k8s.io/client-go/tools/cache"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	builderClient "github.com/fission/fission/pkg/builder/client"
 	"github.com/fission/fission/pkg/cache"
 	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/fetcher"
+	fetcherConfig "github.com/fission/fission/pkg/fetcher/config"
 	"github.com/fission/fission/pkg/utils"
 )
 
@@ -40,15 +47,30 @@ type (
 		logger           *zap.Logger
 		fissionClient    *crd.FissionClient
 		k8sClient        *kubernetes.Clientset
+		fetcherConfig    *fetcherConfig.Config
 		podStore         k8sCache.Store
 		pkgStore         k8sCache.Store
 		builderNamespace string
 		storageSvcUrl    string
+
+		limiter             *buildLimiter
+		defaultBuildTimeout time.Duration
+		maxRevisions        int
+		buildCache          *buildCacheIndex
+
+		buildsLock sync.Mutex
+		builds     map[string]*runningBuild
+	}
+
+	// runningBuild is a build in progress, which is canceled if its package is
+	// deleted or built again.
+	runningBuild struct {
+		cancel context.CancelFunc
 	}
 )
 
 func makePackageWatcher(logger *zap.Logger, fissionClient *crd.FissionClient, k8sClientSet *kubernetes.Clientset,
-	builderNamespace string, storageSvcUrl string) *packageWatcher {
+	fetcherConfig *fetcherConfig.Config, builderNamespace string, storageSvcUrl string) *packageWatcher {
 	lw := k8sCache.NewListWatchFromClient(k8sClientSet.CoreV1().RESTClient(), "pods", metav1.NamespaceAll, fields.Everything())
 	store, controller := k8sCache.NewInformer(lw, &apiv1.Pod{}, 30*time.Second, k8sCache.ResourceEventHandlerFuncs{})
 	go controller.Run(make(chan struct{}))
@@ -57,23 +79,57 @@ func makePackageWatcher(logger *zap.Logger, fissionClient *crd.FissionClient, k8
 		logger:           logger.Named("package_watcher"),
 		fissionClient:    fissionClient,
 		k8sClient:        k8sClientSet,
+		fetcherConfig:    fetcherConfig,
 		podStore:         store,
 		builderNamespace: builderNamespace,
 		storageSvcUrl:    storageSvcUrl,
+		builds:           make(map[string]*runningBuild),
+	}
+
+	maxBuilds := getIntEnv(pkgw.logger, "MAX_CONCURRENT_BUILDS")
+	maxBuildsPerEnv := getIntEnv(pkgw.logger, "MAX_CONCURRENT_BUILDS_PER_ENVIRONMENT")
+	pkgw.limiter = makeBuildLimiter(maxBuilds, maxBuildsPerEnv)
+	pkgw.defaultBuildTimeout = time.Duration(getIntEnv(pkgw.logger, "DEFAULT_BUILD_TIMEOUT")) * time.Second
+	pkgw.maxRevisions = getIntEnv(pkgw.logger, "MAX_PACKAGE_REVISIONS")
+	if pkgw.maxRevisions == 0 {
+		pkgw.maxRevisions = DEFAULT_MAX_PACKAGE_REVISIONS
+	}
+	pkgw.buildCache = makeBuildCacheIndex(k8sClientSet, os.Getenv("BUILD_CACHE_NAMESPACE"))
+	if pkgw.buildCache == nil {
+		pkgw.logger.Info("BUILD_CACHE_NAMESPACE is not set, package builds are not reused")
 	}
+
 	return pkgw
 }
 
+// getIntEnv returns the value of an optional integer environment variable,
+// zero if not set or invalid.
+func getIntEnv(logger *zap.Logger, name string) int {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return 0
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		logger.Error("failed to parse environment variable, ignoring it", zap.String("name", name), zap.String("value", value))
+		return 0
+	}
+	return i
+}
+
 // build helps to update package status, checks environment builder pod status and
 // dispatches buildPackage to build source package into deployment package.
 // Following is the steps build function takes to complete the whole process.
 // 1. Check package status
-// 2. Update package status to running state
-// 3. Check environment builder pod status
-// 4. Call buildPackage to build package
-// 5. Update package resource in package ref of functions that share the same package
-// 6. Update package status to succeed state
+// 2. Wait for the concurrent build limits in queued state, if reached
+// 3. Update package status to running state
+// 4. Reuse the deployment package of a cached build with the same inputs, if any
+// 5. Check environment builder pod status
+// 6. Call buildPackage to build package
+// 7. Update package resource in package ref of functions that share the same package
+// 8. Update package status to succeed state
 // *. Update package status to failed state,if any one of steps above failed/time out
+// The build stops if the package is deleted or built again meanwhile.
 func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package) {
 	// Ignore duplicate build requests
 	key := fmt.Sprintf("%v-%v", srcpkg.ObjectMeta.Name, srcpkg.ObjectMeta.ResourceVersion)
@@ -85,6 +141,28 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 
 	pkgw.logger.Info("starting build for package", zap.String("package_name", srcpkg.ObjectMeta.Name), zap.String("resource_version", srcpkg.ObjectMeta.ResourceVersion))
 
+	ctx, done := pkgw.trackBuild(srcpkg)
+	defer done()
+
+	// clear the build step statuses of a previous build
+	srcpkg.Status.Steps = nil
+
+	envKey := fmt.Sprintf("%s/%s", srcpkg.Spec.Environment.Namespace, srcpkg.Spec.Environment.Name)
+	if !pkgw.limiter.tryAcquire(envKey) {
+		srcpkg, err = updatePackage(pkgw.logger, pkgw.fissionClient, srcpkg, fv1.BuildStatusQueued,
+			"build queued, waiting for other builds to complete\n", nil)
+		if err != nil {
+			pkgw.logger.Error("error setting package queued state", zap.Error(err))
+			return
+		}
+		err = pkgw.limiter.acquire(ctx, envKey)
+		if err != nil {
+			pkgw.logger.Info("package build canceled while queued", zap.String("package_name", srcpkg.ObjectMeta.Name))
+			return
+		}
+	}
+	defer pkgw.limiter.release(envKey)
+
 	pkg, err := updatePackage(pkgw.logger, pkgw.fissionClient, srcpkg, fv1.BuildStatusRunning, "", nil)
 	if err != nil {
 		pkgw.logger.Error("error setting package pending state", zap.Error(err))
@@ -98,6 +176,30 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 		updatePackage(pkgw.logger, pkgw.fissionClient, pkg,
 			fv1.BuildStatusFailed, fmt.Sprintf("%s: %q", e, pkg.Spec.Environment.Name), nil)
 		return
+	} else if err != nil {
+		e := "error getting environment"
+		pkgw.logger.Error(e, zap.Error(err), zap.String("environment", pkg.Spec.Environment.Name))
+		updatePackage(pkgw.logger, pkgw.fissionClient, pkg,
+			fv1.BuildStatusFailed, fmt.Sprintf("%s %q: %v", e, pkg.Spec.Environment.Name, err), nil)
+		return
+	}
+
+	cacheKey, cacheable := buildCacheKey(pkg, env)
+	if !cacheable {
+		observeBuildCache(buildCacheUncacheable)
+	} else if cached, uploadResp := pkgw.findCachedBuild(ctx, pkg, cacheKey); cached != nil {
+		observeBuildCache(buildCacheHit)
+		pkgw.reuseBuild(pkg, cached, cacheKey, uploadResp)
+		return
+	} else {
+		observeBuildCache(buildCacheMiss)
+	}
+
+	timeout := pkgw.buildTimeout(pkg, env)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
 	// Create a new BackOff for health check on environment builder pod
@@ -107,6 +209,10 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 	//}
 	// Do health check for environment builder pod
 	for healthCheckBackOff.NextExists() {
+		if pkgw.buildStopped(ctx, pkg, "", timeout) {
+			return
+		}
+
 		// Informer store is not able to use label to find the pod,
 		// iterate all available environment builders.
 		items := pkgw.podStore.List()
@@ -169,8 +275,12 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 					zap.String("package", fmt.Sprintf("%s.%s", pkg.ObjectMeta.Name, pkg.ObjectMeta.Namespace)))
 			}
 
-			ctx := context.Background()
-			uploadResp, buildLogs, err := buildPackage(ctx, pkgw.logger, pkgw.fissionClient, builderNs, pkgw.storageSvcUrl, pkg)
+			relay := startBuildLogRelay(pkgw.logger, pkgw.fissionClient, pkg)
+			uploadResp, buildLogs, err := buildPackage(ctx, pkgw.logger, pkgw.fissionClient, pkgw.k8sClient, pkgw.fetcherConfig, builderNs, pkgw.storageSvcUrl, pkg, relay.handle)
+			pkg = relay.stop()
+			if pkgw.buildStopped(ctx, pkg, buildLogs, timeout) {
+				return
+			}
 			if err != nil {
 				pkgw.logger.Error("error building package", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
 				updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusFailed, buildLogs, nil)
@@ -179,33 +289,23 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 
 			pkgw.logger.Info("starting package info update", zap.String("package_name", pkg.ObjectMeta.Name))
 
-			fnList, err := pkgw.fissionClient.CoreV1().
-				Functions(metav1.NamespaceAll).List(metav1.ListOptions{})
+			err = pkgw.updateFunctionPackageRefs(pkg)
 			if err != nil {
-				e := "error getting function list"
-				pkgw.logger.Error(e, zap.Error(err))
-				buildLogs += fmt.Sprintf("%s: %v\n", e, err)
+				buildLogs += fmt.Sprintf("%v\n", err)
 				updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusFailed, buildLogs, nil)
+				return
 			}
 
-			// A package may be used by multiple functions. Update
-			// functions with old package resource version
-			for _, fn := range fnList.Items {
-				if fn.Spec.Package.PackageRef.Name == pkg.ObjectMeta.Name &&
-					fn.Spec.Package.PackageRef.Namespace == pkg.ObjectMeta.Namespace &&
-					fn.Spec.Package.PackageRef.ResourceVersion != pkg.ObjectMeta.ResourceVersion {
-					fn.Spec.Package.PackageRef.ResourceVersion = pkg.ObjectMeta.ResourceVersion
-					// update CRD
-					_, err = pkgw.fissionClient.CoreV1().Functions(fn.ObjectMeta.Namespace).Update(&fn)
-					if err != nil {
-						e := "error updating function package resource version"
-						pkgw.logger.Error(e, zap.Error(err))
-						buildLogs += fmt.Sprintf("%s: %v\n", e, err)
-						updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusFailed, buildLogs, nil)
-						return
-					}
+			// Drop the key of a previous build if the inputs are not cacheable anymore
+			if cacheable {
+				if pkg.ObjectMeta.Annotations == nil {
+					pkg.ObjectMeta.Annotations = make(map[string]string)
 				}
+				pkg.ObjectMeta.Annotations[BUILD_CACHE_KEY_ANNOTATION] = cacheKey
+			} else {
+				delete(pkg.ObjectMeta.Annotations, BUILD_CACHE_KEY_ANNOTATION)
 			}
+			pkgw.recordRevision(pkg, uploadResp, buildLogs)
 
 			_, err = updatePackage(pkgw.logger, pkgw.fissionClient, pkg,
 				fv1.BuildStatusSucceeded, buildLogs, uploadResp)
@@ -215,6 +315,10 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 				return
 			}
 
+			if cacheable {
+				pkgw.addCachedBuild(pkg, cacheKey, uploadResp)
+			}
+
 			pkgw.logger.Info("completed package build request", zap.String("package_name", pkg.ObjectMeta.Name))
 			return
 		}
@@ -228,6 +332,166 @@ func (pkgw *packageWatcher) build(buildCache *cache.Cache, srcpkg *fv1.Package)
 		zap.String("package", fmt.Sprintf("%s.%s", pkg.ObjectMeta.Name, pkg.ObjectMeta.Namespace)))
 }
 
+// trackBuild returns the context of a package build, which is canceled when
+// the package is deleted or built again, and a func to call once done.
+func (pkgw *packageWatcher) trackBuild(pkg *fv1.Package) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	build := &runningBuild{cancel: cancel}
+	key := fmt.Sprintf("%s/%s", pkg.ObjectMeta.Namespace, pkg.ObjectMeta.Name)
+
+	pkgw.buildsLock.Lock()
+	if prev, ok := pkgw.builds[key]; ok {
+		pkgw.logger.Info("canceling build of previous package version", zap.String("package", key))
+		prev.cancel()
+	}
+	pkgw.builds[key] = build
+	pkgw.buildsLock.Unlock()
+
+	return ctx, func() {
+		pkgw.buildsLock.Lock()
+		if pkgw.builds[key] == build {
+			delete(pkgw.builds, key)
+		}
+		pkgw.buildsLock.Unlock()
+		cancel()
+	}
+}
+
+// cancelBuild cancels the build of a deleted package, if any.
+func (pkgw *packageWatcher) cancelBuild(pkg *fv1.Package) {
+	key := fmt.Sprintf("%s/%s", pkg.ObjectMeta.Namespace, pkg.ObjectMeta.Name)
+
+	pkgw.buildsLock.Lock()
+	defer pkgw.buildsLock.Unlock()
+	if build, ok := pkgw.builds[key]; ok {
+		pkgw.logger.Info("canceling build of deleted package", zap.String("package", key))
+		build.cancel()
+		delete(pkgw.builds, key)
+	}
+}
+
+// deleteBuildCache deletes the cache directories the builder keeps for the
+// build steps of a deleted package. Errors are logged only, the cache is
+// gone with the builder pod anyway.
+func (pkgw *packageWatcher) deleteBuildCache(pkg *fv1.Package) {
+	cached := false
+	for _, step := range pkg.Spec.BuildSteps {
+		cached = cached || len(step.CacheDirs) > 0
+	}
+	if !cached {
+		return
+	}
+
+	logger := pkgw.logger.With(zap.String("package", fmt.Sprintf("%s/%s", pkg.ObjectMeta.Namespace, pkg.ObjectMeta.Name)))
+	env, err := pkgw.fissionClient.CoreV1().Environments(pkg.Spec.Environment.Namespace).Get(pkg.Spec.Environment.Name, metav1.GetOptions{})
+	if err != nil {
+		logger.Error("error getting environment of deleted package, build cache not deleted", zap.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	builderC := builderClient.MakeClient(pkgw.logger, fmt.Sprintf("http://%v:8001", builderSvcName(env, pkgw.builderNamespace)))
+	err = builderC.DeleteCache(ctx, string(pkg.ObjectMeta.UID))
+	if err != nil {
+		logger.Error("error deleting build cache of deleted package", zap.Error(err))
+		return
+	}
+	logger.Info("deleted build cache of deleted package")
+}
+
+// buildTimeout returns the build timeout of the package, if any.
+func (pkgw *packageWatcher) buildTimeout(pkg *fv1.Package, env *fv1.Environment) time.Duration {
+	if pkg.Spec.BuildTimeout > 0 {
+		return time.Duration(pkg.Spec.BuildTimeout) * time.Second
+	}
+	if env.Spec.Builder.BuildTimeout > 0 {
+		return time.Duration(env.Spec.Builder.BuildTimeout) * time.Second
+	}
+	return pkgw.defaultBuildTimeout
+}
+
+// buildStopped returns whether the build context is done. The package of a
+// build which timed out is marked as failed; the package of a canceled build
+// is left as is, since it is deleted or built again.
+func (pkgw *packageWatcher) buildStopped(ctx context.Context, pkg *fv1.Package, buildLogs string, timeout time.Duration) bool {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		pkgw.logger.Error("package build timed out", zap.String("package_name", pkg.ObjectMeta.Name),
+			zap.String("package_namespace", pkg.ObjectMeta.Namespace), zap.Duration("timeout", timeout))
+		buildLogs += fmt.Sprintf("build timed out after %v\n", timeout)
+		updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusFailed, buildLogs, nil)
+		return true
+	case context.Canceled:
+		pkgw.logger.Info("package build canceled", zap.String("package_name", pkg.ObjectMeta.Name),
+			zap.String("package_namespace", pkg.ObjectMeta.Namespace))
+		return true
+	}
+	return false
+}
+
+// reuseBuild marks a package succeeded with the deployment archive of a
+// cached package built from the same inputs.
+func (pkgw *packageWatcher) reuseBuild(pkg *fv1.Package, cached *buildCacheEntry, cacheKey string, uploadResp *fetcher.ArchiveUploadResponse) {
+	pkgw.logger.Info("reusing deployment archive of cached package build",
+		zap.String("package", fmt.Sprintf("%s.%s", pkg.ObjectMeta.Name, pkg.ObjectMeta.Namespace)),
+		zap.String("cached_package", cached.Package))
+
+	buildLogs := fmt.Sprintf("cache hit: reused deployment archive of package %s built from identical source, builder image and build command\n",
+		cached.Package)
+
+	err := pkgw.updateFunctionPackageRefs(pkg)
+	if err != nil {
+		buildLogs += fmt.Sprintf("%v\n", err)
+		updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusFailed, buildLogs, nil)
+		return
+	}
+
+	if pkg.ObjectMeta.Annotations == nil {
+		pkg.ObjectMeta.Annotations = make(map[string]string)
+	}
+	pkg.ObjectMeta.Annotations[BUILD_CACHE_KEY_ANNOTATION] = cacheKey
+	// the steps did not run for this package
+	pkg.Status.Steps = nil
+	pkgw.recordRevision(pkg, uploadResp, buildLogs)
+
+	_, err = updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusSucceeded, buildLogs, uploadResp)
+	if err != nil {
+		pkgw.logger.Error("error updating package info", zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name))
+		updatePackage(pkgw.logger, pkgw.fissionClient, pkg, fv1.BuildStatusFailed, buildLogs, nil)
+	}
+}
+
+// updateFunctionPackageRefs updates functions with old package resource
+// version, as a package may be used by multiple functions. Functions pinned
+// to a revision of the package are left as is.
+func (pkgw *packageWatcher) updateFunctionPackageRefs(pkg *fv1.Package) error {
+	fnList, err := pkgw.fissionClient.CoreV1().
+		Functions(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		e := "error getting function list"
+		pkgw.logger.Error(e, zap.Error(err))
+		return errors.Wrap(err, e)
+	}
+
+	for _, fn := range fnList.Items {
+		if fn.Spec.Package.PackageRef.Name == pkg.ObjectMeta.Name &&
+			fn.Spec.Package.PackageRef.Namespace == pkg.ObjectMeta.Namespace &&
+			fn.Spec.Package.PackageRef.Revision == 0 &&
+			fn.Spec.Package.PackageRef.ResourceVersion != pkg.ObjectMeta.ResourceVersion {
+			fn.Spec.Package.PackageRef.ResourceVersion = pkg.ObjectMeta.ResourceVersion
+			// update CRD
+			_, err = pkgw.fissionClient.CoreV1().Functions(fn.ObjectMeta.Namespace).Update(&fn)
+			if err != nil {
+				e := "error updating function package resource version"
+				pkgw.logger.Error(e, zap.Error(err))
+				return errors.Wrap(err, e)
+			}
+		}
+	}
+	return nil
+}
+
 func (pkgw *packageWatcher) watchPackages() {
 	buildCache := cache.MakeCache(0, 0)
 	lw := k8sCache.NewListWatchFromClient(pkgw.fissionClient.CoreV1().RESTClient(), "packages", apiv1.NamespaceAll, fields.Everything())
@@ -255,6 +519,11 @@ func (pkgw *packageWatcher) watchPackages() {
 	pkgStore, controller := k8sCache.NewInformer(lw, &fv1.Package{}, 60*time.Minute, k8sCache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			pkg := obj.(*fv1.Package)
+			// the queue is lost when builder manager restarts
+			if pkg.Status.BuildStatus == fv1.BuildStatusQueued {
+				go pkgw.build(buildCache, pkg)
+				return
+			}
 			processPkg(pkg)
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
@@ -272,6 +541,21 @@ func (pkgw *packageWatcher) watchPackages() {
 			}
 			processPkg(pkg)
 		},
+		DeleteFunc: func(obj interface{}) {
+			pkg, ok := obj.(*fv1.Package)
+			if !ok {
+				tombstone, ok := obj.(k8sCache.DeletedFinalStateUnknown)
+				if !ok {
+					return
+				}
+				pkg, ok = tombstone.Obj.(*fv1.Package)
+				if !ok {
+					return
+				}
+			}
+			pkgw.cancelBuild(pkg)
+			go pkgw.deleteBuildCache(pkg)
+		},
 	})
 
 	pkgw.pkgStore = pkgStore
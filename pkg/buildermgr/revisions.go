@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildermgr
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fetcher"
+)
+
+// DEFAULT_MAX_PACKAGE_REVISIONS is the number of revisions retained per
+// package, unless MAX_PACKAGE_REVISIONS is set.
+const DEFAULT_MAX_PACKAGE_REVISIONS = 10
+
+// revisionBuildLogTail is the size of the end of the build logs kept with a
+// revision. The full logs are in the package status, keeping them with every
+// revision would make the package object too large.
+const revisionBuildLogTail = 2048
+
+// recordRevision adds a revision for the successful build of the package
+// with the uploaded deployment archive and the end of the build logs. The oldest
+// revisions beyond the limit are dropped, except the ones a function is
+// pinned to.
+func (pkgw *packageWatcher) recordRevision(pkg *fv1.Package, uploadResp *fetcher.ArchiveUploadResponse, buildLogs string) {
+	maxRevisions := pkgw.maxRevisions
+	pinned, err := pkgw.pinnedRevisions(pkg)
+	if err != nil {
+		// keep all revisions rather than dropping one still in use
+		pkgw.logger.Error("error finding package revisions pinned by functions, not dropping any revision",
+			zap.Error(err), zap.String("package_name", pkg.ObjectMeta.Name), zap.String("package_namespace", pkg.ObjectMeta.Namespace))
+		maxRevisions = 0
+	}
+
+	pkg.Status.Revisions = addRevision(pkg.Status.Revisions, fv1.PackageRevision{
+		Revision:   pkg.Status.LatestRevision() + 1,
+		Deployment: deploymentArchive(uploadResp),
+		BuildLog:   logTail(buildLogs, revisionBuildLogTail),
+		Timestamp:  metav1.Time{Time: time.Now().UTC()},
+	}, maxRevisions, pinned)
+}
+
+// pinnedRevisions returns the revisions of the package functions are pinned to.
+func (pkgw *packageWatcher) pinnedRevisions(pkg *fv1.Package) (map[int]bool, error) {
+	fnList, err := pkgw.fissionClient.CoreV1().
+		Functions(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting function list")
+	}
+
+	pinned := make(map[int]bool)
+	for _, fn := range fnList.Items {
+		ref := fn.Spec.Package.PackageRef
+		if ref.Name == pkg.ObjectMeta.Name && ref.Namespace == pkg.ObjectMeta.Namespace && ref.Revision > 0 {
+			pinned[ref.Revision] = true
+		}
+	}
+	return pinned, nil
+}
+
+// addRevision appends the revision and drops the oldest revisions not pinned
+// until at most maxRevisions are left, if maxRevisions is greater than zero.
+// The new revision is always kept.
+func addRevision(revisions []fv1.PackageRevision, revision fv1.PackageRevision, maxRevisions int, pinned map[int]bool) []fv1.PackageRevision {
+	revisions = append(revisions, revision)
+	if maxRevisions <= 0 {
+		return revisions
+	}
+
+	toDrop := len(revisions) - maxRevisions
+	kept := make([]fv1.PackageRevision, 0, len(revisions))
+	for i, r := range revisions {
+		if toDrop > 0 && !pinned[r.Revision] && i < len(revisions)-1 {
+			toDrop--
+			continue
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// logTail returns the last lines of the log fitting in size bytes.
+func logTail(log string, size int) string {
+	if len(log) <= size {
+		return log
+	}
+	tail := log[len(log)-size:]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	}
+	return tail
+}
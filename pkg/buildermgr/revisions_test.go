@@ -0,0 +1,49 @@
+package buildermgr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func revisionNumbers(revisions []fv1.PackageRevision) []int {
+	numbers := make([]int, 0, len(revisions))
+	for _, r := range revisions {
+		numbers = append(numbers, r.Revision)
+	}
+	return numbers
+}
+
+func TestAddRevision(t *testing.T) {
+	var revisions []fv1.PackageRevision
+	for i := 1; i <= 3; i++ {
+		revisions = addRevision(revisions, fv1.PackageRevision{Revision: i}, 3, nil)
+	}
+	assert.Equal(t, []int{1, 2, 3}, revisionNumbers(revisions))
+
+	// the oldest revision is dropped
+	revisions = addRevision(revisions, fv1.PackageRevision{Revision: 4}, 3, nil)
+	assert.Equal(t, []int{2, 3, 4}, revisionNumbers(revisions))
+
+	// pinned revisions are kept
+	revisions = addRevision(revisions, fv1.PackageRevision{Revision: 5}, 3, map[int]bool{2: true})
+	assert.Equal(t, []int{2, 4, 5}, revisionNumbers(revisions))
+
+	// even beyond the limit, but never instead of the new revision
+	revisions = addRevision(revisions, fv1.PackageRevision{Revision: 6}, 2, map[int]bool{2: true, 4: true})
+	assert.Equal(t, []int{2, 4, 6}, revisionNumbers(revisions))
+
+	// no limit
+	revisions = addRevision(revisions, fv1.PackageRevision{Revision: 7}, 0, nil)
+	assert.Equal(t, []int{2, 4, 6, 7}, revisionNumbers(revisions))
+}
+
+func TestLogTail(t *testing.T) {
+	assert.Equal(t, "short\n", logTail("short\n", 10))
+	// the first partial line is dropped
+	assert.Equal(t, "line 3\n", logTail("line 1\nline 2\nline 3\n", 10))
+	// unless it is the only line
+	assert.Equal(t, "ong line", logTail("a very long line", 8))
+}
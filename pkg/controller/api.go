@@ -52,6 +52,7 @@ type (
 		storageServiceUrl string
 		builderManagerUrl string
 		workflowApiUrl    string
+		executorUrl       string
 		functionNamespace string
 		featureStatus     map[string]string
 	}
@@ -87,6 +88,13 @@ func MakeAPI(logger *zap.Logger, featureStatus map[string]string) (*API, error)
 		api.workflowApiUrl = "http://workflows-apiserver"
 	}
 
+	u = os.Getenv("EXECUTOR_URL")
+	if len(u) > 0 {
+		api.executorUrl = strings.TrimSuffix(u, "/")
+	} else {
+		api.executorUrl = "http://executor"
+	}
+
 	fnNs := os.Getenv("FISSION_FUNCTION_NAMESPACE")
 	if len(fnNs) > 0 {
 		api.functionNamespace = fnNs
@@ -253,6 +261,7 @@ func (api *API) GetHandler() http.Handler {
 	r.HandleFunc("/proxy/storage/v1/archive", api.StorageServiceProxy)
 	r.HandleFunc("/proxy/logs/{function}", api.FunctionPodLogs).Methods("POST")
 	r.HandleFunc("/proxy/workflows-apiserver/{path:.*}", api.WorkflowApiserverProxy)
+	r.HandleFunc("/proxy/executor/{path:.*}", api.ExecutorAdminProxy)
 	r.HandleFunc("/proxy/svcname", api.GetSvcName).Queries("application", "").Methods("GET")
 
 	r.Handle("/v2/apidocs.json", openAPI()).Methods("GET")
@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/controller/client/rest"
+	executorClient "github.com/fission/fission/pkg/executor/client"
+)
+
+type (
+	ExecutorGetter interface {
+		Executor() ExecutorInterface
+	}
+
+	// ExecutorInterface talks to the admin API of executor through the controller.
+	ExecutorInterface interface {
+		ListFunctionServices(fnNamespace string, fnName string) ([]executorClient.FunctionService, error)
+		EvictFunction(m *metav1.ObjectMeta) (int, error)
+		WarmFunction(m *metav1.ObjectMeta) (*executorClient.FunctionService, error)
+		ListPools(envNamespace string) ([]executorClient.EnvironmentPool, error)
+	}
+
+	Executor struct {
+		client rest.Interface
+	}
+)
+
+func newExecutorClient(c *V1) ExecutorInterface {
+	return &Executor{client: c.restClient}
+}
+
+func (c *Executor) ListFunctionServices(fnNamespace string, fnName string) ([]executorClient.FunctionService, error) {
+	query := url.Values{}
+	if len(fnNamespace) > 0 {
+		query.Set("namespace", fnNamespace)
+	}
+	if len(fnName) > 0 {
+		query.Set("function", fnName)
+	}
+
+	body, err := c.get("executor/functionServices", query)
+	if err != nil {
+		return nil, err
+	}
+
+	fsvcs := make([]executorClient.FunctionService, 0)
+	err = json.Unmarshal(body, &fsvcs)
+	if err != nil {
+		return nil, err
+	}
+	return fsvcs, nil
+}
+
+func (c *Executor) EvictFunction(m *metav1.ObjectMeta) (int, error) {
+	body, err := c.post("executor/evict", m)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(body)))
+}
+
+func (c *Executor) WarmFunction(m *metav1.ObjectMeta) (*executorClient.FunctionService, error) {
+	body, err := c.post("executor/warm", m)
+	if err != nil {
+		return nil, err
+	}
+
+	var fsvc executorClient.FunctionService
+	err = json.Unmarshal(body, &fsvc)
+	if err != nil {
+		return nil, err
+	}
+	return &fsvc, nil
+}
+
+func (c *Executor) ListPools(envNamespace string) ([]executorClient.EnvironmentPool, error) {
+	query := url.Values{}
+	if len(envNamespace) > 0 {
+		query.Set("namespace", envNamespace)
+	}
+
+	body, err := c.get("executor/pools", query)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make([]executorClient.EnvironmentPool, 0)
+	err = json.Unmarshal(body, &pools)
+	if err != nil {
+		return nil, err
+	}
+	return pools, nil
+}
+
+func (c *Executor) get(relativeUrl string, query url.Values) ([]byte, error) {
+	if len(query) > 0 {
+		relativeUrl += "?" + query.Encode()
+	}
+	resp, err := c.client.Proxy(http.MethodGet, relativeUrl, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error executing executor admin request")
+	}
+	defer resp.Body.Close()
+	return handleResponse(resp)
+}
+
+func (c *Executor) post(relativeUrl string, m *metav1.ObjectMeta) ([]byte, error) {
+	reqbody, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Proxy(http.MethodPost, relativeUrl, reqbody)
+	if err != nil {
+		return nil, errors.Wrap(err, "error executing executor admin request")
+	}
+	defer resp.Body.Close()
+	return handleResponse(resp)
+}
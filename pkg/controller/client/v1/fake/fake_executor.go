@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/fission/fission/pkg/controller/client/v1"
+	executorClient "github.com/fission/fission/pkg/executor/client"
+)
+
+type (
+	FakeExecutor struct{}
+)
+
+func newExecutorClient(c *v1.V1) v1.ExecutorInterface {
+	return &FakeExecutor{}
+}
+
+func (c *FakeExecutor) ListFunctionServices(fnNamespace string, fnName string) ([]executorClient.FunctionService, error) {
+	return nil, nil
+}
+
+func (c *FakeExecutor) EvictFunction(m *metav1.ObjectMeta) (int, error) {
+	return 0, nil
+}
+
+func (c *FakeExecutor) WarmFunction(m *metav1.ObjectMeta) (*executorClient.FunctionService, error) {
+	return nil, nil
+}
+
+func (c *FakeExecutor) ListPools(envNamespace string) ([]executorClient.EnvironmentPool, error) {
+	return nil, nil
+}
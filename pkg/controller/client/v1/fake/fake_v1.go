@@ -41,6 +41,10 @@ func (c *FakeV1) Environment() v1.EnvironmentInterface {
 	return newEnvironmentClient(nil)
 }
 
+func (c *FakeV1) Executor() v1.ExecutorInterface {
+	return newExecutorClient(nil)
+}
+
 func (c *FakeV1) Function() v1.FunctionInterface {
 	return newFunctionClient(nil)
 }
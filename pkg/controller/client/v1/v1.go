@@ -30,6 +30,7 @@ type (
 		MiscGetter
 		CanaryConfigGetter
 		EnvironmentGetter
+		ExecutorGetter
 		FunctionGetter
 		HTTPTriggerGetter
 		KubeWatcherGetter
@@ -59,6 +60,10 @@ func (c *V1) Environment() EnvironmentInterface {
 	return newEnvironmentClient(c)
 }
 
+func (c *V1) Executor() ExecutorInterface {
+	return newExecutorClient(c)
+}
+
 func (c *V1) Function() FunctionInterface {
 	return newFunctionClient(c)
 }
@@ -0,0 +1,59 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// ExecutorAdminProxy proxies requests to the admin API of executor. Only
+// the admin endpoints are reachable through it.
+func (api *API) ExecutorAdminProxy(w http.ResponseWriter, r *http.Request) {
+	u := api.executorUrl
+	executorUrl, err := url.Parse(u)
+	if err != nil {
+		e := "error parsing url"
+		api.logger.Error(e, zap.Error(err), zap.String("url", u))
+		http.Error(w, fmt.Sprintf("%s %s: %v", e, u, err), http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	adminPath := path.Clean(fmt.Sprintf("/v2/admin/%s", vars["path"]))
+	if !strings.HasPrefix(adminPath, "/v2/admin/") {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	director := func(req *http.Request) {
+		req.URL.Scheme = executorUrl.Scheme
+		req.URL.Host = executorUrl.Host
+		req.URL.Path = adminPath
+		req.Host = executorUrl.Host
+	}
+	proxy := &httputil.ReverseProxy{
+		Director: director,
+	}
+	proxy.ServeHTTP(w, r)
+}
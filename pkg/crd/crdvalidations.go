@@ -44,6 +44,10 @@ var (
 					Type:        "integer",
 					Description: "IdleTimeout specifies the length of time that a function is idle before the function pod(s) are eligible for deletion. If no traffic to the function is detected within the idle timeout, the executor will then recycle the function pod(s) to release resources.",
 				},
+				"podspec": {
+					Type:        "object",
+					Description: "PodSpec is the pod running the function, for executor type container only. The first container serves the function on its first container port (8888 if no port is given).",
+				},
 			},
 		},
 	}
@@ -136,6 +140,14 @@ var (
 					Type:        "string",
 					Description: "BuildCommand is a custom build command that builder uses to build the source archive.",
 				},
+				"buildTimeout": {
+					Type:        "integer",
+					Description: "(Optional) BuildTimeout is the maximum duration in seconds a build of the package may run, overriding the build timeout of the environment builder.",
+				},
+				"buildSteps": {
+					Type:        "array",
+					Description: "(Optional) BuildSteps are run in order by the environment builder instead of a single build command. A build stops at the first step failing. A step with an image runs in a pod of its own instead of the environment builder.",
+				},
 			},
 		},
 		"status": {
@@ -146,10 +158,18 @@ var (
 					Type:        "string",
 					Description: "BuildStatus is the package build status.",
 				},
+				"steps": {
+					Type:        "array",
+					Description: "Steps is the status and log of each build step, if the package has build steps.",
+				},
 				"buildlog": {
 					Type:        "string",
 					Description: "BuildCommand is a custom build command that builder used to build the source archive.",
 				},
+				"revisions": {
+					Type:        "array",
+					Description: "Revisions are the retained revisions of the package, oldest first, each with the end of its build log. A function may be pinned to one of them with its package reference.",
+				},
 				"lastUpdateTimestamp": {
 					Type:        "string",
 					Nullable:    true,
@@ -248,6 +268,10 @@ var (
 			Type:        "string",
 			Description: "Including resource version in the reference forces the function to be updated on package update, making it possible to cache the function based on its metadata.",
 		},
+		"revision": {
+			Type:        "integer",
+			Description: "(Optional) Revision pins the function to a revision of the package instead of its latest deployment archive.",
+		},
 	}
 	functionPackageRefSchemaProps = map[string]apiextensionsv1beta1.JSONSchemaProps{
 		"packageref": {
@@ -322,7 +346,7 @@ var (
 	executionStrategySchema = map[string]apiextensionsv1beta1.JSONSchemaProps{
 		"ExecutorType": {
 			Type:        "string",
-			Description: "ExecutorType is the executor type of a function used. Defaults to poolmgr. Available value: poolmgr, newdeploy",
+			Description: "ExecutorType is the executor type of a function used. Defaults to poolmgr. Available value: poolmgr, newdeploy, container",
 		},
 		"MinScale": {
 			Type:        "integer",
@@ -336,6 +360,14 @@ var (
 			Type:        "integer",
 			Description: "Only for newdeploy executor to set up target CPU utilization of HPA.",
 		},
+		"TargetConcurrency": {
+			Type:        "integer",
+			Description: "Only for newdeploy executor to scale by in-flight requests per pod instead of CPU utilization.",
+		},
+		"TargetRPS": {
+			Type:        "integer",
+			Description: "Only for newdeploy executor to scale by requests per second per pod instead of CPU utilization.",
+		},
 		"SpecializationTimeout": {
 			Type:        "integer",
 			Description: "Timeout setting for executor to wait for pod specialization.",
@@ -399,6 +431,10 @@ var (
 			Type:        "object",
 			Description: "(Optional) Podspec allows modification of deployed runtime pod with Kubernetes PodSpec.\n You can set either PodSpec or Container, but not both.",
 		},
+		"buildTimeout": {
+			Type:        "integer",
+			Description: "(Optional) BuildTimeout is the maximum duration in seconds a package build may run. If not specified, the default build timeout of builder manager is used.",
+		},
 	}
 	builderSchema = apiextensionsv1beta1.JSONSchemaProps{
 		Type:        "object",
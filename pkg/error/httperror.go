@@ -60,6 +60,8 @@ func MakeErrorFromHTTP(resp *http.Response) error {
 		errCode = ErrorRequestTimeout
 	case http.StatusTooManyRequests:
 		errCode = ErrorTooManyRequests
+	case http.StatusServiceUnavailable:
+		errCode = ErrorServiceUnavailable
 	default:
 		errCode = ErrorInternal
 	}
@@ -87,6 +89,8 @@ func (err Error) HTTPStatus() int {
 		code = http.StatusConflict
 	case ErrorTooManyRequests:
 		code = http.StatusTooManyRequests
+	case ErrorServiceUnavailable:
+		code = http.StatusServiceUnavailable
 	default:
 		code = http.StatusInternalServerError
 	}
@@ -136,6 +140,7 @@ const (
 	ErrorSizeLimitExceeded
 	ErrorRequestTimeout
 	ErrorTooManyRequests
+	ErrorServiceUnavailable
 )
 
 // must match order and len of the above const
@@ -150,4 +155,6 @@ var errorDescriptions = []string{
 	"Checksum verification failed",
 	"Size limit exceeded",
 	"Request time limit exceeded",
+	"Too many requests",
+	"Service unavailable",
 }
@@ -0,0 +1,260 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	ferror "github.com/fission/fission/pkg/error"
+	"github.com/fission/fission/pkg/executor/client"
+	"github.com/fission/fission/pkg/executor/executortype"
+	"github.com/fission/fission/pkg/executor/fscache"
+)
+
+type (
+	// functionWarmer is implemented by executor types specializing function
+	// pods ahead of requests themselves. Function services of other executor
+	// types are warmed the same way as for a request.
+	functionWarmer interface {
+		WarmFunction(context.Context, *fv1.Function) (*fscache.FuncSvc, error)
+	}
+
+	// poolLister is implemented by executor types keeping pools of generic pods.
+	poolLister interface {
+		ListPools() []client.EnvironmentPool
+	}
+)
+
+// listFunctionServices lists the cached function services of all replicas,
+// optionally of a namespace or function.
+func (executor *Executor) listFunctionServices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	namespace, name := query.Get("namespace"), query.Get("function")
+
+	fsvcs := make([]client.FunctionService, 0)
+	for _, et := range executor.executorTypes {
+		for _, state := range et.ListFuncSvcs() {
+			if state.Function == nil ||
+				(len(namespace) > 0 && state.Function.Namespace != namespace) ||
+				(len(name) > 0 && state.Function.Name != name) {
+				continue
+			}
+			fsvcs = append(fsvcs, makeFunctionService(&state, executor.cluster.Self().Name))
+		}
+	}
+
+	for _, body := range executor.gatherFromMembers(r) {
+		remote := []client.FunctionService{}
+		err := json.Unmarshal(body, &remote)
+		if err != nil {
+			executor.logger.Error("failed to decode function services of executor replica", zap.Error(err))
+			continue
+		}
+		fsvcs = append(fsvcs, remote...)
+	}
+
+	sort.Slice(fsvcs, func(i, j int) bool {
+		if fsvcs[i].Function.Namespace != fsvcs[j].Function.Namespace {
+			return fsvcs[i].Function.Namespace < fsvcs[j].Function.Namespace
+		}
+		if fsvcs[i].Function.Name != fsvcs[j].Function.Name {
+			return fsvcs[i].Function.Name < fsvcs[j].Function.Name
+		}
+		return fsvcs[i].Name < fsvcs[j].Name
+	})
+
+	executor.writeJSON(w, fsvcs)
+}
+
+// evictFunction removes the function pods of a function right away, and
+// responds with the number of function services evicted.
+func (executor *Executor) evictFunction(w http.ResponseWriter, r *http.Request) {
+	fn, et, ok := executor.getAdminFunction(w, r)
+	if !ok {
+		return
+	}
+
+	evicted, err := et.EvictFunction(fn)
+	if err != nil {
+		executor.logger.Error("error evicting function", zap.Error(err), zap.String("function", fn.ObjectMeta.Name))
+		code, msg := ferror.GetHTTPError(err)
+		http.Error(w, msg, code)
+		return
+	}
+
+	executor.logger.Info("evicted function services of function",
+		zap.String("function", fn.ObjectMeta.Name),
+		zap.String("namespace", fn.ObjectMeta.Namespace),
+		zap.Int("count", evicted))
+	w.Write([]byte(strconv.Itoa(evicted)))
+}
+
+// warmFunction specializes function pods of a function ahead of requests,
+// and responds with the function service.
+func (executor *Executor) warmFunction(w http.ResponseWriter, r *http.Request) {
+	fn, et, ok := executor.getAdminFunction(w, r)
+	if !ok {
+		return
+	}
+
+	var fsvc *fscache.FuncSvc
+	var err error
+	if warmer, ok := et.(functionWarmer); ok {
+		fsvc, err = warmer.WarmFunction(r.Context(), fn)
+	} else {
+		_, err = executor.getServiceForFunction(fn)
+		if err == nil {
+			fsvc, err = et.GetFuncSvcFromCache(fn)
+		}
+	}
+	if err != nil {
+		executor.logger.Error("error warming function", zap.Error(err), zap.String("function", fn.ObjectMeta.Name))
+		code, msg := ferror.GetHTTPError(err)
+		http.Error(w, msg, code)
+		return
+	}
+
+	executor.writeJSON(w, makeFunctionService(&fscache.FuncSvcState{FuncSvc: *fsvc}, executor.cluster.Self().Name))
+}
+
+// listPools lists the pools of generic pods of all replicas, optionally of
+// the environments of a namespace.
+func (executor *Executor) listPools(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+
+	pools := make([]client.EnvironmentPool, 0)
+	for _, et := range executor.executorTypes {
+		lister, ok := et.(poolLister)
+		if !ok {
+			continue
+		}
+		for _, pool := range lister.ListPools() {
+			if len(namespace) > 0 && pool.Environment.Namespace != namespace {
+				continue
+			}
+			pool.Replica = executor.cluster.Self().Name
+			pools = append(pools, pool)
+		}
+	}
+
+	for _, body := range executor.gatherFromMembers(r) {
+		remote := []client.EnvironmentPool{}
+		err := json.Unmarshal(body, &remote)
+		if err != nil {
+			executor.logger.Error("failed to decode pools of executor replica", zap.Error(err))
+			continue
+		}
+		pools = append(pools, remote...)
+	}
+
+	sort.Slice(pools, func(i, j int) bool {
+		if pools[i].Environment.Namespace != pools[j].Environment.Namespace {
+			return pools[i].Environment.Namespace < pools[j].Environment.Namespace
+		}
+		if pools[i].Environment.Name != pools[j].Environment.Name {
+			return pools[i].Environment.Name < pools[j].Environment.Name
+		}
+		return pools[i].Replica < pools[j].Replica
+	})
+
+	executor.writeJSON(w, pools)
+}
+
+// getAdminFunction returns the function whose metadata is the body of an
+// admin request, and its executor type. The request is forwarded to the
+// replica owning the function; false is returned if the response has been
+// written.
+func (executor *Executor) getAdminFunction(w http.ResponseWriter, r *http.Request) (*fv1.Function, executortype.ExecutorType, bool) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	m := metav1.ObjectMeta{}
+	err = json.Unmarshal(body, &m)
+	if err != nil {
+		http.Error(w, "Failed to parse request", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	if executor.forwardToOwner(w, r, body, &m) {
+		return nil, nil, false
+	}
+
+	fn, err := executor.fissionClient.CoreV1().Functions(m.Namespace).Get(m.Name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			http.Error(w, "Failed to find function", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to get function", http.StatusInternalServerError)
+		}
+		return nil, nil, false
+	}
+
+	t := fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType
+	et, exists := executor.executorTypes[t]
+	if !exists {
+		http.Error(w, fmt.Sprintf("Unknown executor type '%v'", t), http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	return fn, et, true
+}
+
+func (executor *Executor) writeJSON(w http.ResponseWriter, v interface{}) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		executor.logger.Error("failed to encode response", zap.Error(err))
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(resp)
+}
+
+func makeFunctionService(state *fscache.FuncSvcState, replica string) client.FunctionService {
+	fsvc := client.FunctionService{
+		Name:              state.Name,
+		Executor:          state.Executor,
+		Address:           state.Address,
+		KubernetesObjects: state.KubernetesObjects,
+		Ctime:             state.Ctime,
+		Atime:             state.Atime,
+		Active:            state.Active,
+		Draining:          state.Draining,
+		Replica:           replica,
+	}
+	if state.Function != nil {
+		fsvc.Function = *state.Function
+	}
+	if state.Environment != nil {
+		fsvc.Environment = state.Environment.ObjectMeta
+	}
+	return fsvc
+}
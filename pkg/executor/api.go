@@ -51,6 +51,10 @@ func (executor *Executor) getServiceForFunctionApi(w http.ResponseWriter, r *htt
 		return
 	}
 
+	if executor.forwardToOwner(w, r, body, &m) {
+		return
+	}
+
 	fn, err := executor.fissionClient.CoreV1().Functions(m.Namespace).Get(m.Name, metav1.GetOptions{})
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
@@ -166,6 +170,8 @@ func (executor *Executor) tapServices(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tapSvcReqs = executor.forwardTaps(r, tapSvcReqs)
+
 	errs := &multierror.Error{}
 	for _, req := range tapSvcReqs {
 		svcHost := strings.TrimPrefix(req.ServiceUrl, "http://")
@@ -195,6 +201,44 @@ func (executor *Executor) tapServices(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// loadRecorder is implemented by executor types scaling functions by the
+// load reported by routers.
+type loadRecorder interface {
+	RecordLoad(client.FunctionLoadReport)
+}
+
+// functionLoad records the load of functions reported by a router.
+func (executor *Executor) functionLoad(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		executor.logger.Error("failed to read function load request", zap.Error(err))
+		http.Error(w, "Failed to read request", http.StatusInternalServerError)
+		return
+	}
+
+	reports := []client.FunctionLoadReport{}
+	err = json.Unmarshal(body, &reports)
+	if err != nil {
+		executor.logger.Error("failed to decode function load request",
+			zap.Error(err),
+			zap.String("request-payload", string(body)))
+		http.Error(w, "Failed to decode function load request", http.StatusBadRequest)
+		return
+	}
+
+	for _, report := range executor.forwardLoadReports(r, reports) {
+		et, exists := executor.executorTypes[report.FnExecutorType]
+		if !exists {
+			continue
+		}
+		if recorder, ok := et.(loadRecorder); ok {
+			recorder.RecordLoad(report)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func (executor *Executor) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
@@ -212,6 +256,10 @@ func (executor *Executor) unTapService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if executor.forwardToOwner(w, r, body, &tapSvcReq.FnMetadata) {
+		return
+	}
+
 	fn, err := executor.fissionClient.CoreV1().Functions(tapSvcReq.FnMetadata.Namespace).Get(tapSvcReq.FnMetadata.Name, metav1.GetOptions{})
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
@@ -241,8 +289,15 @@ func (executor *Executor) GetHandler() http.Handler {
 	r.HandleFunc("/v2/getServiceForFunction", executor.getServiceForFunctionApi).Methods("POST")
 	r.HandleFunc("/v2/tapService", executor.tapService).Methods("POST") // for backward compatibility
 	r.HandleFunc("/v2/tapServices", executor.tapServices).Methods("POST")
+	r.HandleFunc("/v2/functionLoad", executor.functionLoad).Methods("POST")
 	r.HandleFunc("/healthz", executor.healthHandler).Methods("GET")
 	r.HandleFunc("/v2/unTapService", executor.unTapService).Methods("POST")
+
+	// admin endpoints for inspecting and manipulating function services
+	r.HandleFunc("/v2/admin/functionServices", executor.listFunctionServices).Methods("GET")
+	r.HandleFunc("/v2/admin/evict", executor.evictFunction).Methods("POST")
+	r.HandleFunc("/v2/admin/warm", executor.warmFunction).Methods("POST")
+	r.HandleFunc("/v2/admin/pools", executor.listPools).Methods("GET")
 	return r
 }
 
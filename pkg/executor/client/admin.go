@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+type (
+	// FunctionService is a function service cached by executor, as returned
+	// by the admin API.
+	FunctionService struct {
+		Name              string
+		Function          metav1.ObjectMeta
+		Environment       metav1.ObjectMeta
+		Executor          fv1.ExecutorType
+		Address           string
+		KubernetesObjects []apiv1.ObjectReference
+		Ctime             time.Time
+		Atime             time.Time
+		// Active is true while a poolmgr function pod serves a request.
+		Active bool
+		// Draining is true while the function service is drained before it's reaped.
+		Draining bool
+		// Replica is the executor replica caching the function service, if
+		// executor runs with several replicas.
+		Replica string
+	}
+
+	// EnvironmentPool is the state of the pool of generic pods of an
+	// environment, as returned by the admin API.
+	EnvironmentPool struct {
+		Environment metav1.ObjectMeta
+		// Deployment is the deployment of the generic pods.
+		Deployment string
+		Namespace  string
+		// Poolsize is the number of generic pods the pool is scaled to.
+		Poolsize int32
+		// ReadyPods is the number of ready generic pods not claimed for specialization.
+		ReadyPods int
+		// SpecializedPods is the number of cached function services of the environment.
+		SpecializedPods int
+		// RecentSpecializations is the number of specializations in the last minute.
+		RecentSpecializations int
+		Replica               string
+	}
+)
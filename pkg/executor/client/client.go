@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -38,25 +39,53 @@ import (
 
 type (
 	Client struct {
-		logger      *zap.Logger
-		executorUrl string
-		tappedByUrl map[string]TapServiceRequest
-		requestChan chan TapServiceRequest
-		httpClient  *http.Client
+		logger       *zap.Logger
+		executorUrls []string
+		current      uint32 // index of the executor url in use
+		tappedByUrl  map[string]TapServiceRequest
+		requestChan  chan TapServiceRequest
+		httpClient   *http.Client
 	}
 	TapServiceRequest struct {
 		FnMetadata     metav1.ObjectMeta
 		FnExecutorType fv1.ExecutorType
 		ServiceUrl     string
 	}
+	// FunctionLoadReport is the load of a function seen by a router
+	// during the last report interval, used for request-driven autoscaling.
+	FunctionLoadReport struct {
+		FnMetadata     metav1.ObjectMeta
+		FnExecutorType fv1.ExecutorType
+		// Source identifies the reporting router.
+		Source string
+		// InFlight is the number of requests being served.
+		InFlight int
+		// Requests is the number of requests received during Interval.
+		Requests int
+		Interval time.Duration
+	}
 )
 
+// MakeClient returns an executor client. executorUrl may be a comma separated
+// list of executor replicas; requests fail over to the next one when an
+// executor can't be reached.
 func MakeClient(logger *zap.Logger, executorUrl string) *Client {
+	executorUrls := make([]string, 0)
+	for _, u := range strings.Split(executorUrl, ",") {
+		u = strings.TrimSpace(u)
+		if len(u) > 0 {
+			executorUrls = append(executorUrls, strings.TrimSuffix(u, "/"))
+		}
+	}
+	if len(executorUrls) == 0 {
+		executorUrls = append(executorUrls, "")
+	}
+
 	c := &Client{
-		logger:      logger.Named("executor_client"),
-		executorUrl: strings.TrimSuffix(executorUrl, "/"),
-		tappedByUrl: make(map[string]TapServiceRequest),
-		requestChan: make(chan TapServiceRequest, 100),
+		logger:       logger.Named("executor_client"),
+		executorUrls: executorUrls,
+		tappedByUrl:  make(map[string]TapServiceRequest),
+		requestChan:  make(chan TapServiceRequest, 100),
 		httpClient: &http.Client{
 			Transport: &ochttp.Transport{},
 		},
@@ -66,14 +95,12 @@ func MakeClient(logger *zap.Logger, executorUrl string) *Client {
 }
 
 func (c *Client) GetServiceForFunction(ctx context.Context, metadata *metav1.ObjectMeta) (string, error) {
-	executorUrl := c.executorUrl + "/v2/getServiceForFunction"
-
 	body, err := json.Marshal(metadata)
 	if err != nil {
 		return "", errors.Wrap(err, "could not marshal request body for getting service for function")
 	}
 
-	resp, err := ctxhttp.Post(ctx, c.httpClient, executorUrl, "application/json", bytes.NewReader(body))
+	resp, err := c.post(ctx, "/v2/getServiceForFunction", body)
 	if err != nil {
 		return "", errors.Wrap(err, "error posting to getting service for function")
 	}
@@ -92,7 +119,6 @@ func (c *Client) GetServiceForFunction(ctx context.Context, metadata *metav1.Obj
 }
 
 func (c *Client) UnTapService(ctx context.Context, fnMeta metav1.ObjectMeta, executorType fv1.ExecutorType, serviceUrl *url.URL) error {
-	url := c.executorUrl + "/v2/unTapService"
 	tapSvc := TapServiceRequest{
 		FnMetadata:     fnMeta,
 		FnExecutorType: executorType,
@@ -104,7 +130,7 @@ func (c *Client) UnTapService(ctx context.Context, fnMeta metav1.ObjectMeta, exe
 		return errors.Wrap(err, "could not marshal request body for getting service for function")
 	}
 
-	resp, err := ctxhttp.Post(ctx, c.httpClient, url, "application/json", bytes.NewReader(body))
+	resp, err := c.post(ctx, "/v2/unTapService", body)
 	if err != nil {
 		return errors.Wrap(err, "error posting to getting service for function")
 	}
@@ -163,14 +189,12 @@ func (c *Client) TapService(fnMeta metav1.ObjectMeta, executorType fv1.ExecutorT
 }
 
 func (c *Client) _tapService(tapSvcReqs []TapServiceRequest) error {
-	executorUrl := c.executorUrl + "/v2/tapServices"
-
 	body, err := json.Marshal(tapSvcReqs)
 	if err != nil {
 		return err
 	}
 
-	resp, err := http.Post(executorUrl, "application/json", bytes.NewReader(body))
+	resp, err := c.post(context.Background(), "/v2/tapServices", body)
 	if err != nil {
 		return err
 	}
@@ -182,3 +206,57 @@ func (c *Client) _tapService(tapSvcReqs []TapServiceRequest) error {
 
 	return nil
 }
+
+// ReportFunctionLoad sends the load of functions seen by the router to executor.
+func (c *Client) ReportFunctionLoad(ctx context.Context, reports []FunctionLoadReport) error {
+	body, err := json.Marshal(reports)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal request body for reporting function load")
+	}
+
+	resp, err := c.post(ctx, "/v2/functionLoad", body)
+	if err != nil {
+		return errors.Wrap(err, "error posting to reporting function load")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return ferror.MakeErrorFromHTTP(resp)
+	}
+
+	return nil
+}
+
+// post sends the request to the executor in use, failing over to the next
+// executor when it can't be reached or is unavailable. With a single url,
+// usually a service in front of the executor replicas, the request is retried
+// once since it likely reaches another replica.
+func (c *Client) post(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	attempts := len(c.executorUrls)
+	if attempts < 2 {
+		attempts = 2
+	}
+
+	current := int(atomic.LoadUint32(&c.current))
+	var err error
+	for i := 0; i < attempts; i++ {
+		index := (current + i) % len(c.executorUrls)
+		executorUrl := c.executorUrls[index] + path
+
+		var resp *http.Response
+		resp, err = ctxhttp.Post(ctx, c.httpClient, executorUrl, "application/json", bytes.NewReader(body))
+		if err == nil {
+			if resp.StatusCode != http.StatusServiceUnavailable || i == attempts-1 {
+				atomic.StoreUint32(&c.current, uint32(index))
+				return resp, nil
+			}
+			resp.Body.Close()
+			err = errors.Errorf("executor unavailable")
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		c.logger.Warn("error reaching executor, failing over", zap.Error(err), zap.String("url", executorUrl))
+	}
+	return nil, err
+}
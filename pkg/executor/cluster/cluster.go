@@ -0,0 +1,285 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cluster coordinates the replicas of the executor. One replica is
+// elected leader and runs the cluster-wide background loops, and every
+// function is owned by one replica, which serves and caches its function
+// services. A nil *Cluster stands for a single executor that is both the
+// leader and the owner of all functions.
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/fission/fission/pkg/utils"
+)
+
+const (
+	defaultMemberSelector = "svc=executor"
+	leaderElectionLock    = "executor-leader"
+
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+
+	// how often the ready executor replicas are listed
+	memberRefreshInterval = 5 * time.Second
+)
+
+type (
+	// Member is a replica of the executor.
+	Member struct {
+		Name    string
+		Address string // IP:port of the executor API
+	}
+
+	Cluster struct {
+		logger           *zap.Logger
+		kubernetesClient kubernetes.Interface
+		namespace        string
+		selector         string
+		port             int
+		self             Member
+
+		lock    sync.RWMutex
+		members []Member // ready replicas, sorted by name
+		leading bool
+	}
+)
+
+// MakeCluster returns the cluster of executor replicas configured by the
+// environment, or nil if leader election is disabled.
+//
+// EXECUTOR_LEADER_ELECTION enables leader election; POD_NAME, POD_NAMESPACE
+// and POD_IP identify this replica and EXECUTOR_MEMBER_SELECTOR selects the
+// pods of all replicas.
+func MakeCluster(logger *zap.Logger, kubernetesClient kubernetes.Interface, port int) (*Cluster, error) {
+	enabled, _ := strconv.ParseBool(os.Getenv("EXECUTOR_LEADER_ELECTION"))
+	if !enabled {
+		return nil, nil
+	}
+
+	name, namespace, ip := os.Getenv("POD_NAME"), os.Getenv("POD_NAMESPACE"), os.Getenv("POD_IP")
+	if len(name) == 0 || len(namespace) == 0 || len(ip) == 0 {
+		return nil, errors.New("POD_NAME, POD_NAMESPACE and POD_IP must be set for executor leader election")
+	}
+
+	selector := os.Getenv("EXECUTOR_MEMBER_SELECTOR")
+	if len(selector) == 0 {
+		selector = defaultMemberSelector
+	}
+
+	return &Cluster{
+		logger:           logger.Named("executor_cluster"),
+		kubernetesClient: kubernetesClient,
+		namespace:        namespace,
+		selector:         selector,
+		port:             port,
+		self: Member{
+			Name:    name,
+			Address: fmt.Sprintf("%v:%v", ip, port),
+		},
+	}, nil
+}
+
+// Namespace returns the namespace of the executor replicas.
+func (c *Cluster) Namespace() string {
+	return c.namespace
+}
+
+// Self returns this replica, or an empty member for a single executor.
+func (c *Cluster) Self() Member {
+	if c == nil {
+		return Member{}
+	}
+	return c.self
+}
+
+// IsLeader returns whether this replica runs the cluster-wide background loops.
+func (c *Cluster) IsLeader() bool {
+	if c == nil {
+		return true
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.leading
+}
+
+// Members returns the ready replicas of the executor.
+func (c *Cluster) Members() []Member {
+	if c == nil {
+		return nil
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.members
+}
+
+// Owner returns the replica owning the key, and whether it's this replica.
+func (c *Cluster) Owner(key string) (Member, bool) {
+	if c == nil {
+		return Member{}, true
+	}
+	owner, ok := pickOwner(key, c.Members())
+	if !ok {
+		// before any replica is known to be ready, serve everything locally
+		return c.self, true
+	}
+	return owner, owner.Name == c.self.Name
+}
+
+// Owns returns whether this replica owns the key.
+func (c *Cluster) Owns(key string) bool {
+	_, self := c.Owner(key)
+	return self
+}
+
+// Run keeps the members up to date and campaigns for leadership. Once
+// elected, onStartedLeading runs before the replica reports being leader.
+// A replica losing leadership exits, so that a single leader runs the
+// background loops at any time. For a single executor, onStartedLeading
+// just runs.
+func (c *Cluster) Run(ctx context.Context, onStartedLeading func()) error {
+	if c == nil {
+		onStartedLeading()
+		return nil
+	}
+
+	go c.refreshMembers(ctx)
+
+	lock := &resourcelock.ConfigMapLock{
+		ConfigMapMeta: metav1.ObjectMeta{
+			Namespace: c.namespace,
+			Name:      leaderElectionLock,
+		},
+		Client: c.kubernetesClient.CoreV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: c.self.Name,
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: leaseDuration,
+		RenewDeadline: renewDeadline,
+		RetryPeriod:   retryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				c.logger.Info("elected executor leader", zap.String("member", c.self.Name))
+				onStartedLeading()
+				c.lock.Lock()
+				c.leading = true
+				c.lock.Unlock()
+			},
+			OnStoppedLeading: func() {
+				c.logger.Fatal("lost executor leadership", zap.String("member", c.self.Name))
+			},
+			OnNewLeader: func(identity string) {
+				c.logger.Info("executor leader changed", zap.String("leader", identity))
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "error creating executor leader elector")
+	}
+
+	go elector.Run(ctx)
+	return nil
+}
+
+// refreshMembers periodically lists the ready replicas of the executor.
+func (c *Cluster) refreshMembers(ctx context.Context) {
+	for {
+		pods, err := c.kubernetesClient.CoreV1().Pods(c.namespace).List(metav1.ListOptions{
+			LabelSelector: c.selector,
+		})
+		if err != nil {
+			c.logger.Error("error listing executor replicas", zap.Error(err))
+		} else {
+			members := readyMembers(pods.Items, c.port)
+			c.lock.Lock()
+			c.members = members
+			c.lock.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(memberRefreshInterval):
+		}
+	}
+}
+
+// readyMembers returns the members of the ready pods, sorted by name.
+func readyMembers(pods []apiv1.Pod, port int) []Member {
+	members := make([]Member, 0, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		if !utils.IsReadyPod(pod) {
+			continue
+		}
+		members = append(members, Member{
+			Name:    pod.ObjectMeta.Name,
+			Address: fmt.Sprintf("%v:%v", pod.Status.PodIP, port),
+		})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Name < members[j].Name
+	})
+	return members
+}
+
+// pickOwner picks the owner of the key by rendezvous hashing, so that
+// adding or removing a replica only moves the keys of that replica.
+func pickOwner(key string, members []Member) (Member, bool) {
+	var (
+		owner Member
+		found bool
+		best  uint64
+	)
+	for _, m := range members {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(m.Name))
+		score := h.Sum64()
+		if !found || score > best {
+			owner, best, found = m, score, true
+		}
+	}
+	return owner, found
+}
+
+// FunctionKey returns the ownership key of a function. All versions of a
+// function have the same owner.
+func FunctionKey(m *metav1.ObjectMeta) string {
+	return m.Namespace + "/" + m.Name
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPickOwner(t *testing.T) {
+	members := []Member{{Name: "executor-a"}, {Name: "executor-b"}, {Name: "executor-c"}}
+
+	_, found := pickOwner("default/hello", nil)
+	assert.False(t, found)
+
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("default/fn-%v", i)
+		owner, found := pickOwner(key, members)
+		assert.True(t, found)
+		owners[key] = owner.Name
+		counts[owner.Name]++
+	}
+	// functions are spread over all replicas
+	assert.Len(t, counts, 3)
+
+	// removing a replica only moves the functions it owned
+	for key, owner := range owners {
+		newOwner, _ := pickOwner(key, members[:2])
+		if owner != "executor-c" {
+			assert.Equal(t, owner, newOwner.Name, key)
+		}
+	}
+}
+
+func TestReadyMembers(t *testing.T) {
+	pod := func(name string, ready bool) apiv1.Pod {
+		status := apiv1.ConditionFalse
+		if ready {
+			status = apiv1.ConditionTrue
+		}
+		return apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: apiv1.PodStatus{
+				PodIP: "10.0.0.1",
+				Phase: apiv1.PodRunning,
+				Conditions: []apiv1.PodCondition{
+					{Type: apiv1.PodReady, Status: status},
+				},
+				ContainerStatuses: []apiv1.ContainerStatus{
+					{Ready: ready},
+				},
+			},
+		}
+	}
+
+	members := readyMembers([]apiv1.Pod{pod("executor-b", true), pod("executor-c", false), pod("executor-a", true)}, 8888)
+	assert.Equal(t, []Member{
+		{Name: "executor-a", Address: "10.0.0.1:8888"},
+		{Name: "executor-b", Address: "10.0.0.1:8888"},
+	}, members)
+}
+
+func TestSingleExecutor(t *testing.T) {
+	var c *Cluster
+	assert.True(t, c.IsLeader())
+	assert.True(t, c.Owns("default/hello"))
+
+	ran := false
+	assert.NoError(t, c.Run(context.Background(), func() { ran = true }))
+	assert.True(t, ran)
+}
@@ -29,10 +29,12 @@ import (
 	"github.com/dchest/uniuri"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opencensus.io/plugin/ochttp"
 	"go.uber.org/zap"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/executor/cluster"
 	"github.com/fission/fission/pkg/executor/cms"
 	"github.com/fission/fission/pkg/executor/executortype"
 	"github.com/fission/fission/pkg/executor/executortype/newdeploy"
@@ -49,6 +51,8 @@ type (
 
 		executorTypes map[fv1.ExecutorType]executortype.ExecutorType
 		cms           *cms.ConfigSecretController
+		cluster       *cluster.Cluster
+		peerClient    *http.Client // forwards requests to the replica owning the function
 
 		fissionClient *crd.FissionClient
 
@@ -67,10 +71,13 @@ type (
 )
 
 func MakeExecutor(logger *zap.Logger, cms *cms.ConfigSecretController,
-	fissionClient *crd.FissionClient, types map[fv1.ExecutorType]executortype.ExecutorType) (*Executor, error) {
+	fissionClient *crd.FissionClient, types map[fv1.ExecutorType]executortype.ExecutorType,
+	cluster *cluster.Cluster) (*Executor, error) {
 	executor := &Executor{
 		logger:        logger.Named("executor"),
 		cms:           cms,
+		cluster:       cluster,
+		peerClient:    &http.Client{Transport: &ochttp.Transport{}},
 		fissionClient: fissionClient,
 		executorTypes: types,
 
@@ -250,26 +257,78 @@ func StartExecutor(logger *zap.Logger, functionNamespace string, envBuilderNames
 		return errors.Wrap(err, "Error making fetcher config")
 	}
 
-	executorInstanceID := strings.ToLower(uniuri.NewLen(8))
+	executorCluster, err := cluster.MakeCluster(logger, kubernetesClient, port)
+	if err != nil {
+		return errors.Wrap(err, "error configuring executor leader election")
+	}
+
+	persistence, err := fscache.MakePersistence(logger, kubernetesClient, executorCluster)
+	if err != nil {
+		return errors.Wrap(err, "error configuring executor state persistence")
+	}
+
+	instanceIDStore := persistence
+	if executorCluster != nil && persistence == nil {
+		// replicas share the instance ID, so that the leader doesn't clean up
+		// the function pods of the other replicas
+		instanceIDStore = fscache.MakePersistenceWithStore(logger,
+			fscache.MakeConfigMapStateStore(kubernetesClient, executorCluster.Namespace(), fscache.DefaultStateConfigMap), 0, executorCluster)
+	}
+
+	// reuse the instance ID of the previous executor when its state is
+	// persisted, so that its function pods are kept rather than cleaned up
+	executorInstanceID := instanceIDStore.InstanceID(strings.ToLower(uniuri.NewLen(8)))
 
 	logger.Info("Starting executor", zap.String("instanceID", executorInstanceID))
 
 	gpm := poolmgr.MakeGenericPoolManager(
 		logger,
 		fissionClient, kubernetesClient,
-		functionNamespace, fetcherConfig, executorInstanceID)
+		functionNamespace, fetcherConfig, executorInstanceID, persistence, executorCluster)
 
 	ndm := newdeploy.MakeNewDeploy(
 		logger,
 		fissionClient, kubernetesClient, fissionClient.CoreV1().RESTClient(),
-		functionNamespace, fetcherConfig, executorInstanceID)
+		functionNamespace, fetcherConfig, executorInstanceID, persistence, executorCluster)
+
+	cnm := newdeploy.MakeContainer(
+		logger,
+		fissionClient, kubernetesClient, fissionClient.CoreV1().RESTClient(),
+		functionNamespace, executorInstanceID, persistence, executorCluster)
 
 	executorTypes := make(map[fv1.ExecutorType]executortype.ExecutorType)
 	executorTypes[gpm.GetTypeName()] = gpm
 	executorTypes[ndm.GetTypeName()] = ndm
+	executorTypes[cnm.GetTypeName()] = cnm
 
 	adoptExistingResources, _ := strconv.ParseBool(os.Getenv("ADOPT_EXISTING_RESOURCES"))
 
+	// A single executor cleans up right away. With several replicas, the
+	// leader cleans up once elected.
+	err = executorCluster.Run(context.Background(), func() {
+		cleanupOldExecutorObjects(executorTypes, adoptExistingResources)
+	})
+	if err != nil {
+		return err
+	}
+
+	cms := cms.MakeConfigSecretController(logger, fissionClient, kubernetesClient, executorTypes)
+
+	api, err := MakeExecutor(logger, cms, fissionClient, executorTypes, executorCluster)
+	if err != nil {
+		return err
+	}
+
+	go reaper.CleanupRoleBindings(logger, kubernetesClient, fissionClient, functionNamespace, envBuilderNamespace, time.Minute*30)
+	go api.Serve(port)
+	go serveMetric(logger)
+
+	return nil
+}
+
+// cleanupOldExecutorObjects adopts the resources of previous executors if
+// enabled, and cleans up the rest.
+func cleanupOldExecutorObjects(executorTypes map[fv1.ExecutorType]executortype.ExecutorType, adoptExistingResources bool) {
 	wg := &sync.WaitGroup{}
 	for _, et := range executorTypes {
 		wg.Add(1)
@@ -284,17 +343,4 @@ func StartExecutor(logger *zap.Logger, functionNamespace string, envBuilderNames
 	// set hard timeout for resource adoption
 	// TODO: use context to control the waiting time once kubernetes client supports it.
 	util.WaitTimeout(wg, 30*time.Second)
-
-	cms := cms.MakeConfigSecretController(logger, fissionClient, kubernetesClient, executorTypes)
-
-	api, err := MakeExecutor(logger, cms, fissionClient, executorTypes)
-	if err != nil {
-		return err
-	}
-
-	go reaper.CleanupRoleBindings(logger, kubernetesClient, fissionClient, functionNamespace, envBuilderNamespace, time.Minute*30)
-	go api.Serve(port)
-	go serveMetric(logger)
-
-	return nil
 }
@@ -63,4 +63,12 @@ type ExecutorType interface {
 
 	// getTotalAvailable returns total active instances of particular function
 	GetTotalAvailable(*fv1.Function) int
+
+	// ListFuncSvcs returns the function services in cache with their state.
+	ListFuncSvcs() []fscache.FuncSvcState
+
+	// EvictFunction removes the function pods of a function right away, so
+	// that its next request gets new ones, and returns the number of function
+	// services evicted.
+	EvictFunction(*fv1.Function) (int, error)
 }
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package newdeploy
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	apiv1 "k8s.io/api/core/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/fscache"
+	"github.com/fission/fission/pkg/executor/reaper"
+)
+
+func (deploy *NewDeploy) ListFuncSvcs() []fscache.FuncSvcState {
+	return deploy.fsCache.List()
+}
+
+// EvictFunction scales the deployment of the function to zero regardless of
+// its min scale. The next request scales it up again, the same way as after
+// the idle reaper scaled it to zero.
+func (deploy *NewDeploy) EvictFunction(fn *fv1.Function) (int, error) {
+	fsvc, err := deploy.fsCache.GetByFunctionUID(fn.ObjectMeta.UID)
+	if err != nil {
+		if fscache.IsNotFoundError(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	deployObj := getDeploymentObj(fsvc.KubernetesObjects)
+	if deployObj == nil {
+		return 0, errors.Errorf("error finding deployment of function %v", fn.ObjectMeta.Name)
+	}
+
+	err = deploy.scaleDeployment(deployObj.Namespace, deployObj.Name, 0)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error scaling down deployment of function %v", fn.ObjectMeta.Name)
+	}
+
+	reaper.ObserveReap(string(deploy.executorType), reaper.ReasonEvicted, reaper.ResultScaledDown)
+	reaper.RecordEvent(deploy.logger, deploy.kubernetesClient, deployObj, apiv1.EventTypeNormal, "ScalingDown",
+		fmt.Sprintf("Scaling down evicted function %v to 0 replicas", fn.ObjectMeta.Name))
+	return 1, nil
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package newdeploy
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/executor/cluster"
+	"github.com/fission/fission/pkg/executor/executortype"
+	"github.com/fission/fission/pkg/executor/fscache"
+)
+
+const (
+	// port the function container serves on if its pod spec has none
+	defaultContainerPort = 8888
+
+	// secrets and configmaps are mounted where fetcher puts them for environments
+	containerSecretPath = "/secrets"
+	containerCfgMapPath = "/configs"
+)
+
+// MakeContainer returns the executor type running functions of executor
+// type container. Such a function runs the image given in its pod spec as
+// is, without environment, package or specialization, in a deployment with
+// HPA and service managed the same way as newdeploy.
+func MakeContainer(
+	logger *zap.Logger,
+	fissionClient *crd.FissionClient,
+	kubernetesClient *kubernetes.Clientset,
+	crdClient rest.Interface,
+	namespace string,
+	instanceID string,
+	persistence *fscache.Persistence,
+	executorCluster *cluster.Cluster,
+) executortype.ExecutorType {
+	return makeDeployManager(logger.Named("container"), fv1.ExecutorTypeContainer, fissionClient, kubernetesClient,
+		crdClient, namespace, nil, instanceID, persistence, executorCluster)
+}
+
+// getFunctionEnv returns the environment of the function. Functions of
+// executor type container have none; an empty environment stands for it.
+func (deploy *NewDeploy) getFunctionEnv(fn *fv1.Function) (*fv1.Environment, error) {
+	if deploy.executorType == fv1.ExecutorTypeContainer {
+		return &fv1.Environment{}, nil
+	}
+	return deploy.fissionClient.CoreV1().
+		Environments(fn.Spec.Environment.Namespace).
+		Get(fn.Spec.Environment.Name, metav1.GetOptions{})
+}
+
+// getTargetPort returns the port of the function pod the service sends requests to.
+func (deploy *NewDeploy) getTargetPort(fn *fv1.Function) int {
+	if deploy.executorType == fv1.ExecutorTypeContainer && fn.Spec.PodSpec != nil &&
+		len(fn.Spec.PodSpec.Containers) > 0 && len(fn.Spec.PodSpec.Containers[0].Ports) > 0 {
+		return int(fn.Spec.PodSpec.Containers[0].Ports[0].ContainerPort)
+	}
+	return defaultContainerPort
+}
+
+// getContainerPodSpec returns the pod template of a function of executor
+// type container, built from the pod spec of the function.
+func (deploy *NewDeploy) getContainerPodSpec(fn *fv1.Function, deployNamespace string,
+	deployLabels map[string]string) (*apiv1.PodTemplateSpec, error) {
+
+	if fn.Spec.PodSpec == nil || len(fn.Spec.PodSpec.Containers) == 0 {
+		return nil, errors.Errorf("function %v has no container to run", fn.ObjectMeta.Name)
+	}
+
+	rvCount, err := referencedResourcesRVSum(deploy.kubernetesClient, fn.ObjectMeta.Namespace, fn.Spec.Secrets, fn.Spec.ConfigMaps)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := fn.Spec.PodSpec.DeepCopy()
+	container := &spec.Containers[0]
+	if len(container.Name) == 0 {
+		container.Name = fn.ObjectMeta.Name
+	}
+	if len(container.ImagePullPolicy) == 0 {
+		container.ImagePullPolicy = deploy.runtimeImagePullPolicy
+	}
+	// https://istio.io/docs/setup/kubernetes/additional-setup/requirements/
+	if len(container.Ports) == 0 {
+		container.Ports = []apiv1.ContainerPort{
+			{
+				Name:          "http-env",
+				ContainerPort: int32(defaultContainerPort),
+			},
+		}
+	}
+	container.Resources = mergeResources(container.Resources, fn)
+	// a change of the referenced secrets/configmaps rolls the pods, see RefreshFuncPods
+	container.Env = append(container.Env, apiv1.EnvVar{
+		Name:  fv1.ResourceVersionCount,
+		Value: fmt.Sprintf("%v", rvCount),
+	})
+
+	for i, secret := range fn.Spec.Secrets {
+		if secret.Namespace != deployNamespace {
+			deploy.logger.Warn("secret in a different namespace than the function pod can't be mounted",
+				zap.String("function", fn.ObjectMeta.Name),
+				zap.String("secret", secret.Name),
+				zap.String("secret_namespace", secret.Namespace))
+			continue
+		}
+		name := fmt.Sprintf("fission-secret-%v", i)
+		spec.Volumes = append(spec.Volumes, apiv1.Volume{
+			Name: name,
+			VolumeSource: apiv1.VolumeSource{
+				Secret: &apiv1.SecretVolumeSource{SecretName: secret.Name},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, apiv1.VolumeMount{
+			Name:      name,
+			MountPath: path.Join(containerSecretPath, secret.Namespace, secret.Name),
+			ReadOnly:  true,
+		})
+	}
+
+	for i, cfgmap := range fn.Spec.ConfigMaps {
+		if cfgmap.Namespace != deployNamespace {
+			deploy.logger.Warn("configmap in a different namespace than the function pod can't be mounted",
+				zap.String("function", fn.ObjectMeta.Name),
+				zap.String("configmap", cfgmap.Name),
+				zap.String("configmap_namespace", cfgmap.Namespace))
+			continue
+		}
+		name := fmt.Sprintf("fission-configmap-%v", i)
+		spec.Volumes = append(spec.Volumes, apiv1.Volume{
+			Name: name,
+			VolumeSource: apiv1.VolumeSource{
+				ConfigMap: &apiv1.ConfigMapVolumeSource{
+					LocalObjectReference: apiv1.LocalObjectReference{Name: cfgmap.Name},
+				},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, apiv1.VolumeMount{
+			Name:      name,
+			MountPath: path.Join(containerCfgMapPath, cfgmap.Namespace, cfgmap.Name),
+			ReadOnly:  true,
+		})
+	}
+
+	podLabels := make(map[string]string)
+	for k, v := range deployLabels {
+		podLabels[k] = v
+	}
+
+	return &apiv1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: podLabels,
+		},
+		Spec: *spec,
+	}, nil
+}
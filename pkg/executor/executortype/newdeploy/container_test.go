@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package newdeploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestContainerPodSpec(t *testing.T) {
+	deploy := &NewDeploy{
+		logger:                 zap.NewNop(),
+		executorType:           fv1.ExecutorTypeContainer,
+		runtimeImagePullPolicy: apiv1.PullIfNotPresent,
+	}
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+		Spec: fv1.FunctionSpec{
+			PodSpec: &apiv1.PodSpec{
+				Containers: []apiv1.Container{{Image: "example/hello:v1"}},
+			},
+		},
+	}
+	labels := map[string]string{fv1.EXECUTOR_TYPE: string(fv1.ExecutorTypeContainer)}
+
+	assert.Equal(t, 8888, deploy.getTargetPort(fn))
+
+	pod, err := deploy.getContainerPodSpec(fn, "fission-function", labels)
+	assert.NoError(t, err)
+	container := pod.Spec.Containers[0]
+	assert.Equal(t, "hello", container.Name)
+	assert.Equal(t, "example/hello:v1", container.Image)
+	assert.Equal(t, apiv1.PullIfNotPresent, container.ImagePullPolicy)
+	assert.Equal(t, int32(8888), container.Ports[0].ContainerPort)
+	assert.Equal(t, fv1.ResourceVersionCount, container.Env[0].Name)
+	assert.Equal(t, labels, pod.ObjectMeta.Labels)
+	// the pod spec of the function is left as is
+	assert.Empty(t, fn.Spec.PodSpec.Containers[0].Name)
+
+	fn.Spec.PodSpec.Containers[0].Ports = []apiv1.ContainerPort{{ContainerPort: 8080}}
+	assert.Equal(t, 8080, deploy.getTargetPort(fn))
+
+	fn.Spec.PodSpec = nil
+	_, err = deploy.getContainerPodSpec(fn, "fission-function", labels)
+	assert.Error(t, err)
+}
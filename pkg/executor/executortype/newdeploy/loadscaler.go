@@ -0,0 +1,257 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package newdeploy
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/client"
+)
+
+const (
+	// interval between scaling decisions of request-driven functions
+	loadScaleInterval = 10 * time.Second
+
+	// load reports older than this are dropped, e.g. of a router that went away
+	loadReportExpiry = 30 * time.Second
+
+	// a deployment is only scaled in after it hasn't been scaled out for a
+	// while, so that bursty load doesn't flap the number of pods
+	loadScaleDownDelay = time.Minute
+)
+
+type (
+	// functionLoad is the latest load of a function reported by each router.
+	functionLoad struct {
+		fn          metav1.ObjectMeta
+		reports     map[string]loadSample
+		lastScaleUp time.Time
+	}
+
+	loadSample struct {
+		report   client.FunctionLoadReport
+		received time.Time
+	}
+)
+
+// isRequestDriven returns true if the deployment of the function is scaled
+// by executor with the load reported by routers instead of by an HPA.
+func isRequestDriven(es *fv1.ExecutionStrategy) bool {
+	return es.TargetConcurrency > 0 || es.TargetRPS > 0
+}
+
+// RecordLoad records the load of a function reported by a router. Scaling
+// the deployment to zero by idleObjectReaper is held off while it serves
+// requests; scaling it up from zero happens on the first request, when
+// router asks for the function service.
+func (deploy *NewDeploy) RecordLoad(report client.FunctionLoadReport) {
+	uid := report.FnMetadata.UID
+
+	deploy.loadLock.Lock()
+	load, ok := deploy.loads[uid]
+	if !ok {
+		load = &functionLoad{
+			reports: make(map[string]loadSample),
+		}
+		deploy.loads[uid] = load
+	}
+	load.fn = report.FnMetadata
+	load.reports[report.Source] = loadSample{
+		report:   report,
+		received: time.Now(),
+	}
+	deploy.loadLock.Unlock()
+
+	if report.InFlight == 0 && report.Requests == 0 {
+		return
+	}
+	fsvc, err := deploy.fsCache.GetByFunctionUID(uid)
+	if err != nil {
+		return
+	}
+	err = deploy.fsCache.TouchByAddress(fsvc.Address)
+	if err != nil {
+		deploy.logger.Debug("error touching function service", zap.Error(err), zap.String("function", report.FnMetadata.Name))
+	}
+}
+
+// currentLoad returns the number of in-flight requests and requests per second
+// of a function summed over routers, dropping expired reports.
+func (load *functionLoad) currentLoad(now time.Time) (int, float64) {
+	inFlight := 0
+	rps := 0.0
+	for source, sample := range load.reports {
+		if now.Sub(sample.received) > loadReportExpiry {
+			delete(load.reports, source)
+			continue
+		}
+		inFlight += sample.report.InFlight
+		if sample.report.Interval > 0 {
+			rps += float64(sample.report.Requests) / sample.report.Interval.Seconds()
+		}
+	}
+	return inFlight, rps
+}
+
+// desiredReplicas returns the number of pods serving the load of a function,
+// between max(MinScale, 1) and MaxScale. Scaling to zero is left to
+// idleObjectReaper.
+func desiredReplicas(es *fv1.ExecutionStrategy, inFlight int, rps float64) int32 {
+	var desired float64
+	if es.TargetConcurrency > 0 {
+		desired = math.Ceil(float64(inFlight) / float64(es.TargetConcurrency))
+	} else if es.TargetRPS > 0 {
+		desired = math.Ceil(rps / float64(es.TargetRPS))
+	}
+
+	minScale := es.MinScale
+	if minScale < 1 {
+		minScale = 1
+	}
+	maxScale := es.MaxScale
+	if maxScale < minScale {
+		maxScale = minScale
+	}
+
+	replicas := int32(desired)
+	if replicas < int32(minScale) {
+		replicas = int32(minScale)
+	}
+	if replicas > int32(maxScale) {
+		replicas = int32(maxScale)
+	}
+	return replicas
+}
+
+// loadScaler periodically scales the deployments of request-driven
+// functions by the load reported by routers.
+func (deploy *NewDeploy) loadScaler(ctx context.Context) {
+	ticker := time.NewTicker(loadScaleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deploy.scaleByLoad()
+		}
+	}
+}
+
+func (deploy *NewDeploy) scaleByLoad() {
+	now := time.Now()
+
+	type loadSnapshot struct {
+		fn          metav1.ObjectMeta
+		inFlight    int
+		rps         float64
+		lastScaleUp time.Time
+	}
+
+	// take a snapshot so that routers aren't blocked on kubernetes calls
+	deploy.loadLock.Lock()
+	snapshots := make([]loadSnapshot, 0, len(deploy.loads))
+	for uid, load := range deploy.loads {
+		inFlight, rps := load.currentLoad(now)
+		if len(load.reports) == 0 {
+			delete(deploy.loads, uid)
+			continue
+		}
+		snapshots = append(snapshots, loadSnapshot{
+			fn:          load.fn,
+			inFlight:    inFlight,
+			rps:         rps,
+			lastScaleUp: load.lastScaleUp,
+		})
+	}
+	deploy.loadLock.Unlock()
+
+	for _, snapshot := range snapshots {
+		scaledUp, err := deploy.scaleFunctionByLoad(&snapshot.fn, snapshot.inFlight, snapshot.rps, now.Sub(snapshot.lastScaleUp) >= loadScaleDownDelay)
+		if err != nil {
+			deploy.logger.Error("error scaling function by load", zap.Error(err), zap.String("function", snapshot.fn.Name))
+			continue
+		}
+		if scaledUp {
+			deploy.loadLock.Lock()
+			if load, ok := deploy.loads[snapshot.fn.UID]; ok {
+				load.lastScaleUp = now
+			}
+			deploy.loadLock.Unlock()
+		}
+	}
+}
+
+// scaleFunctionByLoad scales the deployment of a request-driven function to
+// the number of pods serving the given load, and returns true if it's scaled up.
+func (deploy *NewDeploy) scaleFunctionByLoad(fnMeta *metav1.ObjectMeta, inFlight int, rps float64, canScaleDown bool) (bool, error) {
+	obj, exists, err := deploy.funcStore.GetByKey(fnMeta.Namespace + "/" + fnMeta.Name)
+	if err != nil || !exists {
+		return false, err
+	}
+	fn := obj.(*fv1.Function)
+	strategy := &fn.Spec.InvokeStrategy.ExecutionStrategy
+	if fn.ObjectMeta.UID != fnMeta.UID || strategy.ExecutorType != deploy.executorType || !isRequestDriven(strategy) {
+		return false, nil
+	}
+
+	fsvc, err := deploy.fsCache.GetByFunctionUID(fn.ObjectMeta.UID)
+	if err != nil {
+		// not created yet, or being created for the first request
+		return false, nil
+	}
+	deployObj := getDeploymentObj(fsvc.KubernetesObjects)
+	if deployObj == nil {
+		return false, nil
+	}
+
+	currentDeploy, err := deploy.kubernetesClient.AppsV1().
+		Deployments(deployObj.Namespace).Get(deployObj.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	// a deployment scaled to zero is activated by the first request
+	current := *currentDeploy.Spec.Replicas
+	if current == 0 {
+		return false, nil
+	}
+
+	desired := desiredReplicas(strategy, inFlight, rps)
+	if desired == current || (desired < current && !canScaleDown) {
+		return false, nil
+	}
+
+	deploy.logger.Debug("scaling function by load",
+		zap.String("function", fn.ObjectMeta.Name),
+		zap.Int("in_flight", inFlight),
+		zap.Float64("rps", rps),
+		zap.Int32("current_replicas", current),
+		zap.Int32("desired_replicas", desired))
+
+	err = deploy.scaleDeployment(deployObj.Namespace, deployObj.Name, desired)
+	if err != nil {
+		return false, err
+	}
+	return desired > current, nil
+}
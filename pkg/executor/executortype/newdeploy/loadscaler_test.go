@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package newdeploy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/client"
+)
+
+func TestDesiredReplicas(t *testing.T) {
+	concurrency := &fv1.ExecutionStrategy{MinScale: 0, MaxScale: 5, TargetConcurrency: 10}
+	assert.Equal(t, int32(1), desiredReplicas(concurrency, 0, 0))
+	assert.Equal(t, int32(1), desiredReplicas(concurrency, 10, 0))
+	assert.Equal(t, int32(2), desiredReplicas(concurrency, 11, 0))
+	assert.Equal(t, int32(5), desiredReplicas(concurrency, 100, 0))
+
+	rps := &fv1.ExecutionStrategy{MinScale: 2, MaxScale: 10, TargetRPS: 50}
+	assert.Equal(t, int32(2), desiredReplicas(rps, 0, 20))
+	assert.Equal(t, int32(3), desiredReplicas(rps, 0, 120))
+	assert.Equal(t, int32(10), desiredReplicas(rps, 0, 1000))
+}
+
+func TestFunctionLoad(t *testing.T) {
+	now := time.Now()
+	load := &functionLoad{
+		reports: map[string]loadSample{
+			"router-a": {
+				report:   client.FunctionLoadReport{InFlight: 3, Requests: 50, Interval: 5 * time.Second},
+				received: now,
+			},
+			"router-b": {
+				report:   client.FunctionLoadReport{InFlight: 2, Requests: 25, Interval: 5 * time.Second},
+				received: now.Add(-time.Second),
+			},
+			"router-gone": {
+				report:   client.FunctionLoadReport{InFlight: 100, Requests: 1000, Interval: 5 * time.Second},
+				received: now.Add(-2 * loadReportExpiry),
+			},
+		},
+	}
+
+	inFlight, rps := load.currentLoad(now)
+	assert.Equal(t, 5, inFlight)
+	assert.Equal(t, 15.0, rps)
+	assert.Len(t, load.reports, 2)
+}
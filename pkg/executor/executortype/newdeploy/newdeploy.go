@@ -96,9 +96,12 @@ func (deploy *NewDeploy) createOrGetDeployment(fn *fv1.Function, env *fv1.Enviro
 
 		return existingDepl, err
 	} else if k8s_err.IsNotFound(err) {
-		err := deploy.setupRBACObjs(deployNamespace, fn)
-		if err != nil {
-			return nil, err
+		// function containers of executor type container run without fetcher
+		if deploy.executorType == fv1.ExecutorTypeNewdeploy {
+			err := deploy.setupRBACObjs(deployNamespace, fn)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		depl, err := deploy.kubernetesClient.AppsV1().Deployments(deployNamespace).Create(deployment)
@@ -197,6 +200,14 @@ func (deploy *NewDeploy) getDeploymentSpec(fn *fv1.Function, env *fv1.Environmen
 		replicas = *targetReplicas
 	}
 
+	if deploy.executorType == fv1.ExecutorTypeContainer {
+		pod, err := deploy.getContainerPodSpec(fn, deployNamespace, deployLabels)
+		if err != nil {
+			return nil, err
+		}
+		return makeDeployment(deployName, deployLabels, deployAnnotations, replicas, *pod), nil
+	}
+
 	gracePeriodSeconds := int64(6 * 60)
 	if env.Spec.TerminationGracePeriod > 0 {
 		gracePeriodSeconds = env.Spec.TerminationGracePeriod
@@ -225,23 +236,6 @@ func (deploy *NewDeploy) getDeploymentSpec(fn *fv1.Function, env *fv1.Environmen
 
 	resources := deploy.getResources(env, fn)
 
-	// Set maxUnavailable and maxSurge to 20% is because we want
-	// fission to rollout newer function version gradually without
-	// affecting any online service. For example, if you set maxSurge
-	// to 100%, the new ReplicaSet scales up immediately and may
-	// consume all remaining compute resources which might be an
-	// issue if a cluster's resource is on a budget.
-	// TODO: add to ExecutionStrategy so that the user
-	// can do more fine control over different functions.
-	maxUnavailable := intstr.FromString("20%")
-	maxSurge := intstr.FromString("20%")
-
-	// Newdeploy updates the environment variable "LastUpdateTimestamp" of deployment
-	// whenever a configmap/secret gets an update, but it also leaves multiple ReplicaSets for
-	// rollback purpose. Since fission always update a deployment instead of performing a
-	// rollback, set RevisionHistoryLimit to 0 to disable this feature.
-	revisionHistoryLimit := int32(0)
-
 	rvCount, err := referencedResourcesRVSum(deploy.kubernetesClient, fn.ObjectMeta.Namespace, fn.Spec.Secrets, fn.Spec.ConfigMaps)
 	if err != nil {
 		return nil, err
@@ -295,28 +289,7 @@ func (deploy *NewDeploy) getDeploymentSpec(fn *fv1.Function, env *fv1.Environmen
 
 	pod.Spec = *(util.ApplyImagePullSecret(env.Spec.ImagePullSecret, pod.Spec))
 
-	deployment := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        deployName,
-			Labels:      deployLabels,
-			Annotations: deployAnnotations,
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
-			Selector: &metav1.LabelSelector{
-				MatchLabels: deployLabels,
-			},
-			Template: pod,
-			Strategy: appsv1.DeploymentStrategy{
-				Type: appsv1.RollingUpdateDeploymentStrategyType,
-				RollingUpdate: &appsv1.RollingUpdateDeployment{
-					MaxUnavailable: &maxUnavailable,
-					MaxSurge:       &maxSurge,
-				},
-			},
-			RevisionHistoryLimit: &revisionHistoryLimit,
-		},
-	}
+	deployment := makeDeployment(deployName, deployLabels, deployAnnotations, replicas, pod)
 
 	// Order of merging is important here - first fetcher, then containers and lastly pod spec
 	err = deploy.fetcherConfig.AddSpecializingFetcherToPodSpec(
@@ -340,10 +313,59 @@ func (deploy *NewDeploy) getDeploymentSpec(fn *fv1.Function, env *fv1.Environmen
 	return deployment, nil
 }
 
+// makeDeployment returns the deployment running the function pod.
+func makeDeployment(deployName string, deployLabels map[string]string, deployAnnotations map[string]string,
+	replicas int32, pod apiv1.PodTemplateSpec) *appsv1.Deployment {
+
+	// Set maxUnavailable and maxSurge to 20% is because we want
+	// fission to rollout newer function version gradually without
+	// affecting any online service. For example, if you set maxSurge
+	// to 100%, the new ReplicaSet scales up immediately and may
+	// consume all remaining compute resources which might be an
+	// issue if a cluster's resource is on a budget.
+	// TODO: add to ExecutionStrategy so that the user
+	// can do more fine control over different functions.
+	maxUnavailable := intstr.FromString("20%")
+	maxSurge := intstr.FromString("20%")
+
+	// Newdeploy updates the environment variable "LastUpdateTimestamp" of deployment
+	// whenever a configmap/secret gets an update, but it also leaves multiple ReplicaSets for
+	// rollback purpose. Since fission always update a deployment instead of performing a
+	// rollback, set RevisionHistoryLimit to 0 to disable this feature.
+	revisionHistoryLimit := int32(0)
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        deployName,
+			Labels:      deployLabels,
+			Annotations: deployAnnotations,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: deployLabels,
+			},
+			Template: pod,
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{
+					MaxUnavailable: &maxUnavailable,
+					MaxSurge:       &maxSurge,
+				},
+			},
+			RevisionHistoryLimit: &revisionHistoryLimit,
+		},
+	}
+}
+
 // getResources overrides only the resources which are overridden at function level otherwise
 // default to resources specified at environment level
 func (deploy *NewDeploy) getResources(env *fv1.Environment, fn *fv1.Function) apiv1.ResourceRequirements {
-	resources := env.Spec.Resources
+	return mergeResources(env.Spec.Resources, fn)
+}
+
+// mergeResources overrides the given resources with the ones specified at function level.
+func mergeResources(resources apiv1.ResourceRequirements, fn *fv1.Function) apiv1.ResourceRequirements {
 	if resources.Requests == nil {
 		resources.Requests = make(map[apiv1.ResourceName]resource.Quantity)
 	}
@@ -452,7 +474,8 @@ func (deploy *NewDeploy) deleteHpa(ns string, name string) error {
 	return deploy.kubernetesClient.AutoscalingV1().HorizontalPodAutoscalers(ns).Delete(name, &metav1.DeleteOptions{})
 }
 
-func (deploy *NewDeploy) createOrGetSvc(deployLabels map[string]string, deployAnnotations map[string]string, svcName string, svcNamespace string) (*apiv1.Service, error) {
+func (deploy *NewDeploy) createOrGetSvc(deployLabels map[string]string, deployAnnotations map[string]string,
+	svcName string, svcNamespace string, targetPort int) (*apiv1.Service, error) {
 	service := &apiv1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        svcName,
@@ -464,7 +487,7 @@ func (deploy *NewDeploy) createOrGetSvc(deployLabels map[string]string, deployAn
 				{
 					Name:       "http-env",
 					Port:       int32(80),
-					TargetPort: intstr.FromInt(8888),
+					TargetPort: intstr.FromInt(targetPort),
 				},
 			},
 			Selector: deployLabels,
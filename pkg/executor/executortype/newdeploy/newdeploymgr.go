@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -41,6 +42,7 @@ import (
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/executor/cluster"
 	"github.com/fission/fission/pkg/executor/executortype"
 	"github.com/fission/fission/pkg/executor/fscache"
 	"github.com/fission/fission/pkg/executor/reaper"
@@ -55,11 +57,16 @@ type (
 	NewDeploy struct {
 		logger *zap.Logger
 
+		// executorType is either newdeploy or container; both run a
+		// deployment with HPA and service per function, see container.go
+		executorType fv1.ExecutorType
+
 		kubernetesClient *kubernetes.Clientset
 		fissionClient    *crd.FissionClient
 		crdClient        rest.Interface
 		instanceID       string
 		fetcherConfig    *fetcherConfig.Config
+		cluster          *cluster.Cluster // executor replicas, each function is handled by its owner
 
 		runtimeImagePullPolicy apiv1.PullPolicy
 		namespace              string
@@ -75,6 +82,10 @@ type (
 		envController k8sCache.Controller
 
 		defaultIdlePodReapTime time.Duration
+
+		// load reported by routers for request-driven functions, see loadscaler.go
+		loadLock sync.Mutex
+		loads    map[k8sTypes.UID]*functionLoad
 	}
 )
 
@@ -86,7 +97,25 @@ func MakeNewDeploy(
 	namespace string,
 	fetcherConfig *fetcherConfig.Config,
 	instanceID string,
+	persistence *fscache.Persistence,
+	executorCluster *cluster.Cluster,
 ) executortype.ExecutorType {
+	return makeDeployManager(logger.Named("new_deploy"), fv1.ExecutorTypeNewdeploy, fissionClient, kubernetesClient,
+		crdClient, namespace, fetcherConfig, instanceID, persistence, executorCluster)
+}
+
+func makeDeployManager(
+	logger *zap.Logger,
+	executorType fv1.ExecutorType,
+	fissionClient *crd.FissionClient,
+	kubernetesClient *kubernetes.Clientset,
+	crdClient rest.Interface,
+	namespace string,
+	fetcherConfig *fetcherConfig.Config,
+	instanceID string,
+	persistence *fscache.Persistence,
+	executorCluster *cluster.Cluster,
+) *NewDeploy {
 	enableIstio := false
 	if len(os.Getenv("ENABLE_ISTIO")) > 0 {
 		istio, err := strconv.ParseBool(os.Getenv("ENABLE_ISTIO"))
@@ -97,12 +126,14 @@ func MakeNewDeploy(
 	}
 
 	nd := &NewDeploy{
-		logger: logger.Named("new_deploy"),
+		logger:       logger,
+		executorType: executorType,
 
 		fissionClient:    fissionClient,
 		kubernetesClient: kubernetesClient,
 		crdClient:        crdClient,
 		instanceID:       instanceID,
+		cluster:          executorCluster,
 
 		namespace: namespace,
 		fsCache:   fscache.MakeFunctionServiceCache(logger),
@@ -113,16 +144,25 @@ func MakeNewDeploy(
 		useIstio:               enableIstio,
 
 		defaultIdlePodReapTime: 2 * time.Minute,
+
+		loads: make(map[k8sTypes.UID]*functionLoad),
 	}
 
+	// restore the function services of the previous executor before
+	// adopting the existing deployments
+	persistence.Attach(nd.fsCache, string(executorType), nil)
+
 	if nd.crdClient != nil {
 		fnStore, fnController := nd.initFuncController()
 		nd.funcStore = fnStore
 		nd.funcController = fnController
 
-		envStore, envController := nd.initEnvController()
-		nd.envStore = envStore
-		nd.envController = envController
+		// functions of executor type container don't use environments
+		if executorType == fv1.ExecutorTypeNewdeploy {
+			envStore, envController := nd.initEnvController()
+			nd.envStore = envStore
+			nd.envController = envController
+		}
 	}
 
 	return nd
@@ -130,12 +170,15 @@ func MakeNewDeploy(
 
 func (deploy *NewDeploy) Run(ctx context.Context) {
 	go deploy.funcController.Run(ctx.Done())
-	go deploy.envController.Run(ctx.Done())
+	if deploy.envController != nil {
+		go deploy.envController.Run(ctx.Done())
+	}
 	go deploy.idleObjectReaper()
+	go deploy.loadScaler(ctx)
 }
 
 func (deploy *NewDeploy) GetTypeName() fv1.ExecutorType {
-	return fv1.ExecutorTypeNewdeploy
+	return deploy.executorType
 }
 
 func (deploy *NewDeploy) GetFuncSvc(ctx context.Context, fn *fv1.Function) (*fscache.FuncSvc, error) {
@@ -213,16 +256,12 @@ func (deploy *NewDeploy) IsValid(fsvc *fscache.FuncSvc) bool {
 // RefreshFuncPods deleted pods related to the function so that new pods are replenished
 func (deploy *NewDeploy) RefreshFuncPods(logger *zap.Logger, f fv1.Function) error {
 
-	env, err := deploy.fissionClient.CoreV1().Environments(f.Spec.Environment.Namespace).Get(f.Spec.Environment.Name, metav1.GetOptions{})
+	env, err := deploy.getFunctionEnv(&f)
 	if err != nil {
 		return err
 	}
 
-	funcLabels := deploy.getDeployLabels(f.ObjectMeta, metav1.ObjectMeta{
-		Name:      f.Spec.Environment.Name,
-		Namespace: f.Spec.Environment.Namespace,
-		UID:       env.ObjectMeta.UID,
-	})
+	funcLabels := deploy.getDeployLabels(f.ObjectMeta, env.ObjectMeta)
 
 	dep, err := deploy.kubernetesClient.AppsV1().Deployments(metav1.NamespaceAll).List(metav1.ListOptions{
 		LabelSelector: labels.Set(funcLabels).AsSelector().String(),
@@ -239,8 +278,13 @@ func (deploy *NewDeploy) RefreshFuncPods(logger *zap.Logger, f fv1.Function) err
 			return err
 		}
 
+		containerName := f.ObjectMeta.Name
+		if deploy.executorType == fv1.ExecutorTypeContainer && len(deployment.Spec.Template.Spec.Containers) > 0 {
+			containerName = deployment.Spec.Template.Spec.Containers[0].Name
+		}
+
 		patch := fmt.Sprintf(`{"spec" : {"template": {"spec":{"containers":[{"name": "%s", "env":[{"name": "%s", "value": "%v"}]}]}}}}`,
-			f.ObjectMeta.Name, fv1.ResourceVersionCount, rvCount)
+			containerName, fv1.ResourceVersionCount, rvCount)
 
 		_, err = deploy.kubernetesClient.AppsV1().Deployments(deployment.ObjectMeta.Namespace).Patch(deployment.ObjectMeta.Name,
 			k8sTypes.StrategicMergePatchType,
@@ -263,7 +307,7 @@ func (deploy *NewDeploy) AdoptExistingResources() {
 
 	for i := range fnList.Items {
 		fn := &fnList.Items[i]
-		if fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypeNewdeploy {
+		if fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == deploy.executorType {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
@@ -282,11 +326,12 @@ func (deploy *NewDeploy) AdoptExistingResources() {
 }
 
 func (deploy *NewDeploy) CleanupOldExecutorObjects() {
-	deploy.logger.Info("Newdeploy starts to clean orphaned resources", zap.String("instanceID", deploy.instanceID))
+	deploy.logger.Info("Newdeploy starts to clean orphaned resources",
+		zap.String("instanceID", deploy.instanceID), zap.String("executor_type", string(deploy.executorType)))
 
 	errs := &multierror.Error{}
 	listOpts := metav1.ListOptions{
-		LabelSelector: labels.Set(map[string]string{fv1.EXECUTOR_TYPE: string(fv1.ExecutorTypeNewdeploy)}).AsSelector().String(),
+		LabelSelector: labels.Set(map[string]string{fv1.EXECUTOR_TYPE: string(deploy.executorType)}).AsSelector().String(),
 	}
 
 	err := reaper.CleanupHpa(deploy.logger, deploy.kubernetesClient, deploy.instanceID, listOpts)
@@ -315,11 +360,14 @@ func (deploy *NewDeploy) initFuncController() (k8sCache.Store, k8sCache.Controll
 	listWatch := k8sCache.NewListWatchFromClient(deploy.crdClient, "functions", metav1.NamespaceAll, fields.Everything())
 	store, controller := k8sCache.NewInformer(listWatch, &fv1.Function{}, resyncPeriod, k8sCache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
+			fn := obj.(*fv1.Function)
+			if !deploy.owns(&fn.ObjectMeta) {
+				return
+			}
 			// TODO: A workaround to process items in parallel. We should use workqueue ("k8s.io/client-go/util/workqueue")
 			// and worker pattern to process items instead of moving process to another goroutine.
 			// example: https://github.com/kubernetes/kubernetes/blob/master/pkg/controller/job/job_controller.go
 			go func() {
-				fn := obj.(*fv1.Function)
 				deploy.logger.Debug("create deployment for function", zap.Any("fn", fn.ObjectMeta), zap.Any("fnspec", fn.Spec))
 				_, err := deploy.createFunction(fn)
 				if err != nil {
@@ -332,6 +380,11 @@ func (deploy *NewDeploy) initFuncController() (k8sCache.Store, k8sCache.Controll
 		},
 		DeleteFunc: func(obj interface{}) {
 			fn := obj.(*fv1.Function)
+			if !deploy.owns(&fn.ObjectMeta) {
+				// the owner deletes the kubernetes objects
+				deploy.forgetFunction(fn)
+				return
+			}
 			go func() {
 				err := deploy.deleteFunction(fn)
 				if err != nil {
@@ -344,6 +397,27 @@ func (deploy *NewDeploy) initFuncController() (k8sCache.Store, k8sCache.Controll
 		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
 			oldFn := oldObj.(*fv1.Function)
 			newFn := newObj.(*fv1.Function)
+			if !deploy.owns(&newFn.ObjectMeta) {
+				// the function may have moved to another replica
+				deploy.forgetFunction(newFn)
+				return
+			}
+			if oldFn.ObjectMeta.ResourceVersion == newFn.ObjectMeta.ResourceVersion {
+				// on resync, take over the functions that moved to this replica
+				if deploy.cluster != nil &&
+					newFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == deploy.executorType &&
+					!deploy.fsCache.HasFunctionUID(newFn.ObjectMeta.UID) {
+					go func() {
+						_, err := deploy.createFunction(newFn)
+						if err != nil {
+							deploy.logger.Error("error taking over function",
+								zap.Error(err),
+								zap.Any("function", newFn.ObjectMeta))
+						}
+					}()
+				}
+				return
+			}
 			go func() {
 				err := deploy.updateFunction(oldFn, newFn)
 				if err != nil {
@@ -372,6 +446,9 @@ func (deploy *NewDeploy) initEnvController() (k8sCache.Store, k8sCache.Controlle
 				deploy.logger.Debug("Updating all function of the environment that changed, old env:", zap.Any("environment", oldEnv))
 				funcs := deploy.getEnvFunctions(&newEnv.ObjectMeta)
 				for _, f := range funcs {
+					if !deploy.owns(&f.ObjectMeta) {
+						continue
+					}
 					function, err := deploy.fissionClient.CoreV1().Functions(f.ObjectMeta.Namespace).Get(f.ObjectMeta.Name, metav1.GetOptions{})
 					if err != nil {
 						deploy.logger.Error("Error getting function", zap.Error(err), zap.Any("function", function))
@@ -389,6 +466,24 @@ func (deploy *NewDeploy) initEnvController() (k8sCache.Store, k8sCache.Controlle
 	return store, controller
 }
 
+// owns returns whether this executor replica handles the function.
+func (deploy *NewDeploy) owns(m *metav1.ObjectMeta) bool {
+	return deploy.cluster.Owns(cluster.FunctionKey(m))
+}
+
+// forgetFunction drops the function service of a function owned by another
+// executor replica from the cache, leaving its kubernetes objects to the owner.
+func (deploy *NewDeploy) forgetFunction(fn *fv1.Function) {
+	if !deploy.fsCache.HasFunctionUID(fn.ObjectMeta.UID) {
+		return
+	}
+	fsvc, err := deploy.fsCache.GetByFunctionUID(fn.ObjectMeta.UID)
+	if err != nil {
+		return
+	}
+	deploy.fsCache.DeleteEntry(fsvc)
+}
+
 func (deploy *NewDeploy) getEnvFunctions(m *metav1.ObjectMeta) []fv1.Function {
 	funcList, err := deploy.fissionClient.CoreV1().Functions(m.Namespace).List(metav1.ListOptions{})
 	if err != nil {
@@ -404,7 +499,7 @@ func (deploy *NewDeploy) getEnvFunctions(m *metav1.ObjectMeta) []fv1.Function {
 }
 
 func (deploy *NewDeploy) createFunction(fn *fv1.Function) (*fscache.FuncSvc, error) {
-	if fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != fv1.ExecutorTypeNewdeploy {
+	if fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != deploy.executorType {
 		return nil, nil
 	}
 
@@ -433,7 +528,7 @@ func (deploy *NewDeploy) createFunction(fn *fv1.Function) (*fscache.FuncSvc, err
 }
 
 func (deploy *NewDeploy) deleteFunction(fn *fv1.Function) error {
-	if fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != fv1.ExecutorTypeNewdeploy {
+	if fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != deploy.executorType {
 		return nil
 	}
 	err := deploy.fnDelete(fn)
@@ -444,9 +539,7 @@ func (deploy *NewDeploy) deleteFunction(fn *fv1.Function) error {
 }
 
 func (deploy *NewDeploy) fnCreate(fn *fv1.Function) (*fscache.FuncSvc, error) {
-	env, err := deploy.fissionClient.CoreV1().
-		Environments(fn.Spec.Environment.Namespace).
-		Get(fn.Spec.Environment.Name, metav1.GetOptions{})
+	env, err := deploy.getFunctionEnv(fn)
 	if err != nil {
 		return nil, err
 	}
@@ -467,7 +560,7 @@ func (deploy *NewDeploy) fnCreate(fn *fv1.Function) (*fscache.FuncSvc, error) {
 	// Since newdeploy waits for pods of deployment to be ready,
 	// change the order of kubeObject creation (create service first,
 	// then deployment) to take advantage of waiting time.
-	svc, err := deploy.createOrGetSvc(deployLabels, deployAnnotations, objName, ns)
+	svc, err := deploy.createOrGetSvc(deployLabels, deployAnnotations, objName, ns, deploy.getTargetPort(fn))
 	if err != nil {
 		deploy.logger.Error("error creating service", zap.Error(err), zap.String("service", objName))
 		go deploy.cleanupNewdeploy(ns, objName)
@@ -482,13 +575,6 @@ func (deploy *NewDeploy) fnCreate(fn *fv1.Function) (*fscache.FuncSvc, error) {
 		return nil, errors.Wrapf(err, "error creating deployment %v", objName)
 	}
 
-	hpa, err := deploy.createOrGetHpa(objName, &fn.Spec.InvokeStrategy.ExecutionStrategy, depl, deployLabels, deployAnnotations)
-	if err != nil {
-		deploy.logger.Error("error creating HPA", zap.Error(err), zap.String("hpa", objName))
-		go deploy.cleanupNewdeploy(ns, objName)
-		return nil, errors.Wrapf(err, "error creating the HPA %v", objName)
-	}
-
 	kubeObjRefs := []apiv1.ObjectReference{
 		{
 			//obj.TypeMeta.Kind does not work hence this, needs investigation and a fix
@@ -507,14 +593,32 @@ func (deploy *NewDeploy) fnCreate(fn *fv1.Function) (*fscache.FuncSvc, error) {
 			ResourceVersion: svc.ObjectMeta.ResourceVersion,
 			UID:             svc.ObjectMeta.UID,
 		},
-		{
+	}
+
+	if isRequestDriven(&fn.Spec.InvokeStrategy.ExecutionStrategy) {
+		// executor scales the deployment by the load reported by
+		// routers, an HPA left by CPU-based scaling would fight it
+		err = deploy.deleteHpa(ns, objName)
+		if err != nil && !k8sErrs.IsNotFound(err) {
+			deploy.logger.Error("error deleting HPA", zap.Error(err), zap.String("hpa", objName))
+			return nil, errors.Wrapf(err, "error deleting the HPA %v", objName)
+		}
+	} else {
+		hpa, err := deploy.createOrGetHpa(objName, &fn.Spec.InvokeStrategy.ExecutionStrategy, depl, deployLabels, deployAnnotations)
+		if err != nil {
+			deploy.logger.Error("error creating HPA", zap.Error(err), zap.String("hpa", objName))
+			go deploy.cleanupNewdeploy(ns, objName)
+			return nil, errors.Wrapf(err, "error creating the HPA %v", objName)
+		}
+
+		kubeObjRefs = append(kubeObjRefs, apiv1.ObjectReference{
 			Kind:            "horizontalpodautoscaler",
 			Name:            hpa.ObjectMeta.Name,
 			APIVersion:      hpa.TypeMeta.APIVersion,
 			Namespace:       hpa.ObjectMeta.Namespace,
 			ResourceVersion: hpa.ObjectMeta.ResourceVersion,
 			UID:             hpa.ObjectMeta.UID,
-		},
+		})
 	}
 
 	fsvc := &fscache.FuncSvc{
@@ -523,7 +627,7 @@ func (deploy *NewDeploy) fnCreate(fn *fv1.Function) (*fscache.FuncSvc, error) {
 		Environment:       env,
 		Address:           svcAddress,
 		KubernetesObjects: kubeObjRefs,
-		Executor:          fv1.ExecutorTypeNewdeploy,
+		Executor:          deploy.executorType,
 	}
 
 	_, err = deploy.fsCache.Add(*fsvc)
@@ -543,37 +647,38 @@ func (deploy *NewDeploy) updateFunction(oldFn *fv1.Function, newFn *fv1.Function
 		return nil
 	}
 
-	// Ignoring updates to functions which are not of NewDeployment type
-	if newFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != fv1.ExecutorTypeNewdeploy &&
-		oldFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != fv1.ExecutorTypeNewdeploy {
+	// Ignoring updates to functions which are not of this executor type
+	if newFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != deploy.executorType &&
+		oldFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != deploy.executorType {
 		return nil
 	}
 
-	// Executor type is no longer New Deployment
-	if newFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != fv1.ExecutorTypeNewdeploy &&
-		oldFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypeNewdeploy {
-		deploy.logger.Info("function does not use new deployment executor anymore, deleting resources",
+	// Executor type is no longer this one
+	if newFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != deploy.executorType &&
+		oldFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == deploy.executorType {
+		deploy.logger.Info("function does not use this executor type anymore, deleting resources",
 			zap.Any("function", newFn))
 		// IMP - pass the oldFn, as the new/modified function is not in cache
 		return deploy.deleteFunction(oldFn)
 	}
 
-	// Executor type changed to New Deployment from something else
-	if oldFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != fv1.ExecutorTypeNewdeploy &&
-		newFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypeNewdeploy {
-		deploy.logger.Info("function type changed to new deployment, creating resources",
+	// Executor type changed to this one from something else
+	if oldFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType != deploy.executorType &&
+		newFn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == deploy.executorType {
+		deploy.logger.Info("function executor type changed, creating resources",
+			zap.String("executor_type", string(deploy.executorType)),
 			zap.Any("old_function", oldFn.ObjectMeta),
 			zap.Any("new_function", newFn.ObjectMeta))
 		_, err := deploy.createFunction(newFn)
 		if err != nil {
-			deploy.updateStatus(oldFn, err, "error changing the function's type to newdeploy")
+			deploy.updateStatus(oldFn, err, fmt.Sprintf("error changing the function's type to %v", deploy.executorType))
 		}
 		return err
 	}
 
 	deployChanged := false
 
-	if oldFn.Spec.InvokeStrategy != newFn.Spec.InvokeStrategy {
+	if !reflect.DeepEqual(oldFn.Spec.InvokeStrategy, newFn.Spec.InvokeStrategy) {
 
 		// to support backward compatibility, if the function was created in default ns, we fall back to creating the
 		// deployment of the function in fission-function ns, so cleaning up resources there
@@ -588,43 +693,70 @@ func (deploy *NewDeploy) updateFunction(oldFn *fv1.Function, newFn *fv1.Function
 			return err
 		}
 
-		hpa, err := deploy.getHpa(ns, fsvc.Name)
-		if err != nil {
-			deploy.updateStatus(oldFn, err, "error getting HPA while updating function")
-			return err
-		}
+		oldStrategy := &oldFn.Spec.InvokeStrategy.ExecutionStrategy
+		newStrategy := &newFn.Spec.InvokeStrategy.ExecutionStrategy
+
+		if isRequestDriven(newStrategy) {
+			// the deployment is scaled by executor from now on, see loadscaler.go
+			if !isRequestDriven(oldStrategy) {
+				err = deploy.deleteHpa(ns, fsvc.Name)
+				if err != nil && !k8sErrs.IsNotFound(err) {
+					deploy.updateStatus(oldFn, err, "error deleting HPA while updating function")
+					return err
+				}
+			}
+		} else if isRequestDriven(oldStrategy) {
+			depl, err := deploy.kubernetesClient.AppsV1().Deployments(ns).Get(fsvc.Name, metav1.GetOptions{})
+			if err != nil {
+				deploy.updateStatus(oldFn, err, "error getting deployment while updating function")
+				return err
+			}
+			_, err = deploy.createOrGetHpa(fsvc.Name, newStrategy, depl,
+				deploy.getDeployLabels(newFn.ObjectMeta, fsvc.Environment.ObjectMeta), deploy.getDeployAnnotations(newFn.ObjectMeta))
+			if err != nil {
+				deploy.updateStatus(oldFn, err, "error creating HPA while updating function")
+				return err
+			}
+		} else {
+			hpa, err := deploy.getHpa(ns, fsvc.Name)
+			if err != nil {
+				deploy.updateStatus(oldFn, err, "error getting HPA while updating function")
+				return err
+			}
 
-		hpaChanged := false
+			hpaChanged := false
 
-		if newFn.Spec.InvokeStrategy.ExecutionStrategy.MinScale != oldFn.Spec.InvokeStrategy.ExecutionStrategy.MinScale {
-			replicas := int32(newFn.Spec.InvokeStrategy.ExecutionStrategy.MinScale)
-			hpa.Spec.MinReplicas = &replicas
-			hpaChanged = true
-		}
+			if newFn.Spec.InvokeStrategy.ExecutionStrategy.MinScale != oldFn.Spec.InvokeStrategy.ExecutionStrategy.MinScale {
+				replicas := int32(newFn.Spec.InvokeStrategy.ExecutionStrategy.MinScale)
+				hpa.Spec.MinReplicas = &replicas
+				hpaChanged = true
+			}
 
-		if newFn.Spec.InvokeStrategy.ExecutionStrategy.MaxScale != oldFn.Spec.InvokeStrategy.ExecutionStrategy.MaxScale {
-			hpa.Spec.MaxReplicas = int32(newFn.Spec.InvokeStrategy.ExecutionStrategy.MaxScale)
-			hpaChanged = true
-		}
+			if newFn.Spec.InvokeStrategy.ExecutionStrategy.MaxScale != oldFn.Spec.InvokeStrategy.ExecutionStrategy.MaxScale {
+				hpa.Spec.MaxReplicas = int32(newFn.Spec.InvokeStrategy.ExecutionStrategy.MaxScale)
+				hpaChanged = true
+			}
 
-		if newFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent != oldFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent {
-			targetCpupercent := int32(newFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent)
-			hpa.Spec.TargetCPUUtilizationPercentage = &targetCpupercent
-			hpaChanged = true
-		}
+			if newFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent != oldFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent {
+				targetCpupercent := int32(newFn.Spec.InvokeStrategy.ExecutionStrategy.TargetCPUPercent)
+				hpa.Spec.TargetCPUUtilizationPercentage = &targetCpupercent
+				hpaChanged = true
+			}
 
-		if hpaChanged {
-			err := deploy.updateHpa(hpa)
-			if err != nil {
-				deploy.updateStatus(oldFn, err, "error updating HPA while updating function")
-				return err
+			if hpaChanged {
+				err := deploy.updateHpa(hpa)
+				if err != nil {
+					deploy.updateStatus(oldFn, err, "error updating HPA while updating function")
+					return err
+				}
 			}
 		}
 	}
 
 	if oldFn.Spec.Environment != newFn.Spec.Environment ||
 		oldFn.Spec.Package.PackageRef != newFn.Spec.Package.PackageRef ||
-		oldFn.Spec.Package.FunctionName != newFn.Spec.Package.FunctionName {
+		oldFn.Spec.Package.FunctionName != newFn.Spec.Package.FunctionName ||
+		!reflect.DeepEqual(oldFn.Spec.PodSpec, newFn.Spec.PodSpec) {
 		deployChanged = true
 	}
 
@@ -651,8 +783,7 @@ func (deploy *NewDeploy) updateFunction(oldFn *fv1.Function, newFn *fv1.Function
 	}
 
 	if deployChanged {
-		env, err := deploy.fissionClient.CoreV1().Environments(newFn.Spec.Environment.Namespace).
-			Get(newFn.Spec.Environment.Name, metav1.GetOptions{})
+		env, err := deploy.getFunctionEnv(newFn)
 		if err != nil {
 			deploy.updateStatus(oldFn, err, "failed to get environment while updating function")
 			return err
@@ -747,12 +878,12 @@ func (deploy *NewDeploy) fnDelete(fn *fv1.Function) error {
 func (deploy *NewDeploy) getObjName(fn *fv1.Function) string {
 	// use meta uuid of function, this ensure we always get the same name for the same function.
 	uid := fn.ObjectMeta.UID[len(fn.ObjectMeta.UID)-17:]
-	return strings.ToLower(fmt.Sprintf("newdeploy-%v-%v-%v", fn.ObjectMeta.Name, fn.ObjectMeta.Namespace, uid))
+	return strings.ToLower(fmt.Sprintf("%v-%v-%v-%v", deploy.executorType, fn.ObjectMeta.Name, fn.ObjectMeta.Namespace, uid))
 }
 
 func (deploy *NewDeploy) getDeployLabels(fnMeta metav1.ObjectMeta, envMeta metav1.ObjectMeta) map[string]string {
 	return map[string]string{
-		fv1.EXECUTOR_TYPE:         string(fv1.ExecutorTypeNewdeploy),
+		fv1.EXECUTOR_TYPE:         string(deploy.executorType),
 		fv1.ENVIRONMENT_NAME:      envMeta.Name,
 		fv1.ENVIRONMENT_NAMESPACE: envMeta.Namespace,
 		fv1.ENVIRONMENT_UID:       string(envMeta.UID),
@@ -801,23 +932,30 @@ func (deploy *NewDeploy) idleObjectReaper() {
 		for i := range funcSvcs {
 			fsvc := funcSvcs[i]
 
-			if fsvc.Executor != fv1.ExecutorTypeNewdeploy {
+			if fsvc.Executor != deploy.executorType {
+				continue
+			}
+
+			// other replicas don't see the taps of the function
+			if !deploy.owns(fsvc.Function) {
 				continue
 			}
 
 			// For function with the environment that no longer exists, executor
 			// scales down the deployment as usual and prints log to notify user.
-			if _, ok := envList[fsvc.Environment.ObjectMeta.UID]; !ok {
+			reason := reaper.ReasonIdle
+			if _, ok := envList[fsvc.Environment.ObjectMeta.UID]; !ok && deploy.executorType == fv1.ExecutorTypeNewdeploy {
 				deploy.logger.Error("function environment no longer exists",
 					zap.String("environment", fsvc.Environment.ObjectMeta.Name),
 					zap.String("function", fsvc.Name))
+				reason = reaper.ReasonEnvironmentDeleted
 			}
 
 			fn, err := deploy.fissionClient.CoreV1().Functions(fsvc.Function.Namespace).Get(fsvc.Function.Name, metav1.GetOptions{})
 			if err != nil {
 				// Newdeploy manager handles the function delete event and clean cache/kubeobjs itself,
 				// so we ignore the not found error for functions with newdeploy executor type here.
-				if k8sErrs.IsNotFound(err) && fsvc.Executor == fv1.ExecutorTypeNewdeploy {
+				if k8sErrs.IsNotFound(err) && fsvc.Executor == deploy.executorType {
 					continue
 				}
 				deploy.logger.Error("error getting function", zap.Error(err), zap.String("function", fsvc.Function.Name))
@@ -857,7 +995,13 @@ func (deploy *NewDeploy) idleObjectReaper() {
 				err = deploy.scaleDeployment(deployObj.Namespace, deployObj.Name, minScale)
 				if err != nil {
 					deploy.logger.Error("error scaling down function deployment", zap.Error(err), zap.String("function", fsvc.Function.Name))
+					return
 				}
+
+				// pods get terminated gracefully by Kubernetes, see TerminationGracePeriodSeconds
+				reaper.ObserveReap(string(deploy.executorType), reason, reaper.ResultScaledDown)
+				reaper.RecordEvent(deploy.logger, deploy.kubernetesClient, deployObj, apiv1.EventTypeNormal, "ScalingDown",
+					fmt.Sprintf("Scaling down idle function %v from %v to %v replicas (%v)", fsvc.Function.Name, *currentDeploy.Spec.Replicas, minScale, reason))
 			}()
 		}
 	}
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"time"
+
+	k8sTypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/client"
+	"github.com/fission/fission/pkg/executor/fscache"
+	"github.com/fission/fission/pkg/executor/reaper"
+)
+
+func (gpm *GenericPoolManager) ListFuncSvcs() []fscache.FuncSvcState {
+	return gpm.fsCache.List()
+}
+
+// EvictFunction deletes the specialized pods of the function without
+// waiting for their in-flight requests.
+func (gpm *GenericPoolManager) EvictFunction(fn *fv1.Function) (int, error) {
+	evicted := 0
+	for _, state := range gpm.fsCache.List() {
+		fsvc := state.FuncSvc
+		if fsvc.Function.UID != fn.ObjectMeta.UID {
+			continue
+		}
+		if gpm.reapFuncSvc(&fsvc, reaper.ReasonEvicted, 0, 0) {
+			evicted++
+		}
+	}
+	return evicted, nil
+}
+
+// ListPools returns the state of the pools of generic pods of environments.
+func (gpm *GenericPoolManager) ListPools() []client.EnvironmentPool {
+	specialized := make(map[k8sTypes.UID]int)
+	for _, state := range gpm.fsCache.List() {
+		if state.Environment != nil {
+			specialized[state.Environment.ObjectMeta.UID]++
+		}
+	}
+
+	now := time.Now()
+	gps := gpm.listPools()
+	pools := make([]client.EnvironmentPool, 0, len(gps))
+	for _, gp := range gps {
+		pool := client.EnvironmentPool{
+			Environment:           gp.env.ObjectMeta,
+			Namespace:             gp.namespace,
+			Poolsize:              gp.getReplicas(),
+			ReadyPods:             gp.readyPods.len(),
+			SpecializedPods:       specialized[gp.env.ObjectMeta.UID],
+			RecentSpecializations: gp.specializations.count(now),
+		}
+		if gp.deployment != nil {
+			pool.Deployment = gp.deployment.ObjectMeta.Name
+		}
+		pools = append(pools, pool)
+	}
+	return pools
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sTypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// how often the pool size is adjusted
+	poolAutoscaleInterval = 10 * time.Second
+
+	// specializations within the window are counted for the pool size
+	specializationWindow = time.Minute
+
+	// the pool is not scaled down until this long after the last scaling,
+	// so that short gaps between bursts don't make the pool flap
+	poolScaleDownDelay = 2 * time.Minute
+
+	// reasons of the events recorded on the environment
+	eventReasonPoolScaledUp   = "PoolScaledUp"
+	eventReasonPoolScaledDown = "PoolScaledDown"
+)
+
+// specializationCounter counts the recent specializations of a pool.
+type specializationCounter struct {
+	lock  sync.Mutex
+	times []time.Time
+}
+
+// add records a specialization at the given time.
+func (sc *specializationCounter) add(t time.Time) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	sc.times = append(sc.times, t)
+}
+
+// count returns the number of specializations within the window before now.
+func (sc *specializationCounter) count(now time.Time) int {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	// times are in ascending order, drop the ones out of the window
+	i := 0
+	for i < len(sc.times) && now.Sub(sc.times[i]) > specializationWindow {
+		i++
+	}
+	sc.times = sc.times[i:]
+	return len(sc.times)
+}
+
+// getDesiredPoolsize returns the pool size that holds the recent
+// specializations plus the target free pods, within the min and max pool size.
+func getDesiredPoolsize(autoscale *fv1.PoolAutoscale, recentSpecializations int) int32 {
+	desired := recentSpecializations + autoscale.TargetFreePods
+	if desired < autoscale.MinPoolsize {
+		desired = autoscale.MinPoolsize
+	}
+	if desired > autoscale.MaxPoolsize {
+		desired = autoscale.MaxPoolsize
+	}
+	return int32(desired)
+}
+
+// autoscalePool periodically adjusts the replicas of the pool deployment
+// to the recent specialization rate.
+func (gp *GenericPool) autoscalePool(ctx context.Context) {
+	lastScale := time.Now()
+	gp.observePoolsize(gp.getReplicas())
+
+	ticker := time.NewTicker(poolAutoscaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		recent := gp.specializations.count(now)
+		gp.observeRecentSpecializations(recent)
+
+		// only the leader of the executor replicas scales the pool
+		if !gp.cluster.IsLeader() {
+			continue
+		}
+
+		desired := getDesiredPoolsize(gp.env.Spec.Autoscale, recent)
+		replicas := gp.getReplicas()
+		if desired == replicas {
+			continue
+		}
+		if desired < replicas && now.Sub(lastScale) < poolScaleDownDelay {
+			continue
+		}
+
+		err := gp.scalePool(desired, recent)
+		if err != nil {
+			gp.logger.Error("error scaling pool", zap.Error(err),
+				zap.Int32("replicas", replicas), zap.Int32("desired_replicas", desired))
+			continue
+		}
+		lastScale = now
+	}
+}
+
+// scalePool sets the replicas of the pool deployment and records the
+// decision on the environment.
+func (gp *GenericPool) scalePool(replicas int32, recentSpecializations int) error {
+	patch := fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)
+	_, err := gp.kubernetesClient.AppsV1().Deployments(gp.namespace).Patch(gp.deployment.ObjectMeta.Name, k8sTypes.StrategicMergePatchType, []byte(patch))
+	if err != nil {
+		return err
+	}
+
+	current := gp.getReplicas()
+	reason, direction := eventReasonPoolScaledUp, poolScaleDirectionUp
+	if replicas < current {
+		reason, direction = eventReasonPoolScaledDown, poolScaleDirectionDown
+	}
+	message := fmt.Sprintf("Scaled pool %v from %v to %v pods: %v specializations in the last %v, %v target free pods",
+		gp.deployment.ObjectMeta.Name, current, replicas, recentSpecializations, specializationWindow, gp.env.Spec.Autoscale.TargetFreePods)
+
+	gp.logger.Info("scaled pool",
+		zap.String("deployment", gp.deployment.ObjectMeta.Name),
+		zap.Int32("replicas", current),
+		zap.Int32("new_replicas", replicas),
+		zap.Int("recent_specializations", recentSpecializations))
+
+	gp.setReplicas(replicas)
+	gp.observePoolsize(replicas)
+	gp.observePoolScale(direction)
+	gp.recordEnvEvent(reason, message)
+	return nil
+}
+
+// recordEnvEvent creates a Kubernetes event on the environment of the pool.
+func (gp *GenericPool) recordEnvEvent(reason, message string) {
+	now := metav1.Now()
+	event := &apiv1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: gp.env.ObjectMeta.Name + ".",
+			Namespace:    gp.env.ObjectMeta.Namespace,
+		},
+		InvolvedObject: apiv1.ObjectReference{
+			Kind:            fv1.CRD_NAME_ENVIRONMENT,
+			APIVersion:      fv1.CRD_VERSION,
+			Name:            gp.env.ObjectMeta.Name,
+			Namespace:       gp.env.ObjectMeta.Namespace,
+			UID:             gp.env.ObjectMeta.UID,
+			ResourceVersion: gp.env.ObjectMeta.ResourceVersion,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           apiv1.EventTypeNormal,
+		Source:         apiv1.EventSource{Component: "fission-executor"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	_, err := gp.kubernetesClient.CoreV1().Events(event.ObjectMeta.Namespace).Create(event)
+	if err != nil {
+		// just log the error since events are informational only
+		gp.logger.Warn("error recording event on environment", zap.Error(err), zap.String("reason", reason))
+	}
+}
+
+// getReplicas returns the current replicas of the pool deployment.
+func (gp *GenericPool) getReplicas() int32 {
+	return atomic.LoadInt32(&gp.replicas)
+}
+
+// setReplicas records the replicas of the pool deployment, it's called
+// from the autoscaler concurrently with the readers of the pool.
+func (gp *GenericPool) setReplicas(replicas int32) {
+	atomic.StoreInt32(&gp.replicas, replicas)
+}
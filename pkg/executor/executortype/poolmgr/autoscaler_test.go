@@ -0,0 +1,52 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestSpecializationCounter(t *testing.T) {
+	sc := &specializationCounter{}
+	now := time.Now()
+
+	sc.add(now.Add(-2 * specializationWindow))
+	sc.add(now.Add(-specializationWindow / 2))
+	sc.add(now.Add(-time.Second))
+	assert.Equal(t, 2, sc.count(now))
+
+	// expired specializations are dropped
+	assert.Len(t, sc.times, 2)
+	assert.Equal(t, 0, sc.count(now.Add(specializationWindow)))
+}
+
+func TestGetDesiredPoolsize(t *testing.T) {
+	autoscale := &fv1.PoolAutoscale{
+		MinPoolsize:    2,
+		MaxPoolsize:    10,
+		TargetFreePods: 1,
+	}
+
+	assert.Equal(t, int32(2), getDesiredPoolsize(autoscale, 0))
+	assert.Equal(t, int32(6), getDesiredPoolsize(autoscale, 5))
+	assert.Equal(t, int32(10), getDesiredPoolsize(autoscale, 50))
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/fscache"
+	"github.com/fission/fission/pkg/executor/reaper"
+)
+
+// interval of checking whether a draining function service still serves a request
+const drainPollInterval = time.Second
+
+// reapFuncSvc drains an idle function service and deletes its pod. The function
+// service isn't handed out anymore; if it's serving a request, i.e. it hasn't
+// been untapped by router, the pod is deleted once the request finishes or
+// drainTimeout passes. It returns whether the function service is reaped.
+func (gpm *GenericPoolManager) reapFuncSvc(fsvc *fscache.FuncSvc, reason string, minAge time.Duration, drainTimeout time.Duration) bool {
+	// the function service may have been used since it was listed
+	if time.Since(fsvc.Atime) < minAge {
+		return false
+	}
+
+	active, ok := gpm.fsCache.MarkDraining(fsvc)
+	if !ok {
+		// reaped or being drained already
+		return false
+	}
+
+	result := reaper.ResultDrained
+	if active {
+		gpm.logger.Info("draining function service before reaping it",
+			zap.String("function", fsvc.Function.Name),
+			zap.String("address", fsvc.Address),
+			zap.Duration("drain_timeout", drainTimeout))
+		gpm.recordFuncSvcEvent(fsvc, apiv1.EventTypeNormal, "Draining",
+			fmt.Sprintf("Waiting up to %v for in-flight requests of function %v before reaping pod (%v)", drainTimeout, fsvc.Function.Name, reason))
+
+		start := time.Now()
+		if !gpm.waitForDrain(fsvc, drainTimeout) {
+			result = reaper.ResultDrainTimeout
+		}
+		reaper.ObserveDrain(string(fv1.ExecutorTypePoolmgr), result, time.Since(start))
+	}
+
+	gpm.fsCache.DeleteFunctionSvc(fsvc)
+	reaper.ObserveReap(string(fv1.ExecutorTypePoolmgr), reason, result)
+
+	eventType := apiv1.EventTypeNormal
+	message := fmt.Sprintf("Reaping pod of function %v (%v)", fsvc.Function.Name, reason)
+	if result == reaper.ResultDrainTimeout {
+		eventType = apiv1.EventTypeWarning
+		message = fmt.Sprintf("Reaping pod of function %v (%v) with requests still in flight after %v", fsvc.Function.Name, reason, drainTimeout)
+	}
+	gpm.recordFuncSvcEvent(fsvc, eventType, "Reaping", message)
+
+	for i := range fsvc.KubernetesObjects {
+		gpm.logger.Info("release idle function resources",
+			zap.String("function", fsvc.Function.Name),
+			zap.String("address", fsvc.Address),
+			zap.String("executor", string(fsvc.Executor)),
+			zap.String("pod", fsvc.Name),
+			zap.String("reason", reason),
+			zap.String("result", result),
+		)
+		reaper.CleanupKubeObject(gpm.logger, gpm.kubernetesClient, &fsvc.KubernetesObjects[i])
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
+
+// waitForDrain waits for the function service to finish serving its request,
+// and returns false if it's still serving after the timeout.
+func (gpm *GenericPoolManager) waitForDrain(fsvc *fscache.FuncSvc, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		active, err := gpm.fsCache.IsActive(fsvc)
+		if err != nil || !active {
+			// deleted by someone else or untapped
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// recordFuncSvcEvent creates a Kubernetes event on the pods of the function service.
+func (gpm *GenericPoolManager) recordFuncSvcEvent(fsvc *fscache.FuncSvc, eventType string, reason string, message string) {
+	for i := range fsvc.KubernetesObjects {
+		if fsvc.KubernetesObjects[i].Kind != "pod" {
+			continue
+		}
+		reaper.RecordEvent(gpm.logger, gpm.kubernetesClient, &fsvc.KubernetesObjects[i], eventType, reason, message)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/fscache"
+)
+
+func TestWaitForDrain(t *testing.T) {
+	gpm := &GenericPoolManager{
+		logger:  zap.NewNop(),
+		fsCache: fscache.MakeFunctionServiceCache(zap.NewNop()),
+	}
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default", UID: "1234", ResourceVersion: "1"},
+	}
+	fsvc := &fscache.FuncSvc{
+		Name:     "pod-1",
+		Function: &fn.ObjectMeta,
+		Address:  "10.0.0.1:8888",
+		Executor: fv1.ExecutorTypePoolmgr,
+	}
+
+	// a newly specialized function service serves the request it was specialized for
+	gpm.fsCache.AddFunc(*fsvc)
+	active, ok := gpm.fsCache.MarkDraining(fsvc)
+	assert.True(t, active)
+	assert.True(t, ok)
+
+	// draining function services are not handed out, nor drained twice
+	_, err := gpm.fsCache.GetFuncSvc(&fn.ObjectMeta)
+	assert.Error(t, err)
+	_, ok = gpm.fsCache.MarkDraining(fsvc)
+	assert.False(t, ok)
+
+	assert.False(t, gpm.waitForDrain(fsvc, 0))
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		gpm.fsCache.MarkAvailable(fn, fsvc.Address)
+	}()
+	assert.True(t, gpm.waitForDrain(fsvc, 5*time.Second))
+}
@@ -20,7 +20,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	"net"
 	"os"
 	"strings"
@@ -39,9 +38,11 @@ import (
 	k8sTypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
+	k8sCache "k8s.io/client-go/tools/cache"
 
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/executor/cluster"
 	"github.com/fission/fission/pkg/executor/fscache"
 	"github.com/fission/fission/pkg/executor/util"
 	fetcherClient "github.com/fission/fission/pkg/fetcher/client"
@@ -52,7 +53,7 @@ type (
 	GenericPool struct {
 		logger                 *zap.Logger
 		env                    *fv1.Environment
-		replicas               int32                         // num idle pods
+		replicas               int32                         // num idle pods, accessed atomically
 		deployment             *appsv1.Deployment            // kubernetes deployment
 		namespace              string                        // namespace to keep our resources
 		functionNamespace      string                        // fallback namespace for fission functions
@@ -64,14 +65,21 @@ type (
 		runtimeImagePullPolicy apiv1.PullPolicy // pull policy for generic pool to created env deployment
 		kubernetesClient       *kubernetes.Clientset
 		fissionClient          *crd.FissionClient
-		instanceId             string // poolmgr instance id
+		instanceId             string           // poolmgr instance id
+		cluster                *cluster.Cluster // executor replicas, the leader autoscales the pool
 		requestChannel         chan *choosePodRequest
 		fetcherConfig          *fetcherConfig.Config
 		stopCh                 context.CancelFunc
+		readyPods              *readyPodIndex         // ready, unspecialized pods of the pool
+		podController          k8sCache.Controller    // keeps readyPods and placement up to date
+		placement              *podPlacement          // nodes of specialized pods and fetched packages
+		podSelector            podSelector            // picks the pod to specialize for a function
+		specializations        *specializationCounter // recent specializations, to autoscale the pool
 	}
 
 	// serialize the choosing of pods so that choices don't conflict
 	choosePodRequest struct {
+		fn              *fv1.Function
 		newLabels       map[string]string
 		responseChannel chan *choosePodResponse
 	}
@@ -92,7 +100,9 @@ func MakeGenericPool(
 	fsCache *fscache.FunctionServiceCache,
 	fetcherConfig *fetcherConfig.Config,
 	instanceId string,
-	enableIstio bool) (*GenericPool, error) {
+	enableIstio bool,
+	cluster *cluster.Cluster,
+	nodeZones *nodeZones) (*GenericPool, error) {
 
 	gpLogger := logger.Named("generic_pool")
 
@@ -116,11 +126,17 @@ func MakeGenericPool(
 		poolInstanceId:    uniuri.NewLen(8),
 		fetcherConfig:     fetcherConfig,
 		instanceId:        instanceId,
+		cluster:           cluster,
 		useSvc:            false,       // defaults off -- svc takes a second or more to become routable, slowing cold start
 		useIstio:          enableIstio, // defaults off -- istio integration requires pod relabeling and it takes a second or more to become routable, slowing cold start
 		stopCh:            stopCh,
+		readyPods:         makeReadyPodIndex(),
+		placement:         makePodPlacement(),
+		specializations:   &specializationCounter{},
 	}
 
+	gp.podSelector = makePodSelector(env.Spec.PodSelection, gp.placement, nodeZones)
+
 	gp.runtimeImagePullPolicy = utils.GetImagePullPolicy(os.Getenv("RUNTIME_IMAGE_PULL_POLICY"))
 
 	// create fetcher SA in this ns, if not already created
@@ -139,8 +155,16 @@ func MakeGenericPool(
 	}
 	gpLogger.Info("deployment created", zap.Any("environment", env.ObjectMeta))
 
+	gp.podController = gp.makePodController()
+	go gp.podController.Run(ctx.Done())
+
 	go gp.choosePodService(ctx)
 
+	// pools shared by all functions always have a single pod
+	if env.Spec.Autoscale != nil && env.Spec.AllowedFunctionsPerContainer != fv1.AllowedFunctionsPerContainerInfinite {
+		go gp.autoscalePool(ctx)
+	}
+
 	return gp, nil
 }
 
@@ -162,10 +186,13 @@ func (gp *GenericPool) getDeployAnnotations() map[string]string {
 
 // choosePodService serializes the choosing of pods
 func (gp *GenericPool) choosePodService(ctx context.Context) {
+	if !k8sCache.WaitForCacheSync(ctx.Done(), gp.podController.HasSynced) {
+		return
+	}
 	for {
 		select {
 		case req := <-gp.requestChannel:
-			pod, err := gp._choosePod(req.newLabels)
+			pod, err := gp._choosePod(req.fn, req.newLabels)
 			if err != nil {
 				req.responseChannel <- &choosePodResponse{error: err}
 				continue
@@ -179,8 +206,9 @@ func (gp *GenericPool) choosePodService(ctx context.Context) {
 
 // choosePod picks a ready pod from the pool and relabels it, waiting if necessary.
 // returns the pod API object.
-func (gp *GenericPool) choosePod(newLabels map[string]string) (*apiv1.Pod, error) {
+func (gp *GenericPool) choosePod(fn *fv1.Function, newLabels map[string]string) (*apiv1.Pod, error) {
 	req := &choosePodRequest{
+		fn:              fn,
 		newLabels:       newLabels,
 		responseChannel: make(chan *choosePodResponse),
 	}
@@ -190,70 +218,61 @@ func (gp *GenericPool) choosePod(newLabels map[string]string) (*apiv1.Pod, error
 }
 
 // _choosePod is called serially by choosePodService
-func (gp *GenericPool) _choosePod(newLabels map[string]string) (*apiv1.Pod, error) {
+func (gp *GenericPool) _choosePod(fn *fv1.Function, newLabels map[string]string) (*apiv1.Pod, error) {
 	startTime := time.Now()
-	for {
-		// Retries took too long, error out.
-		if time.Since(startTime) > gp.podReadyTimeout {
-			gp.logger.Error("timed out waiting for pod", zap.Any("labels", newLabels), zap.Duration("timeout", gp.podReadyTimeout))
-			return nil, errors.New("timeout: waited too long to get a ready pod")
-		}
+	timeout := time.NewTimer(gp.podReadyTimeout)
+	defer timeout.Stop()
 
-		// Get pods; filter the ones that are ready
-		podList, err := gp.kubernetesClient.CoreV1().Pods(gp.namespace).List(
-			metav1.ListOptions{
-				LabelSelector: labels.Set(
-					gp.deployment.Spec.Selector.MatchLabels).AsSelector().String(),
-			})
-		if err != nil {
-			return nil, err
-		}
-		readyPods := make([]*apiv1.Pod, 0, len(podList.Items))
-		for i := range podList.Items {
-			pod := podList.Items[i]
+	// pods of environments allowing infinite functions per container are
+	// shared, they are neither relabeled nor claimed exclusively
+	exclusive := gp.env.Spec.AllowedFunctionsPerContainer != fv1.AllowedFunctionsPerContainerInfinite
 
-			// Ignore not ready pod here
-			if !utils.IsReadyPod(&pod) {
-				continue
-			}
+	for {
+		// take the channel before looking up the index, so that a pod
+		// becoming ready in between still wakes us up
+		readyCh := gp.readyPods.waitCh()
+		chosenPod := gp.readyPods.claim(exclusive, func(candidates []*apiv1.Pod) *apiv1.Pod {
+			return gp.podSelector.selectPod(fn, candidates)
+		})
+		gp.observeReadyPods()
 
-			// add it to the list of ready pods
-			readyPods = append(readyPods, &pod)
-		}
-		gp.logger.Info("found ready pods",
-			zap.Any("labels", newLabels),
-			zap.Int("ready_count", len(readyPods)),
-			zap.Int("total", len(podList.Items)))
-
-		// If there are no ready pods, wait and retry.
-		if len(readyPods) == 0 {
-			err = gp.waitForReadyPod()
-			if err != nil {
-				return nil, err
+		// If there are no ready pods, wait for the informer to report one.
+		if chosenPod == nil {
+			gp.logger.Debug("no ready pod in pool, waiting", zap.Any("labels", newLabels))
+			select {
+			case <-readyCh:
+				continue
+			case <-timeout.C:
+				gp.observePodClaim(podClaimResultTimeout, time.Since(startTime))
+				gp.logger.Error("timed out waiting for pod", zap.Any("labels", newLabels), zap.Duration("timeout", gp.podReadyTimeout))
+				return nil, gp.podReadyTimeoutError()
 			}
-			continue
 		}
 
-		// Pick a ready pod.  For now just choose randomly;
-		// ideally we'd care about which node it's running on,
-		// and make a good scheduling decision.
-		chosenPod := readyPods[rand.Intn(len(readyPods))]
-
-		if gp.env.Spec.AllowedFunctionsPerContainer != fv1.AllowedFunctionsPerContainerInfinite {
-			// Relabel.  If the pod already got picked and
-			// modified, this should fail; in that case just
-			// retry.
+		if exclusive {
+			// Relabel. The patch carries the resource version the informer
+			// saw, so it fails if the pod got picked and modified (e.g. by
+			// another executor) in the meantime; in that case just retry.
 			labelPatch, _ := json.Marshal(newLabels)
 
 			// Append executor instance id to pod annotations to
 			// indicate this pod is managed by this executor.
 			annotations := gp.getDeployAnnotations()
+			if member := gp.cluster.Self().Name; len(member) > 0 {
+				// the replica caching the pod, to reap it if the replica goes away
+				annotations[fv1.ANNOTATION_EXECUTOR_MEMBER] = member
+			}
 			annotationPatch, _ := json.Marshal(annotations)
 
-			patch := fmt.Sprintf(`{"metadata":{"annotations":%v, "labels":%v}}`, string(annotationPatch), string(labelPatch))
+			patch := fmt.Sprintf(`{"metadata":{"resourceVersion":%q, "annotations":%v, "labels":%v}}`,
+				chosenPod.ObjectMeta.ResourceVersion, string(annotationPatch), string(labelPatch))
 			gp.logger.Info("relabel pod", zap.String("pod", patch))
 			newPod, err := gp.kubernetesClient.CoreV1().Pods(chosenPod.Namespace).Patch(chosenPod.Name, k8sTypes.StrategicMergePatchType, []byte(patch))
 			if err != nil {
+				if k8sErrs.IsNotFound(err) {
+					gp.readyPods.remove(chosenPod.Name)
+				}
+				// the pod stays claimed until the informer reports its latest version
 				gp.logger.Error("failed to relabel pod", zap.Error(err), zap.String("pod", chosenPod.Name))
 				continue
 			}
@@ -263,18 +282,22 @@ func (gp *GenericPool) _choosePod(newLabels map[string]string) (*apiv1.Pod, erro
 			// So we have to check both of them to ensure the patch success.
 			for k, v := range newLabels {
 				if newPod.Labels[k] != v {
+					gp.observePodClaim(podClaimResultError, time.Since(startTime))
 					return nil, errors.Errorf("value of necessary labels '%v' mismatch: want '%v', get '%v'",
 						k, v, newPod.Labels[k])
 				}
 			}
 			for k, v := range annotations {
 				if newPod.Annotations[k] != v {
+					gp.observePodClaim(podClaimResultError, time.Since(startTime))
 					return nil, errors.Errorf("value of necessary annotations '%v' mismatch: want '%v', get '%v'",
 						k, v, newPod.Annotations[k])
 				}
 			}
+			chosenPod = newPod
 		}
 
+		gp.observePodClaim(podClaimResultClaimed, time.Since(startTime))
 		gp.logger.Info("chose pod", zap.Any("labels", newLabels),
 			zap.String("pod", chosenPod.Name), zap.Duration("elapsed_time", time.Since(startTime)))
 
@@ -457,6 +480,7 @@ func (gp *GenericPool) createPool() error {
 
 	pod.Spec = *(util.ApplyImagePullSecret(gp.env.Spec.ImagePullSecret, pod.Spec))
 
+	replicas := gp.getReplicas()
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        gp.getPoolName(),
@@ -464,7 +488,7 @@ func (gp *GenericPool) createPool() error {
 			Annotations: deployAnnotations,
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: &gp.replicas,
+			Replicas: &replicas,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: deployLabels,
 			},
@@ -493,9 +517,16 @@ func (gp *GenericPool) createPool() error {
 			// Update with the latest deployment spec. Kubernetes will trigger
 			// rolling update if spec is different from the one in the cluster.
 			depl, err = gp.kubernetesClient.AppsV1().Deployments(gp.namespace).Update(deployment)
+			if err != nil {
+				return err
+			}
+		}
+		// the pool may have been scaled by the autoscaler of another executor
+		if depl.Spec.Replicas != nil {
+			gp.setReplicas(*depl.Spec.Replicas)
 		}
 		gp.deployment = depl
-		return err
+		return nil
 	} else if !k8sErrs.IsNotFound(err) {
 		gp.logger.Error("error getting deployment in kubernetes", zap.Error(err), zap.String("deployment", deployment.Name))
 		return err
@@ -511,48 +542,32 @@ func (gp *GenericPool) createPool() error {
 	return nil
 }
 
-func (gp *GenericPool) waitForReadyPod() error {
-	startTime := time.Now()
-	for {
-		// TODO: for now we just poll; use a watch instead
-		depl, err := gp.kubernetesClient.AppsV1().Deployments(gp.namespace).Get(
-			gp.deployment.ObjectMeta.Name, metav1.GetOptions{})
-		if err != nil {
-			e := "error waiting for ready pod for deployment"
-			gp.logger.Error(e, zap.String("deployment", gp.deployment.ObjectMeta.Name), zap.String("namespace", gp.namespace))
-			return fmt.Errorf("%s %q in namespace %q", e, gp.deployment.ObjectMeta.Name, gp.namespace)
-		}
-
-		gp.deployment = depl
-		if gp.deployment.Status.AvailableReplicas > 0 {
-			return nil
-		}
-
-		if time.Since(startTime) > gp.podReadyTimeout {
-			podList, err := gp.kubernetesClient.CoreV1().Pods(gp.namespace).List(metav1.ListOptions{
-				LabelSelector: labels.Set(
-					gp.deployment.Spec.Selector.MatchLabels).AsSelector().String(),
-			})
-			if err != nil {
-				gp.logger.Error("error getting pod list after timeout waiting for ready pod", zap.Error(err))
-			}
+// podReadyTimeoutError returns the reason why no pod of the pool became
+// ready in time, by inspecting the container status of a pod.
+func (gp *GenericPool) podReadyTimeoutError() error {
+	podList, err := gp.kubernetesClient.CoreV1().Pods(gp.namespace).List(metav1.ListOptions{
+		LabelSelector: labels.Set(
+			gp.deployment.Spec.Selector.MatchLabels).AsSelector().String(),
+	})
+	if err != nil {
+		gp.logger.Error("error getting pod list after timeout waiting for ready pod", zap.Error(err))
+	}
 
-			// Since even single pod is not ready, choosing the first pod to inspect is a good approximation. In future this can be done better
-			pod := podList.Items[0]
-			errs := &multierror.Error{}
-			for _, cStatus := range pod.Status.ContainerStatuses {
-				if !cStatus.Ready {
-					errs = multierror.Append(errs, errors.New(fmt.Sprintf("%v: %v", cStatus.State.Waiting.Reason, cStatus.State.Waiting.Message)))
-				}
-			}
-			if errs.ErrorOrNil() != nil {
-				return errors.Wrapf(errs, "Timeout: waited too long for pod of deployment %v in namespace %v to be ready",
-					gp.deployment.ObjectMeta.Name, gp.namespace)
+	errs := &multierror.Error{}
+	if podList != nil && len(podList.Items) > 0 {
+		// Since even single pod is not ready, choosing the first pod to inspect is a good approximation. In future this can be done better
+		pod := podList.Items[0]
+		for _, cStatus := range pod.Status.ContainerStatuses {
+			if !cStatus.Ready && cStatus.State.Waiting != nil {
+				errs = multierror.Append(errs, errors.New(fmt.Sprintf("%v: %v", cStatus.State.Waiting.Reason, cStatus.State.Waiting.Message)))
 			}
-			return nil
 		}
-		time.Sleep(1000 * time.Millisecond)
 	}
+	if errs.ErrorOrNil() != nil {
+		return errors.Wrapf(errs, "Timeout: waited too long for pod of deployment %v in namespace %v to be ready",
+			gp.deployment.ObjectMeta.Name, gp.namespace)
+	}
+	return errors.New("timeout: waited too long to get a ready pod")
 }
 
 func (gp *GenericPool) createSvc(name string, labels map[string]string) (*apiv1.Service, error) {
@@ -622,7 +637,7 @@ func (gp *GenericPool) getFuncSvc(ctx context.Context, fn *fv1.Function) (*fscac
 		}
 	}
 
-	pod, err := gp.choosePod(funcLabels)
+	pod, err := gp.choosePod(fn, funcLabels)
 	if err != nil {
 		return nil, err
 	}
@@ -633,6 +648,7 @@ func (gp *GenericPool) getFuncSvc(ctx context.Context, fn *fv1.Function) (*fscac
 		return nil, err
 	}
 	gp.logger.Info("specialized pod", zap.String("pod", pod.ObjectMeta.Name), zap.Any("function", fn.ObjectMeta))
+	gp.placement.addPackage(fn, pod.Spec.NodeName)
 
 	var svcHost string
 	if gp.useSvc && !gp.useIstio {
@@ -713,6 +729,7 @@ func (gp *GenericPool) getFuncSvc(ctx context.Context, fn *fv1.Function) (*fscac
 // destroys the pool -- the deployment, replicaset and pods
 func (gp *GenericPool) destroy() error {
 	gp.stopCh()
+	gp.deletePoolMetrics()
 
 	deletePropagation := metav1.DeletePropagationBackground
 	delOpt := metav1.DeleteOptions{
@@ -721,7 +738,8 @@ func (gp *GenericPool) destroy() error {
 
 	err := gp.kubernetesClient.AppsV1().
 		Deployments(gp.namespace).Delete(gp.deployment.ObjectMeta.Name, &delOpt)
-	if err != nil {
+	// other executor replicas destroy the same pool
+	if err != nil && !k8sErrs.IsNotFound(err) {
 		gp.logger.Error("error destroying deployment",
 			zap.Error(err),
 			zap.String("deployment_name", gp.deployment.ObjectMeta.Name),
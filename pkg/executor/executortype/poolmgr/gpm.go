@@ -39,6 +39,7 @@ import (
 	fv1 "github.com/fission/fission/pkg/apis/core/v1"
 	"github.com/fission/fission/pkg/cache"
 	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/executor/cluster"
 	"github.com/fission/fission/pkg/executor/executortype"
 	"github.com/fission/fission/pkg/executor/fscache"
 	"github.com/fission/fission/pkg/executor/reaper"
@@ -53,6 +54,7 @@ type requestType int
 const (
 	GET_POOL requestType = iota
 	CLEANUP_POOLS
+	LIST_POOLS
 )
 
 type (
@@ -67,6 +69,7 @@ type (
 		functionEnv    *cache.Cache
 		fsCache        *fscache.FunctionServiceCache
 		instanceId     string
+		cluster        *cluster.Cluster
 		requestChannel chan *request
 
 		enableIstio   bool
@@ -77,7 +80,12 @@ type (
 		pkgStore       k8sCache.Store
 		pkgController  k8sCache.Controller
 
+		nodeZones *nodeZones
+
 		defaultIdlePodReapTime time.Duration
+
+		warmingLock sync.Mutex
+		warming     map[string]int // function-key -> number of pods being specialized ahead of traffic
 	}
 	request struct {
 		requestType
@@ -87,7 +95,8 @@ type (
 	}
 	response struct {
 		error
-		pool *GenericPool
+		pool  *GenericPool
+		pools []*GenericPool
 	}
 )
 
@@ -97,7 +106,9 @@ func MakeGenericPoolManager(
 	kubernetesClient *kubernetes.Clientset,
 	functionNamespace string,
 	fetcherConfig *fetcherConfig.Config,
-	instanceId string) executortype.ExecutorType {
+	instanceId string,
+	persistence *fscache.Persistence,
+	cluster *cluster.Cluster) executortype.ExecutorType {
 
 	gpmLogger := logger.Named("generic_pool_manager")
 
@@ -110,11 +121,18 @@ func MakeGenericPoolManager(
 		functionEnv:            cache.MakeCache(10*time.Second, 0),
 		fsCache:                fscache.MakeFunctionServiceCache(gpmLogger),
 		instanceId:             instanceId,
+		cluster:                cluster,
 		requestChannel:         make(chan *request),
 		defaultIdlePodReapTime: 2 * time.Minute,
 		fetcherConfig:          fetcherConfig,
+		warming:                make(map[string]int),
+		nodeZones:              makeNodeZones(kubernetesClient),
 	}
 
+	// restore the function services of the previous executor before
+	// adopting the existing pods
+	persistence.Attach(gpm.fsCache, string(fv1.ExecutorTypePoolmgr), gpm.adoptOrphanPods)
+
 	go gpm.service()
 
 	if len(os.Getenv("ENABLE_ISTIO")) > 0 {
@@ -139,7 +157,10 @@ func (gpm *GenericPoolManager) Run(ctx context.Context) {
 	go gpm.eagerPoolCreator()
 	go gpm.funcController.Run(ctx.Done())
 	go gpm.pkgController.Run(ctx.Done())
+	go gpm.nodeZones.run(ctx.Done())
 	go gpm.idleObjectReaper()
+	go gpm.warmPodsKeeper(ctx)
+	go gpm.orphanPodReaper(ctx)
 }
 
 func (gpm *GenericPoolManager) GetTypeName() fv1.ExecutorType {
@@ -434,7 +455,7 @@ func (gpm *GenericPoolManager) service() {
 
 				pool, err = MakeGenericPool(gpm.logger,
 					gpm.fissionClient, gpm.kubernetesClient, req.env, poolsize,
-					ns, gpm.namespace, gpm.fsCache, gpm.fetcherConfig, gpm.instanceId, gpm.enableIstio)
+					ns, gpm.namespace, gpm.fsCache, gpm.fetcherConfig, gpm.instanceId, gpm.enableIstio, gpm.cluster, gpm.nodeZones)
 				if err != nil {
 					req.responseChannel <- &response{error: err}
 					continue
@@ -460,6 +481,12 @@ func (gpm *GenericPoolManager) service() {
 				}
 			}
 			// no response, caller doesn't wait
+		case LIST_POOLS:
+			pools := make([]*GenericPool, 0, len(gpm.pools))
+			for _, pool := range gpm.pools {
+				pools = append(pools, pool)
+			}
+			req.responseChannel <- &response{pools: pools}
 		}
 	}
 }
@@ -475,6 +502,16 @@ func (gpm *GenericPoolManager) getPool(env *fv1.Environment) (*GenericPool, erro
 	return resp.pool, resp.error
 }
 
+func (gpm *GenericPoolManager) listPools() []*GenericPool {
+	c := make(chan *response)
+	gpm.requestChannel <- &request{
+		requestType:     LIST_POOLS,
+		responseChannel: c,
+	}
+	resp := <-c
+	return resp.pools
+}
+
 func (gpm *GenericPoolManager) cleanupPools(envs []fv1.Environment) {
 	gpm.requestChannel <- &request{
 		requestType: CLEANUP_POOLS,
@@ -530,8 +567,9 @@ func (gpm *GenericPoolManager) eagerPoolCreator() {
 
 		for i := range envs.Items {
 			env := envs.Items[i]
-			// Create pool only if poolsize greater than zero
-			if gpm.getEnvPoolsize(&env) > 0 {
+			// Create pool only if poolsize greater than zero. With several executor
+			// replicas, only the leader creates pools ahead of function calls.
+			if gpm.cluster.IsLeader() && gpm.getEnvPoolsize(&env) > 0 {
 				wg.Add(1)
 				go func() {
 					defer wg.Done()
@@ -552,7 +590,10 @@ func (gpm *GenericPoolManager) eagerPoolCreator() {
 
 func (gpm *GenericPoolManager) getEnvPoolsize(env *fv1.Environment) int32 {
 	var poolsize int32
-	if env.Spec.Version < 3 {
+	if env.Spec.Autoscale != nil {
+		// autoscaled pools start at the minimum size
+		poolsize = int32(env.Spec.Autoscale.MinPoolsize)
+	} else if env.Spec.Version < 3 {
 		poolsize = 3
 	} else {
 		poolsize = int32(env.Spec.Poolsize)
@@ -595,6 +636,10 @@ func (gpm *GenericPoolManager) idleObjectReaper() {
 			continue
 		}
 
+		// function-key -> number of function services left after reaping,
+		// to not reap functions below their warm pods
+		remaining := make(map[string]int)
+
 		for i := range funcSvcs {
 			fsvc := funcSvcs[i]
 
@@ -604,10 +649,12 @@ func (gpm *GenericPoolManager) idleObjectReaper() {
 
 			// For function with the environment that no longer exists, executor
 			// cleanups the idle pod as usual and prints log to notify user.
+			reason := reaper.ReasonIdle
 			if _, ok := envList[fsvc.Environment.ObjectMeta.UID]; !ok {
 				gpm.logger.Warn("function environment no longer exists",
 					zap.String("environment", fsvc.Environment.ObjectMeta.Name),
 					zap.String("function", fsvc.Name))
+				reason = reaper.ReasonEnvironmentDeleted
 			}
 
 			if fsvc.Environment.Spec.AllowedFunctionsPerContainer == fv1.AllowedFunctionsPerContainerInfinite {
@@ -615,36 +662,38 @@ func (gpm *GenericPoolManager) idleObjectReaper() {
 			}
 
 			idlePodReapTime := gpm.defaultIdlePodReapTime
+			drainTimeout := time.Duration(fv1.DEFAULT_FUNCTION_TIMEOUT) * time.Second
 			if fn, ok := fnList[fsvc.Function.UID]; ok {
 				if fn.Spec.IdleTimeout != nil {
 					idlePodReapTime = time.Duration(*fn.Spec.IdleTimeout) * time.Second
 				}
+				// router gives up on requests after the function timeout
+				if fn.Spec.FunctionTimeout > 0 {
+					drainTimeout = time.Duration(fn.Spec.FunctionTimeout) * time.Second
+				}
 			}
 
 			if time.Since(fsvc.Atime) < idlePodReapTime {
 				continue
 			}
 
-			go func() {
-				deleted, err := gpm.fsCache.DeleteOldPoolCache(fsvc, idlePodReapTime)
-				if err != nil {
-					gpm.logger.Error("error deleting Kubernetes objects for function service",
-						zap.Error(err),
-						zap.Any("service", fsvc))
-				}
-				if deleted {
-					for i := range fsvc.KubernetesObjects {
-						gpm.logger.Info("release idle function resources",
-							zap.String("function", fsvc.Function.Name),
-							zap.String("address", fsvc.Address),
-							zap.String("executor", string(fsvc.Executor)),
-							zap.String("pod", fsvc.Name),
-						)
-						reaper.CleanupKubeObject(gpm.logger, gpm.kubernetesClient, &fsvc.KubernetesObjects[i])
-						time.Sleep(50 * time.Millisecond)
+			// warm pods are only kept for the latest version of the function
+			if fn, ok := fnList[fsvc.Function.UID]; ok && fn.ObjectMeta.ResourceVersion == fsvc.Function.ResourceVersion {
+				warmPods := getWarmPods(&fn.Spec.InvokeStrategy.ExecutionStrategy, time.Now())
+				if warmPods > 0 {
+					key := crd.CacheKey(fsvc.Function)
+					total, ok := remaining[key]
+					if !ok {
+						total = gpm.fsCache.GetTotal(fsvc.Function)
+					}
+					if total <= warmPods {
+						continue
 					}
+					remaining[key] = total - 1
 				}
-			}()
+			}
+
+			go gpm.reapFuncSvc(fsvc, reason, idlePodReapTime, drainTimeout)
 		}
 	}
 }
@@ -0,0 +1,96 @@
+package poolmgr
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// results of claiming a pod from the pool, used as metric label
+	podClaimResultClaimed = "claimed"
+	podClaimResultTimeout = "timeout"
+	podClaimResultError   = "error"
+
+	// directions of pool scaling, used as metric label
+	poolScaleDirectionUp   = "up"
+	poolScaleDirectionDown = "down"
+)
+
+var (
+	// envname: the environment's name
+	// envnamespace: the environment's namespace
+	// result: claimed | timeout | error
+	podClaimDuration = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name:       "fission_pool_pod_claim_duration_seconds",
+			Help:       "The time to claim a ready pod from the pool for specialization.",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+		[]string{"envname", "envnamespace", "result"},
+	)
+	poolReadyPods = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fission_pool_ready_pods",
+			Help: "Number of ready, unspecialized pods in the pool.",
+		},
+		[]string{"envname", "envnamespace"},
+	)
+	poolSize = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fission_pool_size",
+			Help: "Number of pods the autoscaled pool is sized to.",
+		},
+		[]string{"envname", "envnamespace"},
+	)
+	poolRecentSpecializations = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fission_pool_recent_specializations",
+			Help: "Number of pods specialized from the autoscaled pool in the last minute.",
+		},
+		[]string{"envname", "envnamespace"},
+	)
+	// direction: up | down
+	poolScaleTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_pool_scale_total",
+			Help: "Number of times the autoscaled pool was resized.",
+		},
+		[]string{"envname", "envnamespace", "direction"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(podClaimDuration)
+	prometheus.MustRegister(poolReadyPods)
+	prometheus.MustRegister(poolSize)
+	prometheus.MustRegister(poolRecentSpecializations)
+	prometheus.MustRegister(poolScaleTotal)
+}
+
+func (gp *GenericPool) observePodClaim(result string, duration time.Duration) {
+	podClaimDuration.WithLabelValues(gp.env.ObjectMeta.Name, gp.env.ObjectMeta.Namespace, result).Observe(duration.Seconds())
+}
+
+func (gp *GenericPool) observeReadyPods() {
+	poolReadyPods.WithLabelValues(gp.env.ObjectMeta.Name, gp.env.ObjectMeta.Namespace).Set(float64(gp.readyPods.len()))
+}
+
+func (gp *GenericPool) observePoolsize(replicas int32) {
+	poolSize.WithLabelValues(gp.env.ObjectMeta.Name, gp.env.ObjectMeta.Namespace).Set(float64(replicas))
+}
+
+func (gp *GenericPool) observeRecentSpecializations(count int) {
+	poolRecentSpecializations.WithLabelValues(gp.env.ObjectMeta.Name, gp.env.ObjectMeta.Namespace).Set(float64(count))
+}
+
+func (gp *GenericPool) observePoolScale(direction string) {
+	poolScaleTotal.WithLabelValues(gp.env.ObjectMeta.Name, gp.env.ObjectMeta.Namespace, direction).Inc()
+}
+
+// deletePoolMetrics drops the gauges of a destroyed pool.
+func (gp *GenericPool) deletePoolMetrics() {
+	poolReadyPods.DeleteLabelValues(gp.env.ObjectMeta.Name, gp.env.ObjectMeta.Namespace)
+	poolSize.DeleteLabelValues(gp.env.ObjectMeta.Name, gp.env.ObjectMeta.Namespace)
+	poolRecentSpecializations.DeleteLabelValues(gp.env.ObjectMeta.Name, gp.env.ObjectMeta.Namespace)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sTypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/cluster"
+	"github.com/fission/fission/pkg/executor/fscache"
+)
+
+const (
+	orphanPodReapInterval = 30 * time.Second
+
+	// a replica may be unready for a short while, e.g. on a failed health check
+	orphanPodGracePeriod = 2 * time.Minute
+)
+
+// orphanPodReaper deletes the specialized pods cached by executor replicas
+// that went away. No replica knows when those pods were last used, so they
+// would never be reaped otherwise. It only runs on the leader.
+func (gpm *GenericPoolManager) orphanPodReaper(ctx context.Context) {
+	if gpm.cluster == nil {
+		return
+	}
+
+	// pod UID -> time the pod was first seen without a replica
+	orphanSince := make(map[string]time.Time)
+	selector := labels.Set(map[string]string{
+		fv1.EXECUTOR_TYPE: string(fv1.ExecutorTypePoolmgr),
+		"managed":         "false",
+	}).AsSelector().String()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(orphanPodReapInterval):
+		}
+
+		if !gpm.cluster.IsLeader() {
+			continue
+		}
+
+		pods, err := gpm.kubernetesClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			gpm.logger.Error("error listing specialized pods", zap.Error(err))
+			continue
+		}
+
+		for _, pod := range orphanedPods(pods.Items, gpm.cluster.Members(), orphanSince, time.Now()) {
+			gpm.logger.Info("deleting specialized pod of executor replica that went away",
+				zap.String("pod", pod.ObjectMeta.Name),
+				zap.String("namespace", pod.ObjectMeta.Namespace),
+				zap.String("member", pod.ObjectMeta.Annotations[fv1.ANNOTATION_EXECUTOR_MEMBER]))
+			err := gpm.kubernetesClient.CoreV1().Pods(pod.ObjectMeta.Namespace).Delete(pod.ObjectMeta.Name, nil)
+			if err != nil {
+				gpm.logger.Error("error deleting orphaned pod", zap.Error(err), zap.String("pod", pod.ObjectMeta.Name))
+			}
+		}
+	}
+}
+
+// orphanedPods returns the pods whose replica has not been a member for
+// the grace period. orphanSince keeps track of when pods lost their replica.
+func orphanedPods(pods []apiv1.Pod, members []cluster.Member, orphanSince map[string]time.Time, now time.Time) []*apiv1.Pod {
+	// the replicas are not known yet
+	if len(members) == 0 {
+		return nil
+	}
+
+	alive := make(map[string]bool)
+	for _, m := range members {
+		alive[m.Name] = true
+	}
+
+	seen := make(map[string]bool)
+	orphans := make([]*apiv1.Pod, 0)
+	for i := range pods {
+		pod := &pods[i]
+		member, ok := pod.ObjectMeta.Annotations[fv1.ANNOTATION_EXECUTOR_MEMBER]
+		if !ok || alive[member] || pod.ObjectMeta.DeletionTimestamp != nil {
+			continue
+		}
+
+		uid := string(pod.ObjectMeta.UID)
+		seen[uid] = true
+		since, ok := orphanSince[uid]
+		if !ok {
+			orphanSince[uid] = now
+			continue
+		}
+		if now.Sub(since) >= orphanPodGracePeriod {
+			orphans = append(orphans, pod)
+		}
+	}
+
+	// forget pods that are gone or got their replica back
+	for uid := range orphanSince {
+		if !seen[uid] {
+			delete(orphanSince, uid)
+		}
+	}
+	return orphans
+}
+
+// adoptOrphanPods annotates the pods of a function service taken over from
+// an executor replica that went away with this replica, so that they are not
+// reaped as orphans.
+func (gpm *GenericPoolManager) adoptOrphanPods(fsvc *fscache.FuncSvc) {
+	patch, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				fv1.ANNOTATION_EXECUTOR_MEMBER: gpm.cluster.Self().Name,
+			},
+		},
+	})
+	for _, obj := range fsvc.KubernetesObjects {
+		if obj.Kind != "pod" {
+			continue
+		}
+		_, err := gpm.kubernetesClient.CoreV1().Pods(obj.Namespace).Patch(obj.Name, k8sTypes.MergePatchType, patch)
+		if err != nil {
+			gpm.logger.Error("error adopting pod of executor replica that went away", zap.Error(err), zap.String("pod", obj.Name))
+		}
+	}
+}
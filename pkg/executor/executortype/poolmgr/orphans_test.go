@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sTypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/executor/cluster"
+)
+
+func TestOrphanedPods(t *testing.T) {
+	pod := func(name, member string) apiv1.Pod {
+		p := apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				UID:         k8sTypes.UID(name),
+				Annotations: map[string]string{},
+			},
+		}
+		if len(member) > 0 {
+			p.ObjectMeta.Annotations[fv1.ANNOTATION_EXECUTOR_MEMBER] = member
+		}
+		return p
+	}
+	pods := []apiv1.Pod{
+		pod("pod-1", "executor-a"),
+		pod("pod-2", "executor-b"),
+		pod("pod-3", ""),
+	}
+	members := []cluster.Member{{Name: "executor-a"}}
+	orphanSince := make(map[string]time.Time)
+	now := time.Now()
+
+	// replicas are not known yet
+	assert.Empty(t, orphanedPods(pods, nil, orphanSince, now))
+
+	// the pod of the replica that went away is kept for the grace period
+	assert.Empty(t, orphanedPods(pods, members, orphanSince, now))
+	orphans := orphanedPods(pods, members, orphanSince, now.Add(orphanPodGracePeriod))
+	assert.Len(t, orphans, 1)
+	assert.Equal(t, "pod-2", orphans[0].ObjectMeta.Name)
+
+	// a replica coming back keeps its pods
+	members = append(members, cluster.Member{Name: "executor-b"})
+	assert.Empty(t, orphanedPods(pods, members, orphanSince, now.Add(2*orphanPodGracePeriod)))
+	assert.Empty(t, orphanSince)
+}
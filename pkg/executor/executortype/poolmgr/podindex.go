@@ -0,0 +1,212 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	"github.com/fission/fission/pkg/utils"
+)
+
+const (
+	// a claimed pod is normally dropped from the index once the informer sees
+	// its new labels; the claim expires in case that event never arrives
+	podClaimExpiry = time.Minute
+)
+
+type (
+	// readyPodIndex keeps the ready, unspecialized pods of a pool. It's fed by
+	// a pod informer so that choosing a pod doesn't need to list pods from the
+	// API server, and waiters are woken up as soon as a pod becomes ready.
+	readyPodIndex struct {
+		lock    sync.Mutex
+		pods    map[string]*apiv1.Pod
+		claimed map[string]podClaim
+		readyCh chan struct{}
+	}
+
+	// podClaim is a pod being relabeled for specialization.
+	podClaim struct {
+		resourceVersion string
+		ctime           time.Time
+	}
+)
+
+func makeReadyPodIndex() *readyPodIndex {
+	return &readyPodIndex{
+		pods:    make(map[string]*apiv1.Pod),
+		claimed: make(map[string]podClaim),
+		readyCh: make(chan struct{}),
+	}
+}
+
+// update adds the pod to the index if it's ready, otherwise removes it.
+func (idx *readyPodIndex) update(pod *apiv1.Pod) {
+	if !utils.IsReadyPod(pod) {
+		idx.remove(pod.ObjectMeta.Name)
+		return
+	}
+
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	_, existed := idx.pods[pod.ObjectMeta.Name]
+	idx.pods[pod.ObjectMeta.Name] = pod
+
+	// the pod changed after it was claimed, e.g. the label patch failed on a
+	// conflict; it's available again with the latest version
+	if claim, ok := idx.claimed[pod.ObjectMeta.Name]; ok && claim.resourceVersion != pod.ObjectMeta.ResourceVersion {
+		delete(idx.claimed, pod.ObjectMeta.Name)
+		existed = false
+	}
+
+	if !existed {
+		idx.notify()
+	}
+}
+
+// remove drops the pod from the index and returns whether it was there.
+func (idx *readyPodIndex) remove(name string) bool {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	_, found := idx.pods[name]
+	delete(idx.pods, name)
+	delete(idx.claimed, name)
+	return found
+}
+
+// notify wakes up all waiters, must be called with lock held.
+func (idx *readyPodIndex) notify() {
+	close(idx.readyCh)
+	idx.readyCh = make(chan struct{})
+}
+
+// waitCh returns a channel that's closed when a pod becomes available.
+// Take the channel before calling claim to not miss any event.
+func (idx *readyPodIndex) waitCh() <-chan struct{} {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+	return idx.readyCh
+}
+
+// claim picks a ready pod that's not claimed yet using choose. If exclusive
+// is true, the pod is not returned again until the informer reports a change
+// of it; pods shared by functions are never claimed exclusively.
+func (idx *readyPodIndex) claim(exclusive bool, choose func([]*apiv1.Pod) *apiv1.Pod) *apiv1.Pod {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	now := time.Now()
+	candidates := make([]*apiv1.Pod, 0, len(idx.pods))
+	for name, pod := range idx.pods {
+		if claim, ok := idx.claimed[name]; ok {
+			if now.Sub(claim.ctime) < podClaimExpiry {
+				continue
+			}
+			delete(idx.claimed, name)
+		}
+		candidates = append(candidates, pod)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	pod := choose(candidates)
+	if pod == nil {
+		return nil
+	}
+	if exclusive {
+		idx.claimed[pod.ObjectMeta.Name] = podClaim{
+			resourceVersion: pod.ObjectMeta.ResourceVersion,
+			ctime:           now,
+		}
+	}
+	return pod
+}
+
+// len returns the number of ready pods that are not claimed.
+func (idx *readyPodIndex) len() int {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+	return len(idx.pods) - len(idx.claimed)
+}
+
+// makePodController returns an informer watching the pods of the pool. It
+// keeps the ready pod index up to date with the unspecialized pods, and the
+// pod placement with the specialized ones.
+func (gp *GenericPool) makePodController() k8sCache.Controller {
+	// specialized pods are relabeled with managed=false, leave the key out
+	// of the selector to watch both
+	poolLabels := gp.getEnvironmentPoolLabels()
+	delete(poolLabels, "managed")
+	selector := labels.Set(poolLabels).AsSelector().String()
+	lw := k8sCache.NewFilteredListWatchFromClient(gp.kubernetesClient.CoreV1().RESTClient(), "pods", gp.namespace,
+		func(options *metav1.ListOptions) {
+			options.LabelSelector = selector
+		})
+
+	resyncPeriod := 30 * time.Second
+	_, controller := k8sCache.NewInformer(lw, &apiv1.Pod{}, resyncPeriod,
+		k8sCache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				gp.updatePod(obj.(*apiv1.Pod))
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				gp.updatePod(newObj.(*apiv1.Pod))
+			},
+			DeleteFunc: func(obj interface{}) {
+				switch o := obj.(type) {
+				case *apiv1.Pod:
+					gp.removePod(o.ObjectMeta.Name)
+				case k8sCache.DeletedFinalStateUnknown:
+					if pod, ok := o.Obj.(*apiv1.Pod); ok {
+						gp.removePod(pod.ObjectMeta.Name)
+					}
+				}
+			},
+		})
+	return controller
+}
+
+func (gp *GenericPool) updatePod(pod *apiv1.Pod) {
+	if pod.ObjectMeta.Labels["managed"] == "true" {
+		gp.placement.remove(pod.ObjectMeta.Name)
+		gp.readyPods.update(pod)
+	} else {
+		// a relabeled pod is specialized (or being specialized) for a function.
+		// Specializations are counted here rather than where pods are chosen
+		// so that the ones of all executor replicas are counted.
+		if gp.readyPods.remove(pod.ObjectMeta.Name) {
+			gp.specializations.add(time.Now())
+		}
+		gp.placement.update(pod)
+	}
+	gp.observeReadyPods()
+}
+
+func (gp *GenericPool) removePod(name string) {
+	gp.readyPods.remove(name)
+	gp.placement.remove(name)
+	gp.observeReadyPods()
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func makeTestPod(name, resourceVersion string, ready bool) *apiv1.Pod {
+	pod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, ResourceVersion: resourceVersion},
+		Status: apiv1.PodStatus{
+			Phase: apiv1.PodPending,
+		},
+	}
+	if ready {
+		pod.Status.Phase = apiv1.PodRunning
+		pod.Status.PodIP = "10.0.0.1"
+		pod.Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}
+		pod.Status.ContainerStatuses = []apiv1.ContainerStatus{{Name: "env", Ready: true}}
+	}
+	return pod
+}
+
+func TestReadyPodIndex(t *testing.T) {
+	idx := makeReadyPodIndex()
+	pickRandom := func(candidates []*apiv1.Pod) *apiv1.Pod {
+		return (&randomSelector{}).selectPod(nil, candidates)
+	}
+
+	// not ready pods are not indexed
+	idx.update(makeTestPod("pod-1", "1", false))
+	assert.Equal(t, 0, idx.len())
+	assert.Nil(t, idx.claim(true, pickRandom))
+
+	// waiters are woken up once a pod becomes ready
+	readyCh := idx.waitCh()
+	idx.update(makeTestPod("pod-1", "2", true))
+	select {
+	case <-readyCh:
+	default:
+		t.Fatal("waiter is not notified of the ready pod")
+	}
+
+	// exclusively claimed pod is not returned again
+	pod := idx.claim(true, pickRandom)
+	assert.Equal(t, "pod-1", pod.ObjectMeta.Name)
+	assert.Nil(t, idx.claim(true, pickRandom))
+	assert.Equal(t, 0, idx.len())
+
+	// pod is available again once the informer reports a newer version
+	idx.update(makeTestPod("pod-1", "3", true))
+	assert.Equal(t, "3", idx.claim(true, pickRandom).ObjectMeta.ResourceVersion)
+
+	// relabeled pod is removed by the informer
+	idx.remove("pod-1")
+	assert.Nil(t, idx.claim(true, pickRandom))
+
+	// shared pods can be returned many times
+	idx.update(makeTestPod("pod-2", "1", true))
+	assert.NotNil(t, idx.claim(false, pickRandom))
+	assert.NotNil(t, idx.claim(false, pickRandom))
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// node labels holding the zone of a node
+	zoneLabel     = "topology.kubernetes.io/zone"
+	zoneLabelBeta = "failure-domain.beta.kubernetes.io/zone"
+
+	// how long a node is considered to have a package after the last
+	// specialization of it on the node
+	packagePlacementExpiry = 30 * time.Minute
+)
+
+type (
+	// podSelector picks a pod from the ready pods of a pool to specialize
+	// for a function.
+	podSelector interface {
+		selectPod(fn *fv1.Function, candidates []*apiv1.Pod) *apiv1.Pod
+	}
+
+	// podPlacement tracks the nodes running the specialized pods of a pool
+	// and the nodes that fetched function packages recently.
+	podPlacement struct {
+		lock     sync.Mutex
+		pods     map[string]placedPod
+		packages map[string]map[string]time.Time
+	}
+
+	// placedPod is a specialized pod of a pool.
+	placedPod struct {
+		node        string
+		functionUID string
+	}
+
+	// nodeZones looks up the zone of nodes in a node informer, so that
+	// choosing a pod doesn't wait for the API server.
+	nodeZones struct {
+		store      k8sCache.Store
+		controller k8sCache.Controller
+	}
+
+	// randomSelector picks a random pod.
+	randomSelector struct{}
+
+	// spreadSelector prefers pods in zones, then on nodes, running the
+	// fewest specialized pods of the function.
+	spreadSelector struct {
+		placement *podPlacement
+		zones     *nodeZones
+	}
+
+	// packageAffinitySelector prefers pods on nodes that fetched the
+	// package of the function recently.
+	packageAffinitySelector struct {
+		placement *podPlacement
+	}
+
+	// leastLoadedSelector prefers pods on nodes running the fewest
+	// specialized pods of the pool.
+	leastLoadedSelector struct {
+		placement *podPlacement
+	}
+)
+
+func makePodSelector(strategy fv1.PodSelectionStrategy, placement *podPlacement, zones *nodeZones) podSelector {
+	switch strategy {
+	case fv1.PodSelectionSpread:
+		return &spreadSelector{
+			placement: placement,
+			zones:     zones,
+		}
+	case fv1.PodSelectionPackageAffinity:
+		return &packageAffinitySelector{placement: placement}
+	case fv1.PodSelectionLeastLoaded:
+		return &leastLoadedSelector{placement: placement}
+	default:
+		return &randomSelector{}
+	}
+}
+
+func makePodPlacement() *podPlacement {
+	return &podPlacement{
+		pods:     make(map[string]placedPod),
+		packages: make(map[string]map[string]time.Time),
+	}
+}
+
+func getPackageKey(fn *fv1.Function) string {
+	ref := fn.Spec.Package.PackageRef
+	if ref.Revision > 0 {
+		return fmt.Sprintf("%v/%v/revision-%v", ref.Namespace, ref.Name, ref.Revision)
+	}
+	return fmt.Sprintf("%v/%v/%v", ref.Namespace, ref.Name, ref.ResourceVersion)
+}
+
+// update records the node of a specialized pod.
+func (pp *podPlacement) update(pod *apiv1.Pod) {
+	if len(pod.Spec.NodeName) == 0 || pod.ObjectMeta.DeletionTimestamp != nil {
+		pp.remove(pod.ObjectMeta.Name)
+		return
+	}
+
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+	pp.pods[pod.ObjectMeta.Name] = placedPod{
+		node:        pod.Spec.NodeName,
+		functionUID: pod.ObjectMeta.Labels[fv1.FUNCTION_UID],
+	}
+}
+
+func (pp *podPlacement) remove(name string) {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+	delete(pp.pods, name)
+}
+
+// addPackage records that the node fetched the package of the function.
+func (pp *podPlacement) addPackage(fn *fv1.Function, node string) {
+	if len(node) == 0 {
+		return
+	}
+
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	key := getPackageKey(fn)
+	nodes, ok := pp.packages[key]
+	if !ok {
+		nodes = make(map[string]time.Time)
+		pp.packages[key] = nodes
+	}
+	nodes[node] = time.Now()
+
+	// drop expired records
+	for k, nodes := range pp.packages {
+		for n, t := range nodes {
+			if time.Since(t) > packagePlacementExpiry {
+				delete(nodes, n)
+			}
+		}
+		if len(nodes) == 0 {
+			delete(pp.packages, k)
+		}
+	}
+}
+
+// hasPackage returns true if the node fetched the package of the function recently.
+func (pp *podPlacement) hasPackage(fn *fv1.Function, node string) bool {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+	t, ok := pp.packages[getPackageKey(fn)][node]
+	return ok && time.Since(t) <= packagePlacementExpiry
+}
+
+// nodeLoad returns the number of specialized pods on each node. If
+// functionUID is not empty, only pods of the function are counted.
+func (pp *podPlacement) nodeLoad(functionUID string) map[string]int {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+	load := make(map[string]int)
+	for _, p := range pp.pods {
+		if len(functionUID) == 0 || p.functionUID == functionUID {
+			load[p.node]++
+		}
+	}
+	return load
+}
+
+func makeNodeZones(kubernetesClient kubernetes.Interface) *nodeZones {
+	lw := &k8sCache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return kubernetesClient.CoreV1().Nodes().List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return kubernetesClient.CoreV1().Nodes().Watch(options)
+		},
+	}
+	resyncPeriod := 5 * time.Minute
+	store, controller := k8sCache.NewInformer(lw, &apiv1.Node{}, resyncPeriod, k8sCache.ResourceEventHandlerFuncs{})
+	return &nodeZones{
+		store:      store,
+		controller: controller,
+	}
+}
+
+func (nz *nodeZones) run(stopCh <-chan struct{}) {
+	nz.controller.Run(stopCh)
+}
+
+// get returns the zone of the node, or empty string if it's unknown.
+func (nz *nodeZones) get(node string) string {
+	obj, exists, err := nz.store.GetByKey(node)
+	if err != nil || !exists {
+		return ""
+	}
+	n := obj.(*apiv1.Node)
+	if zone := n.ObjectMeta.Labels[zoneLabel]; len(zone) > 0 {
+		return zone
+	}
+	return n.ObjectMeta.Labels[zoneLabelBeta]
+}
+
+// pickLowestScore returns the candidate with the lowest score, comparing
+// scores element by element. Ties are broken randomly.
+func pickLowestScore(candidates []*apiv1.Pod, score func(pod *apiv1.Pod) []int) *apiv1.Pod {
+	var best []*apiv1.Pod
+	var bestScore []int
+	for _, pod := range candidates {
+		s := score(pod)
+		switch compareScores(s, bestScore) {
+		case -1:
+			best = []*apiv1.Pod{pod}
+			bestScore = s
+		case 0:
+			best = append(best, pod)
+		}
+	}
+	if len(best) == 0 {
+		return nil
+	}
+	return best[rand.Intn(len(best))]
+}
+
+// compareScores compares two scores, a nil score is greater than any other.
+func compareScores(a, b []int) int {
+	if b == nil {
+		return -1
+	}
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		} else if a[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (s *randomSelector) selectPod(fn *fv1.Function, candidates []*apiv1.Pod) *apiv1.Pod {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+func (s *spreadSelector) selectPod(fn *fv1.Function, candidates []*apiv1.Pod) *apiv1.Pod {
+	nodeLoad := s.placement.nodeLoad(string(fn.ObjectMeta.UID))
+	zoneLoad := make(map[string]int)
+	for node, count := range nodeLoad {
+		zoneLoad[s.zones.get(node)] += count
+	}
+	return pickLowestScore(candidates, func(pod *apiv1.Pod) []int {
+		zone := s.zones.get(pod.Spec.NodeName)
+		return []int{zoneLoad[zone], nodeLoad[pod.Spec.NodeName]}
+	})
+}
+
+func (s *packageAffinitySelector) selectPod(fn *fv1.Function, candidates []*apiv1.Pod) *apiv1.Pod {
+	return pickLowestScore(candidates, func(pod *apiv1.Pod) []int {
+		if s.placement.hasPackage(fn, pod.Spec.NodeName) {
+			return []int{0}
+		}
+		return []int{1}
+	})
+}
+
+func (s *leastLoadedSelector) selectPod(fn *fv1.Function, candidates []*apiv1.Pod) *apiv1.Pod {
+	nodeLoad := s.placement.nodeLoad("")
+	return pickLowestScore(candidates, func(pod *apiv1.Pod) []int {
+		return []int{nodeLoad[pod.Spec.NodeName]}
+	})
+}
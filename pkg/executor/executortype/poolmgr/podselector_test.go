@@ -0,0 +1,176 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func makeTestNode(name, zone string) *apiv1.Node {
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{zoneLabel: zone},
+		},
+	}
+}
+
+func makeTestNodePod(name, node string) *apiv1.Pod {
+	pod := makeTestPod(name, "1", true)
+	pod.Spec.NodeName = node
+	return pod
+}
+
+func makeTestSpecializedPod(name, node string, fnUID k8stypes.UID) *apiv1.Pod {
+	pod := makeTestNodePod(name, node)
+	pod.ObjectMeta.Labels = map[string]string{fv1.FUNCTION_UID: string(fnUID), "managed": "false"}
+	return pod
+}
+
+func makeTestFunction(name string, uid k8stypes.UID) *fv1.Function {
+	return &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: uid},
+		Spec: fv1.FunctionSpec{
+			Package: fv1.FunctionPackageRef{
+				PackageRef: fv1.PackageRef{Namespace: "default", Name: name + "-pkg", ResourceVersion: "1"},
+			},
+		},
+	}
+}
+
+// selectNodes runs the selector many times and returns the nodes of the chosen pods.
+func selectNodes(s podSelector, fn *fv1.Function, candidates []*apiv1.Pod) map[string]bool {
+	nodes := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		nodes[s.selectPod(fn, candidates).Spec.NodeName] = true
+	}
+	return nodes
+}
+
+func TestSpreadSelector(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		makeTestNode("node-a1", "zone-a"),
+		makeTestNode("node-a2", "zone-a"),
+		makeTestNode("node-b1", "zone-b"),
+		makeTestNode("node-b2", "zone-b"),
+	)
+	zones := makeNodeZones(kubeClient)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go zones.run(stopCh)
+	assert.True(t, k8sCache.WaitForCacheSync(stopCh, zones.controller.HasSynced))
+	assert.Equal(t, "zone-b", zones.get("node-b1"))
+	assert.Empty(t, zones.get("node-unknown"))
+
+	placement := makePodPlacement()
+	s := makePodSelector(fv1.PodSelectionSpread, placement, zones)
+	fn := makeTestFunction("foo", "foo-uid")
+
+	candidates := []*apiv1.Pod{
+		makeTestNodePod("pod-a1", "node-a1"),
+		makeTestNodePod("pod-a2", "node-a2"),
+		makeTestNodePod("pod-b1", "node-b1"),
+		makeTestNodePod("pod-b2", "node-b2"),
+	}
+
+	// function already runs in zone-a, so zone-b is preferred
+	placement.update(makeTestSpecializedPod("foo-1", "node-a1", "foo-uid"))
+	nodes := selectNodes(s, fn, candidates)
+	assert.False(t, nodes["node-a1"])
+	assert.False(t, nodes["node-a2"])
+
+	// pods of other functions don't count
+	placement.update(makeTestSpecializedPod("bar-1", "node-b1", "bar-uid"))
+	placement.update(makeTestSpecializedPod("bar-2", "node-b2", "bar-uid"))
+	nodes = selectNodes(s, fn, candidates)
+	assert.False(t, nodes["node-a1"])
+	assert.False(t, nodes["node-a2"])
+
+	// within the same zone the node without pods of the function is preferred
+	placement.update(makeTestSpecializedPod("foo-2", "node-b1", "foo-uid"))
+	nodes = selectNodes(s, fn, candidates)
+	assert.Equal(t, map[string]bool{"node-a2": true, "node-b2": true}, nodes)
+
+	// once removed, the pod no longer counts
+	placement.remove("foo-1")
+	assert.Equal(t, map[string]bool{"node-a1": true, "node-a2": true}, selectNodes(s, fn, candidates))
+}
+
+func TestPackageAffinitySelector(t *testing.T) {
+	placement := makePodPlacement()
+	s := makePodSelector(fv1.PodSelectionPackageAffinity, placement, nil)
+	fn := makeTestFunction("foo", "foo-uid")
+
+	candidates := []*apiv1.Pod{
+		makeTestNodePod("pod-1", "node-1"),
+		makeTestNodePod("pod-2", "node-2"),
+		makeTestNodePod("pod-3", "node-3"),
+	}
+
+	// no node has the package, any pod can be chosen
+	assert.Len(t, selectNodes(s, fn, candidates), 3)
+
+	placement.addPackage(fn, "node-2")
+	assert.Equal(t, map[string]bool{"node-2": true}, selectNodes(s, fn, candidates))
+
+	// a new version of the package isn't cached anywhere
+	fn.Spec.Package.PackageRef.ResourceVersion = "2"
+	assert.Len(t, selectNodes(s, fn, candidates), 3)
+}
+
+func TestLeastLoadedSelector(t *testing.T) {
+	placement := makePodPlacement()
+	s := makePodSelector(fv1.PodSelectionLeastLoaded, placement, nil)
+	fn := makeTestFunction("foo", "foo-uid")
+
+	candidates := []*apiv1.Pod{
+		makeTestNodePod("pod-1", "node-1"),
+		makeTestNodePod("pod-2", "node-2"),
+		makeTestNodePod("pod-3", "node-3"),
+	}
+
+	for i := 0; i < 3; i++ {
+		placement.update(makeTestSpecializedPod(fmt.Sprintf("bar-%v", i), "node-1", "bar-uid"))
+	}
+	placement.update(makeTestSpecializedPod("baz-1", "node-2", "baz-uid"))
+	placement.update(makeTestSpecializedPod("foo-1", "node-3", "foo-uid"))
+	assert.Equal(t, map[string]bool{"node-2": true, "node-3": true}, selectNodes(s, fn, candidates))
+
+	placement.update(makeTestSpecializedPod("baz-2", "node-2", "baz-uid"))
+	assert.Equal(t, map[string]bool{"node-3": true}, selectNodes(s, fn, candidates))
+}
+
+func TestRandomSelector(t *testing.T) {
+	s := makePodSelector("", makePodPlacement(), nil)
+	assert.Nil(t, s.selectPod(nil, nil))
+
+	candidates := []*apiv1.Pod{
+		makeTestNodePod("pod-1", "node-1"),
+		makeTestNodePod("pod-2", "node-2"),
+	}
+	assert.Len(t, selectNodes(s, nil, candidates), 2)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+	ferror "github.com/fission/fission/pkg/error"
+	"github.com/fission/fission/pkg/executor/cluster"
+	"github.com/fission/fission/pkg/executor/fscache"
+)
+
+// getWarmPods returns the number of specialized pods to keep for a function
+// at the given time: the larger of the WarmPods and the WarmPods of every
+// warmup schedule whose window is open.
+func getWarmPods(es *fv1.ExecutionStrategy, now time.Time) int {
+	warmPods := es.WarmPods
+	for _, ws := range es.WarmupSchedules {
+		if ws.WarmPods <= warmPods || ws.Duration <= 0 {
+			continue
+		}
+		schedule, err := cron.Parse(ws.Cron)
+		if err != nil {
+			continue
+		}
+		// the window is open if the schedule fired within the last Duration seconds
+		windowStart := now.Add(-time.Duration(ws.Duration) * time.Second)
+		if !schedule.Next(windowStart).After(now) {
+			warmPods = ws.WarmPods
+		}
+	}
+	return warmPods
+}
+
+// warmPodsKeeper periodically specializes pods for poolmgr functions that
+// have fewer specialized pods than their warm pods.
+func (gpm *GenericPoolManager) warmPodsKeeper(ctx context.Context) {
+	pollSleep := 5 * time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollSleep):
+		}
+
+		now := time.Now()
+		for _, obj := range gpm.funcStore.List() {
+			fn := obj.(*fv1.Function)
+
+			fnExecutorType := fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType
+			if fnExecutorType != "" && fnExecutorType != fv1.ExecutorTypePoolmgr {
+				continue
+			}
+
+			// warm pods are cached by the executor replica serving the function
+			if !gpm.cluster.Owns(cluster.FunctionKey(&fn.ObjectMeta)) {
+				continue
+			}
+
+			warmPods := getWarmPods(&fn.Spec.InvokeStrategy.ExecutionStrategy, now)
+			if warmPods == 0 {
+				continue
+			}
+
+			missing := warmPods - gpm.fsCache.GetTotal(&fn.ObjectMeta) - gpm.warmingPods(fn, 0)
+			for i := 0; i < missing; i++ {
+				gpm.warmingPods(fn, 1)
+				go func() {
+					defer gpm.warmingPods(fn, -1)
+					gpm.warmPod(ctx, fn)
+				}()
+			}
+		}
+	}
+}
+
+// warmingPods adds delta to the number of pods being specialized ahead of
+// traffic for the function, and returns the result.
+func (gpm *GenericPoolManager) warmingPods(fn *fv1.Function, delta int) int {
+	gpm.warmingLock.Lock()
+	defer gpm.warmingLock.Unlock()
+
+	key := crd.CacheKey(&fn.ObjectMeta)
+	gpm.warming[key] += delta
+	count := gpm.warming[key]
+	if count <= 0 {
+		delete(gpm.warming, key)
+	}
+	return count
+}
+
+// errSharedPool is returned when warming a function of an environment
+// allowing infinite functions per container, whose pods are shared by all
+// functions and never reaped.
+var errSharedPool = ferror.MakeError(ferror.ErrorInvalidArgument,
+	"pods of environments allowing infinite functions per container are shared by all functions")
+
+// warmPod specializes a pod for the function to keep it warm.
+func (gpm *GenericPoolManager) warmPod(ctx context.Context, fn *fv1.Function) {
+	fsvc, err := gpm.WarmFunction(ctx, fn)
+	if err == errSharedPool {
+		return
+	}
+	if err != nil {
+		gpm.logger.Error("error specializing warm pod for function",
+			zap.Error(err), zap.String("function", fn.ObjectMeta.Name), zap.String("namespace", fn.ObjectMeta.Namespace))
+		return
+	}
+
+	gpm.logger.Info("specialized warm pod for function",
+		zap.String("function", fn.ObjectMeta.Name),
+		zap.String("namespace", fn.ObjectMeta.Namespace),
+		zap.String("pod", fsvc.Name))
+}
+
+// WarmFunction specializes a pod for the function and leaves it idle in the
+// function service cache for the next request.
+func (gpm *GenericPoolManager) WarmFunction(ctx context.Context, fn *fv1.Function) (*fscache.FuncSvc, error) {
+	env, err := gpm.getFunctionEnv(fn)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting environment of function")
+	}
+
+	if env.Spec.AllowedFunctionsPerContainer == fv1.AllowedFunctionsPerContainerInfinite {
+		return nil, errSharedPool
+	}
+
+	pool, err := gpm.getPool(env)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting pool of function")
+	}
+
+	fsvc, err := pool.getFuncSvc(ctx, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	// function services are added as being used, release it for requests
+	gpm.fsCache.MarkAvailable(fn, fsvc.Address)
+	return fsvc, nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolmgr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestGetWarmPods(t *testing.T) {
+	es := &fv1.ExecutionStrategy{
+		ExecutorType: fv1.ExecutorTypePoolmgr,
+		WarmPods:     1,
+		WarmupSchedules: []fv1.WarmupSchedule{
+			// 8am - 10am every day
+			{Cron: "0 0 8 * * *", Duration: 7200, WarmPods: 5},
+			// 9am - 9:30am every day
+			{Cron: "0 0 9 * * *", Duration: 1800, WarmPods: 10},
+			// ignored
+			{Cron: "invalid", Duration: 7200, WarmPods: 20},
+		},
+	}
+
+	at := func(hour, min int) time.Time {
+		return time.Date(2020, 6, 1, hour, min, 0, 0, time.UTC)
+	}
+
+	assert.Equal(t, 1, getWarmPods(es, at(7, 59)))
+	assert.Equal(t, 5, getWarmPods(es, at(8, 0)))
+	assert.Equal(t, 10, getWarmPods(es, at(9, 15)))
+	assert.Equal(t, 5, getWarmPods(es, at(9, 45)))
+	assert.Equal(t, 1, getWarmPods(es, at(10, 0)))
+
+	assert.Equal(t, 0, getWarmPods(&fv1.ExecutionStrategy{}, at(9, 0)))
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/executor/client"
+	"github.com/fission/fission/pkg/executor/cluster"
+)
+
+// set on requests forwarded between executor replicas, so they're never forwarded again
+const forwardedHeader = "X-Fission-Executor-Forwarded"
+
+// forwardToOwner forwards the request to the executor replica owning the
+// function, and returns whether the response has been written. Forwarded
+// requests, and requests whose owner can't be reached, are served locally.
+func (executor *Executor) forwardToOwner(w http.ResponseWriter, r *http.Request, body []byte, fn *metav1.ObjectMeta) bool {
+	if len(r.Header.Get(forwardedHeader)) > 0 {
+		return false
+	}
+	owner, self := executor.cluster.Owner(cluster.FunctionKey(fn))
+	if self {
+		return false
+	}
+
+	resp, err := executor.postToMember(r.Context(), owner, r.URL.Path, body)
+	if err != nil {
+		executor.logger.Warn("error forwarding request to executor replica owning the function, serving it locally",
+			zap.Error(err),
+			zap.String("member", owner.Name),
+			zap.String("function", fn.Name),
+			zap.String("namespace", fn.Namespace))
+		return false
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+	return true
+}
+
+// forwardTaps forwards the tap requests of functions owned by other
+// replicas, and returns the ones to serve locally.
+func (executor *Executor) forwardTaps(r *http.Request, reqs []client.TapServiceRequest) []client.TapServiceRequest {
+	if len(r.Header.Get(forwardedHeader)) > 0 {
+		return reqs
+	}
+
+	local := make([]client.TapServiceRequest, 0, len(reqs))
+	remote := make(map[cluster.Member][]client.TapServiceRequest)
+	for _, req := range reqs {
+		owner, self := executor.cluster.Owner(cluster.FunctionKey(&req.FnMetadata))
+		if self {
+			local = append(local, req)
+		} else {
+			remote[owner] = append(remote[owner], req)
+		}
+	}
+
+	for owner, ownerReqs := range remote {
+		// taps only keep function services from being reaped, so they're
+		// dropped if the owner can't be reached
+		err := executor.postBatchToMember(r, owner, ownerReqs)
+		if err != nil {
+			executor.logger.Warn("error forwarding tap requests to executor replica",
+				zap.Error(err), zap.String("member", owner.Name), zap.Int("request_count", len(ownerReqs)))
+		}
+	}
+
+	return local
+}
+
+// forwardLoadReports forwards the load reports of functions owned by other
+// replicas, and returns the ones to serve locally.
+func (executor *Executor) forwardLoadReports(r *http.Request, reports []client.FunctionLoadReport) []client.FunctionLoadReport {
+	if len(r.Header.Get(forwardedHeader)) > 0 {
+		return reports
+	}
+
+	local := make([]client.FunctionLoadReport, 0, len(reports))
+	remote := make(map[cluster.Member][]client.FunctionLoadReport)
+	for _, report := range reports {
+		owner, self := executor.cluster.Owner(cluster.FunctionKey(&report.FnMetadata))
+		if self {
+			local = append(local, report)
+		} else {
+			remote[owner] = append(remote[owner], report)
+		}
+	}
+
+	for owner, ownerReports := range remote {
+		// routers report the load again shortly, so the reports are
+		// dropped if the owner can't be reached
+		err := executor.postBatchToMember(r, owner, ownerReports)
+		if err != nil {
+			executor.logger.Warn("error forwarding load reports to executor replica",
+				zap.Error(err), zap.String("member", owner.Name), zap.Int("report_count", len(ownerReports)))
+		}
+	}
+
+	return local
+}
+
+// postBatchToMember forwards a batch of requests received by r to a member.
+func (executor *Executor) postBatchToMember(r *http.Request, member cluster.Member, batch interface{}) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	resp, err := executor.postToMember(r.Context(), member, r.URL.Path, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("executor replica responded with status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// gatherFromMembers sends the GET request r to the other replicas, and
+// returns the bodies of their responses, so that listings cover the function
+// services and pools of all replicas. Replicas that can't be reached are left out.
+func (executor *Executor) gatherFromMembers(r *http.Request) [][]byte {
+	if len(r.Header.Get(forwardedHeader)) > 0 {
+		return nil
+	}
+
+	self := executor.cluster.Self()
+	bodies := make([][]byte, 0)
+	for _, member := range executor.cluster.Members() {
+		if member.Name == self.Name {
+			continue
+		}
+		body, err := executor.getFromMember(r.Context(), member, r.URL.RequestURI())
+		if err != nil {
+			executor.logger.Warn("error gathering response from executor replica",
+				zap.Error(err), zap.String("member", member.Name), zap.String("path", r.URL.Path))
+			continue
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies
+}
+
+func (executor *Executor) getFromMember(ctx context.Context, member cluster.Member, uri string) ([]byte, error) {
+	resp, err := executor.sendToMember(ctx, http.MethodGet, member, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("executor replica responded with status %v", resp.StatusCode)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (executor *Executor) postToMember(ctx context.Context, member cluster.Member, path string, body []byte) (*http.Response, error) {
+	return executor.sendToMember(ctx, http.MethodPost, member, path, bytes.NewReader(body))
+}
+
+func (executor *Executor) sendToMember(ctx context.Context, method string, member cluster.Member, uri string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, "http://"+member.Address+uri, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(forwardedHeader, executor.cluster.Self().Name)
+	return executor.peerClient.Do(req)
+}
@@ -57,6 +57,13 @@ type (
 		Atime time.Time
 	}
 
+	// FuncSvcState is a function service in the cache with its state.
+	FuncSvcState struct {
+		FuncSvc
+		Active   bool // serving a request, only tracked for the pool cache
+		Draining bool // being drained before it's reaped
+	}
+
 	FunctionServiceCache struct {
 		logger            *zap.Logger
 		byFunction        *cache.Cache     // function-key -> funcSvc  : map[string]*funcSvc
@@ -184,6 +191,13 @@ func (fsc *FunctionServiceCache) GetFuncSvc(m *metav1.ObjectMeta) (*FuncSvc, err
 	return &fsvcCopy, nil
 }
 
+// HasFunctionUID returns whether the function cache has a function service
+// of the function, without updating its access time.
+func (fsc *FunctionServiceCache) HasFunctionUID(uid types.UID) bool {
+	_, err := fsc.byFunctionUID.Get(uid)
+	return err == nil
+}
+
 func (fsc *FunctionServiceCache) GetByFunctionUID(uid types.UID) (*FuncSvc, error) {
 	mI, err := fsc.byFunctionUID.Get(uid)
 	if err != nil {
@@ -218,6 +232,12 @@ func (fsc *FunctionServiceCache) GetTotalAvailable(m *metav1.ObjectMeta) int {
 	return fsc.connFunctionCache.GetTotalAvailable(crd.CacheKey(m))
 }
 
+// GetTotal returns the number of function services of the function in the
+// pool cache, including the ones serving requests.
+func (fsc *FunctionServiceCache) GetTotal(m *metav1.ObjectMeta) int {
+	return fsc.connFunctionCache.GetTotal(crd.CacheKey(m))
+}
+
 func (fsc *FunctionServiceCache) MarkAvailable(fn *fv1.Function, svcHost string) {
 	fsc.connFunctionCache.MarkAvailable(crd.CacheKey(&fn.ObjectMeta), svcHost)
 }
@@ -308,6 +328,34 @@ func (fsc *FunctionServiceCache) DeleteFunctionSvc(fsvc *FuncSvc) {
 	fsc.connFunctionCache.DeleteValue(crd.CacheKey(fsvc.Function), fsvc.Address)
 }
 
+// MarkDraining stops handing out the function service of the pool cache, and
+// returns whether it's serving a request. ok is false if the function service
+// is gone or being drained already.
+func (fsc *FunctionServiceCache) MarkDraining(fsvc *FuncSvc) (active bool, ok bool) {
+	return fsc.connFunctionCache.MarkDraining(crd.CacheKey(fsvc.Function), fsvc.Address)
+}
+
+// IsActive returns whether the function service of the pool cache is serving a request.
+func (fsc *FunctionServiceCache) IsActive(fsvc *FuncSvc) (bool, error) {
+	return fsc.connFunctionCache.IsActive(crd.CacheKey(fsvc.Function), fsvc.Address)
+}
+
+// List returns copies of the function services in the cache with their state.
+func (fsc *FunctionServiceCache) List() []FuncSvcState {
+	states := make([]FuncSvcState, 0)
+	for _, fsvcI := range fsc.byFunction.Copy() {
+		states = append(states, FuncSvcState{FuncSvc: *fsvcI.(*FuncSvc)})
+	}
+	for _, entry := range fsc.connFunctionCache.ListEntries() {
+		states = append(states, FuncSvcState{
+			FuncSvc:  *entry.Value.(*FuncSvc),
+			Active:   entry.Active,
+			Draining: entry.Draining,
+		})
+	}
+	return states
+}
+
 func (fsc *FunctionServiceCache) DeleteOld(fsvc *FuncSvc, minAge time.Duration) (bool, error) {
 	if time.Since(fsvc.Atime) < minAge {
 		return false, nil
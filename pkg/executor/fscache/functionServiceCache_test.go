@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	apiv1 "k8s.io/api/core/v1"
@@ -209,3 +210,36 @@ func TestFunctionServiceNewCache(t *testing.T) {
 	}
 	fsc.DeleteFunctionSvc(fsvc)
 }
+
+func TestFunctionServiceCacheList(t *testing.T) {
+	fsc := MakeFunctionServiceCache(zap.NewNop())
+
+	newdeployFsvc := FuncSvc{
+		Name:     "newdeploy-foo",
+		Function: &metav1.ObjectMeta{Name: "foo", UID: "1212"},
+		Address:  "foo.fission-function",
+		Executor: fv1.ExecutorTypeNewdeploy,
+	}
+	_, err := fsc.Add(newdeployFsvc)
+	assert.NoError(t, err)
+
+	poolFsvc := FuncSvc{
+		Name:     "pod-bar",
+		Function: &metav1.ObjectMeta{Name: "bar", UID: "3434"},
+		Address:  "10.0.0.1:8888",
+		Executor: fv1.ExecutorTypePoolmgr,
+	}
+	fsc.AddFunc(poolFsvc)
+	_, ok := fsc.MarkDraining(&poolFsvc)
+	assert.True(t, ok)
+
+	states := map[string]FuncSvcState{}
+	for _, state := range fsc.List() {
+		states[state.Name] = state
+	}
+	assert.Len(t, states, 2)
+	assert.Equal(t, "foo.fission-function", states["newdeploy-foo"].Address)
+	assert.False(t, states["newdeploy-foo"].Active)
+	assert.True(t, states["pod-bar"].Active)
+	assert.True(t, states["pod-bar"].Draining)
+}
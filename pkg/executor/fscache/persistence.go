@@ -0,0 +1,681 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fscache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/fission/fission/pkg/crd"
+	"github.com/fission/fission/pkg/executor/cluster"
+	poolcache "github.com/fission/fission/pkg/newcache"
+)
+
+const (
+	StateBackendConfigMap = "configmap"
+	StateBackendFile      = "file"
+
+	DefaultStateConfigMap          = "executor-state"
+	defaultStateCheckpointInterval = 10 * time.Second
+
+	// key of the executor instance ID in the state store
+	stateKeyInstanceID = "instance-id"
+
+	// label of the ConfigMaps of a configmap state store, set to its name
+	stateConfigMapLabel = "executorState"
+	// annotation of a ConfigMap of a configmap state store with the key saved in it
+	stateConfigMapKeyAnnotation = "executorStateKey"
+	// key of the state in a ConfigMap of a configmap state store
+	stateConfigMapDataKey = "state"
+	// the state saved in a ConfigMap is kept well below the 1 MiB limit of
+	// Kubernetes objects, leaving room for the metadata
+	maxStateConfigMapSize = 900 * 1024
+
+	// how long the state of an executor replica that went away is kept for
+	// the other replicas to take over its function services
+	departedStateExpiry = 5 * time.Minute
+)
+
+// invalidConfigMapNameChars are the characters replaced in ConfigMap names
+// derived from state keys.
+var invalidConfigMapNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// restoredActiveExpiry is how long a function service restored as serving a
+// request stays so without being used. The untap of router may have been lost
+// while the executor restarted, and router gives up on requests long before.
+var restoredActiveExpiry = 5 * time.Minute
+
+type (
+	// StateStore saves and loads the executor state by key.
+	StateStore interface {
+		// Load returns the data saved under the key, or nil if nothing was saved.
+		Load(key string) ([]byte, error)
+
+		// Save replaces the data saved under the key.
+		Save(key string, data []byte) error
+
+		// Delete removes the data saved under the key.
+		Delete(key string) error
+
+		// Keys returns the keys with saved data.
+		Keys() ([]string, error)
+	}
+
+	// configMapStateStore keeps the state saved under each key in a ConfigMap
+	// of its own, named after the store and the key, so that executor types
+	// and replicas never write the same ConfigMap.
+	configMapStateStore struct {
+		kubernetesClient kubernetes.Interface
+		namespace        string
+		name             string
+	}
+
+	// fileStateStore keeps the state in files of a directory, usually on a persistent volume.
+	fileStateStore struct {
+		dir string
+	}
+
+	// Persistence checkpoints function service caches to a state store
+	// and restores them when the executor restarts. With several executor
+	// replicas, each replica checkpoints the functions it owns under its own
+	// key, and takes over the function services of replicas that went away.
+	Persistence struct {
+		logger   *zap.Logger
+		store    StateStore
+		interval time.Duration
+		cluster  *cluster.Cluster
+	}
+
+	// checkpoint is the saved state of a function service cache
+	checkpoint struct {
+		// function services of the function cache
+		FuncSvcs []*FuncSvc `json:"funcSvcs,omitempty"`
+
+		// function services of the pool cache, with whether they're serving requests
+		PoolFuncSvcs []poolCheckpointEntry `json:"poolFuncSvcs,omitempty"`
+	}
+
+	poolCheckpointEntry struct {
+		FuncSvc *FuncSvc `json:"funcSvc"`
+		Active  bool     `json:"active,omitempty"`
+	}
+)
+
+// MakePersistence returns the persistence configured by the environment, or
+// nil if persistence is disabled.
+//
+//	EXECUTOR_STATE_BACKEND: "configmap", "file", or empty to disable persistence
+//	EXECUTOR_STATE_NAMESPACE, EXECUTOR_STATE_CONFIGMAP: the namespace and name prefix of the ConfigMaps of the configmap backend
+//	EXECUTOR_STATE_DIR: the directory of the file backend
+//	EXECUTOR_STATE_CHECKPOINT_INTERVAL: how often the caches are saved, e.g. "10s"
+func MakePersistence(logger *zap.Logger, kubernetesClient kubernetes.Interface, executorCluster *cluster.Cluster) (*Persistence, error) {
+	var store StateStore
+
+	backend := os.Getenv("EXECUTOR_STATE_BACKEND")
+	switch backend {
+	case "":
+		return nil, nil
+	case StateBackendConfigMap:
+		namespace := os.Getenv("EXECUTOR_STATE_NAMESPACE")
+		if len(namespace) == 0 {
+			return nil, errors.New("EXECUTOR_STATE_NAMESPACE must be set for the configmap state backend")
+		}
+		name := os.Getenv("EXECUTOR_STATE_CONFIGMAP")
+		if len(name) == 0 {
+			name = DefaultStateConfigMap
+		}
+		// the name prefixes the ConfigMap names and labels them
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid EXECUTOR_STATE_CONFIGMAP %q: %v", name, strings.Join(errs, ", "))
+		}
+		store = MakeConfigMapStateStore(kubernetesClient, namespace, name)
+	case StateBackendFile:
+		dir := os.Getenv("EXECUTOR_STATE_DIR")
+		if len(dir) == 0 {
+			return nil, errors.New("EXECUTOR_STATE_DIR must be set for the file state backend")
+		}
+		store = MakeFileStateStore(dir)
+	default:
+		return nil, fmt.Errorf("unknown executor state backend %q", backend)
+	}
+
+	interval := defaultStateCheckpointInterval
+	if s := os.Getenv("EXECUTOR_STATE_CHECKPOINT_INTERVAL"); len(s) > 0 {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid EXECUTOR_STATE_CHECKPOINT_INTERVAL %q", s)
+		}
+		interval = d
+	}
+
+	logger.Info("executor state persistence enabled",
+		zap.String("backend", backend), zap.Duration("checkpoint_interval", interval))
+
+	return MakePersistenceWithStore(logger, store, interval, executorCluster), nil
+}
+
+// MakePersistenceWithStore returns a persistence saving to the given store.
+func MakePersistenceWithStore(logger *zap.Logger, store StateStore, interval time.Duration, executorCluster *cluster.Cluster) *Persistence {
+	return &Persistence{
+		logger:   logger.Named("executor_state"),
+		store:    store,
+		interval: interval,
+		cluster:  executorCluster,
+	}
+}
+
+// InstanceID returns the executor instance ID saved by a previous executor,
+// so that the function pods it created are not cleaned up as old objects.
+// If there is none, newID is saved and returned.
+func (p *Persistence) InstanceID(newID string) string {
+	if p == nil {
+		return newID
+	}
+
+	data, err := p.store.Load(stateKeyInstanceID)
+	if err != nil {
+		p.logger.Error("error loading executor instance ID, using a new one", zap.Error(err))
+		return newID
+	}
+	if len(data) > 0 {
+		return string(data)
+	}
+
+	err = p.store.Save(stateKeyInstanceID, []byte(newID))
+	if err != nil {
+		// another executor may have saved its ID at the same time
+		if data, loadErr := p.store.Load(stateKeyInstanceID); loadErr == nil && len(data) > 0 {
+			return string(data)
+		}
+		p.logger.Error("error saving executor instance ID", zap.Error(err))
+	}
+	return newID
+}
+
+// Attach restores the function service cache from the state saved under
+// the key, then periodically saves the cache under the key. With several
+// executor replicas, the key is the one of this replica, and the function
+// services of replicas that went away are taken over; adopt, if not nil, is
+// called for each function service taken over.
+func (p *Persistence) Attach(fsc *FunctionServiceCache, key string, adopt func(fsvc *FuncSvc)) {
+	if p == nil {
+		return
+	}
+
+	memberKey := getMemberKey(key, p.cluster.Self().Name)
+	data, err := p.store.Load(memberKey)
+	if err != nil {
+		p.logger.Error("error loading function service cache", zap.Error(err), zap.String("key", memberKey))
+	} else if len(data) > 0 {
+		fsvcs, err := fsc.restore(data, nil)
+		if err != nil {
+			p.logger.Error("error restoring function service cache", zap.Error(err), zap.String("key", memberKey))
+		} else {
+			p.logger.Info("restored function service cache", zap.String("key", memberKey), zap.Int("function_services", len(fsvcs)))
+		}
+	}
+
+	go p.checkpointer(fsc, memberKey, data)
+	if p.cluster != nil {
+		go p.takeover(fsc, key, adopt)
+	}
+}
+
+// getMemberKey returns the key of the state saved by an executor replica.
+func getMemberKey(key string, member string) string {
+	if len(member) == 0 {
+		return key
+	}
+	return key + "." + member
+}
+
+// owns returns whether this executor replica keeps the function service.
+func (p *Persistence) owns(fsvc *FuncSvc) bool {
+	return p.cluster.Owns(cluster.FunctionKey(fsvc.Function))
+}
+
+// checkpointer saves the function service cache whenever it changed since the last save.
+func (p *Persistence) checkpointer(fsc *FunctionServiceCache, key string, last []byte) {
+	for {
+		time.Sleep(p.interval)
+
+		data, err := fsc.checkpoint(p.owns)
+		if err != nil {
+			p.logger.Error("error checkpointing function service cache", zap.Error(err), zap.String("key", key))
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+
+		err = p.store.Save(key, data)
+		if err != nil {
+			p.logger.Error("error saving function service cache", zap.Error(err), zap.String("key", key))
+			continue
+		}
+		last = data
+	}
+}
+
+// takeover restores the function services saved by executor replicas that
+// went away, for the functions this replica owns now. The state of each
+// replica is taken over once; the leader deletes it after departedStateExpiry.
+func (p *Persistence) takeover(fsc *FunctionServiceCache, key string, adopt func(fsvc *FuncSvc)) {
+	// state key -> time the replica was first seen gone
+	departedSince := make(map[string]time.Time)
+	takenOver := make(map[string]bool)
+
+	for {
+		time.Sleep(p.interval)
+
+		members := p.cluster.Members()
+		if len(members) == 0 {
+			// the replicas are not known yet
+			continue
+		}
+		keys, err := p.store.Keys()
+		if err != nil {
+			p.logger.Error("error listing executor state", zap.Error(err))
+			continue
+		}
+
+		alive := map[string]bool{
+			getMemberKey(key, p.cluster.Self().Name): true,
+		}
+		for _, m := range members {
+			alive[getMemberKey(key, m.Name)] = true
+		}
+
+		now := time.Now()
+		seen := make(map[string]bool)
+		for _, k := range keys {
+			if !strings.HasPrefix(k, key+".") || alive[k] {
+				continue
+			}
+			seen[k] = true
+			since, ok := departedSince[k]
+			if !ok {
+				departedSince[k] = now
+				since = now
+			}
+
+			if now.Sub(since) >= departedStateExpiry {
+				if p.cluster.IsLeader() {
+					err := p.store.Delete(k)
+					if err != nil {
+						p.logger.Error("error deleting executor state", zap.Error(err), zap.String("key", k))
+					}
+				}
+				continue
+			}
+			if takenOver[k] {
+				continue
+			}
+
+			data, err := p.store.Load(k)
+			if err != nil {
+				p.logger.Error("error loading function service cache", zap.Error(err), zap.String("key", k))
+				continue
+			}
+			fsvcs, err := fsc.restore(data, p.owns)
+			if err != nil {
+				p.logger.Error("error restoring function service cache", zap.Error(err), zap.String("key", k))
+				continue
+			}
+			takenOver[k] = true
+			for _, fsvc := range fsvcs {
+				if adopt != nil {
+					adopt(fsvc)
+				}
+			}
+			p.logger.Info("took over function services of executor replica that went away",
+				zap.String("key", k), zap.Int("function_services", len(fsvcs)))
+		}
+
+		// forget replicas whose state is gone or that came back
+		for k := range departedSince {
+			if !seen[k] {
+				delete(departedSince, k)
+				delete(takenOver, k)
+			}
+		}
+	}
+}
+
+// checkpoint returns the gzipped state of the cache. If keep is not nil,
+// only the function services it returns true for are saved.
+func (fsc *FunctionServiceCache) checkpoint(keep func(fsvc *FuncSvc) bool) ([]byte, error) {
+	cp := checkpoint{}
+
+	for _, fsvcI := range fsc.byFunction.Copy() {
+		fsvc := *fsvcI.(*FuncSvc)
+		if keep != nil && !keep(&fsvc) {
+			continue
+		}
+		cp.FuncSvcs = append(cp.FuncSvcs, &fsvc)
+	}
+	for _, entry := range fsc.connFunctionCache.ListEntries() {
+		fsvc := *entry.Value.(*FuncSvc)
+		if keep != nil && !keep(&fsvc) {
+			continue
+		}
+		cp.PoolFuncSvcs = append(cp.PoolFuncSvcs, poolCheckpointEntry{
+			FuncSvc: &fsvc,
+			Active:  entry.Active,
+		})
+	}
+
+	// caches are maps, sort the entries so that unchanged caches give the same data
+	sort.Slice(cp.FuncSvcs, func(i, j int) bool {
+		return funcSvcKey(cp.FuncSvcs[i]) < funcSvcKey(cp.FuncSvcs[j])
+	})
+	sort.Slice(cp.PoolFuncSvcs, func(i, j int) bool {
+		return funcSvcKey(cp.PoolFuncSvcs[i].FuncSvc) < funcSvcKey(cp.PoolFuncSvcs[j].FuncSvc)
+	})
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding function service cache")
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err = zw.Write(data)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error compressing function service cache")
+	}
+	return buf.Bytes(), nil
+}
+
+// restore adds the function services of a checkpoint to the cache, keeping
+// their access times and state, and returns the function services added.
+// Function services already in the cache, or that keep (if not nil) returns
+// false for, are skipped.
+func (fsc *FunctionServiceCache) restore(data []byte, keep func(fsvc *FuncSvc) bool) ([]*FuncSvc, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "error decompressing function service cache")
+	}
+	data, err = ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decompressing function service cache")
+	}
+
+	cp := checkpoint{}
+	err = json.Unmarshal(data, &cp)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding function service cache")
+	}
+
+	restored := make([]*FuncSvc, 0, len(cp.FuncSvcs)+len(cp.PoolFuncSvcs))
+	for _, fsvc := range cp.FuncSvcs {
+		if fsvc.Function == nil || (keep != nil && !keep(fsvc)) {
+			continue
+		}
+		if _, err := fsc.byFunction.Get(crd.CacheKey(fsvc.Function)); err == nil {
+			continue
+		}
+		// Add resets the access time, so set the caches directly
+		fsc.byFunction.Set(crd.CacheKey(fsvc.Function), fsvc)
+		fsc.byAddress.Set(fsvc.Address, *fsvc.Function)
+		fsc.byFunctionUID.Set(fsvc.Function.UID, *fsvc.Function)
+		fsc.setFuncAlive(fsvc.Function.Name, string(fsvc.Function.UID), true)
+		restored = append(restored, fsvc)
+	}
+	for _, entry := range cp.PoolFuncSvcs {
+		fsvc := entry.FuncSvc
+		if fsvc == nil || fsvc.Function == nil || (keep != nil && !keep(fsvc)) {
+			continue
+		}
+		if _, err := fsc.connFunctionCache.IsActive(crd.CacheKey(fsvc.Function), fsvc.Address); err == nil {
+			continue
+		}
+		active := entry.Active && time.Since(fsvc.Atime) < restoredActiveExpiry
+		fsc.connFunctionCache.SetEntry(poolcache.Entry{
+			Function: crd.CacheKey(fsvc.Function),
+			Address:  fsvc.Address,
+			Value:    fsvc,
+			Active:   active,
+		})
+		fsc.setFuncAlive(fsvc.Function.Name, string(fsvc.Function.UID), true)
+		if active {
+			fsc.expireRestoredActive(fsvc, fsvc.Atime)
+		}
+		restored = append(restored, fsvc)
+	}
+
+	return restored, nil
+}
+
+// expireRestoredActive marks a function service restored as serving a request
+// available if it isn't used within restoredActiveExpiry after atime, i.e. its
+// request ended but router's untap didn't reach the executor.
+func (fsc *FunctionServiceCache) expireRestoredActive(fsvc *FuncSvc, atime time.Time) {
+	time.AfterFunc(restoredActiveExpiry-time.Since(atime), func() {
+		key := crd.CacheKey(fsvc.Function)
+		active, err := fsc.connFunctionCache.IsActive(key, fsvc.Address)
+		if err != nil || !active {
+			// deleted or untapped meanwhile
+			return
+		}
+		if last := fsvc.Atime; !last.Equal(atime) {
+			// tapped since, check again later
+			fsc.expireRestoredActive(fsvc, last)
+			return
+		}
+		fsc.logger.Info("marking restored function service available, no untap received",
+			zap.String("function", fsvc.Function.Name),
+			zap.String("address", fsvc.Address))
+		fsc.connFunctionCache.MarkAvailable(key, fsvc.Address)
+	})
+}
+
+func funcSvcKey(fsvc *FuncSvc) string {
+	return crd.CacheKey(fsvc.Function) + "/" + fsvc.Address
+}
+
+// MakeConfigMapStateStore returns a state store keeping the state saved under
+// each key in a ConfigMap named after the store and the key, created on the
+// first save.
+func MakeConfigMapStateStore(kubernetesClient kubernetes.Interface, namespace, name string) StateStore {
+	return &configMapStateStore{
+		kubernetesClient: kubernetesClient,
+		namespace:        namespace,
+		name:             name,
+	}
+}
+
+// configMapName returns the name of the ConfigMap of the key. Keys that are not
+// valid names as is get a hash of the key appended, to keep names unique.
+func (s *configMapStateStore) configMapName(key string) string {
+	name := s.name + "-" + key
+	if len(validation.IsDNS1123Subdomain(name)) == 0 {
+		return name
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	suffix := fmt.Sprintf("-%08x", h.Sum32())
+	name = strings.Trim(invalidConfigMapNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+	if len(name) > validation.DNS1123SubdomainMaxLength-len(suffix) {
+		name = strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-len(suffix)], "-.")
+	}
+	return name + suffix
+}
+
+func (s *configMapStateStore) Load(key string) ([]byte, error) {
+	cm, err := s.kubernetesClient.CoreV1().ConfigMaps(s.namespace).Get(s.configMapName(key), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return cm.BinaryData[stateConfigMapDataKey], nil
+}
+
+func (s *configMapStateStore) Save(key string, data []byte) error {
+	if len(data) > maxStateConfigMapSize {
+		return errors.Errorf("executor state %q of %v bytes exceeds the limit of %v bytes of the configmap state backend, use the file state backend instead",
+			key, len(data), maxStateConfigMapSize)
+	}
+
+	name := s.configMapName(key)
+	cm, err := s.kubernetesClient.CoreV1().ConfigMaps(s.namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+		cm = &apiv1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: s.namespace,
+				Labels: map[string]string{
+					stateConfigMapLabel: s.name,
+				},
+				Annotations: map[string]string{
+					stateConfigMapKeyAnnotation: key,
+				},
+			},
+			BinaryData: map[string][]byte{stateConfigMapDataKey: data},
+		}
+		_, err = s.kubernetesClient.CoreV1().ConfigMaps(s.namespace).Create(cm)
+		return err
+	}
+
+	cm.BinaryData = map[string][]byte{stateConfigMapDataKey: data}
+	_, err = s.kubernetesClient.CoreV1().ConfigMaps(s.namespace).Update(cm)
+	return err
+}
+
+func (s *configMapStateStore) Delete(key string) error {
+	err := s.kubernetesClient.CoreV1().ConfigMaps(s.namespace).Delete(s.configMapName(key), &metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *configMapStateStore) Keys() ([]string, error) {
+	cms, err := s.kubernetesClient.CoreV1().ConfigMaps(s.namespace).List(metav1.ListOptions{
+		LabelSelector: labels.Set{stateConfigMapLabel: s.name}.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(cms.Items))
+	for _, cm := range cms.Items {
+		if key, ok := cm.ObjectMeta.Annotations[stateConfigMapKeyAnnotation]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// MakeFileStateStore returns a state store keeping the state in files of the directory.
+func MakeFileStateStore(dir string) StateStore {
+	return &fileStateStore{
+		dir: dir,
+	}
+}
+
+func (s *fileStateStore) Load(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(s.dir, key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *fileStateStore) Save(key string, data []byte) error {
+	err := os.MkdirAll(s.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename it, so a crash never leaves a partial file
+	tmp, err := ioutil.TempFile(s.dir, key+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dir, key))
+}
+
+func (s *fileStateStore) Delete(key string) error {
+	err := os.Remove(filepath.Join(s.dir, key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileStateStore) Keys() ([]string, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	keys := make([]string, 0, len(files))
+	for _, f := range files {
+		// skip the temporary files of saves in progress
+		if f.IsDir() || strings.Contains(f.Name(), ".tmp") {
+			continue
+		}
+		keys = append(keys, f.Name())
+	}
+	return keys, nil
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fscache
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/crd"
+)
+
+func TestCheckpointRestore(t *testing.T) {
+	logger := zap.NewNop()
+	fsc := MakeFunctionServiceCache(logger)
+
+	ctime := time.Now().Add(-time.Hour).Round(time.Second)
+	atime := time.Now().Add(-time.Minute).Round(time.Second)
+
+	fsvc := FuncSvc{
+		Name: "foo",
+		Function: &metav1.ObjectMeta{
+			Name:            "foo",
+			Namespace:       "default",
+			UID:             "1212",
+			ResourceVersion: "1",
+		},
+		Address:  "10.0.0.1:8888",
+		Executor: fv1.ExecutorTypeNewdeploy,
+	}
+	_, err := fsc.Add(fsvc)
+	assert.NoError(t, err)
+	stored, err := fsc.byFunction.Get("1212_1")
+	assert.NoError(t, err)
+	stored.(*FuncSvc).Ctime = ctime
+	stored.(*FuncSvc).Atime = atime
+
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "bar",
+			Namespace:       "default",
+			UID:             "3434",
+			ResourceVersion: "1",
+		},
+	}
+	fsc.AddFunc(FuncSvc{Name: "bar-1", Function: &fn.ObjectMeta, Address: "10.0.0.2:8888", Executor: fv1.ExecutorTypePoolmgr})
+	fsc.AddFunc(FuncSvc{Name: "bar-2", Function: &fn.ObjectMeta, Address: "10.0.0.3:8888", Executor: fv1.ExecutorTypePoolmgr})
+	fsc.MarkAvailable(fn, "10.0.0.3:8888")
+
+	data, err := fsc.checkpoint(nil)
+	assert.NoError(t, err)
+
+	// an unchanged cache gives the same data
+	again, err := fsc.checkpoint(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	restored := MakeFunctionServiceCache(logger)
+	fsvcs, err := restored.restore(data, nil)
+	assert.NoError(t, err)
+	assert.Len(t, fsvcs, 3)
+
+	// function services in the cache already are skipped
+	fsvcs, err = restored.restore(data, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, fsvcs)
+
+	got, err := restored.GetByFunctionUID("1212")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:8888", got.Address)
+	assert.True(t, got.Ctime.Equal(ctime))
+	assert.NoError(t, restored.TouchByAddress("10.0.0.1:8888"))
+
+	// the in-use function service is still counted, the idle one is served
+	assert.Equal(t, 2, restored.GetTotal(&fn.ObjectMeta))
+	assert.Equal(t, 1, restored.GetTotalAvailable(&fn.ObjectMeta))
+	got, err = restored.GetFuncSvc(&fn.ObjectMeta)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar-2", got.Name)
+}
+
+func TestRestoreActiveExpiry(t *testing.T) {
+	logger := zap.NewNop()
+	fsc := MakeFunctionServiceCache(logger)
+
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "foo",
+			Namespace:       "default",
+			UID:             "1212",
+			ResourceVersion: "1",
+		},
+	}
+	fsc.AddFunc(FuncSvc{Name: "foo-1", Function: &fn.ObjectMeta, Address: "10.0.0.1:8888", Executor: fv1.ExecutorTypePoolmgr})
+	fsc.AddFunc(FuncSvc{Name: "foo-2", Function: &fn.ObjectMeta, Address: "10.0.0.2:8888", Executor: fv1.ExecutorTypePoolmgr})
+	for _, entry := range fsc.connFunctionCache.ListEntries() {
+		if entry.Address == "10.0.0.2:8888" {
+			entry.Value.(*FuncSvc).Atime = time.Now().Add(-time.Hour)
+		}
+	}
+
+	data, err := fsc.checkpoint(nil)
+	assert.NoError(t, err)
+
+	expiry := restoredActiveExpiry
+	restoredActiveExpiry = 500 * time.Millisecond
+	defer func() { restoredActiveExpiry = expiry }()
+
+	// the function service unused for long is restored available
+	restored := MakeFunctionServiceCache(logger)
+	_, err = restored.restore(data, nil)
+	assert.NoError(t, err)
+	isActive := func(address string) bool {
+		active, err := restored.connFunctionCache.IsActive(crd.CacheKey(&fn.ObjectMeta), address)
+		assert.NoError(t, err)
+		return active
+	}
+	assert.Equal(t, 2, restored.GetTotal(&fn.ObjectMeta))
+	assert.True(t, isActive("10.0.0.1:8888"))
+	assert.False(t, isActive("10.0.0.2:8888"))
+
+	// the other one is available once no untap arrived in time
+	time.Sleep(time.Second)
+	assert.False(t, isActive("10.0.0.1:8888"))
+	assert.False(t, isActive("10.0.0.2:8888"))
+}
+
+func TestStateStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "executor-state")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	stores := map[string]StateStore{
+		StateBackendConfigMap: MakeConfigMapStateStore(fake.NewSimpleClientset(), "fission", "executor-state"),
+		StateBackendFile:      MakeFileStateStore(dir),
+	}
+
+	for backend, store := range stores {
+		data, err := store.Load("poolmgr")
+		assert.NoError(t, err, backend)
+		assert.Nil(t, data, backend)
+
+		assert.NoError(t, store.Save("poolmgr", []byte("a")), backend)
+		assert.NoError(t, store.Save("newdeploy", []byte("b")), backend)
+		assert.NoError(t, store.Save("poolmgr", []byte("c")), backend)
+
+		data, err = store.Load("poolmgr")
+		assert.NoError(t, err, backend)
+		assert.Equal(t, []byte("c"), data, backend)
+		data, err = store.Load("newdeploy")
+		assert.NoError(t, err, backend)
+		assert.Equal(t, []byte("b"), data, backend)
+
+		keys, err := store.Keys()
+		assert.NoError(t, err, backend)
+		assert.Equal(t, []string{"newdeploy", "poolmgr"}, keys, backend)
+
+		assert.NoError(t, store.Delete("newdeploy"), backend)
+		assert.NoError(t, store.Delete("newdeploy"), backend)
+		data, err = store.Load("newdeploy")
+		assert.NoError(t, err, backend)
+		assert.Nil(t, data, backend)
+
+		p := MakePersistenceWithStore(zap.NewNop(), store, time.Second, nil)
+		id := p.InstanceID("first")
+		assert.Equal(t, "first", id, backend)
+		assert.Equal(t, "first", p.InstanceID("second"), backend)
+	}
+
+	// disabled persistence keeps the new instance ID
+	var p *Persistence
+	assert.Equal(t, "new", p.InstanceID("new"))
+}
+
+func TestConfigMapStateStore(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	store := MakeConfigMapStateStore(client, "fission", "executor-state")
+
+	// each key is saved in a ConfigMap of its own
+	assert.NoError(t, store.Save("poolmgr.executor-0", []byte("a")))
+	assert.NoError(t, store.Save("poolmgr.executor-1", []byte("b")))
+	assert.NoError(t, store.Save("Newdeploy_executor", []byte("c")))
+	cms, err := client.CoreV1().ConfigMaps("fission").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	names := make([]string, 0, len(cms.Items))
+	for _, cm := range cms.Items {
+		names = append(names, cm.ObjectMeta.Name)
+	}
+	assert.Contains(t, names, "executor-state-poolmgr.executor-0")
+	assert.Contains(t, names, "executor-state-poolmgr.executor-1")
+	assert.Len(t, names, 3)
+
+	data, err := store.Load("Newdeploy_executor")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("c"), data)
+
+	// ConfigMaps of other stores are not listed
+	other := MakeConfigMapStateStore(client, "fission", "other-state")
+	assert.NoError(t, other.Save("poolmgr", []byte("d")))
+	keys, err := store.Keys()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Newdeploy_executor", "poolmgr.executor-0", "poolmgr.executor-1"}, keys)
+
+	// state too large for a ConfigMap is rejected
+	err = store.Save("poolmgr.executor-0", make([]byte, maxStateConfigMapSize+1))
+	assert.Error(t, err)
+	data, err = store.Load("poolmgr.executor-0")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), data)
+}
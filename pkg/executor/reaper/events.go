@@ -0,0 +1,63 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reaper
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// kinds of the kubernetes objects of function services, which are
+// referenced in lower case
+var objectKinds = map[string]string{
+	"pod":        "Pod",
+	"deployment": "Deployment",
+	"service":    "Service",
+}
+
+// RecordEvent creates a Kubernetes event on the given kubernetes object of a function service.
+func RecordEvent(logger *zap.Logger, kubeClient *kubernetes.Clientset, kubeobj *apiv1.ObjectReference, eventType string, reason string, message string) {
+	involved := *kubeobj
+	if kind, ok := objectKinds[strings.ToLower(kubeobj.Kind)]; ok {
+		involved.Kind = kind
+	}
+
+	now := meta_v1.Now()
+	event := &apiv1.Event{
+		ObjectMeta: meta_v1.ObjectMeta{
+			GenerateName: kubeobj.Name + ".",
+			Namespace:    kubeobj.Namespace,
+		},
+		InvolvedObject: involved,
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         apiv1.EventSource{Component: "fission-executor"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	_, err := kubeClient.CoreV1().Events(event.ObjectMeta.Namespace).Create(event)
+	if err != nil {
+		// just log the error since events are informational only
+		logger.Warn("error recording event", zap.Error(err), zap.String("object", kubeobj.Name), zap.String("reason", reason))
+	}
+}
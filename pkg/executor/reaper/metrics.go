@@ -0,0 +1,55 @@
+package reaper
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// reasons of reaping a function service, used as metric label
+	ReasonIdle               = "idle"
+	ReasonEnvironmentDeleted = "environment_deleted"
+	ReasonEvicted            = "evicted"
+
+	// results of reaping a function service, used as metric label
+	ResultDrained      = "drained"
+	ResultDrainTimeout = "drain_timeout"
+	ResultScaledDown   = "scaled_down"
+)
+
+var (
+	// executortype: the executor type of the function service
+	// reason: idle | environment_deleted | evicted
+	// result: drained | drain_timeout | scaled_down
+	reapTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_executor_reaped_function_services_total",
+			Help: "Number of function services reaped by the idle reaper or evicted through the admin API, by reason and result.",
+		},
+		[]string{"executortype", "reason", "result"},
+	)
+	reapDrainDuration = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name:       "fission_executor_reap_drain_duration_seconds",
+			Help:       "The time waiting for in-flight requests of a function service to finish before reaping it.",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+		[]string{"executortype", "result"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(reapTotal)
+	prometheus.MustRegister(reapDrainDuration)
+}
+
+// ObserveReap records a reap decision of the idle reaper.
+func ObserveReap(executorType string, reason string, result string) {
+	reapTotal.WithLabelValues(executorType, reason, result).Inc()
+}
+
+// ObserveDrain records the time a function service was drained before reaping it.
+func ObserveDrain(executorType string, result string, duration time.Duration) {
+	reapDrainDuration.WithLabelValues(executorType, result).Observe(duration.Seconds())
+}
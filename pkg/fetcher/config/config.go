@@ -132,6 +132,7 @@ func (cfg *Config) NewSpecializeRequest(fn *fv1.Function, env *fv1.Environment)
 			Secrets:     fn.Spec.Secrets,
 			ConfigMaps:  fn.Spec.ConfigMaps,
 			KeepArchive: env.Spec.KeepArchive,
+			Revision:    fn.Spec.Package.PackageRef.Revision,
 		},
 		LoadReq: fetcher.FunctionLoadRequest{
 			FilePath:         filepath.Join(cfg.sharedMountPath, targetFilename),
@@ -163,6 +164,49 @@ func (cfg *Config) AddSpecializingFetcherToPodSpec(podSpec *apiv1.PodSpec, mainC
 	)
 }
 
+// AddBuildStepToPodSpec adds the container of a build step to the pod spec,
+// as an init container run between a fetcher fetching the workspace of the step
+// into the shared volume and a fetcher putting it back once the step is done.
+func (cfg *Config) AddBuildStepToPodSpec(podSpec *apiv1.PodSpec, step apiv1.Container,
+	fetchReq fetcher.FunctionFetchRequest, putReq fetcher.ArchivePutRequest) error {
+
+	fetchPayload, err := json.Marshal(fetchReq)
+	if err != nil {
+		return err
+	}
+	putPayload, err := json.Marshal(putReq)
+	if err != nil {
+		return err
+	}
+
+	volumes, mounts := cfg.volumesWithMounts()
+	fetcherContainer := func(name string, command []string) apiv1.Container {
+		return apiv1.Container{
+			Name:                     name,
+			Command:                  command,
+			Image:                    cfg.fetcherImage,
+			ImagePullPolicy:          cfg.fetcherImagePullPolicy,
+			TerminationMessagePath:   "/dev/termination-log",
+			TerminationMessagePolicy: apiv1.TerminationMessageFallbackToLogsOnError,
+			VolumeMounts:             mounts,
+			Resources:                cfg.resourceRequirements,
+		}
+	}
+
+	step.VolumeMounts = append(step.VolumeMounts, mounts...)
+	podSpec.Volumes = append(podSpec.Volumes, volumes...)
+	podSpec.InitContainers = append(podSpec.InitContainers,
+		fetcherContainer("fetcher", cfg.fetcherCommand("-fetch-request", string(fetchPayload))),
+		step)
+	podSpec.Containers = append(podSpec.Containers,
+		fetcherContainer("uploader", cfg.fetcherCommand("-put-request", string(putPayload))))
+	if podSpec.ServiceAccountName == "" {
+		podSpec.ServiceAccountName = fv1.FissionFetcherSA
+	}
+
+	return nil
+}
+
 func (cfg *Config) fetcherCommand(extraArgs ...string) []string {
 	command := []string{"/fetcher",
 		"-secret-dir", cfg.sharedSecretPath,
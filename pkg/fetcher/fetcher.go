@@ -238,6 +238,18 @@ func (fetcher *Fetcher) Fetch(ctx context.Context, pkg *fv1.Package, req Functio
 		var archive *fv1.Archive
 		if req.FetchType == fv1.FETCH_SOURCE {
 			archive = &pkg.Spec.Source
+		} else if req.FetchType == fv1.FETCH_DEPLOYMENT && req.Revision > 0 {
+			// revisions are only recorded for succeeded builds
+			revision := pkg.Status.GetRevision(req.Revision)
+			if revision == nil {
+				e := "cannot fetch deployment: package revision not found"
+				fetcher.logger.Error(e,
+					zap.String("package_name", pkg.ObjectMeta.Name),
+					zap.String("package_namespace", pkg.ObjectMeta.Namespace),
+					zap.Int("revision", req.Revision))
+				return http.StatusNotFound, errors.New(fmt.Sprintf("%s: pkg %s.%s has no revision %d", e, pkg.ObjectMeta.Name, pkg.ObjectMeta.Namespace, req.Revision))
+			}
+			archive = &revision.Deployment
 		} else if req.FetchType == fv1.FETCH_DEPLOYMENT {
 			// sometimes, the user may invoke the function even before the source code is built into a deploy pkg.
 			// this results in executor sending a fetch request of type FETCH_DEPLOYMENT and since pkg.Spec.Deployment.Url will be empty,
@@ -509,6 +521,46 @@ func (fetcher *Fetcher) UploadHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(rBody)
 }
 
+// Put zips the contents of the requested file or directory and puts the
+// archive to the requested URL.
+func (fetcher *Fetcher) Put(ctx context.Context, req ArchivePutRequest) error {
+	if len(req.Filename) == 0 {
+		return errors.New("put request received for an empty file name")
+	}
+
+	srcFilepath := filepath.Join(fetcher.sharedVolumePath, req.Filename)
+	dstFilepath := filepath.Join(fetcher.sharedVolumePath, req.Filename+".zip")
+	err := fetcher.archive(srcFilepath, dstFilepath)
+	if err != nil {
+		return errors.Wrap(err, "error archiving zip file")
+	}
+	defer os.Remove(dstFilepath)
+
+	f, err := os.Open(dstFilepath)
+	if err != nil {
+		return errors.Wrap(err, "error opening zip file")
+	}
+	defer f.Close()
+
+	httpReq, err := http.NewRequest(http.MethodPut, req.Url, f)
+	if err != nil {
+		return errors.Wrap(err, "error making put request")
+	}
+	httpReq.Header.Set("Content-Type", "application/zip")
+	resp, err := fetcher.httpClient.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return errors.Wrapf(err, "error putting zip file to %v", req.Url)
+	}
+	defer resp.Body.Close()
+
+	err = ferror.MakeErrorFromHTTP(resp)
+	if err != nil {
+		return errors.Wrapf(err, "error putting zip file to %v", req.Url)
+	}
+	fetcher.logger.Info("successfully put", zap.String("file", srcFilepath), zap.String("url", req.Url))
+	return nil
+}
+
 func (fetcher *Fetcher) rename(src string, dst string) error {
 	err := os.Rename(src, dst)
 	if err != nil {
@@ -44,6 +44,10 @@ type (
 		Secrets       []fv1.SecretReference    `json:"secretList"`
 		ConfigMaps    []fv1.ConfigMapReference `json:"configMapList"`
 		KeepArchive   bool                     `json:"keeparchive"`
+
+		// Revision of the package to fetch the deployment archive of,
+		// the latest deployment archive if 0.
+		Revision int `json:"revision,omitempty"`
 	}
 
 	FunctionLoadRequest struct {
@@ -82,4 +86,12 @@ type (
 		ArchiveDownloadUrl string       `json:"archiveDownloadUrl"`
 		Checksum           fv1.Checksum `json:"checksum"`
 	}
+
+	// ArchivePutRequest describes a file or directory of the shared volume
+	// to put to a URL as a zip archive, such as the workspace of a build step
+	// sent back to the environment builder.
+	ArchivePutRequest struct {
+		Filename string `json:"filename"`
+		Url      string `json:"url"`
+	}
 )
@@ -140,6 +140,11 @@ func createEnvironmentFromCmd(input cli.Input) (*fv1.Environment, error) {
 		e = multierror.Append(e, err)
 	}
 
+	autoscale, err := getPoolAutoscale(input, nil)
+	if err != nil {
+		e = multierror.Append(e, err)
+	}
+
 	if e.ErrorOrNil() != nil {
 		return nil, e.ErrorOrNil()
 	}
@@ -159,8 +164,9 @@ func createEnvironmentFromCmd(input cli.Input) (*fv1.Environment, error) {
 				Image: envImg,
 			},
 			Builder: fv1.Builder{
-				Image:   envBuilderImg,
-				Command: envBuildCmd,
+				Image:        envBuilderImg,
+				Command:      envBuildCmd,
+				BuildTimeout: input.Int(flagkey.EnvBuildTimeout),
 			},
 			Poolsize:                     poolsize,
 			Resources:                    *resourceReq,
@@ -168,6 +174,8 @@ func createEnvironmentFromCmd(input cli.Input) (*fv1.Environment, error) {
 			TerminationGracePeriod:       envGracePeriod,
 			KeepArchive:                  keepArchive,
 			ImagePullSecret:              pullSecret,
+			PodSelection:                 fv1.PodSelectionStrategy(input.String(flagkey.EnvPodSelection)),
+			Autoscale:                    autoscale,
 		},
 	}
 
@@ -178,3 +186,39 @@ func createEnvironmentFromCmd(input cli.Input) (*fv1.Environment, error) {
 
 	return env, nil
 }
+
+// getPoolAutoscale returns the pool autoscaling setting of environment
+// based on the existing one and the flags given.
+func getPoolAutoscale(input cli.Input, existing *fv1.PoolAutoscale) (*fv1.PoolAutoscale, error) {
+	autoscaleFlagSet := input.IsSet(flagkey.EnvMinPoolsize) ||
+		input.IsSet(flagkey.EnvMaxPoolsize) || input.IsSet(flagkey.EnvTargetFreePods)
+	if !autoscaleFlagSet {
+		return existing, nil
+	}
+	if input.IsSet(flagkey.EnvPoolsize) {
+		return nil, errors.Errorf("--%v can't be used with pool autoscaling", flagkey.EnvPoolsize)
+	}
+
+	pa := &fv1.PoolAutoscale{
+		MinPoolsize:    input.Int(flagkey.EnvMinPoolsize),
+		MaxPoolsize:    input.Int(flagkey.EnvMaxPoolsize),
+		TargetFreePods: input.Int(flagkey.EnvTargetFreePods),
+	}
+	if existing != nil {
+		if !input.IsSet(flagkey.EnvMinPoolsize) {
+			pa.MinPoolsize = existing.MinPoolsize
+		}
+		if !input.IsSet(flagkey.EnvMaxPoolsize) {
+			pa.MaxPoolsize = existing.MaxPoolsize
+		}
+		if !input.IsSet(flagkey.EnvTargetFreePods) {
+			pa.TargetFreePods = existing.TargetFreePods
+		}
+	}
+
+	err := pa.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return pa, nil
+}
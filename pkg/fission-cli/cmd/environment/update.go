@@ -84,8 +84,8 @@ func updateExistingEnvironmentWithCmd(env *fv1.Environment, input cli.Input) (*f
 	envBuildCmd := input.String(flagkey.EnvBuildcommand)
 	envExternalNetwork := input.Bool(flagkey.EnvExternalNetwork)
 
-	if len(envImg) == 0 && len(envBuilderImg) == 0 && len(envBuildCmd) == 0 {
-		e = multierror.Append(e, errors.New("need --image to specify env image, or use --builder to specify env builder, or use --buildcmd to specify new build command"))
+	if len(envImg) == 0 && len(envBuilderImg) == 0 && len(envBuildCmd) == 0 && !input.IsSet(flagkey.EnvBuildTimeout) {
+		e = multierror.Append(e, errors.New("need --image to specify env image, or use --builder to specify env builder, or use --buildcmd to specify new build command, or use --buildtimeout to specify new build timeout"))
 	}
 
 	if len(envImg) > 0 {
@@ -102,9 +102,21 @@ func updateExistingEnvironmentWithCmd(env *fv1.Environment, input cli.Input) (*f
 	if len(envBuildCmd) > 0 {
 		env.Spec.Builder.Command = envBuildCmd
 	}
+	if input.IsSet(flagkey.EnvBuildTimeout) {
+		env.Spec.Builder.BuildTimeout = input.Int(flagkey.EnvBuildTimeout)
+	}
 
 	if input.IsSet(flagkey.EnvPoolsize) {
 		env.Spec.Poolsize = input.Int(flagkey.EnvPoolsize)
+		// a fixed pool size turns autoscaling off
+		env.Spec.Autoscale = nil
+	}
+
+	autoscale, err := getPoolAutoscale(input, env.Spec.Autoscale)
+	if err != nil {
+		e = multierror.Append(e, err)
+	} else {
+		env.Spec.Autoscale = autoscale
 	}
 
 	if input.IsSet(flagkey.EnvGracePeriod) {
@@ -119,6 +131,10 @@ func updateExistingEnvironmentWithCmd(env *fv1.Environment, input cli.Input) (*f
 		env.Spec.ImagePullSecret = input.String(flagkey.EnvImagePullSecret)
 	}
 
+	if input.IsSet(flagkey.EnvPodSelection) {
+		env.Spec.PodSelection = fv1.PodSelectionStrategy(input.String(flagkey.EnvPodSelection))
+	}
+
 	env.Spec.AllowAccessToExternalNetwork = envExternalNetwork
 
 	// TODO: allow to update resource.
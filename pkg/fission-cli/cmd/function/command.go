@@ -36,6 +36,9 @@ func Commands() *cobra.Command {
 			flag.FnExecutorType, flag.FnCfgMap, flag.FnSecret,
 			flag.FnSpecializationTimeout, flag.FnExecutionTimeout,
 			flag.FnIdleTimeout, flag.FnConcurrency,
+			flag.FnWarmPods, flag.FnWarmup,
+			flag.FnCircuitBreaker, flag.FnBreakerFailures, flag.FnBreakerOpenDuration, flag.FnBreakerHalfOpen,
+			flag.FnImage, flag.FnPort,
 
 			// TODO retired pkg & trigger related flags from function cmd
 			flag.PkgCode, flag.PkgSrcArchive, flag.PkgDeployArchive,
@@ -48,6 +51,7 @@ func Commands() *cobra.Command {
 			flag.RunTimeMinCPU, flag.RunTimeMaxCPU, flag.RunTimeMinMemory,
 			flag.RunTimeMaxMemory, flag.ReplicasMin,
 			flag.ReplicasMax, flag.RunTimeTargetCPU,
+			flag.RunTimeTargetConcurrency, flag.RunTimeTargetRPS,
 
 			flag.NamespaceFunction, flag.NamespaceEnvironment, flag.SpecSave, flag.SpecDry},
 	})
@@ -87,6 +91,9 @@ func Commands() *cobra.Command {
 			flag.FnExecutorType, flag.FnSecret, flag.FnCfgMap,
 			flag.FnSpecializationTimeout, flag.FnExecutionTimeout,
 			flag.FnIdleTimeout, flag.FnConcurrency,
+			flag.FnWarmPods, flag.FnWarmup,
+			flag.FnCircuitBreaker, flag.FnBreakerFailures, flag.FnBreakerOpenDuration, flag.FnBreakerHalfOpen,
+			flag.FnImage, flag.FnPort,
 
 			flag.PkgCode, flag.PkgSrcArchive, flag.PkgDeployArchive,
 			flag.PkgSrcChecksum, flag.PkgDeployChecksum, flag.PkgInsecure,
@@ -94,12 +101,23 @@ func Commands() *cobra.Command {
 
 			flag.RunTimeMinCPU, flag.RunTimeMaxCPU, flag.RunTimeMinMemory,
 			flag.RunTimeMaxMemory, flag.ReplicasMin, flag.ReplicasMax,
-			flag.RunTimeTargetCPU,
+			flag.RunTimeTargetCPU, flag.RunTimeTargetConcurrency, flag.RunTimeTargetRPS,
 
 			flag.NamespaceFunction, flag.NamespaceEnvironment, flag.SpecSave,
 		},
 	})
 
+	rollbackCmd := &cobra.Command{
+		Use:     "rollback",
+		Aliases: []string{},
+		Short:   "Roll back a function to a revision of its package",
+		RunE:    wrapper.Wrapper(Rollback),
+	}
+	wrapper.SetFlags(rollbackCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.FnName, flag.FnRollbackRevision},
+		Optional: []flag.Flag{flag.NamespaceFunction},
+	})
+
 	deleteCmd := &cobra.Command{
 		Use:     "delete",
 		Aliases: []string{},
@@ -151,13 +169,62 @@ func Commands() *cobra.Command {
 		},
 	})
 
+	podsListCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{},
+		Short:   "List function pods cached by executor, with their address, idle time and state",
+		RunE:    wrapper.Wrapper(PodsList),
+	}
+	wrapper.SetFlags(podsListCmd, flag.FlagSet{
+		Optional: []flag.Flag{flag.FnName, flag.NamespaceFunction, flag.AllNamespaces},
+	})
+
+	podsEvictCmd := &cobra.Command{
+		Use:     "evict",
+		Aliases: []string{},
+		Short:   "Remove the pods of a function right away, its next request gets new ones",
+		RunE:    wrapper.Wrapper(PodsEvict),
+	}
+	wrapper.SetFlags(podsEvictCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.FnName},
+		Optional: []flag.Flag{flag.NamespaceFunction},
+	})
+
+	podsWarmCmd := &cobra.Command{
+		Use:     "warm",
+		Aliases: []string{},
+		Short:   "Specialize a pod for a function ahead of requests",
+		RunE:    wrapper.Wrapper(PodsWarm),
+	}
+	wrapper.SetFlags(podsWarmCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.FnName},
+		Optional: []flag.Flag{flag.NamespaceFunction},
+	})
+
+	podsPoolsCmd := &cobra.Command{
+		Use:     "pools",
+		Aliases: []string{},
+		Short:   "Show the pools of generic pods of environments",
+		RunE:    wrapper.Wrapper(PodsPools),
+	}
+	wrapper.SetFlags(podsPoolsCmd, flag.FlagSet{
+		Optional: []flag.Flag{flag.FnEnvName, flag.NamespaceEnvironment, flag.AllNamespaces},
+	})
+
+	podsCmd := &cobra.Command{
+		Use:     "pods",
+		Aliases: []string{},
+		Short:   "Inspect and manage the pods executor keeps for functions",
+	}
+	podsCmd.AddCommand(podsListCmd, podsEvictCmd, podsWarmCmd, podsPoolsCmd)
+
 	command := &cobra.Command{
 		Use:     "function",
 		Aliases: []string{"fn"},
 		Short:   "Create, update and manage functions",
 	}
 
-	command.AddCommand(createCmd, getCmd, getmetaCmd, updateCmd, deleteCmd, listCmd, logsCmd, testCmd)
+	command.AddCommand(createCmd, getCmd, getmetaCmd, updateCmd, rollbackCmd, deleteCmd, listCmd, logsCmd, testCmd, podsCmd)
 
 	return command
 }
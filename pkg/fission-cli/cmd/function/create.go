@@ -18,7 +18,9 @@ package function
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
@@ -102,6 +104,11 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 		fnConcurrency = input.Int(flagkey.FnConcurrency)
 	}
 
+	circuitBreaker, err := getCircuitBreaker(input, nil)
+	if err != nil {
+		return err
+	}
+
 	pkgName := input.String(flagkey.FnPackageName)
 
 	secretNames := input.StringSlice(flagkey.FnSecret)
@@ -118,8 +125,19 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 
 	var pkgMetadata *metav1.ObjectMeta
 	var envName string
+	var podSpec *apiv1.PodSpec
 
-	if len(pkgName) > 0 {
+	if invokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypeContainer {
+		// the function container is run as is, without environment and package
+		podSpec, err = getContainerPodSpec(input, fnName, nil)
+		if err != nil {
+			return err
+		}
+		pkgMetadata = &metav1.ObjectMeta{}
+		envNamespace = ""
+	} else if input.IsSet(flagkey.FnImage) || input.IsSet(flagkey.FnPort) {
+		return errors.Errorf("--%v and --%v are only supported by executor type \"%v\"", flagkey.FnImage, flagkey.FnPort, fv1.ExecutorTypeContainer)
+	} else if len(pkgName) > 0 {
 		var pkg *fv1.Package
 
 		if toSpec {
@@ -306,6 +324,8 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 			FunctionTimeout: fnTimeout,
 			IdleTimeout:     &fnIdleTimeout,
 			Concurrency:     fnConcurrency,
+			CircuitBreaker:  circuitBreaker,
+			PodSpec:         podSpec,
 		},
 	}
 
@@ -374,6 +394,105 @@ func (opts *CreateSubCommand) run(input cli.Input) error {
 	return nil
 }
 
+// getCircuitBreaker returns the circuit breaker setting of function
+// based on the existing one and the flags given.
+func getCircuitBreaker(input cli.Input, existing *fv1.CircuitBreaker) (*fv1.CircuitBreaker, error) {
+	if input.IsSet(flagkey.FnCircuitBreaker) && !input.Bool(flagkey.FnCircuitBreaker) {
+		return nil, nil
+	}
+
+	breakerFlagSet := input.IsSet(flagkey.FnBreakerFailures) ||
+		input.IsSet(flagkey.FnBreakerOpenDuration) || input.IsSet(flagkey.FnBreakerHalfOpen)
+	if !input.Bool(flagkey.FnCircuitBreaker) && !breakerFlagSet {
+		return existing, nil
+	}
+
+	cb := &fv1.CircuitBreaker{}
+	if existing != nil {
+		*cb = *existing
+	}
+	if input.IsSet(flagkey.FnBreakerFailures) {
+		cb.ConsecutiveFailures = input.Int(flagkey.FnBreakerFailures)
+	}
+	if input.IsSet(flagkey.FnBreakerOpenDuration) {
+		cb.OpenDuration = input.Int(flagkey.FnBreakerOpenDuration)
+	}
+	if input.IsSet(flagkey.FnBreakerHalfOpen) {
+		cb.HalfOpenRequests = input.Int(flagkey.FnBreakerHalfOpen)
+	}
+
+	err := cb.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return cb, nil
+}
+
+// getContainerPodSpec returns the pod spec of a function of executor type
+// container based on the existing one and the flags given.
+func getContainerPodSpec(input cli.Input, fnName string, existing *apiv1.PodSpec) (*apiv1.PodSpec, error) {
+	podSpec := &apiv1.PodSpec{
+		Containers: []apiv1.Container{{Name: fnName}},
+	}
+	if existing != nil && len(existing.Containers) > 0 {
+		podSpec = existing.DeepCopy()
+	}
+	container := &podSpec.Containers[0]
+
+	if input.IsSet(flagkey.FnImage) {
+		container.Image = input.String(flagkey.FnImage)
+	}
+	if len(container.Image) == 0 {
+		return nil, errors.Errorf("need --%v argument for executor type \"%v\"", flagkey.FnImage, fv1.ExecutorTypeContainer)
+	}
+
+	if input.IsSet(flagkey.FnPort) {
+		port := input.Int(flagkey.FnPort)
+		if port <= 0 || port > 65535 {
+			return nil, errors.Errorf("--%v must be a value between 1 - 65535", flagkey.FnPort)
+		}
+		container.Ports = []apiv1.ContainerPort{
+			{
+				Name:          "http-env",
+				ContainerPort: int32(port),
+			},
+		}
+	}
+
+	return podSpec, nil
+}
+
+// getWarmupSchedules parses the warmup schedules given in the form of
+// "pods:duration:cron", e.g. "5:2h:0 0 8 * * 1-5".
+func getWarmupSchedules(input cli.Input) ([]fv1.WarmupSchedule, error) {
+	var schedules []fv1.WarmupSchedule
+	for _, w := range input.StringSlice(flagkey.FnWarmup) {
+		parts := strings.SplitN(w, ":", 3)
+		if len(parts) != 3 {
+			return nil, errors.Errorf("invalid %v '%v', must be in the form of pods:duration:cron", flagkey.FnWarmup, w)
+		}
+		pods, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing pods of %v '%v'", flagkey.FnWarmup, w)
+		}
+		duration, err := time.ParseDuration(parts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing duration of %v '%v'", flagkey.FnWarmup, w)
+		}
+		ws := fv1.WarmupSchedule{
+			Cron:     strings.TrimSpace(parts[2]),
+			Duration: int(duration.Seconds()),
+			WarmPods: pods,
+		}
+		err = ws.Validate()
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, ws)
+	}
+	return schedules, nil
+}
+
 func getInvokeStrategy(input cli.Input, existingInvokeStrategy *fv1.InvokeStrategy) (strategy *fv1.InvokeStrategy, err error) {
 	var es *fv1.ExecutionStrategy
 
@@ -403,8 +522,11 @@ func getExecutionStrategy(input cli.Input) (strategy *fv1.ExecutionStrategy, err
 		fnExecutor = fv1.ExecutorTypePoolmgr
 	case string(fv1.ExecutorTypeNewdeploy):
 		fnExecutor = fv1.ExecutorTypeNewdeploy
+	case string(fv1.ExecutorTypeContainer):
+		fnExecutor = fv1.ExecutorTypeContainer
 	default:
-		return nil, errors.Errorf("executor type must be one of '%v' or '%v'", fv1.ExecutorTypePoolmgr, fv1.ExecutorTypeNewdeploy)
+		return nil, errors.Errorf("executor type must be one of '%v', '%v' or '%v'",
+			fv1.ExecutorTypePoolmgr, fv1.ExecutorTypeNewdeploy, fv1.ExecutorTypeContainer)
 	}
 
 	specializationTimeout := fv1.DefaultSpecializationTimeOut
@@ -417,19 +539,36 @@ func getExecutionStrategy(input cli.Input) (strategy *fv1.ExecutionStrategy, err
 	}
 
 	if fnExecutor == fv1.ExecutorTypePoolmgr {
-		if input.IsSet(flagkey.RuntimeTargetcpu) || input.IsSet(flagkey.ReplicasMinscale) || input.IsSet(flagkey.ReplicasMaxscale) {
-			return nil, errors.New("to set target CPU or min/max scale for function, please specify \"--executortype newdeploy\"")
+		if input.IsSet(flagkey.RuntimeTargetcpu) || input.IsSet(flagkey.ReplicasMinscale) || input.IsSet(flagkey.ReplicasMaxscale) ||
+			input.IsSet(flagkey.RuntimeTargetConcurrency) || input.IsSet(flagkey.RuntimeTargetRPS) {
+			return nil, errors.New("to set scaling targets or min/max scale for function, please specify \"--executortype newdeploy\"")
 		}
 
 		if input.IsSet(flagkey.RuntimeMincpu) || input.IsSet(flagkey.RuntimeMaxcpu) || input.IsSet(flagkey.RuntimeMinmemory) || input.IsSet(flagkey.RuntimeMaxmemory) {
 			console.Warn("To limit CPU/Memory for function with executor type \"poolmgr\", please specify resources limits when creating environment")
 		}
 
+		warmPods := input.Int(flagkey.FnWarmPods)
+		if warmPods < 0 {
+			return nil, errors.Errorf("%v must be greater than or equal to 0", flagkey.FnWarmPods)
+		}
+
+		warmupSchedules, err := getWarmupSchedules(input)
+		if err != nil {
+			return nil, err
+		}
+
 		strategy = &fv1.ExecutionStrategy{
 			ExecutorType:          fv1.ExecutorTypePoolmgr,
 			SpecializationTimeout: specializationTimeout,
+			WarmPods:              warmPods,
+			WarmupSchedules:       warmupSchedules,
 		}
 	} else {
+		if input.IsSet(flagkey.FnWarmPods) || input.IsSet(flagkey.FnWarmup) {
+			return nil, errors.New("warm pods are only supported by executor type \"poolmgr\"")
+		}
+
 		targetCPU := DEFAULT_TARGET_CPU_PERCENTAGE
 		if input.IsSet(flagkey.RuntimeTargetcpu) {
 			targetCPU, err = getTargetCPU(input)
@@ -455,6 +594,11 @@ func getExecutionStrategy(input cli.Input) (strategy *fv1.ExecutionStrategy, err
 			return nil, fmt.Errorf("minscale (%v) can not be greater than maxscale (%v)", minScale, maxScale)
 		}
 
+		targetConcurrency, targetRPS, err := getRequestTargets(input, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+
 		// Right now a simple single case strategy implementation
 		// This will potentially get more sophisticated once we have more strategies in place
 		strategy = &fv1.ExecutionStrategy{
@@ -462,6 +606,8 @@ func getExecutionStrategy(input cli.Input) (strategy *fv1.ExecutionStrategy, err
 			MinScale:              minScale,
 			MaxScale:              maxScale,
 			TargetCPUPercent:      targetCPU,
+			TargetConcurrency:     targetConcurrency,
+			TargetRPS:             targetRPS,
 			SpecializationTimeout: specializationTimeout,
 		}
 	}
@@ -481,8 +627,11 @@ func updateExecutionStrategy(input cli.Input, existingExecutionStrategy *fv1.Exe
 			fnExecutor = fv1.ExecutorTypePoolmgr
 		case string(fv1.ExecutorTypeNewdeploy):
 			fnExecutor = fv1.ExecutorTypeNewdeploy
+		case string(fv1.ExecutorTypeContainer):
+			fnExecutor = fv1.ExecutorTypeContainer
 		default:
-			return nil, errors.Errorf("executor type must be one of '%v' or '%v'", fv1.ExecutorTypePoolmgr, fv1.ExecutorTypeNewdeploy)
+			return nil, errors.Errorf("executor type must be one of '%v', '%v' or '%v'",
+				fv1.ExecutorTypePoolmgr, fv1.ExecutorTypeNewdeploy, fv1.ExecutorTypeContainer)
 		}
 	}
 
@@ -500,18 +649,43 @@ func updateExecutionStrategy(input cli.Input, existingExecutionStrategy *fv1.Exe
 	}
 
 	if fnExecutor == fv1.ExecutorTypePoolmgr {
-		if input.IsSet(flagkey.RuntimeTargetcpu) || input.IsSet(flagkey.ReplicasMinscale) || input.IsSet(flagkey.ReplicasMaxscale) {
-			return nil, errors.New("to set target CPU or min/max scale for function, please specify \"--executortype newdeploy\"")
+		if input.IsSet(flagkey.RuntimeTargetcpu) || input.IsSet(flagkey.ReplicasMinscale) || input.IsSet(flagkey.ReplicasMaxscale) ||
+			input.IsSet(flagkey.RuntimeTargetConcurrency) || input.IsSet(flagkey.RuntimeTargetRPS) {
+			return nil, errors.New("to set scaling targets or min/max scale for function, please specify \"--executortype newdeploy\"")
 		}
 
 		if input.IsSet(flagkey.RuntimeMincpu) || input.IsSet(flagkey.RuntimeMaxcpu) || input.IsSet(flagkey.RuntimeMinmemory) || input.IsSet(flagkey.RuntimeMaxmemory) {
 			console.Warn("To limit CPU/Memory for function with executor type \"poolmgr\", please specify resources limits when creating environment")
 		}
+
+		warmPods := existingExecutionStrategy.WarmPods
+		if input.IsSet(flagkey.FnWarmPods) {
+			warmPods = input.Int(flagkey.FnWarmPods)
+			if warmPods < 0 {
+				return nil, errors.Errorf("%v must be greater than or equal to 0", flagkey.FnWarmPods)
+			}
+		}
+
+		// the given warmup schedules replace the existing ones
+		warmupSchedules := existingExecutionStrategy.WarmupSchedules
+		if input.IsSet(flagkey.FnWarmup) {
+			warmupSchedules, err = getWarmupSchedules(input)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		strategy = &fv1.ExecutionStrategy{
 			ExecutorType:          fv1.ExecutorTypePoolmgr,
 			SpecializationTimeout: specializationTimeout,
+			WarmPods:              warmPods,
+			WarmupSchedules:       warmupSchedules,
 		}
 	} else {
+		if input.IsSet(flagkey.FnWarmPods) || input.IsSet(flagkey.FnWarmup) {
+			return nil, errors.New("warm pods are only supported by executor type \"poolmgr\"")
+		}
+
 		targetCPU := existingExecutionStrategy.TargetCPUPercent
 		minScale := existingExecutionStrategy.MinScale
 		maxScale := existingExecutionStrategy.MaxScale
@@ -552,6 +726,12 @@ func updateExecutionStrategy(input cli.Input, existingExecutionStrategy *fv1.Exe
 			return nil, fmt.Errorf("minscale (%v) can not be greater than maxscale (%v)", minScale, maxScale)
 		}
 
+		targetConcurrency, targetRPS, err := getRequestTargets(input,
+			existingExecutionStrategy.TargetConcurrency, existingExecutionStrategy.TargetRPS)
+		if err != nil {
+			return nil, err
+		}
+
 		// Right now a simple single case strategy implementation
 		// This will potentially get more sophisticated once we have more strategies in place
 		strategy = &fv1.ExecutionStrategy{
@@ -559,6 +739,8 @@ func updateExecutionStrategy(input cli.Input, existingExecutionStrategy *fv1.Exe
 			MinScale:              minScale,
 			MaxScale:              maxScale,
 			TargetCPUPercent:      targetCPU,
+			TargetConcurrency:     targetConcurrency,
+			TargetRPS:             targetRPS,
 			SpecializationTimeout: specializationTimeout,
 		}
 	}
@@ -573,3 +755,27 @@ func getTargetCPU(input cli.Input) (int, error) {
 	}
 	return targetCPU, nil
 }
+
+// getRequestTargets returns the target concurrency and target RPS of request-driven
+// autoscaling, the given ones unless set by flags. Setting one clears the other,
+// and setting both to 0 goes back to scaling by CPU usage.
+func getRequestTargets(input cli.Input, targetConcurrency int, targetRPS int) (int, int, error) {
+	if input.IsSet(flagkey.RuntimeTargetConcurrency) && input.IsSet(flagkey.RuntimeTargetRPS) {
+		return 0, 0, errors.Errorf("only one of %v and %v can be set", flagkey.RuntimeTargetConcurrency, flagkey.RuntimeTargetRPS)
+	}
+	if input.IsSet(flagkey.RuntimeTargetConcurrency) {
+		targetConcurrency = input.Int(flagkey.RuntimeTargetConcurrency)
+		if targetConcurrency < 0 {
+			return 0, 0, errors.Errorf("%v must be greater than or equal to 0", flagkey.RuntimeTargetConcurrency)
+		}
+		targetRPS = 0
+	}
+	if input.IsSet(flagkey.RuntimeTargetRPS) {
+		targetRPS = input.Int(flagkey.RuntimeTargetRPS)
+		if targetRPS < 0 {
+			return 0, 0, errors.Errorf("%v must be greater than or equal to 0", flagkey.RuntimeTargetRPS)
+		}
+		targetConcurrency = 0
+	}
+	return targetConcurrency, targetRPS, nil
+}
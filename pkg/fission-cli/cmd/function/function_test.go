@@ -357,6 +357,102 @@ func TestGetInvokeStrategy(t *testing.T) {
 			expectedResult:         nil,
 			expectError:            true,
 		},
+		{
+			name: "poolmgr keeps warm pods",
+			testArgs: map[string]interface{}{
+				flagkey.FnWarmPods: 2,
+				flagkey.FnWarmup:   []string{"5:2h:0 0 8 * * 1-5"},
+			},
+			existingInvokeStrategy: nil,
+			expectedResult: &fv1.InvokeStrategy{
+				StrategyType: fv1.StrategyTypeExecution,
+				ExecutionStrategy: fv1.ExecutionStrategy{
+					ExecutorType:          fv1.ExecutorTypePoolmgr,
+					SpecializationTimeout: fv1.DefaultSpecializationTimeOut,
+					WarmPods:              2,
+					WarmupSchedules:       []fv1.WarmupSchedule{{Cron: "0 0 8 * * 1-5", Duration: 7200, WarmPods: 5}},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "executor type set to container",
+			testArgs: map[string]interface{}{
+				flagkey.FnExecutorType:   string(fv1.ExecutorTypeContainer),
+				flagkey.ReplicasMaxscale: 3,
+			},
+			existingInvokeStrategy: nil,
+			expectedResult: &fv1.InvokeStrategy{
+				StrategyType: fv1.StrategyTypeExecution,
+				ExecutionStrategy: fv1.ExecutionStrategy{
+					ExecutorType:          fv1.ExecutorTypeContainer,
+					MinScale:              1,
+					MaxScale:              3,
+					TargetCPUPercent:      80,
+					SpecializationTimeout: fv1.DefaultSpecializationTimeOut,
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "switch from target concurrency to target rps",
+			testArgs: map[string]interface{}{
+				flagkey.RuntimeTargetRPS: 50,
+			},
+			existingInvokeStrategy: &fv1.InvokeStrategy{
+				StrategyType: fv1.StrategyTypeExecution,
+				ExecutionStrategy: fv1.ExecutionStrategy{
+					ExecutorType:          fv1.ExecutorTypeNewdeploy,
+					MinScale:              0,
+					MaxScale:              5,
+					TargetCPUPercent:      DEFAULT_TARGET_CPU_PERCENTAGE,
+					TargetConcurrency:     10,
+					SpecializationTimeout: fv1.DefaultSpecializationTimeOut,
+				},
+			},
+			expectedResult: &fv1.InvokeStrategy{
+				StrategyType: fv1.StrategyTypeExecution,
+				ExecutionStrategy: fv1.ExecutionStrategy{
+					ExecutorType:          fv1.ExecutorTypeNewdeploy,
+					MinScale:              0,
+					MaxScale:              5,
+					TargetCPUPercent:      DEFAULT_TARGET_CPU_PERCENTAGE,
+					TargetRPS:             50,
+					SpecializationTimeout: fv1.DefaultSpecializationTimeOut,
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "target concurrency and target rps can't be both set",
+			testArgs: map[string]interface{}{
+				flagkey.FnExecutorType:           string(fv1.ExecutorTypeNewdeploy),
+				flagkey.RuntimeTargetConcurrency: 10,
+				flagkey.RuntimeTargetRPS:         50,
+			},
+			existingInvokeStrategy: nil,
+			expectedResult:         nil,
+			expectError:            true,
+		},
+		{
+			name: "target concurrency is not supported by poolmgr",
+			testArgs: map[string]interface{}{
+				flagkey.RuntimeTargetConcurrency: 10,
+			},
+			existingInvokeStrategy: nil,
+			expectedResult:         nil,
+			expectError:            true,
+		},
+		{
+			name: "warm pods are not supported by newdeploy",
+			testArgs: map[string]interface{}{
+				flagkey.FnExecutorType: string(fv1.ExecutorTypeNewdeploy),
+				flagkey.FnWarmPods:     2,
+			},
+			existingInvokeStrategy: nil,
+			expectedResult:         nil,
+			expectError:            true,
+		},
 	}
 
 	for _, c := range cases {
@@ -383,3 +479,29 @@ func TestGetInvokeStrategy(t *testing.T) {
 		})
 	}
 }
+
+func TestGetContainerPodSpec(t *testing.T) {
+	flags := dummy.TestFlagSet()
+	_, err := getContainerPodSpec(flags, "hello", nil)
+	assert.Error(t, err, "image is required")
+
+	flags.Set(flagkey.FnImage, "example/hello:v1")
+	podSpec, err := getContainerPodSpec(flags, "hello", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", podSpec.Containers[0].Name)
+	assert.Equal(t, "example/hello:v1", podSpec.Containers[0].Image)
+	assert.Empty(t, podSpec.Containers[0].Ports)
+
+	// update keeps the image and sets the port
+	flags = dummy.TestFlagSet()
+	flags.Set(flagkey.FnPort, 8080)
+	updated, err := getContainerPodSpec(flags, "hello", podSpec)
+	assert.NoError(t, err)
+	assert.Equal(t, "example/hello:v1", updated.Containers[0].Image)
+	assert.Equal(t, int32(8080), updated.Containers[0].Ports[0].ContainerPort)
+	assert.Empty(t, podSpec.Containers[0].Ports)
+
+	flags.Set(flagkey.FnPort, 0)
+	_, err = getContainerPodSpec(flags, "hello", podSpec)
+	assert.Error(t, err)
+}
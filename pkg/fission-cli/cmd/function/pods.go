@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+)
+
+type PodsSubCommand struct {
+	cmd.CommandActioner
+}
+
+// PodsList lists the function services cached by executor.
+func PodsList(input cli.Input) error {
+	return (&PodsSubCommand{}).list(input)
+}
+
+// PodsEvict removes the pods of a function, so that its next request gets a cold start.
+func PodsEvict(input cli.Input) error {
+	return (&PodsSubCommand{}).evict(input)
+}
+
+// PodsWarm specializes a pod for a function ahead of requests.
+func PodsWarm(input cli.Input) error {
+	return (&PodsSubCommand{}).warm(input)
+}
+
+// PodsPools lists the pools of generic pods of environments.
+func PodsPools(input cli.Input) error {
+	return (&PodsSubCommand{}).pools(input)
+}
+
+func (opts *PodsSubCommand) list(input cli.Input) error {
+	namespace := input.String(flagkey.NamespaceFunction)
+	if input.Bool(flagkey.AllNamespaces) {
+		namespace = metav1.NamespaceAll
+	}
+
+	fsvcs, err := opts.Client().V1().Executor().ListFunctionServices(namespace, input.String(flagkey.FnName))
+	if err != nil {
+		return errors.Wrap(err, "error listing function pods")
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", "FUNCTION", "NAMESPACE", "EXECUTORTYPE", "NAME", "ADDRESS", "ACTIVE", "DRAINING", "IDLE", "AGE", "REPLICA")
+	for _, fsvc := range fsvcs {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			fsvc.Function.Name, fsvc.Function.Namespace, fsvc.Executor, fsvc.Name, fsvc.Address,
+			fsvc.Active, fsvc.Draining,
+			now.Sub(fsvc.Atime).Round(time.Second),
+			now.Sub(fsvc.Ctime).Round(time.Second),
+			fsvc.Replica)
+	}
+	w.Flush()
+
+	return nil
+}
+
+func (opts *PodsSubCommand) evict(input cli.Input) error {
+	m := &metav1.ObjectMeta{
+		Name:      input.String(flagkey.FnName),
+		Namespace: input.String(flagkey.NamespaceFunction),
+	}
+
+	evicted, err := opts.Client().V1().Executor().EvictFunction(m)
+	if err != nil {
+		return errors.Wrapf(err, "error evicting pods of function '%v'", m.Name)
+	}
+
+	fmt.Printf("evicted %v function service(s) of function '%v'\n", evicted, m.Name)
+	return nil
+}
+
+func (opts *PodsSubCommand) warm(input cli.Input) error {
+	m := &metav1.ObjectMeta{
+		Name:      input.String(flagkey.FnName),
+		Namespace: input.String(flagkey.NamespaceFunction),
+	}
+
+	fsvc, err := opts.Client().V1().Executor().WarmFunction(m)
+	if err != nil {
+		return errors.Wrapf(err, "error warming function '%v'", m.Name)
+	}
+
+	fmt.Printf("function '%v' warmed, served by '%v' at %v\n", m.Name, fsvc.Name, fsvc.Address)
+	return nil
+}
+
+func (opts *PodsSubCommand) pools(input cli.Input) error {
+	envName := input.String(flagkey.FnEnvironmentName)
+
+	namespace := input.String(flagkey.NamespaceEnvironment)
+	if input.Bool(flagkey.AllNamespaces) {
+		namespace = metav1.NamespaceAll
+	}
+
+	pools, err := opts.Client().V1().Executor().ListPools(namespace)
+	if err != nil {
+		return errors.Wrap(err, "error listing environment pools")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", "ENVIRONMENT", "NAMESPACE", "DEPLOYMENT", "POOLSIZE", "READY", "SPECIALIZED", "RECENTSPECIALIZATIONS", "REPLICA")
+	for _, pool := range pools {
+		if len(envName) > 0 && pool.Environment.Name != envName {
+			continue
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			pool.Environment.Name, pool.Environment.Namespace, pool.Deployment, pool.Poolsize,
+			pool.ReadyPods, pool.SpecializedPods, pool.RecentSpecializations, pool.Replica)
+	}
+	w.Flush()
+
+	return nil
+}
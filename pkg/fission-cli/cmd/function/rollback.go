@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package function
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+)
+
+type RollbackSubCommand struct {
+	cmd.CommandActioner
+	function *fv1.Function
+}
+
+func Rollback(input cli.Input) error {
+	return (&RollbackSubCommand{}).do(input)
+}
+
+func (opts *RollbackSubCommand) do(input cli.Input) error {
+	err := opts.complete(input)
+	if err != nil {
+		return err
+	}
+	return opts.run(input)
+}
+
+// complete pins the package reference of the function to the revision,
+// after checking the package still retains it. Revision 0 unpins the
+// function, which then follows the latest build of its package again.
+func (opts *RollbackSubCommand) complete(input cli.Input) error {
+	fnName := input.String(flagkey.FnName)
+	revision := input.Int(flagkey.FnRollbackRevision)
+	if revision < 0 {
+		return errors.Errorf("--%v must be greater than or equal to 0", flagkey.FnRollbackRevision)
+	}
+
+	fn, err := opts.Client().V1().Function().Get(&metav1.ObjectMeta{
+		Name:      fnName,
+		Namespace: input.String(flagkey.NamespaceFunction),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error getting function %v", fnName)
+	}
+
+	if fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypeContainer {
+		return errors.Errorf("function %v of executor type \"%v\" has no package to roll back", fnName, fv1.ExecutorTypeContainer)
+	}
+
+	ref := fn.Spec.Package.PackageRef
+	pkg, err := opts.Client().V1().Package().Get(&metav1.ObjectMeta{
+		Name:      ref.Name,
+		Namespace: ref.Namespace,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error getting package %v of function %v", ref.Name, fnName)
+	}
+
+	if revision > 0 && pkg.Status.GetRevision(revision) == nil {
+		return errors.Errorf("package %v has no revision %v, see 'fission package history --%v %v' for its revisions",
+			pkg.ObjectMeta.Name, revision, flagkey.PkgName, pkg.ObjectMeta.Name)
+	}
+
+	// The function is updated with its resource version, so that the
+	// update fails rather than overwrite a concurrent one.
+	fn.Spec.Package.PackageRef.Revision = revision
+	fn.Spec.Package.PackageRef.ResourceVersion = pkg.ObjectMeta.ResourceVersion
+	opts.function = fn
+
+	return nil
+}
+
+func (opts *RollbackSubCommand) run(input cli.Input) error {
+	_, err := opts.Client().V1().Function().Update(opts.function)
+	if err != nil {
+		return errors.Wrap(err, "error updating function")
+	}
+
+	ref := opts.function.Spec.Package.PackageRef
+	if ref.Revision == 0 {
+		fmt.Printf("Function '%v' uses the latest build of package '%v'\n", opts.function.ObjectMeta.Name, ref.Name)
+	} else {
+		fmt.Printf("Function '%v' rolled back to revision %v of package '%v'\n", opts.function.ObjectMeta.Name, ref.Revision, ref.Name)
+	}
+	return nil
+}
@@ -163,6 +163,12 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 		function.Spec.Concurrency = input.Int(flagkey.FnConcurrency)
 	}
 
+	circuitBreaker, err := getCircuitBreaker(input, function.Spec.CircuitBreaker)
+	if err != nil {
+		return err
+	}
+	function.Spec.CircuitBreaker = circuitBreaker
+
 	if len(pkgName) == 0 {
 		pkgName = function.Spec.Package.PackageRef.Name
 	}
@@ -180,6 +186,23 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 
 	function.Spec.Resources = *resReqs
 
+	if function.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypeContainer {
+		// the function container is run as is, there's no package to update
+		podSpec, err := getContainerPodSpec(input, fnName, function.Spec.PodSpec)
+		if err != nil {
+			return err
+		}
+		function.Spec.PodSpec = podSpec
+		opts.function = function
+		return nil
+	}
+
+	if input.IsSet(flagkey.FnImage) || input.IsSet(flagkey.FnPort) {
+		return errors.Errorf("--%v and --%v are only supported by executor type \"%v\"", flagkey.FnImage, flagkey.FnPort, fv1.ExecutorTypeContainer)
+	}
+	// pod spec is only used by executor type container
+	function.Spec.PodSpec = nil
+
 	pkg, err := opts.Client().V1().Package().Get(&metav1.ObjectMeta{
 		Namespace: fnNamespace,
 		Name:      pkgName,
@@ -225,11 +248,20 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 	// TODO : One corner case where user just updates the pkg reference with fnUpdate, but internally this new pkg reference
 	// references a diff env than the spec
 
+	// a function rolled back to a package revision stays pinned to
+	// it, unless it gets another package or the package is updated
+	revision := function.Spec.Package.PackageRef.Revision
+	if newPkgMeta.Name != function.Spec.Package.PackageRef.Name ||
+		newPkgMeta.ResourceVersion != pkg.ObjectMeta.ResourceVersion {
+		revision = 0
+	}
+
 	// update function spec with new package metadata
 	function.Spec.Package.PackageRef = fv1.PackageRef{
 		Namespace:       newPkgMeta.Namespace,
 		Name:            newPkgMeta.Name,
 		ResourceVersion: newPkgMeta.ResourceVersion,
+		Revision:        revision,
 	}
 
 	if function.Spec.Environment.Name != pkg.Spec.Environment.Name {
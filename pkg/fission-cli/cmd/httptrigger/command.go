@@ -33,7 +33,7 @@ func Commands() *cobra.Command {
 		Required: []flag.Flag{flag.HtUrl, flag.HtFnName},
 		Optional: []flag.Flag{flag.HtName, flag.HtMethod, flag.HtIngress,
 			flag.HtIngressRule, flag.HtIngressAnnotation, flag.HtIngressTLS,
-			flag.HtFnWeight, flag.HtHost, flag.NamespaceFunction, flag.SpecSave, flag.SpecDry},
+			flag.HtFnWeight, flag.HtHost, flag.HtProtocol, flag.HtStreaming, flag.HtPingInterval, flag.HtRoutingRule, flag.HtStickyHeader, flag.HtMirror, flag.HtMirrorPercentage, flag.HtCacheTTL, flag.HtCacheHeader, flag.HtCacheQuery, flag.HtAuth, flag.HtAuthSecret, flag.HtAuthHeader, flag.HtJWTIssuer, flag.HtJWTAudience, flag.HtJWTClaim, flag.NamespaceFunction, flag.SpecSave, flag.SpecDry},
 	})
 
 	getCmd := &cobra.Command{
@@ -57,7 +57,7 @@ func Commands() *cobra.Command {
 		Required: []flag.Flag{flag.HtName},
 		Optional: []flag.Flag{flag.HtUrl, flag.HtFnName,
 			flag.HtMethod, flag.HtIngress, flag.HtIngressRule, flag.HtIngressAnnotation,
-			flag.HtIngressTLS, flag.HtFnWeight, flag.HtHost, flag.NamespaceTrigger},
+			flag.HtIngressTLS, flag.HtFnWeight, flag.HtHost, flag.HtProtocol, flag.HtStreaming, flag.HtPingInterval, flag.HtRoutingRule, flag.HtStickyHeader, flag.HtMirror, flag.HtMirrorPercentage, flag.HtCacheTTL, flag.HtCacheHeader, flag.HtCacheQuery, flag.HtAuth, flag.HtAuthSecret, flag.HtAuthHeader, flag.HtJWTIssuer, flag.HtJWTAudience, flag.HtJWTClaim, flag.NamespaceTrigger},
 	})
 
 	deleteCmd := &cobra.Command{
@@ -65,6 +65,11 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 		return err
 	}
 
+	err = setHtRoutingRules(input, functionRef)
+	if err != nil {
+		return err
+	}
+
 	triggerName := input.String(flagkey.HtName)
 	// just name triggers by uuid.
 	if len(triggerName) == 0 {
@@ -137,6 +142,47 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 
 	host := input.String(flagkey.HtHost)
 
+	protocol, err := GetProtocol(input.String(flagkey.HtProtocol))
+	if err != nil {
+		return err
+	}
+
+	var streaming *fv1.StreamingConfig
+	if input.Bool(flagkey.HtStreaming) {
+		streaming = &fv1.StreamingConfig{
+			PingInterval: input.Int(flagkey.HtPingInterval),
+		}
+	}
+
+	var mirror *fv1.MirrorConfig
+	if shadowFn := input.String(flagkey.HtMirror); len(shadowFn) > 0 {
+		mirror = &fv1.MirrorConfig{
+			FunctionName: shadowFn,
+			Percentage:   input.Int(flagkey.HtMirrorPercentage),
+		}
+	}
+
+	var cache *fv1.ResponseCache
+	if ttl := input.Int(flagkey.HtCacheTTL); ttl > 0 {
+		cache = &fv1.ResponseCache{
+			TTL:         ttl,
+			Headers:     input.StringSlice(flagkey.HtCacheHeader),
+			QueryParams: input.StringSlice(flagkey.HtCacheQuery),
+		}
+	}
+
+	var auth *fv1.TriggerAuth
+	if authType := input.String(flagkey.HtAuth); len(authType) > 0 && authType != "none" {
+		auth = &fv1.TriggerAuth{
+			Type:       fv1.TriggerAuthType(authType),
+			SecretName: input.String(flagkey.HtAuthSecret),
+			Header:     input.String(flagkey.HtAuthHeader),
+			Issuer:     input.String(flagkey.HtJWTIssuer),
+			Audience:   input.String(flagkey.HtJWTAudience),
+			Claims:     input.StringSlice(flagkey.HtJWTClaim),
+		}
+	}
+
 	opts.trigger = &fv1.HTTPTrigger{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      triggerName,
@@ -149,6 +195,11 @@ func (opts *CreateSubCommand) complete(input cli.Input) error {
 			FunctionReference: *functionRef,
 			CreateIngress:     createIngress,
 			IngressConfig:     *ingressConfig,
+			Protocol:          protocol,
+			Streaming:         streaming,
+			Mirror:            mirror,
+			Cache:             cache,
+			Auth:              auth,
 		},
 	}
 
@@ -207,6 +258,78 @@ func GetMethod(method string) (string, error) {
 	}
 }
 
+// GetProtocol returns the protocol for router to talk to function pod
+func GetProtocol(protocol string) (fv1.TriggerProtocol, error) {
+	switch fv1.TriggerProtocol(strings.ToLower(protocol)) {
+	case "":
+		return "", nil
+	case fv1.TriggerProtocolHTTP:
+		return fv1.TriggerProtocolHTTP, nil
+	case fv1.TriggerProtocolH2C:
+		return fv1.TriggerProtocolH2C, nil
+	default:
+		return "", fmt.Errorf("invalid or unsupported protocol %v", protocol)
+	}
+}
+
+// GetRoutingRules parses canary routing rules in the format of
+// "header|cookie|query:key[=value]:function".
+func GetRoutingRules(rules []string) ([]fv1.RoutingRule, error) {
+	routingRules := make([]fv1.RoutingRule, 0, len(rules))
+	for _, r := range rules {
+		parts := strings.SplitN(r, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid routing rule %v, expected format is header|cookie|query:key[=value]:function", r)
+		}
+		// function name cannot contain ":", so the last ":"
+		// separates function name from the value to match.
+		i := strings.LastIndex(parts[1], ":")
+		if i <= 0 || i == len(parts[1])-1 {
+			return nil, fmt.Errorf("invalid routing rule %v, expected format is header|cookie|query:key[=value]:function", r)
+		}
+		rule := fv1.RoutingRule{
+			Match:        fv1.RoutingRuleMatchType(strings.ToLower(parts[0])),
+			FunctionName: parts[1][i+1:],
+		}
+		kv := strings.SplitN(parts[1][:i], "=", 2)
+		rule.Key = kv[0]
+		if len(kv) == 2 {
+			rule.Value = kv[1]
+		}
+		err := rule.Validate()
+		if err != nil {
+			return nil, err
+		}
+		routingRules = append(routingRules, rule)
+	}
+	return routingRules, nil
+}
+
+// setHtRoutingRules sets canary routing rules and sticky session to the function reference.
+func setHtRoutingRules(input cli.Input, ref *fv1.FunctionReference) error {
+	if input.IsSet(flagkey.HtRoutingRule) {
+		rules, err := GetRoutingRules(input.StringSlice(flagkey.HtRoutingRule))
+		if err != nil {
+			return err
+		}
+		ref.Rules = rules
+	}
+
+	if input.IsSet(flagkey.HtStickyHeader) {
+		if header := input.String(flagkey.HtStickyHeader); len(header) > 0 {
+			ref.StickySession = &fv1.StickySession{Header: header}
+		} else {
+			ref.StickySession = nil
+		}
+	}
+
+	if ref.Type != fv1.FunctionReferenceTypeFunctionWeights && (len(ref.Rules) > 0 || ref.StickySession != nil) {
+		return errors.New("routing rules and sticky session only apply to canary triggers, use --function and --weight to set two functions")
+	}
+
+	return nil
+}
+
 func setHtFunctionRef(functionList []string, functionWeightsList []int) (*fv1.FunctionReference, error) {
 	if len(functionList) == 1 {
 		return &fv1.FunctionReference{
@@ -92,9 +92,22 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 			return errors.Wrap(err, "error setting function weight")
 		}
 
+		// keep routing rules of canary trigger unless they are changed below
+		if functionRef.Type == fv1.FunctionReferenceTypeFunctionWeights {
+			functionRef.Rules = ht.Spec.FunctionReference.Rules
+			functionRef.StickySession = ht.Spec.FunctionReference.StickySession
+		}
+
 		ht.Spec.FunctionReference = *functionRef
 	}
 
+	if input.IsSet(flagkey.HtRoutingRule) || input.IsSet(flagkey.HtStickyHeader) {
+		err = setHtRoutingRules(input, &ht.Spec.FunctionReference)
+		if err != nil {
+			return err
+		}
+	}
+
 	if input.IsSet(flagkey.HtIngress) {
 		ht.Spec.CreateIngress = input.Bool(flagkey.HtIngress)
 	}
@@ -103,6 +116,101 @@ func (opts *UpdateSubCommand) complete(input cli.Input) error {
 		ht.Spec.Host = input.String(flagkey.HtHost)
 	}
 
+	if input.IsSet(flagkey.HtProtocol) {
+		protocol, err := GetProtocol(input.String(flagkey.HtProtocol))
+		if err != nil {
+			return err
+		}
+		ht.Spec.Protocol = protocol
+	}
+
+	if input.IsSet(flagkey.HtStreaming) {
+		if !input.Bool(flagkey.HtStreaming) {
+			ht.Spec.Streaming = nil
+		} else if ht.Spec.Streaming == nil {
+			ht.Spec.Streaming = &fv1.StreamingConfig{}
+		}
+	}
+
+	if input.IsSet(flagkey.HtPingInterval) {
+		if ht.Spec.Streaming == nil {
+			return errors.New("ping interval only applies to triggers with streaming enabled, use --streaming to enable it")
+		}
+		ht.Spec.Streaming.PingInterval = input.Int(flagkey.HtPingInterval)
+	}
+
+	if input.IsSet(flagkey.HtMirror) {
+		if shadowFn := input.String(flagkey.HtMirror); len(shadowFn) > 0 {
+			ht.Spec.Mirror = &fv1.MirrorConfig{
+				FunctionName: shadowFn,
+				Percentage:   input.Int(flagkey.HtMirrorPercentage),
+			}
+		} else {
+			ht.Spec.Mirror = nil
+		}
+	} else if input.IsSet(flagkey.HtMirrorPercentage) {
+		if ht.Spec.Mirror == nil {
+			return errors.New("trigger has no shadow function, use --mirror to set one")
+		}
+		ht.Spec.Mirror.Percentage = input.Int(flagkey.HtMirrorPercentage)
+	}
+
+	if input.IsSet(flagkey.HtCacheTTL) {
+		if ttl := input.Int(flagkey.HtCacheTTL); ttl > 0 {
+			if ht.Spec.Cache == nil {
+				ht.Spec.Cache = &fv1.ResponseCache{}
+			}
+			ht.Spec.Cache.TTL = ttl
+		} else {
+			ht.Spec.Cache = nil
+		}
+	}
+
+	if input.IsSet(flagkey.HtCacheHeader) || input.IsSet(flagkey.HtCacheQuery) {
+		if ht.Spec.Cache == nil {
+			return errors.New("response caching is not enabled for the trigger, use --cachettl to enable it")
+		}
+		if input.IsSet(flagkey.HtCacheHeader) {
+			ht.Spec.Cache.Headers = input.StringSlice(flagkey.HtCacheHeader)
+		}
+		if input.IsSet(flagkey.HtCacheQuery) {
+			ht.Spec.Cache.QueryParams = input.StringSlice(flagkey.HtCacheQuery)
+		}
+	}
+
+	if input.IsSet(flagkey.HtAuth) {
+		if authType := input.String(flagkey.HtAuth); len(authType) > 0 && authType != "none" {
+			if ht.Spec.Auth == nil {
+				ht.Spec.Auth = &fv1.TriggerAuth{}
+			}
+			ht.Spec.Auth.Type = fv1.TriggerAuthType(authType)
+		} else {
+			ht.Spec.Auth = nil
+		}
+	}
+
+	if input.IsSet(flagkey.HtAuthSecret) || input.IsSet(flagkey.HtAuthHeader) || input.IsSet(flagkey.HtJWTIssuer) ||
+		input.IsSet(flagkey.HtJWTAudience) || input.IsSet(flagkey.HtJWTClaim) {
+		if ht.Spec.Auth == nil {
+			return errors.New("authentication is not enabled for the trigger, use --auth to enable it")
+		}
+		if input.IsSet(flagkey.HtAuthSecret) {
+			ht.Spec.Auth.SecretName = input.String(flagkey.HtAuthSecret)
+		}
+		if input.IsSet(flagkey.HtAuthHeader) {
+			ht.Spec.Auth.Header = input.String(flagkey.HtAuthHeader)
+		}
+		if input.IsSet(flagkey.HtJWTIssuer) {
+			ht.Spec.Auth.Issuer = input.String(flagkey.HtJWTIssuer)
+		}
+		if input.IsSet(flagkey.HtJWTAudience) {
+			ht.Spec.Auth.Audience = input.String(flagkey.HtJWTAudience)
+		}
+		if input.IsSet(flagkey.HtJWTClaim) {
+			ht.Spec.Auth.Claims = input.StringSlice(flagkey.HtJWTClaim)
+		}
+	}
+
 	if input.IsSet(flagkey.HtIngressRule) || input.IsSet(flagkey.HtIngressAnnotation) || input.IsSet(flagkey.HtIngressTLS) {
 		ingress, err := GetIngressConfig(
 			input.StringSlice(flagkey.HtIngressAnnotation), input.String(flagkey.HtIngressRule),
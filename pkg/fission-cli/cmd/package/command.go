@@ -32,7 +32,7 @@ func Commands() *cobra.Command {
 	wrapper.SetFlags(createCmd, flag.FlagSet{
 		Required: []flag.Flag{flag.PkgEnvironment},
 		Optional: []flag.Flag{flag.PkgName, flag.PkgCode, flag.PkgSrcArchive, flag.PkgDeployArchive,
-			flag.PkgSrcChecksum, flag.PkgDeployChecksum, flag.PkgInsecure, flag.PkgBuildCmd,
+			flag.PkgSrcChecksum, flag.PkgDeployChecksum, flag.PkgInsecure, flag.PkgBuildCmd, flag.PkgBuildTimeout,
 			flag.NamespacePackage, flag.NamespaceEnvironment, flag.SpecSave, flag.SpecDry},
 	})
 
@@ -64,7 +64,7 @@ func Commands() *cobra.Command {
 	wrapper.SetFlags(updateCmd, flag.FlagSet{
 		Required: []flag.Flag{flag.PkgName},
 		Optional: []flag.Flag{flag.PkgEnvironment, flag.PkgCode, flag.PkgSrcArchive, flag.PkgDeployArchive,
-			flag.PkgSrcChecksum, flag.PkgDeployChecksum, flag.PkgInsecure, flag.PkgBuildCmd, flag.PkgForce,
+			flag.PkgSrcChecksum, flag.PkgDeployChecksum, flag.PkgInsecure, flag.PkgBuildCmd, flag.PkgBuildTimeout, flag.PkgForce,
 			flag.NamespacePackage, flag.NamespaceEnvironment},
 	})
 
@@ -97,6 +97,26 @@ func Commands() *cobra.Command {
 		Optional: []flag.Flag{flag.NamespacePackage},
 	})
 
+	logsCmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show build logs of a package",
+		RunE:  wrapper.Wrapper(Logs),
+	}
+	wrapper.SetFlags(logsCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.PkgName},
+		Optional: []flag.Flag{flag.PkgLogFollow, flag.PkgLogStep, flag.NamespacePackage},
+	})
+
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the revisions of a package recorded by its builds",
+		RunE:  wrapper.Wrapper(History),
+	}
+	wrapper.SetFlags(historyCmd, flag.FlagSet{
+		Required: []flag.Flag{flag.PkgName},
+		Optional: []flag.Flag{flag.NamespacePackage},
+	})
+
 	rebuildCmd := &cobra.Command{
 		Use:   "rebuild",
 		Short: "Rebuild a failed package",
@@ -113,7 +133,7 @@ func Commands() *cobra.Command {
 		Short:   "Create, update and manage packages",
 	}
 
-	command.AddCommand(createCmd, getSrcCmd, getDeployCmd, updateCmd, deleteCmd, listCmd, infoCmd, rebuildCmd)
+	command.AddCommand(createCmd, getSrcCmd, getDeployCmd, updateCmd, deleteCmd, listCmd, infoCmd, logsCmd, historyCmd, rebuildCmd)
 
 	return command
 }
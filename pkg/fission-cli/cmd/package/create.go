@@ -159,6 +159,10 @@ func CreatePackage(input cli.Input, client client.Interface, pkgName string, pkg
 		pkgSpec.BuildCommand = buildcmd
 	}
 
+	if input.IsSet(flagkey.PkgBuildTimeout) {
+		pkgSpec.BuildTimeout = input.Int(flagkey.PkgBuildTimeout)
+	}
+
 	if len(pkgName) == 0 {
 		pkgName = strings.ToLower(uuid.NewV4().String())
 	}
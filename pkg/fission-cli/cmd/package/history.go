@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package _package
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+)
+
+type HistorySubCommand struct {
+	cmd.CommandActioner
+	name      string
+	namespace string
+}
+
+func History(input cli.Input) error {
+	return (&HistorySubCommand{}).do(input)
+}
+
+func (opts *HistorySubCommand) do(input cli.Input) error {
+	err := opts.complete(input)
+	if err != nil {
+		return err
+	}
+	return opts.run(input)
+}
+
+func (opts *HistorySubCommand) complete(input cli.Input) error {
+	opts.name = input.String(flagkey.PkgName)
+	opts.namespace = input.String(flagkey.NamespacePackage)
+	return nil
+}
+
+// run prints the retained revisions of the package, newest first, with the
+// functions pinned to each of them. The revision of the current deployment
+// archive of the package is marked as current.
+func (opts *HistorySubCommand) run(input cli.Input) error {
+	pkg, err := opts.Client().V1().Package().Get(&metav1.ObjectMeta{
+		Namespace: opts.namespace,
+		Name:      opts.name,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error finding package %s", opts.name)
+	}
+
+	fnList, err := GetFunctionsByPackage(opts.Client(), pkg.ObjectMeta.Name, pkg.ObjectMeta.Namespace)
+	if err != nil {
+		return errors.Wrapf(err, "error getting functions of package %s", opts.name)
+	}
+	pinned := make(map[int][]string)
+	for _, fn := range fnList {
+		if rev := fn.Spec.Package.PackageRef.Revision; rev > 0 {
+			pinned[rev] = append(pinned[rev], fn.ObjectMeta.Name)
+		}
+	}
+
+	if len(pkg.Status.Revisions) == 0 {
+		fmt.Printf("Package %v has no revisions, revisions are recorded by successful builds\n", pkg.ObjectMeta.Name)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", "REVISION", "BUILTAT", "CHECKSUM", "CURRENT", "PINNED_FUNCTIONS")
+
+	for i := len(pkg.Status.Revisions) - 1; i >= 0; i-- {
+		revision := pkg.Status.Revisions[i]
+		current := ""
+		if revision.Deployment.URL == pkg.Spec.Deployment.URL {
+			current = "*"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", revision.Revision, revision.Timestamp.Format(time.RFC822),
+			revision.Deployment.Checksum.Sum, current, strings.Join(pinned[revision.Revision], ","))
+	}
+
+	w.Flush()
+
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package _package
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/fission-cli/cliwrapper/cli"
+	"github.com/fission/fission/pkg/fission-cli/cmd"
+	flagkey "github.com/fission/fission/pkg/fission-cli/flag/key"
+)
+
+type LogsSubCommand struct {
+	cmd.CommandActioner
+	name      string
+	namespace string
+	follow    bool
+	step      string
+}
+
+func Logs(input cli.Input) error {
+	return (&LogsSubCommand{}).do(input)
+}
+
+func (opts *LogsSubCommand) do(input cli.Input) error {
+	err := opts.complete(input)
+	if err != nil {
+		return err
+	}
+	return opts.run(input)
+}
+
+func (opts *LogsSubCommand) complete(input cli.Input) error {
+	opts.name = input.String(flagkey.PkgName)
+	opts.namespace = input.String(flagkey.NamespacePackage)
+	opts.follow = input.Bool(flagkey.PkgLogFollow)
+	opts.step = input.String(flagkey.PkgLogStep)
+	return nil
+}
+
+// run prints the build logs of the package. When following, the build log
+// the builder manager updates while the package builds is polled until the
+// build completes.
+func (opts *LogsSubCommand) run(input cli.Input) error {
+	m := &metav1.ObjectMeta{
+		Namespace: opts.namespace,
+		Name:      opts.name,
+	}
+
+	var printed string
+	for {
+		pkg, err := opts.Client().V1().Package().Get(m)
+		if err != nil {
+			return errors.Wrapf(err, "error finding package %s", opts.name)
+		}
+
+		buildLog := pkg.Status.BuildLog
+		if len(opts.step) > 0 {
+			buildLog = ""
+			found := false
+			for _, step := range pkg.Status.Steps {
+				if step.Name == opts.step {
+					buildLog, found = step.Log, true
+					break
+				}
+			}
+			if !found && !opts.isBuilding(pkg) {
+				return errors.Errorf("package %s has no build step %s", opts.name, opts.step)
+			}
+		}
+
+		// replace escaped line breaker character
+		buildLog = strings.ReplaceAll(buildLog, `\n`, "\n")
+		if strings.HasPrefix(buildLog, printed) {
+			fmt.Print(buildLog[len(printed):])
+		} else {
+			// the package is being built again
+			fmt.Print(buildLog)
+		}
+		printed = buildLog
+
+		if !opts.follow {
+			return nil
+		}
+		if !opts.isBuilding(pkg) {
+			fmt.Printf("Package %v build status: %v\n", pkg.ObjectMeta.Name, pkg.Status.BuildStatus)
+			return nil
+		}
+
+		time.Sleep(1 * time.Second)
+	}
+}
+
+func (opts *LogsSubCommand) isBuilding(pkg *fv1.Package) bool {
+	return pkg.Status.BuildStatus == fv1.BuildStatusPending ||
+		pkg.Status.BuildStatus == fv1.BuildStatusQueued ||
+		pkg.Status.BuildStatus == fv1.BuildStatusRunning
+}
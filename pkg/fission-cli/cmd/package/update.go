@@ -131,6 +131,11 @@ func UpdatePackage(input cli.Input, client client.Interface, pkg *fv1.Package) (
 		needToUpdate = true
 	}
 
+	if input.IsSet(flagkey.PkgBuildTimeout) {
+		pkg.Spec.BuildTimeout = input.Int(flagkey.PkgBuildTimeout)
+		needToUpdate = true
+	}
+
 	if input.IsSet(flagkey.PkgSrcArchive) {
 		srcArchive, err := CreateArchive(client, input, srcArchiveFiles, noZip, insecure, srcChecksum, "", "")
 		if err != nil {
@@ -174,6 +179,7 @@ func UpdatePackage(input cli.Input, client client.Interface, pkg *fv1.Package) (
 		// change into pending state to trigger package build
 		pkg.Status = fv1.PackageStatus{
 			BuildStatus:         fv1.BuildStatusPending,
+			Revisions:           pkg.Status.Revisions,
 			LastUpdateTimestamp: metav1.Time{Time: time.Now().UTC()},
 		}
 	}
@@ -191,8 +197,12 @@ func UpdatePackage(input cli.Input, client client.Interface, pkg *fv1.Package) (
 func UpdateFunctionPackageResourceVersion(client client.Interface, pkgMeta *metav1.ObjectMeta, fnList ...fv1.Function) error {
 	errs := &multierror.Error{}
 
-	// update resource version of package reference of functions that shared the same package
+	// update resource version of package reference of functions that shared the same package,
+	// except the ones pinned to a revision of the package
 	for _, fn := range fnList {
+		if fn.Spec.Package.PackageRef.Revision > 0 {
+			continue
+		}
 		fn.Spec.Package.PackageRef.ResourceVersion = pkgMeta.ResourceVersion
 		_, err := client.V1().Function().Update(&fn)
 		if err != nil {
@@ -208,6 +218,7 @@ func updatePackageStatus(client client.Interface, pkg *fv1.Package, status fv1.B
 	case fv1.BuildStatusNone, fv1.BuildStatusPending, fv1.BuildStatusRunning, fv1.BuildStatusSucceeded, fv1.CanaryConfigStatusAborted:
 		pkg.Status = fv1.PackageStatus{
 			BuildStatus:         status,
+			Revisions:           pkg.Status.Revisions,
 			LastUpdateTimestamp: metav1.Time{Time: time.Now().UTC()},
 		}
 		pkg, err := client.V1().Package().Update(pkg)
@@ -184,6 +184,15 @@ func PrintPackageSummary(writer io.Writer, pkg *fv1.Package) {
 	fmt.Fprintf(w, "%v\t%v\n", "Name:", pkg.ObjectMeta.Name)
 	fmt.Fprintf(w, "%v\t%v\n", "Environment:", pkg.Spec.Environment.Name)
 	fmt.Fprintf(w, "%v\t%v\n", "Status:", pkg.Status.BuildStatus)
+	if latest := pkg.Status.LatestRevision(); latest > 0 {
+		fmt.Fprintf(w, "%v\t%v\n", "Latest Revision:", latest)
+	}
+	if len(pkg.Status.Steps) > 0 {
+		fmt.Fprintf(w, "%v\n", "Build Steps:")
+		for _, step := range pkg.Status.Steps {
+			fmt.Fprintf(w, "  %v\t%v\n", step.Name, step.Status)
+		}
+	}
 	fmt.Fprintf(w, "%v\n%v", "Build Logs:", buildlog)
 	w.Flush()
 }
@@ -350,6 +350,10 @@ func applyResources(fclient client.Interface, specDir string, fr *FissionResourc
 	// of the package. This ensures that various caches can invalidate themselves
 	// when the package changes.
 	for i, f := range fr.Functions {
+		// functions of executor type container have no package
+		if f.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypeContainer {
+			continue
+		}
 		k := mapKey(&metav1.ObjectMeta{
 			Namespace: f.Spec.Package.PackageRef.Namespace,
 			Name:      f.Spec.Package.PackageRef.Name,
@@ -522,7 +526,8 @@ func hasDeploymentConfig(m *metav1.ObjectMeta, fr *FissionResources) bool {
 func waitForPackageBuild(fclient client.Interface, pkg *fv1.Package) (*fv1.Package, error) {
 	start := time.Now()
 	for {
-		if pkg.Status.BuildStatus != fv1.BuildStatusRunning {
+		if pkg.Status.BuildStatus != fv1.BuildStatusQueued &&
+			pkg.Status.BuildStatus != fv1.BuildStatusRunning {
 			return pkg, nil
 		}
 		if time.Since(start) > 5*time.Minute {
@@ -585,7 +590,9 @@ func applyPackages(fclient client.Interface, fr *FissionResources, delete bool)
 			} else if reflect.DeepEqual(existingObj.Spec.Environment, o.Spec.Environment) &&
 				!reflect.DeepEqual(existingObj.Spec.Source, fv1.Archive{}) &&
 				reflect.DeepEqual(existingObj.Spec.Source, o.Spec.Source) &&
-				existingObj.Spec.BuildCommand == o.Spec.BuildCommand {
+				existingObj.Spec.BuildCommand == o.Spec.BuildCommand &&
+				existingObj.Spec.BuildTimeout == o.Spec.BuildTimeout &&
+				reflect.DeepEqual(existingObj.Spec.BuildSteps, o.Spec.BuildSteps) {
 
 				keep = true
 			}
@@ -597,6 +604,9 @@ func applyPackages(fclient client.Interface, fr *FissionResources, delete bool)
 				// update
 				o.ObjectMeta.ResourceVersion = existingObj.ObjectMeta.ResourceVersion
 
+				// revisions are recorded by builds, not in specs
+				o.Status.Revisions = existingObj.Status.Revisions
+
 				// We may be racing against the package builder to update the
 				// package (a previous version might have been getting built).  So,
 				// wait for the package to have a non-running build status.
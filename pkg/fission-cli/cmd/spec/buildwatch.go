@@ -86,6 +86,7 @@ func (w *packageBuildWatcher) watch(ctx context.Context) {
 				continue
 			}
 			if pkg.Status.BuildStatus == fv1.BuildStatusPending ||
+				pkg.Status.BuildStatus == fv1.BuildStatusQueued ||
 				pkg.Status.BuildStatus == fv1.BuildStatusRunning {
 				keepWaiting = true
 			}
@@ -77,6 +77,7 @@ var (
 	NamespaceFunction    = Flag{Type: String, Name: flagkey.NamespaceFunction, Aliases: []string{"fns"}, Usage: "Namespace for function object", DefaultValue: metav1.NamespaceDefault}
 	NamespaceEnvironment = Flag{Type: String, Name: flagkey.NamespaceEnvironment, Aliases: []string{"envns"}, Usage: "Namespace for environment object", DefaultValue: metav1.NamespaceDefault}
 	NamespacePackage     = Flag{Type: String, Name: flagkey.NamespacePackage, Aliases: []string{"pkgns"}, Usage: "Namespace for package object", DefaultValue: metav1.NamespaceDefault}
+	AllNamespaces        = Flag{Type: Bool, Name: flagkey.AllNamespaces, Short: "A", Usage: "Include the objects of all namespaces, the namespace flags are ignored"}
 	NamespaceTrigger     = Flag{Type: String, Name: flagkey.NamespaceTrigger, Aliases: []string{"triggerns"}, Usage: "Namespace for trigger object", DefaultValue: metav1.NamespaceDefault}
 	NamespaceCanary      = Flag{Type: String, Name: flagkey.NamespaceCanary, Aliases: []string{"canaryns"}, Usage: "Namespace for canary config object", DefaultValue: metav1.NamespaceDefault}
 
@@ -86,6 +87,9 @@ var (
 	RunTimeMinMemory = Flag{Type: Int, Name: flagkey.RuntimeMinmemory, Usage: "Minimum memory to be assigned to pod (In megabyte)"}
 	RunTimeMaxMemory = Flag{Type: Int, Name: flagkey.RuntimeMaxmemory, Usage: "Maximum memory to be assigned to pod (In megabyte)"}
 
+	RunTimeTargetConcurrency = Flag{Type: Int, Name: flagkey.RuntimeTargetConcurrency, Usage: "Target average in-flight requests per pod for scaling, instead of target CPU usage"}
+	RunTimeTargetRPS         = Flag{Type: Int, Name: flagkey.RuntimeTargetRPS, Usage: "Target average requests per second per pod for scaling, instead of target CPU usage"}
+
 	ReplicasMin = Flag{Type: Int, Name: flagkey.ReplicasMinscale, Usage: "Minimum number of pods (Uses resource inputs to configure HPA)", DefaultValue: 1}
 	ReplicasMax = Flag{Type: Int, Name: flagkey.ReplicasMaxscale, Usage: "Maximum number of pods (Uses resource inputs to configure HPA)", DefaultValue: 1}
 
@@ -97,7 +101,7 @@ var (
 	FnBuildCmd              = Flag{Type: String, Name: flagkey.FnBuildCmd, Usage: "Package build command for builder to run with"}
 	FnSecret                = Flag{Type: StringSlice, Name: flagkey.FnSecret, Usage: "Function access to secret, should be present in the same namespace as the function. You can provide multiple secrets using multiple --secrets flags. In the case of fn update the the secrets will be replaced by the provided list of secrets."}
 	FnCfgMap                = Flag{Type: StringSlice, Name: flagkey.FnCfgMap, Usage: "Function access to configmap, should be present in the same namespace as the function. You can provide multiple configmaps using multiple --configmap flags. In case of fn update the configmaps will be replaced by the provided list of configmaps."}
-	FnExecutorType          = Flag{Type: String, Name: flagkey.FnExecutorType, Usage: "Executor type for execution; one of 'poolmgr', 'newdeploy'", DefaultValue: string(fv1.ExecutorTypePoolmgr)}
+	FnExecutorType          = Flag{Type: String, Name: flagkey.FnExecutorType, Usage: "Executor type for execution; one of 'poolmgr', 'newdeploy', 'container'", DefaultValue: string(fv1.ExecutorTypePoolmgr)}
 	FnExecutionTimeout      = Flag{Type: Int, Name: flagkey.FnExecutionTimeout, Aliases: []string{"ft"}, Usage: "Maximum time for a request to wait for the response from the function", DefaultValue: 60}
 	FnLogPod                = Flag{Type: String, Name: flagkey.FnLogPod, Usage: "Function pod name (use the latest pod name if unspecified)"}
 	FnLogFollow             = Flag{Type: Bool, Name: flagkey.FnLogFollow, Short: "f", Usage: "Specify if the logs should be streamed"}
@@ -112,7 +116,16 @@ var (
 	FnTestHeader            = Flag{Type: StringSlice, Name: flagkey.FnTestHeader, Short: "H", Usage: "Request headers"}
 	FnTestQuery             = Flag{Type: StringSlice, Name: flagkey.FnTestQuery, Short: "q", Usage: "Request query parameters: -q key1=value1 -q key2=value2"}
 	FnIdleTimeout           = Flag{Type: Int, Name: flagkey.FnIdleTimeout, Usage: "The length of time (in seconds) that a function is idle before pod(s) are eligible for recycling", DefaultValue: 120}
+	FnCircuitBreaker        = Flag{Type: Bool, Name: flagkey.FnCircuitBreaker, Usage: "Enable circuit breaker in router to fail fast with 503 when the function keeps failing"}
+	FnBreakerFailures       = Flag{Type: Int, Name: flagkey.FnBreakerFailures, Usage: "Number of consecutive failures to open the circuit breaker, defaults to 5"}
+	FnBreakerOpenDuration   = Flag{Type: Int, Name: flagkey.FnBreakerOpenDuration, Usage: "Time (in seconds) the circuit breaker stays open before letting trial requests through, defaults to 30"}
+	FnBreakerHalfOpen       = Flag{Type: Int, Name: flagkey.FnBreakerHalfOpen, Usage: "Number of trial requests that must succeed to close the circuit breaker, defaults to 1"}
 	FnConcurrency           = Flag{Type: Int, Name: flagkey.FnConcurrency, Aliases: []string{"con"}, Usage: "Maximum number of pods specialized concurrently to serve requests", DefaultValue: 5}
+	FnWarmPods              = Flag{Type: Int, Name: flagkey.FnWarmPods, Usage: "Number of specialized pods kept for the function at all times (poolmgr only)"}
+	FnImage                 = Flag{Type: String, Name: flagkey.FnImage, Usage: "Image of the function container, which serves the function over HTTP (container only)"}
+	FnPort                  = Flag{Type: Int, Name: flagkey.FnPort, Usage: "Port the function container listens on (container only), defaults to 8888"}
+	FnRollbackRevision      = Flag{Type: Int, Name: flagkey.FnRollbackRevision, Usage: "Revision of the function package to roll back to (see fission package history), 0 for the latest build"}
+	FnWarmup                = Flag{Type: StringSlice, Name: flagkey.FnWarmup, Usage: "Keep specialized pods during a time window (poolmgr only): --warmup pods:duration:cron, where cron has the same format as time trigger, e.g. --warmup \"5:2h:0 0 8 * * 1-5\" keeps 5 pods for 2 hours from 8am on weekdays, can be specified multiple times"}
 
 	HtName              = Flag{Type: String, Name: flagkey.HtName, Usage: "HTTP trigger name"}
 	HtMethod            = Flag{Type: String, Name: flagkey.HtMethod, Usage: "HTTP Method: GET|POST|PUT|DELETE|HEAD", DefaultValue: http.MethodGet}
@@ -125,6 +138,22 @@ var (
 	HtFnName            = Flag{Type: StringSlice, Name: flagkey.HtFnName, Usage: "Name(s) of the function for this trigger. (If 2 functions are supplied with this flag, traffic gets routed to them based on weights supplied with --weight flag.)"}
 	HtFnWeight          = Flag{Type: IntSlice, Name: flagkey.HtFnWeight, Usage: "Weight for each function supplied with --function flag, in the same order. Used for canary deployment"}
 	HtFnFilter          = Flag{Type: String, Name: flagkey.HtFilter, Usage: "Name of the function for trigger(s)"}
+	HtProtocol          = Flag{Type: String, Name: flagkey.HtProtocol, Usage: "Protocol for router to talk to function pod: http|h2c (use h2c for gRPC services)"}
+	HtStreaming         = Flag{Type: Bool, Name: flagkey.HtStreaming, Usage: "Enable long-lived WebSocket and server-sent events connections, which are not bound by function timeout"}
+	HtPingInterval      = Flag{Type: Int, Name: flagkey.HtPingInterval, Usage: "Interval (in seconds) for router to ping idle streaming connections, defaults to 30 seconds"}
+	HtRoutingRule       = Flag{Type: StringSlice, Name: flagkey.HtRoutingRule, Usage: "Route canary requests matching a header, cookie or query parameter to a function: --routingrule header|cookie|query:key[=value]:function (evaluated in order, value is optional)"}
+	HtStickyHeader      = Flag{Type: String, Name: flagkey.HtStickyHeader, Usage: "Name of request header identifying a session, requests with the same header value reach the same canary function"}
+	HtMirror            = Flag{Type: String, Name: flagkey.HtMirror, Usage: "Name of the shadow function receiving a copy of requests, the responses of shadow function are discarded"}
+	HtMirrorPercentage  = Flag{Type: Int, Name: flagkey.HtMirrorPercentage, Usage: "Percentage of requests to mirror to the shadow function (1-100)", DefaultValue: 100}
+	HtCacheTTL          = Flag{Type: Int, Name: flagkey.HtCacheTTL, Usage: "Time (in seconds) for router to cache successful responses of GET requests, 0 disables response caching"}
+	HtCacheHeader       = Flag{Type: StringSlice, Name: flagkey.HtCacheHeader, Usage: "Request header that is part of the response cache key, can be specified multiple times"}
+	HtAuth              = Flag{Type: String, Name: flagkey.HtAuth, Usage: "Authentication method of the trigger: jwt|apikey|hmac (use \"none\" to disable authentication in update)"}
+	HtAuthSecret        = Flag{Type: String, Name: flagkey.HtAuthSecret, Usage: "Name of the secret holding the credentials: the \"jwks\" key for jwt, client name to API key pairs for apikey, the \"key\" key for hmac"}
+	HtAuthHeader        = Flag{Type: String, Name: flagkey.HtAuthHeader, Usage: "Request header carrying the credential, defaults to Authorization for jwt, X-Api-Key for apikey and X-Fission-Signature for hmac"}
+	HtJWTIssuer         = Flag{Type: String, Name: flagkey.HtJWTIssuer, Usage: "Required issuer (iss claim) of JWTs"}
+	HtJWTAudience       = Flag{Type: String, Name: flagkey.HtJWTAudience, Usage: "Required audience (aud claim) of JWTs"}
+	HtJWTClaim          = Flag{Type: StringSlice, Name: flagkey.HtJWTClaim, Usage: "JWT claim passed to the function in X-Fission-Auth-Claim-* header, can be specified multiple times"}
+	HtCacheQuery        = Flag{Type: StringSlice, Name: flagkey.HtCacheQuery, Usage: "Query parameter that is part of the response cache key, can be specified multiple times (defaults to the whole query string)"}
 
 	TtName    = Flag{Type: String, Name: flagkey.TtName, Usage: "Time Trigger name"}
 	TtCron    = Flag{Type: String, Name: flagkey.TtCron, Usage: "Time trigger cron spec with each asterisk representing respectively second, minute, hour, the day of the month, month and day of the week. Also supports readable formats like '@every 5m', '@hourly'"}
@@ -153,11 +182,16 @@ var (
 	EnvImage                  = Flag{Type: String, Name: flagkey.EnvImage, Usage: "Environment image URL"}
 	EnvBuilderImage           = Flag{Type: String, Name: flagkey.EnvBuilderImage, Usage: "Environment builder image URL"}
 	EnvBuildCmd               = Flag{Type: String, Name: flagkey.EnvBuildcommand, Usage: "Build command for environment builder to build source package"}
+	EnvBuildTimeout           = Flag{Type: Int, Name: flagkey.EnvBuildTimeout, Usage: "Maximum time in seconds a package build of the environment may run, 0 for the default of builder manager"}
 	EnvKeepArchive            = Flag{Type: Bool, Name: flagkey.EnvKeeparchive, Usage: "Keep the archive instead of extracting it into a directory (mainly for the JVM environment because .jar is one kind of zip archive)"}
 	EnvExternalNetwork        = Flag{Type: Bool, Name: flagkey.EnvExternalNetwork, Usage: "Allow pod to access external network (only works when istio feature is enabled)"}
 	EnvTerminationGracePeriod = Flag{Type: Int64, Name: flagkey.EnvGracePeriod, Aliases: []string{"period"}, Usage: "Grace time (in seconds) for pod to perform connection draining before termination (default value will be used if 0 is given)", DefaultValue: 360}
 	EnvVersion                = Flag{Type: Int, Name: flagkey.EnvVersion, Usage: "Environment API version (1 means v1 interface)", DefaultValue: 1}
 	EnvImagePullSecret        = Flag{Type: String, Name: flagkey.EnvImagePullSecret, Usage: "Secret for Kubernetes to pull an image from a private registry"}
+	EnvPodSelection           = Flag{Type: String, Name: flagkey.EnvPodSelection, Usage: "Strategy to choose a pod from the pool to specialize: random|spread|package-affinity|least-loaded"}
+	EnvMinPoolsize            = Flag{Type: Int, Name: flagkey.EnvMinPoolsize, Usage: "Minimum size of the autoscaled pool, setting any of the autoscaling flags sizes the pool by the recent specializations instead of --poolsize", DefaultValue: 1}
+	EnvMaxPoolsize            = Flag{Type: Int, Name: flagkey.EnvMaxPoolsize, Usage: "Maximum size of the autoscaled pool", DefaultValue: 10}
+	EnvTargetFreePods         = Flag{Type: Int, Name: flagkey.EnvTargetFreePods, Usage: "Number of free pods the autoscaled pool keeps on top of the pods specialized in the last minute", DefaultValue: 1}
 
 	KwName      = Flag{Type: String, Name: flagkey.KwName, Usage: "Watch name"}
 	KwFnName    = Flag{Type: String, Name: flagkey.KwFnName, Usage: "Function name"}
@@ -178,6 +212,9 @@ var (
 	PkgSrcArchive     = Flag{Type: StringSlice, Name: flagkey.PkgSrcArchive, Aliases: []string{"source", "src"}, Usage: "URL or local paths for source archive"}
 	PkgSrcChecksum    = Flag{Type: String, Name: flagkey.PkgSrcChecksum, Usage: "SHA256 checksum of source archive when providing URL"}
 	PkgInsecure       = Flag{Type: Bool, Name: flagkey.PkgInsecure, Usage: "Skip generating SHA256 checksum for file integrity validation"}
+	PkgBuildTimeout   = Flag{Type: Int, Name: flagkey.PkgBuildTimeout, Usage: "Maximum time in seconds the package build may run, 0 for the build timeout of the environment"}
+	PkgLogStep        = Flag{Type: String, Name: flagkey.PkgLogStep, Usage: "Show the logs of a build step only"}
+	PkgLogFollow      = Flag{Type: Bool, Name: flagkey.PkgLogFollow, Short: "f", Usage: "Follow the build logs until the build completes"}
 
 	SpecSave     = Flag{Type: Bool, Name: flagkey.SpecSave, Usage: "Save to the spec directory instead of creating on cluster"}
 	SpecDir      = Flag{Type: String, Name: flagkey.SpecDir, Usage: "Directory to store specs, defaults to ./specs"}
@@ -31,6 +31,7 @@ const (
 	NamespacePackage     = "pkgNamespace"
 	NamespaceTrigger     = "triggerNamespace"
 	NamespaceCanary      = "canaryNamespace"
+	AllNamespaces        = "all-namespaces"
 
 	RuntimeMincpu    = "mincpu"
 	RuntimeMaxcpu    = "maxcpu"
@@ -38,6 +39,9 @@ const (
 	RuntimeMaxmemory = "maxmemory"
 	RuntimeTargetcpu = "targetcpu"
 
+	RuntimeTargetConcurrency = "targetconcurrency"
+	RuntimeTargetRPS         = "targetrps"
+
 	ReplicasMinscale = "minscale"
 	ReplicasMaxscale = "maxscale"
 
@@ -66,6 +70,15 @@ const (
 	FnTestQuery             = "query"
 	FnIdleTimeout           = "idletimeout"
 	FnConcurrency           = "concurrency"
+	FnCircuitBreaker        = "circuitbreaker"
+	FnBreakerFailures       = "breakerfailures"
+	FnBreakerOpenDuration   = "breakeropenduration"
+	FnBreakerHalfOpen       = "breakerhalfopen"
+	FnWarmPods              = "warmpods"
+	FnWarmup                = "warmup"
+	FnImage                 = "image"
+	FnPort                  = "port"
+	FnRollbackRevision      = "to-revision"
 
 	HtName              = resourceName
 	HtMethod            = "method"
@@ -78,6 +91,22 @@ const (
 	HtFnName            = "function"
 	HtFnWeight          = "weight"
 	HtFilter            = HtFnName
+	HtProtocol          = "protocol"
+	HtStreaming         = "streaming"
+	HtPingInterval      = "pinginterval"
+	HtRoutingRule       = "routingrule"
+	HtStickyHeader      = "stickyheader"
+	HtMirror            = "mirror"
+	HtMirrorPercentage  = "mirrorpercentage"
+	HtCacheTTL          = "cachettl"
+	HtCacheHeader       = "cacheheader"
+	HtCacheQuery        = "cachequery"
+	HtAuth              = "auth"
+	HtAuthSecret        = "authsecret"
+	HtAuthHeader        = "authheader"
+	HtJWTIssuer         = "jwtissuer"
+	HtJWTAudience       = "jwtaudience"
+	HtJWTClaim          = "jwtclaim"
 
 	TtName      = resourceName
 	TtCron      = "cron"
@@ -107,10 +136,15 @@ const (
 	EnvBuilderImage    = "builder"
 	EnvBuildcommand    = "buildcmd"
 	EnvKeeparchive     = "keeparchive"
+	EnvBuildTimeout    = "buildtimeout"
 	EnvExternalNetwork = "externalnetwork"
 	EnvGracePeriod     = "graceperiod"
 	EnvVersion         = "version"
 	EnvImagePullSecret = "imagepullsecret"
+	EnvPodSelection    = "podselection"
+	EnvMinPoolsize     = "minpoolsize"
+	EnvMaxPoolsize     = "maxpoolsize"
+	EnvTargetFreePods  = "targetfreepods"
 
 	KwName      = resourceName
 	KwFnName    = "function"
@@ -131,6 +165,9 @@ const (
 	PkgOutput         = Output
 	PkgStatus         = "status"
 	PkgOrphan         = "orphan"
+	PkgLogFollow      = "follow"
+	PkgLogStep        = "step"
+	PkgBuildTimeout   = "buildtimeout"
 
 	SpecSave     = "spec"
 	SpecDir      = "specdir"
@@ -30,9 +30,14 @@ const (
 	getValue requestType = iota
 	listValue
 	getTotalAvailable
+	getTotal
 	setValue
 	markAvailable
 	deleteValue
+	listEntries
+	setEntry
+	markDraining
+	getActive
 )
 
 type (
@@ -40,6 +45,8 @@ type (
 	value struct {
 		val      interface{}
 		isActive bool
+		// draining values are not handed out anymore, they're about to be deleted
+		draining bool
 	}
 	// Cache is simple cache having two keys [function][address] mapped to value and requestChannel for operation on it
 	Cache struct {
@@ -52,6 +59,7 @@ type (
 		function        interface{}
 		address         interface{}
 		value           interface{}
+		active          bool
 		responseChannel chan *response
 	}
 	response struct {
@@ -59,6 +67,18 @@ type (
 		allValues      []interface{}
 		value          interface{}
 		totalAvailable int
+		entries        []Entry
+		active         bool
+		started        bool
+	}
+	// Entry is a value in the cache with its keys and state, used to save
+	// and restore the cache
+	Entry struct {
+		Function interface{}
+		Address  interface{}
+		Value    interface{}
+		Active   bool
+		Draining bool
 	}
 )
 
@@ -85,7 +105,7 @@ func (c *Cache) service() {
 					fmt.Sprintf("function Name '%v' not found", req.function))
 			} else {
 				for addr := range values {
-					if !values[addr].isActive {
+					if !values[addr].isActive && !values[addr].draining {
 						// update atime
 						// mark active
 						values[addr].isActive = true
@@ -117,6 +137,9 @@ func (c *Cache) service() {
 				}
 			}
 			req.responseChannel <- resp
+		case getTotal:
+			resp.totalAvailable = len(c.cache[req.function])
+			req.responseChannel <- resp
 		case setValue:
 			if _, ok := c.cache[req.function]; ok {
 				c.cache[req.function][req.address] = &value{
@@ -139,6 +162,45 @@ func (c *Cache) service() {
 		case deleteValue:
 			delete(c.cache[req.function], req.address)
 			req.responseChannel <- resp
+		case listEntries:
+			entries := make([]Entry, 0)
+			for function, values := range c.cache {
+				for address, value := range values {
+					entries = append(entries, Entry{
+						Function: function,
+						Address:  address,
+						Value:    value.val,
+						Active:   value.isActive,
+						Draining: value.draining,
+					})
+				}
+			}
+			resp.entries = entries
+			req.responseChannel <- resp
+		case setEntry:
+			if _, ok := c.cache[req.function]; !ok {
+				c.cache[req.function] = make(map[interface{}]*value)
+			}
+			c.cache[req.function][req.address] = &value{
+				val:      req.value,
+				isActive: req.active,
+			}
+			req.responseChannel <- resp
+		case markDraining:
+			if v, ok := c.cache[req.function][req.address]; ok && !v.draining {
+				v.draining = true
+				resp.active = v.isActive
+				resp.started = true
+			}
+			req.responseChannel <- resp
+		case getActive:
+			if v, ok := c.cache[req.function][req.address]; ok {
+				resp.active = v.isActive
+			} else {
+				resp.error = ferror.MakeError(ferror.ErrorNotFound,
+					fmt.Sprintf("function '%v' address '%v' not found", req.function, req.address))
+			}
+			req.responseChannel <- resp
 		default:
 			resp.error = ferror.MakeError(ferror.ErrorInvalidArgument,
 				fmt.Sprintf("invalid request type: %v", req.requestType))
@@ -182,6 +244,18 @@ func (c *Cache) GetTotalAvailable(function interface{}) int {
 	return resp.totalAvailable
 }
 
+// GetTotal returns a total number of function services, active or not
+func (c *Cache) GetTotal(function interface{}) int {
+	respChannel := make(chan *response)
+	c.requestChannel <- &request{
+		requestType:     getTotal,
+		function:        function,
+		responseChannel: respChannel,
+	}
+	resp := <-respChannel
+	return resp.totalAvailable
+}
+
 // SetValue marks the value at key [function][address] as active(begin used)
 func (c *Cache) SetValue(function, address, value interface{}) {
 	respChannel := make(chan *response)
@@ -217,3 +291,55 @@ func (c *Cache) DeleteValue(function, address interface{}) error {
 	resp := <-respChannel
 	return resp.error
 }
+
+// ListEntries returns all the values stored in the Cache with their keys and state
+func (c *Cache) ListEntries() []Entry {
+	respChannel := make(chan *response)
+	c.requestChannel <- &request{
+		requestType:     listEntries,
+		responseChannel: respChannel,
+	}
+	resp := <-respChannel
+	return resp.entries
+}
+
+// SetEntry stores the value at key [function][address] keeping the state of the entry
+func (c *Cache) SetEntry(e Entry) {
+	respChannel := make(chan *response)
+	c.requestChannel <- &request{
+		requestType:     setEntry,
+		function:        e.Function,
+		address:         e.Address,
+		value:           e.Value,
+		active:          e.Active,
+		responseChannel: respChannel,
+	}
+	<-respChannel
+}
+
+// MarkDraining stops handing out the value at key [function][address], and returns
+// whether it's active. started is false if the value doesn't exist or is draining already.
+func (c *Cache) MarkDraining(function, address interface{}) (active bool, started bool) {
+	respChannel := make(chan *response)
+	c.requestChannel <- &request{
+		requestType:     markDraining,
+		function:        function,
+		address:         address,
+		responseChannel: respChannel,
+	}
+	resp := <-respChannel
+	return resp.active, resp.started
+}
+
+// IsActive returns whether the value at key [function][address] is active
+func (c *Cache) IsActive(function, address interface{}) (bool, error) {
+	respChannel := make(chan *response)
+	c.requestChannel <- &request{
+		requestType:     getActive,
+		function:        function,
+		address:         address,
+		responseChannel: respChannel,
+	}
+	resp := <-respChannel
+	return resp.active, resp.error
+}
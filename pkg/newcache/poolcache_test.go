@@ -34,6 +34,11 @@ func TestPoolCache(t *testing.T) {
 
 	c.MarkAvailable("func", "ip")
 
+	// inactive values are counted too
+	if c.GetTotal("func") != 1 || c.GetTotal("func2") != 1 {
+		log.Panicf("expected 1 item")
+	}
+
 	_, err := c.GetValue("func")
 	checkErr(err)
 
@@ -44,6 +49,36 @@ func TestPoolCache(t *testing.T) {
 		log.Panicf("found deleted element")
 	}
 
+	// restored entries keep their state
+	c.SetEntry(Entry{Function: "func3", Address: "ip3", Value: "value3", Active: false})
+	entries := c.ListEntries()
+	if len(entries) != 2 {
+		log.Panicf("expected 2 entries")
+	}
+	_, err = c.GetValue("func3")
+	checkErr(err)
+
+	// draining values are not handed out
+	c.SetValue("func4", "ip4", "value4")
+	draining, started := c.MarkDraining("func4", "ip4")
+	if !draining || !started {
+		log.Panicf("expected draining to start on an active value")
+	}
+	_, started = c.MarkDraining("func4", "ip4")
+	if started {
+		log.Panicf("expected value to be draining already")
+	}
+	c.MarkAvailable("func4", "ip4")
+	isActive, err := c.IsActive("func4", "ip4")
+	checkErr(err)
+	if isActive {
+		log.Panicf("expected value to be inactive")
+	}
+	_, err = c.GetValue("func4")
+	if err == nil {
+		log.Panicf("found draining element")
+	}
+
 	c.SetValue("expires", "42", "all answers")
 
 	time.Sleep(150 * time.Millisecond)
@@ -0,0 +1,687 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// authentication results of a request, used as metric label
+	authResultAllowed       = "allowed"
+	authResultUnauthorized  = "unauthorized"
+	authResultForbidden     = "forbidden"
+	authResultRejected      = "rejected"
+	authResultInternalError = "error"
+
+	// keys of the trigger auth secret
+	authSecretKeyJWKS = "jwks"
+	authSecretKeyHMAC = "key"
+
+	// interval for router to reload credentials from the trigger auth secret
+	authSecretRefreshInterval = time.Minute
+
+	// allowed clock skew when checking the time claims of a JWT
+	jwtClockSkew = time.Minute
+
+	// requests with a larger body are rejected by HMAC authentication
+	hmacMaxBodySize = 10 * 1024 * 1024
+)
+
+type (
+	// authInfo is the verified identity of a request.
+	authInfo struct {
+		authType fv1.TriggerAuthType
+		subject  string
+		issuer   string
+		claims   map[string]string
+	}
+
+	// authError is the reason a request fails authentication.
+	authError struct {
+		status int
+		msg    string
+	}
+
+	// jsonWebKey is a public key of a JSON Web Key Set.
+	jsonWebKey struct {
+		kid string
+		alg string
+		key crypto.PublicKey
+	}
+
+	// authCredentials is the key material loaded from the trigger auth secret.
+	authCredentials struct {
+		jwks    []jsonWebKey
+		apiKeys map[string][]byte
+		hmacKey []byte
+	}
+
+	// triggerAuthenticator authenticates requests of an HTTP trigger.
+	triggerAuthenticator struct {
+		logger    *zap.Logger
+		namespace string
+		spec      fv1.TriggerAuth
+		getSecret func(namespace, name string) (*apiv1.Secret, error)
+
+		lock     sync.Mutex
+		creds    *authCredentials
+		loadErr  error
+		loadedAt time.Time
+	}
+
+	// authenticatorSet keeps the authenticators of triggers, so that
+	// loaded credentials survive router updates.
+	authenticatorSet struct {
+		sync.Mutex
+		logger         *zap.Logger
+		getSecret      func(namespace, name string) (*apiv1.Secret, error)
+		authenticators map[k8stypes.UID]*triggerAuthenticator
+	}
+)
+
+func makeAuthenticatorSet(logger *zap.Logger, kubeClient *kubernetes.Clientset) *authenticatorSet {
+	return &authenticatorSet{
+		logger: logger.Named("authenticator"),
+		getSecret: func(namespace, name string) (*apiv1.Secret, error) {
+			return kubeClient.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+		},
+		authenticators: make(map[k8stypes.UID]*triggerAuthenticator),
+	}
+}
+
+func (err *authError) Error() string {
+	return err.msg
+}
+
+// result returns the metric label of the authentication failure.
+func (err *authError) result() string {
+	switch err.status {
+	case http.StatusUnauthorized:
+		return authResultUnauthorized
+	case http.StatusForbidden:
+		return authResultForbidden
+	case http.StatusInternalServerError:
+		return authResultInternalError
+	default:
+		return authResultRejected
+	}
+}
+
+// get returns the authenticator of the trigger. A new authenticator is created
+// if the trigger has no authenticator yet or the auth spec has changed.
+func (as *authenticatorSet) get(trigger *fv1.HTTPTrigger) *triggerAuthenticator {
+	if trigger.Spec.Auth == nil {
+		return nil
+	}
+
+	as.Lock()
+	defer as.Unlock()
+
+	ta, ok := as.authenticators[trigger.ObjectMeta.UID]
+	if !ok || ta.namespace != trigger.ObjectMeta.Namespace || !reflect.DeepEqual(ta.spec, *trigger.Spec.Auth) {
+		ta = &triggerAuthenticator{
+			logger:    as.logger.With(zap.String("trigger", trigger.ObjectMeta.Name), zap.String("namespace", trigger.ObjectMeta.Namespace)),
+			namespace: trigger.ObjectMeta.Namespace,
+			spec:      *trigger.Spec.Auth,
+			getSecret: as.getSecret,
+		}
+		as.authenticators[trigger.ObjectMeta.UID] = ta
+	}
+	return ta
+}
+
+// prune removes authenticators of triggers that no longer exist.
+func (as *authenticatorSet) prune(triggers []fv1.HTTPTrigger) {
+	active := make(map[k8stypes.UID]struct{}, len(triggers))
+	for _, t := range triggers {
+		active[t.ObjectMeta.UID] = struct{}{}
+	}
+
+	as.Lock()
+	defer as.Unlock()
+
+	for uid := range as.authenticators {
+		if _, ok := active[uid]; !ok {
+			delete(as.authenticators, uid)
+		}
+	}
+}
+
+// wrap returns a handler that authenticates requests of the trigger before
+// passing them to next.
+func (as *authenticatorSet) wrap(trigger *fv1.HTTPTrigger, next http.HandlerFunc) http.HandlerFunc {
+	ta := as.get(trigger)
+	namespace, name := trigger.ObjectMeta.Namespace, trigger.ObjectMeta.Name
+
+	return func(rw http.ResponseWriter, req *http.Request) {
+		// auth info headers are set by router only, never trust the client
+		removeAuthInfoFromHeader(req)
+
+		if ta == nil {
+			next(rw, req)
+			return
+		}
+
+		info, err := ta.authenticate(req)
+		if err != nil {
+			triggerAuthCompleted(namespace, name, err.result())
+			if err.status == http.StatusUnauthorized && ta.spec.Type == fv1.TriggerAuthTypeJWT {
+				rw.Header().Set("WWW-Authenticate", `Bearer realm="fission"`)
+			}
+			writeErrorResponse(rw, setRequestIDToHeader(req), err.status, err.msg)
+			return
+		}
+
+		triggerAuthCompleted(namespace, name, authResultAllowed)
+		setAuthInfoToHeader(info, req)
+		next(rw, req)
+	}
+}
+
+// stripAuthInfo returns a handler that drops the auth info headers sent by
+// the client before passing requests to next, for routes without trigger
+// authentication.
+func stripAuthInfo(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		removeAuthInfoFromHeader(req)
+		next(rw, req)
+	}
+}
+
+// authenticateAny returns a handler that passes requests to next if they are
+// allowed by any of the authenticators, for the internal route of a function
+// whose HTTP triggers require authentication. Without authenticators, only the
+// auth info headers sent by the client are dropped.
+func authenticateAny(authenticators []*triggerAuthenticator, next http.HandlerFunc) http.HandlerFunc {
+	if len(authenticators) == 0 {
+		return stripAuthInfo(next)
+	}
+
+	return func(rw http.ResponseWriter, req *http.Request) {
+		removeAuthInfoFromHeader(req)
+
+		var firstErr *authError
+		for _, ta := range authenticators {
+			info, err := ta.authenticate(req)
+			if err == nil {
+				setAuthInfoToHeader(info, req)
+				next(rw, req)
+				return
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		writeErrorResponse(rw, setRequestIDToHeader(req), firstErr.status, firstErr.msg)
+	}
+}
+
+// authenticate verifies the credential of the request.
+func (ta *triggerAuthenticator) authenticate(req *http.Request) (*authInfo, *authError) {
+	creds, err := ta.credentials()
+	if err != nil {
+		ta.logger.Error("error loading credentials of trigger", zap.Error(err), zap.String("secret", ta.spec.SecretName))
+		return nil, &authError{status: http.StatusInternalServerError, msg: "error loading credentials"}
+	}
+
+	switch ta.spec.Type {
+	case fv1.TriggerAuthTypeJWT:
+		return ta.authenticateJWT(creds, req)
+	case fv1.TriggerAuthTypeAPIKey:
+		return ta.authenticateAPIKey(creds, req)
+	case fv1.TriggerAuthTypeHMAC:
+		return ta.authenticateHMAC(creds, req)
+	default:
+		return nil, &authError{status: http.StatusInternalServerError, msg: fmt.Sprintf("unknown authentication type %q", ta.spec.Type)}
+	}
+}
+
+// credentials returns the credentials of the trigger, reloading them from
+// the secret periodically so that rotated keys are picked up.
+func (ta *triggerAuthenticator) credentials() (*authCredentials, error) {
+	ta.lock.Lock()
+	defer ta.lock.Unlock()
+
+	if time.Since(ta.loadedAt) < authSecretRefreshInterval {
+		if ta.loadErr != nil {
+			return nil, ta.loadErr
+		}
+		if ta.creds != nil {
+			return ta.creds, nil
+		}
+	}
+
+	secret, err := ta.getSecret(ta.namespace, ta.spec.SecretName)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			// credentials are revoked, not looked up again until the next
+			// refresh so that failing requests don't load the apiserver
+			ta.creds = nil
+			ta.loadErr = err
+			ta.loadedAt = time.Now()
+			return nil, err
+		}
+		if ta.creds != nil {
+			// keep using the last known credentials until the secret is reachable
+			ta.logger.Error("error reloading credentials of trigger, using the last known credentials", zap.Error(err))
+			ta.loadedAt = time.Now()
+			return ta.creds, nil
+		}
+		return nil, err
+	}
+
+	creds, err := parseAuthCredentials(ta.spec.Type, secret.Data)
+	if err != nil {
+		return nil, err
+	}
+	ta.creds = creds
+	ta.loadErr = nil
+	ta.loadedAt = time.Now()
+	return creds, nil
+}
+
+func parseAuthCredentials(authType fv1.TriggerAuthType, data map[string][]byte) (*authCredentials, error) {
+	creds := &authCredentials{}
+
+	switch authType {
+	case fv1.TriggerAuthTypeJWT:
+		jwks, err := parseJWKS(data[authSecretKeyJWKS])
+		if err != nil {
+			return nil, err
+		}
+		creds.jwks = jwks
+	case fv1.TriggerAuthTypeAPIKey:
+		creds.apiKeys = make(map[string][]byte, len(data))
+		for client, key := range data {
+			key = bytes.TrimSpace(key)
+			if len(key) > 0 {
+				creds.apiKeys[client] = key
+			}
+		}
+		if len(creds.apiKeys) == 0 {
+			return nil, errors.New("no api key found in secret")
+		}
+	case fv1.TriggerAuthTypeHMAC:
+		creds.hmacKey = data[authSecretKeyHMAC]
+		if len(creds.hmacKey) == 0 {
+			return nil, errors.Errorf("no %q found in secret", authSecretKeyHMAC)
+		}
+	}
+
+	return creds, nil
+}
+
+// getCredential returns the credential carried in the request header.
+func getCredential(req *http.Request, header string) string {
+	value := strings.TrimSpace(req.Header.Get(header))
+	if len(value) > 7 && strings.EqualFold(value[:7], "Bearer ") {
+		value = strings.TrimSpace(value[7:])
+	}
+	return value
+}
+
+func (ta *triggerAuthenticator) authenticateAPIKey(creds *authCredentials, req *http.Request) (*authInfo, *authError) {
+	header := ta.spec.Header
+	if len(header) == 0 {
+		header = "X-Api-Key"
+	}
+	key := getCredential(req, header)
+	if len(key) == 0 {
+		return nil, &authError{status: http.StatusUnauthorized, msg: "missing api key"}
+	}
+
+	// compare against all keys to not leak which key matched through timing
+	var subject string
+	for client, k := range creds.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), k) == 1 {
+			subject = client
+		}
+	}
+	if len(subject) == 0 {
+		return nil, &authError{status: http.StatusUnauthorized, msg: "invalid api key"}
+	}
+
+	return &authInfo{authType: fv1.TriggerAuthTypeAPIKey, subject: subject}, nil
+}
+
+func (ta *triggerAuthenticator) authenticateHMAC(creds *authCredentials, req *http.Request) (*authInfo, *authError) {
+	header := ta.spec.Header
+	if len(header) == 0 {
+		header = "X-Fission-Signature"
+	}
+	signature := strings.TrimSpace(req.Header.Get(header))
+	if len(signature) == 0 {
+		return nil, &authError{status: http.StatusUnauthorized, msg: "missing request signature"}
+	}
+
+	// signature format: [sha1=|sha256=|sha512=]{hex digest}, defaults to sha256
+	newHash := sha256.New
+	if kv := strings.SplitN(signature, "=", 2); len(kv) == 2 {
+		switch strings.ToLower(kv[0]) {
+		case "sha1":
+			newHash = sha1.New
+		case "sha256":
+			newHash = sha256.New
+		case "sha512":
+			newHash = sha512.New
+		default:
+			return nil, &authError{status: http.StatusUnauthorized, msg: "unsupported signature algorithm"}
+		}
+		signature = kv[1]
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return nil, &authError{status: http.StatusUnauthorized, msg: "malformed request signature"}
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, err = ioutil.ReadAll(io.LimitReader(req.Body, hmacMaxBodySize+1))
+		req.Body.Close()
+		if err != nil {
+			return nil, &authError{status: http.StatusBadRequest, msg: "error reading request body"}
+		}
+		if len(body) > hmacMaxBodySize {
+			return nil, &authError{status: http.StatusRequestEntityTooLarge, msg: "request body too large to verify signature"}
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if !hmac.Equal(expected, computeHMAC(newHash, creds.hmacKey, body)) {
+		return nil, &authError{status: http.StatusUnauthorized, msg: "invalid request signature"}
+	}
+
+	return &authInfo{authType: fv1.TriggerAuthTypeHMAC}, nil
+}
+
+func computeHMAC(newHash func() hash.Hash, key, body []byte) []byte {
+	mac := hmac.New(newHash, key)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func (ta *triggerAuthenticator) authenticateJWT(creds *authCredentials, req *http.Request) (*authInfo, *authError) {
+	header := ta.spec.Header
+	if len(header) == 0 {
+		header = "Authorization"
+	}
+	token := getCredential(req, header)
+	if len(token) == 0 {
+		return nil, &authError{status: http.StatusUnauthorized, msg: "missing bearer token"}
+	}
+
+	claims, err := verifyJWT(creds.jwks, token, time.Now())
+	if err != nil {
+		return nil, &authError{status: http.StatusUnauthorized, msg: fmt.Sprintf("invalid token: %v", err)}
+	}
+
+	issuer, _ := claims["iss"].(string)
+	if len(ta.spec.Issuer) > 0 && issuer != ta.spec.Issuer {
+		return nil, &authError{status: http.StatusForbidden, msg: "token issuer is not allowed"}
+	}
+	if len(ta.spec.Audience) > 0 && !hasAudience(claims["aud"], ta.spec.Audience) {
+		return nil, &authError{status: http.StatusForbidden, msg: "token audience is not allowed"}
+	}
+
+	info := &authInfo{
+		authType: fv1.TriggerAuthTypeJWT,
+		issuer:   issuer,
+		claims:   make(map[string]string, len(ta.spec.Claims)),
+	}
+	info.subject, _ = claims["sub"].(string)
+	for _, name := range ta.spec.Claims {
+		switch v := claims[name].(type) {
+		case nil:
+		case string:
+			info.claims[name] = v
+		default:
+			b, _ := json.Marshal(v)
+			info.claims[name] = string(b)
+		}
+	}
+	return info, nil
+}
+
+func hasAudience(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifyJWT verifies the signature and time claims of a compact serialized
+// JWT, and returns its claims.
+func verifyJWT(jwks []jsonWebKey, token string, now time.Time) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, errors.Wrap(err, "malformed token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed token signature")
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, k := range jwks {
+		if (len(header.Kid) > 0 && k.kid != header.Kid) || (len(k.alg) > 0 && k.alg != header.Alg) {
+			continue
+		}
+		if verifyJWTSignature(header.Alg, k.key, signed, signature) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("signature verification failed")
+	}
+
+	claims := make(map[string]interface{})
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, errors.Wrap(err, "malformed token claims")
+	}
+	if exp, ok := claims["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(jwtClockSkew)) {
+		return nil, errors.New("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(jwtClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errors.New("token is not valid yet")
+	}
+	return claims, nil
+}
+
+func decodeJWTSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hashFunc crypto.Hash
+	switch {
+	case strings.HasSuffix(alg, "256"):
+		hashFunc = crypto.SHA256
+	case strings.HasSuffix(alg, "384"):
+		hashFunc = crypto.SHA384
+	case strings.HasSuffix(alg, "512"):
+		hashFunc = crypto.SHA512
+	default:
+		return errors.Errorf("unsupported algorithm %q", alg)
+	}
+	h := hashFunc.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch alg {
+	case "RS256", "RS384", "RS512":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key type mismatch")
+		}
+		return rsa.VerifyPKCS1v15(rsaKey, hashFunc, digest, signature)
+	case "PS256", "PS384", "PS512":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key type mismatch")
+		}
+		return rsa.VerifyPSS(rsaKey, hashFunc, digest, signature, nil)
+	case "ES256", "ES384", "ES512":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("key type mismatch")
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return errors.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+// parseJWKS parses the public signing keys of a JSON Web Key Set.
+func parseJWKS(data []byte) ([]jsonWebKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, errors.Wrap(err, "error parsing jwks")
+	}
+
+	var jwks []jsonWebKey
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		var key crypto.PublicKey
+		switch k.Kty {
+		case "RSA":
+			n, err := decodeBigInt(k.N)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing modulus of key %q", k.Kid)
+			}
+			e, err := decodeBigInt(k.E)
+			if err != nil || !e.IsInt64() {
+				return nil, errors.Errorf("error parsing exponent of key %q", k.Kid)
+			}
+			key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				return nil, errors.Errorf("unsupported curve %q of key %q", k.Crv, k.Kid)
+			}
+			x, errX := decodeBigInt(k.X)
+			y, errY := decodeBigInt(k.Y)
+			if errX != nil || errY != nil || !curve.IsOnCurve(x, y) {
+				return nil, errors.Errorf("error parsing point of key %q", k.Kid)
+			}
+			key = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		default:
+			// symmetric keys are not allowed to verify tokens
+			continue
+		}
+		jwks = append(jwks, jsonWebKey{kid: k.Kid, alg: k.Alg, key: key})
+	}
+
+	if len(jwks) == 0 {
+		return nil, errors.New("no signing key found in jwks")
+	}
+	return jwks, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
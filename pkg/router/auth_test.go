@@ -0,0 +1,330 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	k8sCache "k8s.io/client-go/tools/cache"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	"github.com/fission/fission/pkg/utils"
+)
+
+func makeTestAuthenticator(spec fv1.TriggerAuth, data map[string][]byte) *triggerAuthenticator {
+	return &triggerAuthenticator{
+		logger:    zap.NewNop(),
+		namespace: "default",
+		spec:      spec,
+		getSecret: func(namespace, name string) (*apiv1.Secret, error) {
+			return &apiv1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Data:       data,
+			}, nil
+		},
+	}
+}
+
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	assert.Nil(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTAuth(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":"k1","alg":"RS256","n":"%s","e":"%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+
+	ta := makeTestAuthenticator(fv1.TriggerAuth{
+		Type:     fv1.TriggerAuthTypeJWT,
+		Issuer:   "https://issuer.example.com",
+		Audience: "fission",
+		Claims:   []string{"email", "roles"},
+	}, map[string][]byte{authSecretKeyJWKS: []byte(jwks)})
+
+	claims := map[string]interface{}{
+		"iss":   "https://issuer.example.com",
+		"aud":   []string{"fission", "other"},
+		"sub":   "user-1",
+		"email": "user@example.com",
+		"roles": []string{"admin"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("Authorization", "Bearer "+signTestJWT(t, key, "k1", claims))
+	info, authErr := ta.authenticate(req)
+	assert.Nil(t, authErr)
+	assert.Equal(t, "user-1", info.subject)
+	assert.Equal(t, "user@example.com", info.claims["email"])
+	assert.Equal(t, `["admin"]`, info.claims["roles"])
+
+	// missing token
+	_, authErr = ta.authenticate(httptest.NewRequest(http.MethodGet, "/foo", nil))
+	assert.Equal(t, http.StatusUnauthorized, authErr.status)
+
+	// unknown key
+	req.Header.Set("Authorization", "Bearer "+signTestJWT(t, key, "k2", claims))
+	_, authErr = ta.authenticate(req)
+	assert.Equal(t, http.StatusUnauthorized, authErr.status)
+
+	// tampered claims
+	token := signTestJWT(t, key, "k1", claims)
+	parts := strings.Split(token, ".")
+	payload, _ := json.Marshal(map[string]interface{}{"iss": "https://issuer.example.com", "aud": "fission", "sub": "admin"})
+	req.Header.Set("Authorization", "Bearer "+parts[0]+"."+base64.RawURLEncoding.EncodeToString(payload)+"."+parts[2])
+	_, authErr = ta.authenticate(req)
+	assert.Equal(t, http.StatusUnauthorized, authErr.status)
+
+	// expired token
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	req.Header.Set("Authorization", "Bearer "+signTestJWT(t, key, "k1", claims))
+	_, authErr = ta.authenticate(req)
+	assert.Equal(t, http.StatusUnauthorized, authErr.status)
+
+	// valid token for another audience
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	claims["aud"] = "other"
+	req.Header.Set("Authorization", "Bearer "+signTestJWT(t, key, "k1", claims))
+	_, authErr = ta.authenticate(req)
+	assert.Equal(t, http.StatusForbidden, authErr.status)
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	ta := makeTestAuthenticator(fv1.TriggerAuth{Type: fv1.TriggerAuthTypeAPIKey}, map[string][]byte{
+		"client-a": []byte("key-a"),
+		"client-b": []byte("key-b\n"),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("X-Api-Key", "key-b")
+	info, authErr := ta.authenticate(req)
+	assert.Nil(t, authErr)
+	assert.Equal(t, "client-b", info.subject)
+
+	req.Header.Set("X-Api-Key", "key-c")
+	_, authErr = ta.authenticate(req)
+	assert.Equal(t, http.StatusUnauthorized, authErr.status)
+}
+
+func TestHMACAuth(t *testing.T) {
+	secret := []byte("webhook-secret")
+	ta := makeTestAuthenticator(fv1.TriggerAuth{Type: fv1.TriggerAuthTypeHMAC, Header: "X-Hub-Signature-256"},
+		map[string][]byte{authSecretKeyHMAC: secret})
+
+	body := `{"action":"opened"}`
+	req := httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader(body))
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(computeHMAC(sha256.New, secret, []byte(body))))
+	_, authErr := ta.authenticate(req)
+	assert.Nil(t, authErr)
+
+	// body is still readable by the function handler
+	b, err := ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, body, string(b))
+
+	req = httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader(`{"action":"closed"}`))
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(computeHMAC(sha256.New, secret, []byte(body))))
+	_, authErr = ta.authenticate(req)
+	assert.Equal(t, http.StatusUnauthorized, authErr.status)
+}
+
+func TestCredentialsNotFound(t *testing.T) {
+	lookups := 0
+	ta := makeTestAuthenticator(fv1.TriggerAuth{Type: fv1.TriggerAuthTypeAPIKey, SecretName: "keys"}, nil)
+	ta.getSecret = func(namespace, name string) (*apiv1.Secret, error) {
+		lookups++
+		return nil, k8serrors.NewNotFound(apiv1.Resource("secrets"), name)
+	}
+
+	// the missing secret is not looked up again until the next refresh
+	for i := 0; i < 3; i++ {
+		_, err := ta.credentials()
+		assert.True(t, k8serrors.IsNotFound(err))
+	}
+	assert.Equal(t, 1, lookups)
+
+	ta.loadedAt = time.Now().Add(-authSecretRefreshInterval)
+	_, err := ta.credentials()
+	assert.True(t, k8serrors.IsNotFound(err))
+	assert.Equal(t, 2, lookups)
+}
+
+func TestAuthenticatorSetWrap(t *testing.T) {
+	as := &authenticatorSet{
+		logger: zap.NewNop(),
+		getSecret: func(namespace, name string) (*apiv1.Secret, error) {
+			return &apiv1.Secret{Data: map[string][]byte{"client-a": []byte("key-a")}}, nil
+		},
+		authenticators: make(map[k8stypes.UID]*triggerAuthenticator),
+	}
+	trigger := &fv1.HTTPTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default", UID: "foo-uid"},
+		Spec: fv1.HTTPTriggerSpec{
+			Auth: &fv1.TriggerAuth{Type: fv1.TriggerAuthTypeAPIKey, SecretName: "keys"},
+		},
+	}
+
+	var subject string
+	handler := as.wrap(trigger, func(rw http.ResponseWriter, req *http.Request) {
+		subject = req.Header.Get("X-Fission-Auth-Subject")
+	})
+
+	// auth headers sent by client are dropped
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("X-Api-Key", "key-a")
+	req.Header.Set("X-Fission-Auth-Subject", "admin")
+	rw := httptest.NewRecorder()
+	handler(rw, req)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "client-a", subject)
+
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	rw = httptest.NewRecorder()
+	handler(rw, req)
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+
+	as.prune([]fv1.HTTPTrigger{})
+	assert.Len(t, as.authenticators, 0)
+}
+
+func TestStripAuthInfo(t *testing.T) {
+	var subject string
+	handler := stripAuthInfo(func(rw http.ResponseWriter, req *http.Request) {
+		subject = req.Header.Get("X-Fission-Auth-Subject")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/fission-function/foo", nil)
+	req.Header.Set("X-Fission-Auth-Subject", "admin")
+	handler(httptest.NewRecorder(), req)
+	assert.Empty(t, subject)
+}
+
+func TestInternalRouteRequiresTriggerAuth(t *testing.T) {
+	fnStore := k8sCache.NewStore(k8sCache.MetaNamespaceKeyFunc)
+	functions := []fv1.Function{
+		{ObjectMeta: metav1.ObjectMeta{Name: "protected", Namespace: "default", UID: "protected-uid"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "default", UID: "canary-uid"}},
+	}
+	for i := range functions {
+		assert.NoError(t, fnStore.Add(&functions[i]))
+	}
+
+	ts := &HTTPTriggerSet{
+		logger: zap.NewNop(),
+		triggers: []fv1.HTTPTrigger{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "protected", Namespace: "default", UID: "protected-trigger-uid"},
+				Spec: fv1.HTTPTriggerSpec{
+					RelativeURL:       "/protected",
+					Method:            http.MethodGet,
+					FunctionReference: fv1.FunctionReference{Type: fv1.FunctionReferenceTypeFunctionName, Name: "protected"},
+					Auth:              &fv1.TriggerAuth{Type: fv1.TriggerAuthTypeAPIKey, SecretName: "keys"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "weighted", Namespace: "default", UID: "weighted-trigger-uid"},
+				Spec: fv1.HTTPTriggerSpec{
+					RelativeURL: "/weighted",
+					Method:      http.MethodGet,
+					FunctionReference: fv1.FunctionReference{
+						Type:            fv1.FunctionReferenceTypeFunctionWeights,
+						FunctionWeights: map[string]int{"protected": 90, "canary": 10},
+					},
+					Auth: &fv1.TriggerAuth{Type: fv1.TriggerAuthTypeAPIKey, SecretName: "keys"},
+				},
+			},
+		},
+		functions:       functions,
+		resolver:        makeFunctionReferenceResolver(fnStore),
+		rateLimiters:    makeRateLimiterSet(nil),
+		circuitBreakers: makeCircuitBreakerSet(),
+		authenticators: &authenticatorSet{
+			logger: zap.NewNop(),
+			getSecret: func(namespace, name string) (*apiv1.Secret, error) {
+				return &apiv1.Secret{Data: map[string][]byte{"client-a": []byte("key-a")}}, nil
+			},
+			authenticators: make(map[k8stypes.UID]*triggerAuthenticator),
+		},
+	}
+	router := ts.getRouter(nil)
+
+	// functions behind a trigger requiring authentication can't be reached
+	// through their internal route without credentials
+	for _, fn := range functions {
+		req := httptest.NewRequest(http.MethodGet, utils.UrlForFunction(fn.ObjectMeta.Name, fn.ObjectMeta.Namespace), nil)
+		req.Header.Set("X-Api-Key", "key-b")
+		rw := httptest.NewRecorder()
+		router.ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusUnauthorized, rw.Code, fn.ObjectMeta.Name)
+	}
+}
+
+func TestAuthenticateAny(t *testing.T) {
+	jwt := makeTestAuthenticator(fv1.TriggerAuth{Type: fv1.TriggerAuthTypeJWT}, map[string][]byte{authSecretKeyJWKS: []byte(`{"keys":[]}`)})
+	apiKey := makeTestAuthenticator(fv1.TriggerAuth{Type: fv1.TriggerAuthTypeAPIKey}, map[string][]byte{"client-a": []byte("key-a")})
+
+	var subject string
+	handler := authenticateAny([]*triggerAuthenticator{jwt, apiKey}, func(rw http.ResponseWriter, req *http.Request) {
+		subject = req.Header.Get("X-Fission-Auth-Subject")
+	})
+
+	// any authenticator allowing the request is enough
+	req := httptest.NewRequest(http.MethodGet, "/fission-function/foo", nil)
+	req.Header.Set("X-Api-Key", "key-a")
+	rw := httptest.NewRecorder()
+	handler(rw, req)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "client-a", subject)
+
+	subject = ""
+	req = httptest.NewRequest(http.MethodGet, "/fission-function/foo", nil)
+	req.Header.Set("X-Fission-Auth-Subject", "admin")
+	rw = httptest.NewRecorder()
+	handler(rw, req)
+	assert.NotEqual(t, http.StatusOK, rw.Code)
+	assert.Empty(t, subject)
+}
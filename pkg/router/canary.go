@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"hash/fnv"
+	"net/http"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+// getBackend picks the function to send a canary request to. Routing rules
+// take precedence, then sticky session, and weighted random selection
+// is the fallback.
+func getBackend(fnRef *fv1.FunctionReference, fnMap map[string]*fv1.Function,
+	fnWtDistributionList []FunctionWeightDistribution, req *http.Request) *fv1.Function {
+
+	for _, rule := range fnRef.Rules {
+		if matchRoutingRule(&rule, req) {
+			return fnMap[rule.FunctionName]
+		}
+	}
+
+	if fnRef.StickySession != nil {
+		if key := req.Header.Get(fnRef.StickySession.Header); len(key) > 0 {
+			return getStickyBackend(fnMap, fnWtDistributionList, key)
+		}
+	}
+
+	return getCanaryBackend(fnMap, fnWtDistributionList)
+}
+
+// matchRoutingRule returns true if the request matches the routing rule.
+func matchRoutingRule(rule *fv1.RoutingRule, req *http.Request) bool {
+	var values []string
+
+	switch rule.Match {
+	case fv1.RoutingRuleMatchTypeHeader:
+		values = req.Header[http.CanonicalHeaderKey(rule.Key)]
+	case fv1.RoutingRuleMatchTypeCookie:
+		if c, err := req.Cookie(rule.Key); err == nil {
+			values = []string{c.Value}
+		}
+	case fv1.RoutingRuleMatchTypeQuery:
+		values = req.URL.Query()[rule.Key]
+	}
+
+	for _, v := range values {
+		if len(rule.Value) == 0 || v == rule.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// getStickyBackend picks a function by the hash of session key, so that
+// requests of a session always reach the same function as long as the
+// function weights stay the same.
+func getStickyBackend(fnMap map[string]*fv1.Function, fnWtDistributionList []FunctionWeightDistribution, key string) *fv1.Function {
+	total := fnWtDistributionList[len(fnWtDistributionList)-1].sumPrefix
+	if total <= 0 {
+		// all weights are zero
+		return fnMap[fnWtDistributionList[0].name]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	// a number between 1 and the sum of weights, as in getCanaryBackend
+	number := int(h.Sum32()%uint32(total)) + 1
+	fnName := findCeil(number, fnWtDistributionList)
+	return fnMap[fnName]
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func makeCanaryTestFunctions() (map[string]*fv1.Function, []FunctionWeightDistribution) {
+	fnMap := map[string]*fv1.Function{
+		"fn-v1": {ObjectMeta: metav1.ObjectMeta{Name: "fn-v1"}},
+		"fn-v2": {ObjectMeta: metav1.ObjectMeta{Name: "fn-v2"}},
+	}
+	distribution := []FunctionWeightDistribution{
+		{name: "fn-v1", weight: 50, sumPrefix: 50},
+		{name: "fn-v2", weight: 50, sumPrefix: 100},
+	}
+	return fnMap, distribution
+}
+
+func TestGetBackendByRules(t *testing.T) {
+	fnMap, distribution := makeCanaryTestFunctions()
+	fnRef := &fv1.FunctionReference{
+		Type: fv1.FunctionReferenceTypeFunctionWeights,
+		Rules: []fv1.RoutingRule{
+			{Match: fv1.RoutingRuleMatchTypeHeader, Key: "x-tester", Value: "true", FunctionName: "fn-v2"},
+			{Match: fv1.RoutingRuleMatchTypeCookie, Key: "tenant", Value: "acme", FunctionName: "fn-v1"},
+			{Match: fv1.RoutingRuleMatchTypeQuery, Key: "preview", FunctionName: "fn-v2"},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("X-Tester", "true")
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "fn-v2", getBackend(fnRef, fnMap, distribution, req).ObjectMeta.Name)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.AddCookie(&http.Cookie{Name: "tenant", Value: "acme"})
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "fn-v1", getBackend(fnRef, fnMap, distribution, req).ObjectMeta.Name)
+	}
+
+	// any value matches if rule value is empty
+	req = httptest.NewRequest(http.MethodGet, "/foo?preview=1", nil)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "fn-v2", getBackend(fnRef, fnMap, distribution, req).ObjectMeta.Name)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("X-Tester", "false")
+	assert.False(t, matchRoutingRule(&fnRef.Rules[0], req))
+	assert.NotNil(t, getBackend(fnRef, fnMap, distribution, req))
+}
+
+func TestGetBackendBySticky(t *testing.T) {
+	fnMap, distribution := makeCanaryTestFunctions()
+	fnRef := &fv1.FunctionReference{
+		Type:          fv1.FunctionReferenceTypeFunctionWeights,
+		StickySession: &fv1.StickySession{Header: "X-User-Id"},
+	}
+
+	chosen := make(map[string]bool)
+	for _, user := range []string{"alice", "bob", "carol", "dave", "eve", "frank", "grace", "heidi"} {
+		req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		req.Header.Set("X-User-Id", user)
+
+		fn := getBackend(fnRef, fnMap, distribution, req)
+		chosen[fn.ObjectMeta.Name] = true
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, fn.ObjectMeta.Name, getBackend(fnRef, fnMap, distribution, req).ObjectMeta.Name)
+		}
+	}
+	// sessions are spread across functions
+	assert.Len(t, chosen, 2)
+}
+
+func TestFindCeil(t *testing.T) {
+	distribution := []FunctionWeightDistribution{
+		{name: "a", sumPrefix: 10},
+		{name: "b", sumPrefix: 30},
+		{name: "c", sumPrefix: 60},
+		{name: "d", sumPrefix: 100},
+	}
+	// numbers are 1-based, up to the sum of weights
+	assert.Equal(t, "a", findCeil(1, distribution))
+	assert.Equal(t, "a", findCeil(10, distribution))
+	assert.Equal(t, "b", findCeil(11, distribution))
+	assert.Equal(t, "b", findCeil(30, distribution))
+	assert.Equal(t, "c", findCeil(45, distribution))
+	assert.Equal(t, "d", findCeil(61, distribution))
+	assert.Equal(t, "d", findCeil(100, distribution))
+	assert.Equal(t, "", findCeil(101, distribution))
+}
@@ -0,0 +1,400 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	ferror "github.com/fission/fission/pkg/error"
+)
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+const (
+	// outcomes of a request reported to a circuit breaker
+	breakerOutcomeSuccess breakerOutcome = iota
+	breakerOutcomeFailure
+	// the request says nothing about the health of the function,
+	// e.g. client canceled it.
+	breakerOutcomeIgnored
+)
+
+const (
+	defaultBreakerConsecutiveFailures = 5
+	defaultBreakerOpenDuration        = 30 * time.Second
+	defaultBreakerHalfOpenRequests    = 1
+
+	// idle time for a closed service address breaker to be evicted
+	serviceBreakerExpiry = 10 * time.Minute
+)
+
+type (
+	circuitState int
+
+	breakerOutcome int
+
+	// circuitBreaker tracks the failures of a function or of one service
+	// address of a function.
+	circuitBreaker struct {
+		fnMeta     metav1.ObjectMeta
+		serviceUrl string
+		spec       fv1.CircuitBreaker
+
+		lock              sync.Mutex
+		state             circuitState
+		failures          int
+		openedAt          time.Time
+		halfOpenInFlight  int
+		halfOpenSuccesses int
+		atime             time.Time
+	}
+
+	// circuitBreakerSet keeps the circuit breakers of functions and their
+	// service addresses, so that the breaker state survives router updates.
+	circuitBreakerSet struct {
+		sync.Mutex
+		functions map[k8stypes.UID]*circuitBreaker
+		services  map[string]*circuitBreaker
+		lastSweep time.Time
+	}
+
+	// circuitBreakerStatus is the breaker state shown on the debug endpoint.
+	circuitBreakerStatus struct {
+		Namespace  string     `json:"namespace"`
+		Function   string     `json:"function"`
+		ServiceUrl string     `json:"serviceUrl,omitempty"`
+		State      string     `json:"state"`
+		Failures   int        `json:"failures"`
+		OpenedAt   *time.Time `json:"openedAt,omitempty"`
+	}
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+func makeCircuitBreakerSet() *circuitBreakerSet {
+	return &circuitBreakerSet{
+		functions: make(map[k8stypes.UID]*circuitBreaker),
+		services:  make(map[string]*circuitBreaker),
+		lastSweep: time.Now(),
+	}
+}
+
+func makeCircuitBreaker(fnMeta metav1.ObjectMeta, serviceUrl string, spec fv1.CircuitBreaker) *circuitBreaker {
+	cb := &circuitBreaker{
+		fnMeta:     fnMeta,
+		serviceUrl: serviceUrl,
+		spec:       normalizeBreakerSpec(spec),
+		atime:      time.Now(),
+	}
+	observeCircuitState(&cb.fnMeta, cb.serviceUrl, circuitClosed)
+	return cb
+}
+
+// getFunctionBreaker returns the breaker of the function, or nil if
+// circuit breaking is not enabled for the function.
+func (cbs *circuitBreakerSet) getFunctionBreaker(fn *fv1.Function) *circuitBreaker {
+	if cbs == nil || fn.Spec.CircuitBreaker == nil {
+		return nil
+	}
+
+	cbs.Lock()
+	defer cbs.Unlock()
+
+	cb, ok := cbs.functions[fn.ObjectMeta.UID]
+	if !ok || cb.spec != normalizeBreakerSpec(*fn.Spec.CircuitBreaker) {
+		cb = makeCircuitBreaker(fn.ObjectMeta, "", *fn.Spec.CircuitBreaker)
+		cbs.functions[fn.ObjectMeta.UID] = cb
+	}
+	return cb
+}
+
+// getServiceBreaker returns the breaker of a service address of the function,
+// or nil if circuit breaking is not enabled for the function.
+func (cbs *circuitBreakerSet) getServiceBreaker(fn *fv1.Function, serviceUrl *url.URL) *circuitBreaker {
+	if cbs == nil || fn.Spec.CircuitBreaker == nil || serviceUrl == nil {
+		return nil
+	}
+
+	cbs.Lock()
+	defer cbs.Unlock()
+
+	now := time.Now()
+
+	// evict breakers of addresses that are gone, e.g. pods recycled by executor
+	if now.Sub(cbs.lastSweep) > serviceBreakerExpiry {
+		for k, cb := range cbs.services {
+			if cb.expired(now) {
+				delete(cbs.services, k)
+				deleteCircuitState(&cb.fnMeta, cb.serviceUrl)
+			}
+		}
+		cbs.lastSweep = now
+	}
+
+	key := fmt.Sprintf("%v/%v", fn.ObjectMeta.UID, serviceUrl.Host)
+	cb, ok := cbs.services[key]
+	if !ok || cb.spec != normalizeBreakerSpec(*fn.Spec.CircuitBreaker) {
+		cb = makeCircuitBreaker(fn.ObjectMeta, serviceUrl.Host, *fn.Spec.CircuitBreaker)
+		cbs.services[key] = cb
+	}
+	return cb
+}
+
+// prune removes breakers of functions that no longer exist or
+// have circuit breaking disabled.
+func (cbs *circuitBreakerSet) prune(functions []fv1.Function) {
+	active := make(map[k8stypes.UID]struct{}, len(functions))
+	for _, fn := range functions {
+		if fn.Spec.CircuitBreaker != nil {
+			active[fn.ObjectMeta.UID] = struct{}{}
+		}
+	}
+
+	cbs.Lock()
+	defer cbs.Unlock()
+
+	for uid, cb := range cbs.functions {
+		if _, ok := active[uid]; !ok {
+			delete(cbs.functions, uid)
+			deleteCircuitState(&cb.fnMeta, cb.serviceUrl)
+		}
+	}
+	for k, cb := range cbs.services {
+		if _, ok := active[cb.fnMeta.UID]; !ok {
+			delete(cbs.services, k)
+			deleteCircuitState(&cb.fnMeta, cb.serviceUrl)
+		}
+	}
+}
+
+// status returns the state of all breakers.
+func (cbs *circuitBreakerSet) status() []circuitBreakerStatus {
+	cbs.Lock()
+	breakers := make([]*circuitBreaker, 0, len(cbs.functions)+len(cbs.services))
+	for _, cb := range cbs.functions {
+		breakers = append(breakers, cb)
+	}
+	for _, cb := range cbs.services {
+		breakers = append(breakers, cb)
+	}
+	cbs.Unlock()
+
+	result := make([]circuitBreakerStatus, 0, len(breakers))
+	for _, cb := range breakers {
+		result = append(result, cb.status())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		if result[i].Function != result[j].Function {
+			return result[i].Function < result[j].Function
+		}
+		return result[i].ServiceUrl < result[j].ServiceUrl
+	})
+	return result
+}
+
+// debugHandler shows the state of circuit breakers.
+func (cbs *circuitBreakerSet) debugHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := json.Marshal(cbs.status())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// allow returns true if a request may go through. If it does, the
+// caller must report the outcome of the request with done.
+// A nil breaker allows every request.
+func (cb *circuitBreaker) allow() bool {
+	if cb == nil {
+		return true
+	}
+
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	cb.atime = time.Now()
+
+	if cb.state == circuitOpen {
+		if time.Since(cb.openedAt) < cb.openDuration() {
+			circuitRejected(&cb.fnMeta, cb.serviceUrl)
+			return false
+		}
+		cb.setState(circuitHalfOpen)
+		cb.halfOpenInFlight = 0
+		cb.halfOpenSuccesses = 0
+	}
+
+	if cb.state == circuitHalfOpen {
+		// only let a limited number of trial requests through
+		if cb.halfOpenInFlight+cb.halfOpenSuccesses >= cb.spec.HalfOpenRequests {
+			circuitRejected(&cb.fnMeta, cb.serviceUrl)
+			return false
+		}
+		cb.halfOpenInFlight++
+	}
+
+	return true
+}
+
+// done reports the outcome of a request allowed by the breaker.
+func (cb *circuitBreaker) done(outcome breakerOutcome) {
+	if cb == nil {
+		return
+	}
+
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	switch cb.state {
+	case circuitHalfOpen:
+		if cb.halfOpenInFlight > 0 {
+			cb.halfOpenInFlight--
+		}
+		switch outcome {
+		case breakerOutcomeSuccess:
+			cb.halfOpenSuccesses++
+			if cb.halfOpenSuccesses >= cb.spec.HalfOpenRequests {
+				cb.failures = 0
+				cb.setState(circuitClosed)
+			}
+		case breakerOutcomeFailure:
+			cb.open()
+		}
+	case circuitClosed:
+		switch outcome {
+		case breakerOutcomeSuccess:
+			cb.failures = 0
+		case breakerOutcomeFailure:
+			cb.failures++
+			if cb.failures >= cb.spec.ConsecutiveFailures {
+				cb.open()
+			}
+		}
+	}
+}
+
+func (cb *circuitBreaker) open() {
+	cb.openedAt = time.Now()
+	cb.setState(circuitOpen)
+}
+
+func (cb *circuitBreaker) setState(state circuitState) {
+	if cb.state != state {
+		cb.state = state
+		observeCircuitState(&cb.fnMeta, cb.serviceUrl, state)
+	}
+}
+
+func (cb *circuitBreaker) openDuration() time.Duration {
+	if cb.spec.OpenDuration > 0 {
+		return time.Duration(cb.spec.OpenDuration) * time.Second
+	}
+	return defaultBreakerOpenDuration
+}
+
+func (cb *circuitBreaker) expired(now time.Time) bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	return cb.state == circuitClosed && now.Sub(cb.atime) > serviceBreakerExpiry
+}
+
+func (cb *circuitBreaker) status() circuitBreakerStatus {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	s := circuitBreakerStatus{
+		Namespace:  cb.fnMeta.Namespace,
+		Function:   cb.fnMeta.Name,
+		ServiceUrl: cb.serviceUrl,
+		State:      cb.state.String(),
+		Failures:   cb.failures,
+	}
+	if cb.state != circuitClosed {
+		openedAt := cb.openedAt
+		s.OpenedAt = &openedAt
+	}
+	return s
+}
+
+// normalizeBreakerSpec fills in the defaults, so that specs can be
+// compared with the one of an existing breaker.
+func normalizeBreakerSpec(spec fv1.CircuitBreaker) fv1.CircuitBreaker {
+	if spec.ConsecutiveFailures <= 0 {
+		spec.ConsecutiveFailures = defaultBreakerConsecutiveFailures
+	}
+	if spec.HalfOpenRequests <= 0 {
+		spec.HalfOpenRequests = defaultBreakerHalfOpenRequests
+	}
+	return spec
+}
+
+// getBreakerOutcome tells whether a response from function service
+// counts as a failure.
+func getBreakerOutcome(resp *http.Response, err error) breakerOutcome {
+	if err != nil {
+		if err == context.Canceled {
+			return breakerOutcomeIgnored
+		}
+		if fe, ok := err.(ferror.Error); ok &&
+			(fe.Code == ferror.ErrorTooManyRequests || fe.Code == ferror.ErrorServiceUnavailable) {
+			// function is busy, or the request is rejected by
+			// the breaker of a service address
+			return breakerOutcomeIgnored
+		}
+		return breakerOutcomeFailure
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return breakerOutcomeFailure
+	}
+	return breakerOutcomeSuccess
+}
+
+// makeCircuitOpenError returns the error for requests rejected by an open breaker.
+func makeCircuitOpenError(fnMeta *metav1.ObjectMeta) error {
+	return ferror.MakeError(ferror.ErrorServiceUnavailable,
+		fmt.Sprintf("circuit breaker of function %v is open", fnMeta.Name))
+}
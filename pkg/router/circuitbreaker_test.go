@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	ferror "github.com/fission/fission/pkg/error"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	cb := makeCircuitBreaker(metav1.ObjectMeta{Name: "foo", Namespace: "bar"}, "", fv1.CircuitBreaker{
+		ConsecutiveFailures: 2,
+		OpenDuration:        1,
+		HalfOpenRequests:    2,
+	})
+
+	// success resets the failure count
+	assert.True(t, cb.allow())
+	cb.done(breakerOutcomeFailure)
+	assert.True(t, cb.allow())
+	cb.done(breakerOutcomeSuccess)
+	assert.True(t, cb.allow())
+	cb.done(breakerOutcomeFailure)
+	assert.Equal(t, circuitClosed, cb.state)
+
+	// consecutive failures open the breaker
+	assert.True(t, cb.allow())
+	cb.done(breakerOutcomeFailure)
+	assert.Equal(t, circuitOpen, cb.state)
+	assert.False(t, cb.allow())
+
+	// half-open after open duration, only limited trial requests go through
+	cb.openedAt = time.Now().Add(-2 * time.Second)
+	assert.True(t, cb.allow())
+	assert.Equal(t, circuitHalfOpen, cb.state)
+	assert.True(t, cb.allow())
+	assert.False(t, cb.allow())
+
+	// a failed trial request opens the breaker again
+	cb.done(breakerOutcomeSuccess)
+	cb.done(breakerOutcomeFailure)
+	assert.Equal(t, circuitOpen, cb.state)
+
+	// all trial requests succeed, breaker closes
+	cb.openedAt = time.Now().Add(-2 * time.Second)
+	assert.True(t, cb.allow())
+	assert.True(t, cb.allow())
+	cb.done(breakerOutcomeSuccess)
+	assert.Equal(t, circuitHalfOpen, cb.state)
+	cb.done(breakerOutcomeSuccess)
+	assert.Equal(t, circuitClosed, cb.state)
+	assert.True(t, cb.allow())
+
+	// nil breaker allows everything
+	var nilBreaker *circuitBreaker
+	assert.True(t, nilBreaker.allow())
+	nilBreaker.done(breakerOutcomeFailure)
+}
+
+func TestCircuitBreakerSet(t *testing.T) {
+	cbs := makeCircuitBreakerSet()
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "foo-uid"},
+	}
+	u, _ := url.Parse("http://10.0.0.1:8888")
+
+	// disabled for function without circuit breaker spec
+	assert.Nil(t, cbs.getFunctionBreaker(fn))
+	assert.Nil(t, cbs.getServiceBreaker(fn, u))
+
+	fn.Spec.CircuitBreaker = &fv1.CircuitBreaker{}
+	cb := cbs.getFunctionBreaker(fn)
+	assert.NotNil(t, cb)
+	assert.Equal(t, defaultBreakerConsecutiveFailures, cb.spec.ConsecutiveFailures)
+	assert.True(t, cb == cbs.getFunctionBreaker(fn))
+	assert.NotNil(t, cbs.getServiceBreaker(fn, u))
+	assert.Len(t, cbs.status(), 2)
+
+	// breaker is recreated once spec changes
+	fn.Spec.CircuitBreaker = &fv1.CircuitBreaker{ConsecutiveFailures: 1}
+	assert.False(t, cb == cbs.getFunctionBreaker(fn))
+
+	cbs.prune([]fv1.Function{})
+	assert.Len(t, cbs.status(), 0)
+}
+
+func TestGetBreakerOutcome(t *testing.T) {
+	assert.Equal(t, breakerOutcomeSuccess, getBreakerOutcome(&http.Response{StatusCode: http.StatusOK}, nil))
+	assert.Equal(t, breakerOutcomeSuccess, getBreakerOutcome(&http.Response{StatusCode: http.StatusInternalServerError}, nil))
+	assert.Equal(t, breakerOutcomeFailure, getBreakerOutcome(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+	assert.Equal(t, breakerOutcomeFailure, getBreakerOutcome(nil, errors.New("dial tcp: connection refused")))
+	assert.Equal(t, breakerOutcomeIgnored, getBreakerOutcome(nil, context.Canceled))
+	assert.Equal(t, breakerOutcomeIgnored, getBreakerOutcome(nil, ferror.MakeError(ferror.ErrorTooManyRequests, "busy")))
+}
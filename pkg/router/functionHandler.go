@@ -59,6 +59,14 @@ type (
 		isDebugEnv               bool
 		svcAddrUpdateThrottler   *throttler.Throttler
 		functionTimeoutMap       map[k8stypes.UID]int
+		rateLimiter              *triggerRateLimiter
+		requestQueue             *requestQueueSet
+		trafficMirror            *trafficMirror
+		mirrorFunction           *fv1.Function
+		circuitBreakers          *circuitBreakerSet
+		responseCache            *responseCache
+		functionLoads            *functionLoadTracker
+		h2cTransports            *h2cTransportSet
 	}
 
 	tsRoundTripperParams struct {
@@ -79,6 +87,16 @@ type (
 		// Try to get a new one from executor.
 		// Default svcAddrRetryCount is 5.
 		svcAddrRetryCount int
+
+		// queueMaxLength is the max number of requests of a function that router
+		// holds while there is no function service available (e.g. executor
+		// responds 429 because the function reaches its max concurrency).
+		// Requests beyond the limit are rejected immediately. 0 disables queueing.
+		queueMaxLength int
+
+		// queueMaxWait is the max duration a request stays in the queue before
+		// router gives up and returns the error to user.
+		queueMaxWait time.Duration
 	}
 
 	// A layer on top of http.DefaultTransport, with retries.
@@ -90,6 +108,13 @@ type (
 		serviceUrl       *url.URL
 		urlFromCache     bool
 		totalRetry       int
+
+		// cacheResult is the response cache result of the request.
+		cacheResult string
+
+		// session holds the function service of a streaming
+		// connection, nil if the trigger is not in streaming mode.
+		session *streamingSession
 	}
 
 	// To keep the request body open during retries, we create an interface with Close operation being a no-op.
@@ -141,13 +166,24 @@ func (w *fakeCloseReadCloser) RealClose() error {
 // inside ServeHttp function of the reverseProxy.
 // Earlier, GetServiceForFunction was called inside handler function and fission explicitly set http status code to 500
 // if it returned an error.
+//
+// If circuit breaking is enabled for the function, requests are rejected with 503
+// without any retries while the breaker of the function or of its service address is open.
 func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	breaker := roundTripper.funcHandler.circuitBreakers.getFunctionBreaker(roundTripper.funcHandler.function)
+	if !breaker.allow() {
+		return nil, makeCircuitOpenError(&roundTripper.funcHandler.function.ObjectMeta)
+	}
+	resp, err := roundTripper.roundTrip(req)
+	breaker.done(getBreakerOutcome(resp, err))
+	return resp, err
+}
+
+// roundTrip forwards the request to the function service with retries.
+func (roundTripper *RetryingRoundTripper) roundTrip(req *http.Request) (*http.Response, error) {
 	// set the timeout for transport context
 	roundTripper.addForwardedHostHeader(req)
 
-	transport := roundTripper.getDefaultTransport()
-	ocRoundTripper := &ochttp.Transport{Base: transport}
-
 	executingTimeout := roundTripper.funcHandler.tsRoundTripperParams.timeout
 
 	// wrap the req.Body with another ReadCloser interface.
@@ -162,6 +198,9 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 		}
 	}()
 
+	// wake up queued requests of the function once this one is done
+	defer roundTripper.funcHandler.signalRequestQueue()
+
 	roundTripper.logger.Debug("request headers", zap.Any("headers", req.Header))
 
 	// The reason for request failure may vary from case to case.
@@ -184,16 +223,22 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 		if retryCounter == 0 {
 			// get function service url from cache or executor
 			roundTripper.serviceUrl, err = roundTripper.funcHandler.getServiceEntryFromExecutor()
+			if err != nil {
+				if statusCode, _ := ferror.GetHTTPError(err); statusCode == http.StatusTooManyRequests {
+					// no function service available now, hold the request in queue
+					// until a pod gets specialized or a slot opens.
+					roundTripper.serviceUrl, err = roundTripper.funcHandler.waitForServiceEntry(req.Context(), err)
+				}
+			}
 			if err != nil {
 				// We might want a specific error code or header for fission failures as opposed to
 				// user function bugs.
 				statusCode, errMsg := ferror.GetHTTPError(err)
-				// if statusCode == http.StatusTooManyRequests {
-				// 	time.Sleep(executingTimeout)
-				// 	executingTimeout = executingTimeout * time.Duration(roundTripper.funcHandler.tsRoundTripperParams.timeoutExponent)
-				// 	continue
-				// } else {
 				if roundTripper.funcHandler.isDebugEnv {
+					reqID := req.Header.Get(HEADER_REQUEST_ID)
+					errMsg = errorMessageWithRequestID(errMsg, reqID)
+					header := make(http.Header)
+					header.Set(HEADER_REQUEST_ID, reqID)
 					return &http.Response{
 						StatusCode:    statusCode,
 						Proto:         req.Proto,
@@ -202,14 +247,22 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 						Body:          ioutil.NopCloser(bytes.NewBufferString(errMsg)),
 						ContentLength: int64(len(errMsg)),
 						Request:       req,
-						Header:        make(http.Header),
+						Header:        header,
 					}, nil
 				}
+				if statusCode == http.StatusTooManyRequests || err == context.Canceled {
+					return nil, err
+				}
 				return nil, ferror.MakeError(http.StatusInternalServerError, err.Error())
-				// }
 			}
 			if roundTripper.funcHandler.function.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypePoolmgr {
-				defer roundTripper.funcHandler.unTapService(roundTripper.funcHandler.function, roundTripper.serviceUrl)
+				serviceUrl := roundTripper.serviceUrl
+				defer func() {
+					// service of a streaming connection is released when the connection closes
+					if !roundTripper.session.owns(serviceUrl) {
+						roundTripper.funcHandler.unTapService(roundTripper.funcHandler.function, serviceUrl)
+					}
+				}()
 			}
 
 			// modify the request to reflect the service url
@@ -222,7 +275,13 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 			// multiple functions per container, we could use the
 			// function metadata here.
 			// leave the query string intact (req.URL.RawQuery)
-			req.URL.Path = "/"
+			//
+			// h2c functions (e.g. gRPC services) route by the
+			// original path, so keep it as-is.
+			if !roundTripper.funcHandler.isH2C() {
+				req.URL.Path = "/"
+				req.URL.RawPath = ""
+			}
 
 			// Overwrite request host with internal host,
 			// or request will be blocked in some situations
@@ -230,20 +289,31 @@ func (roundTripper *RetryingRoundTripper) RoundTrip(req *http.Request) (*http.Re
 			req.Host = roundTripper.serviceUrl.Host
 		}
 
-		// over-riding default settings.
-		transport.DialContext = (&net.Dialer{
-			Timeout:   executingTimeout,
-			KeepAlive: roundTripper.funcHandler.tsRoundTripperParams.keepAliveTime,
-		}).DialContext
+		var transport http.RoundTripper = roundTripper.getTransport(executingTimeout)
+		// ochttp wraps the response body, which hides the writable
+		// connection ReverseProxy needs to tunnel upgraded requests.
+		if !isUpgradeRequest(req) {
+			transport = &ochttp.Transport{Base: transport}
+		}
 
 		// Do NOT assign returned request to "req"
 		// because the request used in the last round
 		// will be canceled when calling setContext.
 		newReq := roundTripper.setContext(req)
 
+		// fail fast if the service address keeps failing
+		svcBreaker := roundTripper.funcHandler.circuitBreakers.getServiceBreaker(roundTripper.funcHandler.function, roundTripper.serviceUrl)
+		if !svcBreaker.allow() {
+			return nil, makeCircuitOpenError(fnMeta)
+		}
+
 		// forward the request to the function service
-		resp, err := ocRoundTripper.RoundTrip(newReq)
+		resp, err := transport.RoundTrip(newReq)
+		svcBreaker.done(getBreakerOutcome(resp, err))
 		if err == nil {
+			if roundTripper.session != nil {
+				roundTripper.session.start(roundTripper.serviceUrl)
+			}
 			// return response back to user
 			return resp, nil
 		}
@@ -325,6 +395,24 @@ func (roundTripper RetryingRoundTripper) getDefaultTransport() *http.Transport {
 	}
 }
 
+// getTransport returns the transport for sending requests to function pod
+// with the given dial timeout.
+func (roundTripper RetryingRoundTripper) getTransport(dialTimeout time.Duration) http.RoundTripper {
+	if roundTripper.funcHandler.isH2C() {
+		return roundTripper.funcHandler.h2cTransports.get(dialTimeout)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: roundTripper.funcHandler.tsRoundTripperParams.keepAliveTime,
+	}
+
+	// over-riding default settings.
+	transport := roundTripper.getDefaultTransport()
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
 // setContext returns a shallow copy of request with a new timeout context.
 func (roundTripper *RetryingRoundTripper) setContext(req *http.Request) *http.Request {
 	if roundTripper.closeContextFunc != nil {
@@ -334,7 +422,14 @@ func (roundTripper *RetryingRoundTripper) setContext(req *http.Request) *http.Re
 	// that user aborts connection before timeout. Otherwise,
 	// the request won't be canceled until the deadline exceeded
 	// which may be a potential security issue.
-	ctx, closeCtx := context.WithTimeout(req.Context(), roundTripper.funcTimeout)
+	var ctx context.Context
+	var closeCtx context.CancelFunc
+	if roundTripper.session != nil {
+		// streaming connections last until either side closes it
+		ctx, closeCtx = context.WithCancel(req.Context())
+	} else {
+		ctx, closeCtx = context.WithTimeout(req.Context(), roundTripper.funcTimeout)
+	}
 	roundTripper.closeContextFunc = &closeCtx
 
 	return req.WithContext(ctx)
@@ -355,14 +450,32 @@ func (fh *functionHandler) tapService(fn *fv1.Function, serviceUrl *url.URL) {
 }
 
 func (fh functionHandler) handler(responseWriter http.ResponseWriter, request *http.Request) {
+	// correlation id for matching client side errors with router logs
+	reqID := setRequestIDToHeader(request)
+	fh.logger = fh.logger.With(zap.String("request_id", reqID))
+
+	// enforce trigger rate limit before asking executor for a function service
+	if fh.rateLimiter != nil {
+		result, retryAfter := fh.rateLimiter.acquire(request)
+		triggerRequestCompleted(fh.httpTrigger.ObjectMeta.Namespace, fh.httpTrigger.ObjectMeta.Name, result)
+		if result != rateLimitResultAllowed {
+			fh.logger.Debug("request rejected by trigger rate limit",
+				zap.String("trigger", fh.httpTrigger.ObjectMeta.Name),
+				zap.String("result", result))
+			writeTooManyRequests(responseWriter, reqID, retryAfter, "too many requests")
+			return
+		}
+		defer fh.rateLimiter.release()
+	}
+
 	if fh.httpTrigger != nil && fh.httpTrigger.Spec.FunctionReference.Type == fv1.FunctionReferenceTypeFunctionWeights {
 		// canary deployment. need to determine the function to send request to now
-		fn := getCanaryBackend(fh.functionMap, fh.fnWeightDistributionList)
+		fn := getBackend(&fh.httpTrigger.Spec.FunctionReference, fh.functionMap, fh.fnWeightDistributionList, request)
 		if fn == nil {
 			fh.logger.Error("could not get canary backend",
 				zap.Any("fnMap", fh.functionMap),
 				zap.Any("distributionList", fh.fnWeightDistributionList))
-			// TODO : write error to responseWrite and return response
+			writeErrorResponse(responseWriter, reqID, http.StatusInternalServerError, "could not get canary backend")
 			return
 		}
 		fh.function = fn
@@ -375,6 +488,31 @@ func (fh functionHandler) handler(responseWriter http.ResponseWriter, request *h
 	// system params
 	setFunctionMetadataToHeader(&fh.function.ObjectMeta, request)
 
+	// serve the request from response cache if possible
+	cacheKey, cacheResult := fh.getResponseCacheKey(request)
+	if cacheResult == cacheResultMiss {
+		if entry, ok := fh.responseCache.get(cacheKey); ok {
+			start := time.Now()
+			responseWriter.Header().Set(HEADER_REQUEST_ID, reqID)
+			entry.write(responseWriter)
+
+			go fh.collectFunctionMetric(start, &RetryingRoundTripper{cacheResult: cacheResultHit}, request, &http.Response{
+				StatusCode:    entry.statusCode,
+				ContentLength: int64(len(entry.body)),
+			})
+			return
+		}
+	}
+
+	// send a copy of sampled requests to the shadow function
+	if fh.mirrorFunction != nil && shouldMirror(fh.httpTrigger.Spec.Mirror, request) {
+		if body, ok := copyRequestBody(request); ok {
+			fh.trafficMirror.mirror(fh.mirrorFunction, request, body)
+		} else {
+			fh.logger.Debug("request body is too large to mirror")
+		}
+	}
+
 	director := func(req *http.Request) {
 		if _, ok := req.Header["User-Agent"]; !ok {
 			// explicitly disable User-Agent so it's not set to default value
@@ -391,6 +529,7 @@ func (fh functionHandler) handler(responseWriter http.ResponseWriter, request *h
 		logger:      fh.logger.Named("roundtripper"),
 		funcHandler: &fh,
 		funcTimeout: time.Duration(fnTimeout) * time.Second,
+		cacheResult: cacheResult,
 	}
 
 	start := time.Now()
@@ -400,11 +539,32 @@ func (fh functionHandler) handler(responseWriter http.ResponseWriter, request *h
 		Transport:    rrt,
 		ErrorHandler: fh.getProxyErrorHandler(start, rrt),
 		ModifyResponse: func(resp *http.Response) error {
+			resp.Header.Set(HEADER_REQUEST_ID, reqID)
+			if cacheResult == cacheResultMiss {
+				resp.Header.Set(HEADER_CACHE, cacheResultMiss)
+			}
 			go fh.collectFunctionMetric(start, rrt, request, resp)
 			return nil
 		},
 	}
 
+	if fh.isH2C() {
+		// flush immediately to keep streaming responses (e.g. gRPC server streaming) flowing
+		proxy.FlushInterval = -1
+	}
+
+	if fh.isStreaming() {
+		rrt.session = makeStreamingSession(&fh)
+		defer rrt.session.end()
+
+		srw := makeStreamingResponseWriter(fh.logger, responseWriter, fh.streamingPingInterval())
+		defer srw.close()
+		responseWriter = srw
+
+		// flush immediately so that events reach client without delay
+		proxy.FlushInterval = -1
+	}
+
 	defer func() {
 		// If the context is closed when RoundTrip returns, client may receive
 		// truncated response body due to "context canceled" error. To avoid
@@ -418,22 +578,55 @@ func (fh functionHandler) handler(responseWriter http.ResponseWriter, request *h
 		rrt.closeContext()
 	}()
 
+	var recorder *cacheRecorder
+	if cacheResult == cacheResultMiss {
+		recorder = makeCacheRecorder(responseWriter, fh.responseCache.maxEntryBytes)
+		responseWriter = recorder
+	}
+
+	// count the request for request-driven autoscaling of the function
+	fh.functionLoads.start(fh.function)
+	defer fh.functionLoads.done(fh.function)
+
 	proxy.ServeHTTP(responseWriter, request)
+
+	if recorder != nil {
+		if entry, ok := recorder.response(cacheKey, fh.httpTrigger.Spec.Cache); ok {
+			fh.responseCache.set(entry)
+		}
+	}
+}
+
+// getResponseCacheKey returns the response cache key of the request and
+// whether the response cache is used for it. Streaming triggers never
+// use the response cache.
+func (fh functionHandler) getResponseCacheKey(req *http.Request) (string, string) {
+	if fh.responseCache == nil || fh.httpTrigger == nil || fh.httpTrigger.Spec.Cache == nil || fh.isStreaming() {
+		return "", cacheResultBypass
+	}
+	key, ok := getCacheKey(fh.httpTrigger, fh.function, req)
+	if !ok {
+		return "", cacheResultBypass
+	}
+	return key, cacheResultMiss
+}
+
+// isH2C returns true if requests should be proxied to function pod over HTTP/2 cleartext.
+func (fh functionHandler) isH2C() bool {
+	return fh.httpTrigger != nil && fh.httpTrigger.Spec.Protocol == fv1.TriggerProtocolH2C
 }
 
 // findCeil picks a function from the functionWeightDistribution list based on the
-// random number generated. It uses the prefix calculated for the function weights.
+// random number generated. It uses the prefix calculated for the function weights:
+// numbers are 1-based, a function is picked by the numbers in (previous sumPrefix, sumPrefix].
 func findCeil(randomNumber int, wtDistrList []FunctionWeightDistribution) string {
 	low := 0
 	high := len(wtDistrList) - 1
 
-	for {
-		if low >= high {
-			break
-		}
-
-		mid := low + high/2
-		if randomNumber >= wtDistrList[mid].sumPrefix {
+	// find the first function whose sumPrefix is greater than or equal to the number
+	for low < high {
+		mid := (low + high) / 2
+		if wtDistrList[mid].sumPrefix < randomNumber {
 			low = mid + 1
 		} else {
 			high = mid
@@ -449,7 +642,13 @@ func findCeil(randomNumber int, wtDistrList []FunctionWeightDistribution) string
 
 // picks a function to route to based on a random number generated
 func getCanaryBackend(fnMap map[string]*fv1.Function, fnWtDistributionList []FunctionWeightDistribution) *fv1.Function {
-	randomNumber := rand.Intn(fnWtDistributionList[len(fnWtDistributionList)-1].sumPrefix + 1)
+	total := fnWtDistributionList[len(fnWtDistributionList)-1].sumPrefix
+	if total <= 0 {
+		// all weights are zero
+		return fnMap[fnWtDistributionList[0].name]
+	}
+	// a number between 1 and the sum of weights
+	randomNumber := rand.Intn(total) + 1
 	fnName := findCeil(randomNumber, fnWtDistributionList)
 	return fnMap[fnName]
 }
@@ -529,6 +728,14 @@ func (fh functionHandler) getServiceEntryFromExecutor() (*url.URL, error) {
 	service, err := fh.executor.GetServiceForFunction(ctx, &fh.function.ObjectMeta)
 	if err != nil {
 		statusCode, errMsg := ferror.GetHTTPError(err)
+		if statusCode == http.StatusTooManyRequests {
+			// normal backpressure, the request is queued and retried
+			fh.logger.Debug("no function service available from GetServiceForFunction",
+				zap.String("error_message", errMsg),
+				zap.String("function_name", fh.function.ObjectMeta.Name),
+				zap.String("function_namespace", fh.function.ObjectMeta.Namespace))
+			return nil, err
+		}
 		fh.logger.Error("error from GetServiceForFunction",
 			zap.Error(err),
 			zap.String("error_message", errMsg),
@@ -549,6 +756,79 @@ func (fh functionHandler) getServiceEntryFromExecutor() (*url.URL, error) {
 	return serviceUrl, nil
 }
 
+// waitForServiceEntry holds the request in the queue of the function and retries
+// executor until a function service is available, the request is canceled or the
+// max wait time is reached. lastErr is returned if the request cannot be queued.
+func (fh functionHandler) waitForServiceEntry(ctx context.Context, lastErr error) (*url.URL, error) {
+	params := fh.tsRoundTripperParams
+	if fh.requestQueue == nil || params.queueMaxLength <= 0 {
+		fh.logger.Error("no function service available and request queueing is disabled",
+			zap.Error(lastErr), zap.String("function_name", fh.function.ObjectMeta.Name))
+		return nil, lastErr
+	}
+
+	uid := fh.function.ObjectMeta.UID
+	fnMeta := &fh.function.ObjectMeta
+	start := time.Now()
+
+	length, ok := fh.requestQueue.enter(uid, params.queueMaxLength)
+	observeQueueLength(fnMeta, length)
+	if !ok {
+		fh.logger.Error("no function service available and request queue of function is full",
+			zap.Error(lastErr), zap.String("function_name", fnMeta.Name), zap.Int("queue_length", length))
+		observeQueueWait(fnMeta, queueResultRejected, time.Since(start))
+		return nil, lastErr
+	}
+	defer func() {
+		observeQueueLength(fnMeta, fh.requestQueue.leave(uid))
+	}()
+
+	deadline := time.NewTimer(params.queueMaxWait)
+	defer deadline.Stop()
+
+	backoff := params.timeout
+	for {
+		retry := time.NewTimer(backoff)
+		select {
+		case <-fh.requestQueue.waitChan(uid):
+		case <-retry.C:
+		case <-deadline.C:
+			retry.Stop()
+			fh.logger.Error("no function service available after waiting in queue",
+				zap.Error(lastErr), zap.String("function_name", fnMeta.Name), zap.Duration("wait_time", time.Since(start)))
+			observeQueueWait(fnMeta, queueResultTimeout, time.Since(start))
+			return nil, lastErr
+		case <-ctx.Done():
+			retry.Stop()
+			observeQueueWait(fnMeta, queueResultCanceled, time.Since(start))
+			return nil, ctx.Err()
+		}
+		retry.Stop()
+
+		serviceUrl, err := fh.getServiceEntryFromExecutor()
+		if err == nil {
+			observeQueueWait(fnMeta, queueResultServed, time.Since(start))
+			return serviceUrl, nil
+		}
+		if statusCode, _ := ferror.GetHTTPError(err); statusCode != http.StatusTooManyRequests {
+			return nil, err
+		}
+		lastErr = err
+
+		if backoff < params.queueMaxWait {
+			backoff = backoff * time.Duration(params.timeoutExponent)
+		}
+	}
+}
+
+// signalRequestQueue wakes up the queued requests of the function.
+func (fh functionHandler) signalRequestQueue() {
+	if fh.requestQueue == nil {
+		return
+	}
+	fh.requestQueue.signal(fh.function.ObjectMeta.UID)
+}
+
 // getProxyErrorHandler returns a reverse proxy error handler
 func (fh functionHandler) getProxyErrorHandler(start time.Time, rrt *RetryingRoundTripper) func(rw http.ResponseWriter, req *http.Request, err error) {
 	return func(rw http.ResponseWriter, req *http.Request, err error) {
@@ -569,6 +849,18 @@ func (fh functionHandler) getProxyErrorHandler(start time.Time, rrt *RetryingRou
 			msg = "function not responses before the timeout"
 			fh.logger.Error(msg, zap.Any("function", fh.function), zap.Any("request_header", req.Header))
 		default:
+			if fe, ok := err.(ferror.Error); ok && fe.Code == ferror.ErrorTooManyRequests {
+				status = http.StatusTooManyRequests
+				msg = "function is busy, please retry later"
+				fh.logger.Debug(msg, zap.Error(err), zap.Any("function", fh.function))
+				break
+			}
+			if fe, ok := err.(ferror.Error); ok && fe.Code == ferror.ErrorServiceUnavailable {
+				status = http.StatusServiceUnavailable
+				msg = "function is temporarily unavailable, please retry later"
+				fh.logger.Debug(msg, zap.Error(err), zap.Any("function", fh.function))
+				break
+			}
 			status = http.StatusBadGateway
 			msg = "error sending request to function"
 			fh.logger.Error(msg, zap.Error(err), zap.Any("function", fh.function), zap.Any("request_header", req.Header))
@@ -579,10 +871,30 @@ func (fh functionHandler) getProxyErrorHandler(start time.Time, rrt *RetryingRou
 			ContentLength: 0,
 		})
 
-		// TODO: return error message that contains traceable UUID back to user. Issue #693
-		rw.WriteHeader(status)
-		rw.Write([]byte(msg))
+		if isGRPCRequest(req) {
+			writeGRPCErrorResponse(rw, req.Header.Get(HEADER_REQUEST_ID), status, msg)
+			return
+		}
+		writeErrorResponse(rw, req.Header.Get(HEADER_REQUEST_ID), status, msg)
+	}
+}
+
+// writeErrorResponse writes an error back to the client along with the
+// request id, so that the error can be traced in router logs.
+func writeErrorResponse(rw http.ResponseWriter, reqID string, status int, msg string) {
+	if len(reqID) > 0 {
+		rw.Header().Set(HEADER_REQUEST_ID, reqID)
+	}
+	rw.WriteHeader(status)
+	rw.Write([]byte(errorMessageWithRequestID(msg, reqID)))
+}
+
+// errorMessageWithRequestID appends the request id to an error message.
+func errorMessageWithRequestID(msg string, reqID string) string {
+	if len(reqID) == 0 {
+		return msg
 	}
+	return fmt.Sprintf("%v (request id: %v)", msg, reqID)
 }
 
 func (fh functionHandler) collectFunctionMetric(start time.Time, rrt *RetryingRoundTripper, req *http.Request, resp *http.Response) {
@@ -590,8 +902,12 @@ func (fh functionHandler) collectFunctionMetric(start time.Time, rrt *RetryingRo
 
 	// Metrics stuff
 	funcMetricLabels := &functionLabels{
-		namespace: fh.function.ObjectMeta.Namespace,
-		name:      fh.function.ObjectMeta.Name,
+		namespace:     fh.function.ObjectMeta.Namespace,
+		name:          fh.function.ObjectMeta.Name,
+		responseCache: rrt.cacheResult,
+	}
+	if len(funcMetricLabels.responseCache) == 0 {
+		funcMetricLabels.responseCache = cacheResultBypass
 	}
 	// generate complete http labels
 	source := req.Header.Get("X-Fission-Flow-Source")
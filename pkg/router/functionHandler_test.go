@@ -66,4 +66,11 @@ func TestProxyErrorHandler(t *testing.T) {
 	respRecorder = httptest.NewRecorder()
 	errHandler(respRecorder, req, errors.New("dummy"))
 	assert.Equal(t, http.StatusBadGateway, respRecorder.Code)
+
+	req.Header.Set(HEADER_REQUEST_ID, "dummy-request-id")
+	respRecorder = httptest.NewRecorder()
+	errHandler(respRecorder, req, errors.New("dummy"))
+	assert.Equal(t, http.StatusBadGateway, respRecorder.Code)
+	assert.Equal(t, "dummy-request-id", respRecorder.Header().Get(HEADER_REQUEST_ID))
+	assert.Contains(t, respRecorder.Body.String(), "dummy-request-id")
 }
@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	executorClient "github.com/fission/fission/pkg/executor/client"
+)
+
+// interval of reporting the load of functions to executor
+const functionLoadReportInterval = 5 * time.Second
+
+type (
+	// functionLoadTracker counts the in-flight and received requests of
+	// functions scaled by request load (ExecutionStrategy.TargetConcurrency
+	// or TargetRPS), and periodically reports them to executor.
+	functionLoadTracker struct {
+		sync.Mutex
+		logger   *zap.Logger
+		executor *executorClient.Client
+		source   string
+		loads    map[k8stypes.UID]*functionLoadCounter
+	}
+
+	functionLoadCounter struct {
+		fnMeta       metav1.ObjectMeta
+		executorType fv1.ExecutorType
+		inFlight     int
+		requests     int
+	}
+)
+
+func makeFunctionLoadTracker(logger *zap.Logger, executor *executorClient.Client) *functionLoadTracker {
+	if executor == nil {
+		return nil
+	}
+	// router pods are told apart by their hostname, i.e. the pod name
+	source, err := os.Hostname()
+	if err != nil {
+		logger.Error("error getting hostname for reporting function load", zap.Error(err))
+	}
+	tracker := &functionLoadTracker{
+		logger:   logger.Named("function_load"),
+		executor: executor,
+		source:   source,
+		loads:    make(map[k8stypes.UID]*functionLoadCounter),
+	}
+	go tracker.service()
+	return tracker
+}
+
+// isRequestDriven returns true if the function is scaled by request load.
+func isRequestDriven(fn *fv1.Function) bool {
+	es := fn.Spec.InvokeStrategy.ExecutionStrategy
+	return es.TargetConcurrency > 0 || es.TargetRPS > 0
+}
+
+// start counts a request of the function.
+func (t *functionLoadTracker) start(fn *fv1.Function) {
+	if t == nil || !isRequestDriven(fn) {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	load, ok := t.loads[fn.ObjectMeta.UID]
+	if !ok {
+		load = &functionLoadCounter{
+			fnMeta: metav1.ObjectMeta{
+				Name:            fn.ObjectMeta.Name,
+				Namespace:       fn.ObjectMeta.Namespace,
+				ResourceVersion: fn.ObjectMeta.ResourceVersion,
+				UID:             fn.ObjectMeta.UID,
+			},
+			executorType: fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType,
+		}
+		t.loads[fn.ObjectMeta.UID] = load
+	}
+	load.inFlight++
+	load.requests++
+}
+
+// done marks a request of the function counted by start as finished.
+func (t *functionLoadTracker) done(fn *fv1.Function) {
+	if t == nil || !isRequestDriven(fn) {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	if load, ok := t.loads[fn.ObjectMeta.UID]; ok && load.inFlight > 0 {
+		load.inFlight--
+	}
+}
+
+// reports returns the load of functions since the last call. Functions
+// without load are reported once more, so that executor sees the drop,
+// and then forgotten.
+func (t *functionLoadTracker) reports(interval time.Duration) []executorClient.FunctionLoadReport {
+	t.Lock()
+	defer t.Unlock()
+	reports := make([]executorClient.FunctionLoadReport, 0, len(t.loads))
+	for uid, load := range t.loads {
+		reports = append(reports, executorClient.FunctionLoadReport{
+			FnMetadata:     load.fnMeta,
+			FnExecutorType: load.executorType,
+			Source:         t.source,
+			InFlight:       load.inFlight,
+			Requests:       load.requests,
+			Interval:       interval,
+		})
+		if load.inFlight == 0 && load.requests == 0 {
+			delete(t.loads, uid)
+		}
+		load.requests = 0
+	}
+	return reports
+}
+
+func (t *functionLoadTracker) service() {
+	ticker := time.NewTicker(functionLoadReportInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		reports := t.reports(functionLoadReportInterval)
+		if len(reports) == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), functionLoadReportInterval)
+		err := t.executor.ReportFunctionLoad(ctx, reports)
+		cancel()
+		if err != nil {
+			t.logger.Error("error reporting function load", zap.Error(err), zap.Int("function_count", len(reports)))
+		}
+	}
+}
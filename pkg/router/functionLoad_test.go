@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestFunctionLoadTracker(t *testing.T) {
+	tracker := &functionLoadTracker{
+		logger: zap.NewNop(),
+		source: "router-a",
+		loads:  make(map[k8stypes.UID]*functionLoadCounter),
+	}
+
+	fn := &fv1.Function{
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default", UID: "1234"},
+		Spec: fv1.FunctionSpec{
+			InvokeStrategy: fv1.InvokeStrategy{
+				ExecutionStrategy: fv1.ExecutionStrategy{
+					ExecutorType:      fv1.ExecutorTypeNewdeploy,
+					TargetConcurrency: 10,
+				},
+			},
+		},
+	}
+	cpuScaled := fn.DeepCopy()
+	cpuScaled.ObjectMeta.UID = "5678"
+	cpuScaled.Spec.InvokeStrategy.ExecutionStrategy.TargetConcurrency = 0
+
+	tracker.start(fn)
+	tracker.start(fn)
+	tracker.done(fn)
+	tracker.start(fn)
+	tracker.start(cpuScaled)
+
+	reports := tracker.reports(5 * time.Second)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "hello", reports[0].FnMetadata.Name)
+	assert.Equal(t, fv1.ExecutorTypeNewdeploy, reports[0].FnExecutorType)
+	assert.Equal(t, "router-a", reports[0].Source)
+	assert.Equal(t, 2, reports[0].InFlight)
+	assert.Equal(t, 3, reports[0].Requests)
+
+	// requests are counted per report interval
+	tracker.done(fn)
+	tracker.done(fn)
+	reports = tracker.reports(5 * time.Second)
+	assert.Len(t, reports, 1)
+	assert.Equal(t, 0, reports[0].InFlight)
+	assert.Equal(t, 0, reports[0].Requests)
+
+	// idle functions are forgotten after reporting the drop
+	assert.Empty(t, tracker.reports(5*time.Second))
+
+	// a nil tracker is disabled
+	var disabled *functionLoadTracker
+	disabled.start(fn)
+	disabled.done(fn)
+}
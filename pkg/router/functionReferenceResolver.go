@@ -18,6 +18,7 @@ package router
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
@@ -152,7 +153,16 @@ func (frr *functionReferenceResolver) resolveByFunctionWeights(namespace string,
 	fnWtDistrList := make([]FunctionWeightDistribution, 0)
 	sumPrefix := 0
 
-	for functionName, functionWeight := range fr.FunctionWeights {
+	// keep the distribution in a stable order, so that
+	// sticky sessions hash to the same function.
+	functionNames := make([]string, 0, len(fr.FunctionWeights))
+	for functionName := range fr.FunctionWeights {
+		functionNames = append(functionNames, functionName)
+	}
+	sort.Strings(functionNames)
+
+	for _, functionName := range functionNames {
+		functionWeight := fr.FunctionWeights[functionName]
 		// get function from cache
 		obj, isExist, err := frr.store.Get(&fv1.Function{
 			ObjectMeta: metav1.ObjectMeta{
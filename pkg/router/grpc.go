@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// gRPC status codes, see https://github.com/grpc/grpc/blob/master/doc/statuscodes.md
+const (
+	grpcStatusCanceled          = 1
+	grpcStatusUnknown           = 2
+	grpcStatusDeadlineExceeded  = 4
+	grpcStatusPermissionDenied  = 7
+	grpcStatusResourceExhausted = 8
+	grpcStatusInternal          = 13
+	grpcStatusUnavailable       = 14
+	grpcStatusUnauthenticated   = 16
+)
+
+// h2cTransportSet keeps the h2c transports of router by dial timeout, so
+// that connections to h2c function pods are reused and multiplexed across
+// requests and router updates.
+type h2cTransportSet struct {
+	lock          sync.Mutex
+	keepAliveTime time.Duration
+	transports    map[time.Duration]*http2.Transport
+}
+
+func makeH2CTransportSet(keepAliveTime time.Duration) *h2cTransportSet {
+	return &h2cTransportSet{
+		keepAliveTime: keepAliveTime,
+		transports:    make(map[time.Duration]*http2.Transport),
+	}
+}
+
+// get returns the h2c transport with the given dial timeout.
+func (s *h2cTransportSet) get(dialTimeout time.Duration) *http2.Transport {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	transport, ok := s.transports[dialTimeout]
+	if !ok {
+		dialer := &net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: s.keepAliveTime,
+		}
+		// http2.Transport always dials TLS connection, override
+		// DialTLS to talk HTTP/2 over cleartext TCP connection.
+		transport = &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return dialer.Dial(network, addr)
+			},
+		}
+		s.transports[dialTimeout] = transport
+	}
+	return transport
+}
+
+// isGRPCRequest returns true if the request is a gRPC call.
+func isGRPCRequest(req *http.Request) bool {
+	return req.ProtoMajor == 2 && strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc")
+}
+
+// httpStatusToGRPCStatus maps a router error status to the gRPC status code.
+func httpStatusToGRPCStatus(status int) int {
+	switch status {
+	case 499:
+		return grpcStatusCanceled
+	case http.StatusGatewayTimeout:
+		return grpcStatusDeadlineExceeded
+	case http.StatusTooManyRequests:
+		return grpcStatusResourceExhausted
+	case http.StatusUnauthorized:
+		return grpcStatusUnauthenticated
+	case http.StatusForbidden:
+		return grpcStatusPermissionDenied
+	case http.StatusInternalServerError:
+		return grpcStatusInternal
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return grpcStatusUnavailable
+	default:
+		return grpcStatusUnknown
+	}
+}
+
+// writeGRPCErrorResponse writes a trailers-only gRPC response, since
+// gRPC clients read the call status from grpc-status instead of
+// the HTTP status code.
+func writeGRPCErrorResponse(rw http.ResponseWriter, reqID string, status int, msg string) {
+	if len(reqID) > 0 {
+		rw.Header().Set(HEADER_REQUEST_ID, reqID)
+	}
+	rw.Header().Set("Content-Type", "application/grpc")
+	rw.Header().Set("Grpc-Status", strconv.Itoa(httpStatusToGRPCStatus(status)))
+	rw.Header().Set("Grpc-Message", encodeGRPCMessage(errorMessageWithRequestID(msg, reqID)))
+	rw.WriteHeader(http.StatusOK)
+}
+
+// encodeGRPCMessage percent-encodes the message as required by
+// https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md
+func encodeGRPCMessage(msg string) string {
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			sb.WriteByte(c)
+		} else {
+			sb.WriteString(fmt.Sprintf("%%%02X", c))
+		}
+	}
+	return sb.String()
+}
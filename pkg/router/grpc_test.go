@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestGRPCProxyErrorHandler(t *testing.T) {
+	fh := &functionHandler{
+		logger: zap.NewNop(),
+		function: &fv1.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dummy",
+				Namespace: "dummy-bar",
+			},
+		},
+		httpTrigger: &fv1.HTTPTrigger{
+			Spec: fv1.HTTPTriggerSpec{
+				Protocol: fv1.TriggerProtocolH2C,
+			},
+		},
+	}
+	assert.True(t, fh.isH2C())
+
+	errHandler := fh.getProxyErrorHandler(time.Now(), &RetryingRoundTripper{})
+
+	req := httptest.NewRequest("POST", "http://foobar.com/helloworld.Greeter/SayHello", nil)
+	req.ProtoMajor = 2
+	req.Header.Set("Content-Type", "application/grpc+proto")
+	assert.True(t, isGRPCRequest(req))
+
+	respRecorder := httptest.NewRecorder()
+	errHandler(respRecorder, req, errors.New("dummy"))
+	assert.Equal(t, http.StatusOK, respRecorder.Code)
+	assert.Equal(t, "application/grpc", respRecorder.Header().Get("Content-Type"))
+	assert.Equal(t, "14", respRecorder.Header().Get("Grpc-Status"))
+}
+
+func TestEncodeGRPCMessage(t *testing.T) {
+	assert.Equal(t, "foo bar", encodeGRPCMessage("foo bar"))
+	assert.Equal(t, "100%25 done%0A", encodeGRPCMessage("100% done\n"))
+}
+
+func TestH2CTransportSet(t *testing.T) {
+	transports := makeH2CTransportSet(time.Minute)
+	transport := transports.get(time.Second)
+	assert.True(t, transport.AllowHTTP)
+	// connections are reused across requests
+	assert.True(t, transport == transports.get(time.Second))
+	assert.False(t, transport == transports.get(2*time.Second))
+}
@@ -18,6 +18,7 @@ package router
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"time"
 
@@ -57,10 +58,18 @@ type HTTPTriggerSet struct {
 	tsRoundTripperParams       *tsRoundTripperParams
 	isDebugEnv                 bool
 	svcAddrUpdateThrottler     *throttler.Throttler
+	rateLimiters               *rateLimiterSet
+	requestQueue               *requestQueueSet
+	trafficMirror              *trafficMirror
+	circuitBreakers            *circuitBreakerSet
+	responseCache              *responseCache
+	authenticators             *authenticatorSet
+	functionLoads              *functionLoadTracker
+	h2cTransports              *h2cTransportSet
 }
 
 func makeHTTPTriggerSet(logger *zap.Logger, fmap *functionServiceMap, fissionClient *crd.FissionClient,
-	kubeClient *kubernetes.Clientset, executor *executorClient.Client, crdClient rest.Interface, params *tsRoundTripperParams, isDebugEnv bool, actionThrottler *throttler.Throttler) (*HTTPTriggerSet, k8sCache.Store, k8sCache.Store) {
+	kubeClient *kubernetes.Clientset, executor *executorClient.Client, crdClient rest.Interface, params *tsRoundTripperParams, isDebugEnv bool, actionThrottler *throttler.Throttler, responseCacheSize int64, trustedProxies []*net.IPNet) (*HTTPTriggerSet, k8sCache.Store, k8sCache.Store) {
 
 	httpTriggerSet := &HTTPTriggerSet{
 		logger:                     logger.Named("http_trigger_set"),
@@ -74,6 +83,16 @@ func makeHTTPTriggerSet(logger *zap.Logger, fmap *functionServiceMap, fissionCli
 		tsRoundTripperParams:       params,
 		isDebugEnv:                 isDebugEnv,
 		svcAddrUpdateThrottler:     actionThrottler,
+		rateLimiters:               makeRateLimiterSet(trustedProxies),
+		requestQueue:               makeRequestQueueSet(),
+		trafficMirror:              makeTrafficMirror(logger, executor),
+		circuitBreakers:            makeCircuitBreakerSet(),
+		authenticators:             makeAuthenticatorSet(logger, kubeClient),
+		functionLoads:              makeFunctionLoadTracker(logger, executor),
+		h2cTransports:              makeH2CTransportSet(params.keepAliveTime),
+	}
+	if responseCacheSize > 0 {
+		httpTriggerSet.responseCache = makeResponseCache(responseCacheSize)
 	}
 	var tStore, fnStore k8sCache.Store
 	var tController, fnController k8sCache.Controller
@@ -118,6 +137,8 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 
 	// HTTP triggers setup by the user
 	homeHandled := false
+	// authenticators of the triggers of each function requiring authentication
+	fnAuthenticators := make(map[types.UID][]*triggerAuthenticator)
 	for i := range ts.triggers {
 		trigger := ts.triggers[i]
 
@@ -137,6 +158,12 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 			ts.logger.Panic("resolve result type not implemented", zap.Any("type", rr.resolveResultType))
 		}
 
+		if ta := ts.authenticators.get(&trigger); ta != nil {
+			for _, fn := range rr.functionMap {
+				fnAuthenticators[fn.ObjectMeta.UID] = append(fnAuthenticators[fn.ObjectMeta.UID], ta)
+			}
+		}
+
 		fh := &functionHandler{
 			logger:                   ts.logger.Named(trigger.ObjectMeta.Name),
 			fmap:                     ts.functionServiceMap,
@@ -148,6 +175,12 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 			isDebugEnv:               ts.isDebugEnv,
 			svcAddrUpdateThrottler:   ts.svcAddrUpdateThrottler,
 			functionTimeoutMap:       fnTimeoutMap,
+			rateLimiter:              ts.rateLimiters.get(&trigger),
+			requestQueue:             ts.requestQueue,
+			circuitBreakers:          ts.circuitBreakers,
+			responseCache:            ts.responseCache,
+			functionLoads:            ts.functionLoads,
+			h2cTransports:            ts.h2cTransports,
 		}
 
 		// The functionHandler for HTTP trigger with fn reference type "FunctionReferenceTypeFunctionName",
@@ -163,7 +196,21 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 			}
 		}
 
-		ht := muxRouter.HandleFunc(trigger.Spec.RelativeURL, fh.handler)
+		if trigger.Spec.Mirror != nil {
+			mr, err := ts.resolver.resolveByName(trigger.ObjectMeta.Namespace, trigger.Spec.Mirror.FunctionName)
+			if err != nil {
+				// keep serving the trigger without mirroring
+				ts.logger.Error("error resolving shadow function of trigger, skip mirroring",
+					zap.Error(err),
+					zap.String("trigger", trigger.ObjectMeta.Name),
+					zap.String("function", trigger.Spec.Mirror.FunctionName))
+			} else {
+				fh.trafficMirror = ts.trafficMirror
+				fh.mirrorFunction = mr.functionMap[trigger.Spec.Mirror.FunctionName]
+			}
+		}
+
+		ht := muxRouter.HandleFunc(trigger.Spec.RelativeURL, ts.authenticators.wrap(&trigger, fh.handler))
 		ht.Methods(trigger.Spec.Method)
 		if trigger.Spec.Host != "" {
 			ht.Host(trigger.Spec.Host)
@@ -183,6 +230,13 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 		muxRouter.HandleFunc("/", defaultHomeHandler).Methods("GET")
 	}
 
+	// drop rate limiters of deleted triggers
+	ts.rateLimiters.prune(ts.triggers)
+	// drop authenticators of deleted triggers
+	ts.authenticators.prune(ts.triggers)
+	// drop circuit breakers of deleted functions
+	ts.circuitBreakers.prune(ts.functions)
+
 	// Internal triggers for each function by name. Non-http
 	// triggers route into these.
 	for i := range ts.functions {
@@ -196,8 +250,16 @@ func (ts *HTTPTriggerSet) getRouter(fnTimeoutMap map[types.UID]int) *mux.Router
 			isDebugEnv:             ts.isDebugEnv,
 			svcAddrUpdateThrottler: ts.svcAddrUpdateThrottler,
 			functionTimeoutMap:     fnTimeoutMap,
+			requestQueue:           ts.requestQueue,
+			circuitBreakers:        ts.circuitBreakers,
+			functionLoads:          ts.functionLoads,
 		}
-		muxRouter.HandleFunc(utils.UrlForFunction(fn.ObjectMeta.Name, fn.ObjectMeta.Namespace), fh.handler)
+		// Internal routes are served on the same port as HTTP triggers, so a function
+		// behind an HTTP trigger requiring authentication can't be reached without the
+		// credentials of one of its triggers. Other triggers of the cluster calling it
+		// have to send them too. The identity headers are never trusted.
+		muxRouter.HandleFunc(utils.UrlForFunction(fn.ObjectMeta.Name, fn.ObjectMeta.Namespace),
+			authenticateAny(fnAuthenticators[fn.ObjectMeta.UID], fh.handler))
 	}
 
 	// Healthz endpoint for the router.
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var globalFunctionCallCount uint64
@@ -18,10 +19,13 @@ type (
 	// cache in this service.
 	//
 	// namespace and name are the metadata of the function.
+	//
+	// responseCache is the response cache result of the call.
 	functionLabels struct {
-		cached    bool
-		namespace string
-		name      string
+		cached        bool
+		namespace     string
+		name          string
+		responseCache string
 	}
 
 	// httpLabels is the set of metrics labels that relate to HTTP
@@ -47,7 +51,7 @@ var (
 	metricAddr = ":8080"
 
 	// function + http labels as strings
-	labelsStrings = []string{"cached", "namespace", "name", "host", "path", "method", "code"}
+	labelsStrings = []string{"cached", "namespace", "name", "host", "path", "method", "code", "response_cache"}
 
 	// Function http calls count
 	// cached: true | false, is this function service address cached locally
@@ -56,6 +60,7 @@ var (
 	// code: http status code
 	// path: the client call the function on which http path
 	// method: the function's http method
+	// response_cache: hit | miss | bypass, whether the response is served from router response cache
 
 	// fission flow recorder labels
 	flowLabelStrings = []string{"source", "destination", "stype", "dtype", "method", "code"}
@@ -103,6 +108,85 @@ var (
 		},
 		labelsStrings,
 	)
+	// Trigger requests count
+	// namespace: trigger namespace
+	// name: trigger name
+	// result: allowed | rate_limited | concurrency_limited
+	triggerRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_trigger_requests_total",
+			Help: "Count of requests to Fission HTTP triggers, by rate limit result",
+		},
+		[]string{"namespace", "name", "result"},
+	)
+	// Trigger authentication results
+	// namespace: trigger namespace
+	// name: trigger name
+	// result: allowed | unauthorized | forbidden | rejected | error
+	triggerAuthRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_trigger_auth_requests_total",
+			Help: "Count of requests to Fission HTTP triggers, by authentication result",
+		},
+		[]string{"namespace", "name", "result"},
+	)
+	// Function request queue
+	// namespace: function namespace
+	// name: function name
+	// result: served | timeout | rejected | canceled
+	functionQueueLength = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fission_function_queue_length",
+			Help: "Number of requests waiting in router for a function service",
+		},
+		[]string{"namespace", "name"},
+	)
+	functionQueueWait = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name:       "fission_function_queue_wait_seconds",
+			Help:       "The time requests waited in router for a function service",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+		[]string{"namespace", "name", "result"},
+	)
+	// Mirrored requests sent to shadow functions
+	// namespace: shadow function namespace
+	// name: shadow function name
+	// code: http status code | error | dropped
+	functionMirrorCalls = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_function_mirror_calls_total",
+			Help: "Count of mirrored requests sent to shadow functions",
+		},
+		[]string{"namespace", "name", "code"},
+	)
+	functionMirrorDuration = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name:       "fission_function_mirror_duration_seconds",
+			Help:       "Runtime duration of mirrored requests sent to shadow functions",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+		[]string{"namespace", "name", "code"},
+	)
+	// Circuit breakers of functions
+	// namespace: function namespace
+	// name: function name
+	// service: service address of the function, empty for the function breaker
+	// state: 0 closed | 1 open | 2 half-open
+	functionCircuitState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "fission_function_circuit_breaker_state",
+			Help: "State of function circuit breakers in router, 0 closed, 1 open, 2 half-open",
+		},
+		[]string{"namespace", "name", "service"},
+	)
+	functionCircuitRejected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "fission_function_circuit_breaker_rejected_total",
+			Help: "Count of requests rejected by function circuit breakers",
+		},
+		[]string{"namespace", "name", "service"},
+	)
 	fissionFlowRecorder = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "fission_flow_recorder_by_router",
@@ -119,6 +203,14 @@ func init() {
 	prometheus.MustRegister(functionCallOverhead)
 	prometheus.MustRegister(functionCallResponseSize)
 	prometheus.MustRegister(fissionFlowRecorder)
+	prometheus.MustRegister(triggerRequests)
+	prometheus.MustRegister(triggerAuthRequests)
+	prometheus.MustRegister(functionQueueLength)
+	prometheus.MustRegister(functionQueueWait)
+	prometheus.MustRegister(functionMirrorCalls)
+	prometheus.MustRegister(functionMirrorDuration)
+	prometheus.MustRegister(functionCircuitState)
+	prometheus.MustRegister(functionCircuitRejected)
 }
 
 func labelsToStrings(f *functionLabels, h *httpLabels) []string {
@@ -136,6 +228,7 @@ func labelsToStrings(f *functionLabels, h *httpLabels) []string {
 		h.path,
 		h.method,
 		fmt.Sprint(h.code),
+		f.responseCache,
 	}
 }
 
@@ -179,3 +272,38 @@ func functionCallCompleted(f *functionLabels, h *httpLabels, overhead, duration
 		functionCallResponseSize.WithLabelValues(l...).Observe(float64(respSize))
 	}
 }
+
+func triggerRequestCompleted(namespace, name, result string) {
+	triggerRequests.WithLabelValues(namespace, name, result).Inc()
+}
+
+func triggerAuthCompleted(namespace, name, result string) {
+	triggerAuthRequests.WithLabelValues(namespace, name, result).Inc()
+}
+
+func observeQueueLength(fn *metav1.ObjectMeta, length int) {
+	functionQueueLength.WithLabelValues(fn.Namespace, fn.Name).Set(float64(length))
+}
+
+func mirrorRequestCompleted(fn *metav1.ObjectMeta, code string, duration time.Duration) {
+	functionMirrorCalls.WithLabelValues(fn.Namespace, fn.Name, code).Inc()
+	if code != mirrorResultDropped {
+		functionMirrorDuration.WithLabelValues(fn.Namespace, fn.Name, code).Observe(duration.Seconds())
+	}
+}
+
+func observeCircuitState(fn *metav1.ObjectMeta, service string, state circuitState) {
+	functionCircuitState.WithLabelValues(fn.Namespace, fn.Name, service).Set(float64(state))
+}
+
+func deleteCircuitState(fn *metav1.ObjectMeta, service string) {
+	functionCircuitState.DeleteLabelValues(fn.Namespace, fn.Name, service)
+}
+
+func circuitRejected(fn *metav1.ObjectMeta, service string) {
+	functionCircuitRejected.WithLabelValues(fn.Namespace, fn.Name, service).Inc()
+}
+
+func observeQueueWait(fn *metav1.ObjectMeta, result string, wait time.Duration) {
+	functionQueueWait.WithLabelValues(fn.Namespace, fn.Name, result).Observe(wait.Seconds())
+}
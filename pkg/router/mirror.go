@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+	executorClient "github.com/fission/fission/pkg/executor/client"
+)
+
+const (
+	// HEADER_MIRROR tells the shadow function that the request is a mirrored copy.
+	HEADER_MIRROR = "X-Fission-Mirror"
+
+	// max number of mirrored requests router sends concurrently,
+	// sampled requests beyond it are dropped.
+	mirrorMaxInFlight = 100
+
+	// requests with a larger body are not mirrored, to bound router memory usage.
+	mirrorMaxBodySize = 1 << 20
+
+	// results of a mirrored request besides the HTTP status code, used as metric label
+	mirrorResultError   = "error"
+	mirrorResultDropped = "dropped"
+)
+
+type (
+	// trafficMirror sends copies of live requests to shadow functions and
+	// discards the responses. The results are only recorded as metrics, so
+	// that a new function version can be compared with the serving one
+	// without affecting clients.
+	trafficMirror struct {
+		logger   *zap.Logger
+		executor *executorClient.Client
+		client   *http.Client
+		slots    chan struct{}
+	}
+)
+
+func makeTrafficMirror(logger *zap.Logger, executor *executorClient.Client) *trafficMirror {
+	return &trafficMirror{
+		logger:   logger.Named("traffic_mirror"),
+		executor: executor,
+		client:   &http.Client{},
+		slots:    make(chan struct{}, mirrorMaxInFlight),
+	}
+}
+
+// shouldMirror decides whether the request is sampled for mirroring.
+func shouldMirror(config *fv1.MirrorConfig, req *http.Request) bool {
+	if config == nil || isUpgradeRequest(req) {
+		return false
+	}
+	return rand.Intn(100) < config.Percentage
+}
+
+// copyRequestBody reads the request body into memory and restores it
+// for the original request. It returns false if the body is too large
+// to be mirrored.
+func copyRequestBody(req *http.Request) ([]byte, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+	if req.ContentLength > mirrorMaxBodySize {
+		return nil, false
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, mirrorMaxBodySize+1))
+	if err != nil || len(body) > mirrorMaxBodySize {
+		// hand the bytes already read back to the original request
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		return nil, false
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, true
+}
+
+// mirror sends a copy of the request to the shadow function asynchronously.
+// The request must not be modified by the caller afterwards except its body.
+func (m *trafficMirror) mirror(fn *fv1.Function, req *http.Request, body []byte) {
+	select {
+	case m.slots <- struct{}{}:
+	default:
+		m.logger.Debug("too many mirrored requests in flight, dropping",
+			zap.String("function", fn.ObjectMeta.Name))
+		mirrorRequestCompleted(&fn.ObjectMeta, mirrorResultDropped, 0)
+		return
+	}
+
+	// clone the request before handing it over to another goroutine,
+	// since the original one is modified while being proxied.
+	header := make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	query := req.URL.RawQuery
+	method := req.Method
+
+	go func() {
+		defer func() { <-m.slots }()
+
+		start := time.Now()
+		code, err := m.send(fn, method, query, header, body)
+		if err != nil {
+			m.logger.Debug("error sending mirrored request",
+				zap.Error(err),
+				zap.String("function", fn.ObjectMeta.Name))
+			mirrorRequestCompleted(&fn.ObjectMeta, mirrorResultError, time.Since(start))
+			return
+		}
+		mirrorRequestCompleted(&fn.ObjectMeta, fmt.Sprint(code), time.Since(start))
+	}()
+}
+
+func (m *trafficMirror) send(fn *fv1.Function, method string, query string, header http.Header, body []byte) (int, error) {
+	timeout := time.Duration(fv1.DEFAULT_FUNCTION_TIMEOUT) * time.Second
+	if fn.Spec.FunctionTimeout > 0 {
+		timeout = time.Duration(fn.Spec.FunctionTimeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	service, err := m.executor.GetServiceForFunction(ctx, &fn.ObjectMeta)
+	if err != nil {
+		return 0, err
+	}
+	serviceUrl, err := url.Parse(fmt.Sprintf("http://%v", service))
+	if err != nil {
+		return 0, err
+	}
+	if fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypePoolmgr {
+		defer func() {
+			untapCtx, untapCancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer untapCancel()
+			err := m.executor.UnTapService(untapCtx, fn.ObjectMeta, fn.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType, serviceUrl)
+			if err != nil {
+				m.logger.Error("error from UnTapService", zap.Error(err), zap.String("function", fn.ObjectMeta.Name))
+			}
+		}()
+	}
+
+	target := *serviceUrl
+	target.Path = "/"
+	target.RawQuery = query
+
+	req, err := http.NewRequest(method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header = header
+	req.Header.Set(HEADER_MIRROR, "true")
+	setFunctionMetadataToHeader(&fn.ObjectMeta, req)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// throw away the shadow response
+	io.Copy(ioutil.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
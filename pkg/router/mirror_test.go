@@ -0,0 +1,65 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestShouldMirror(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	assert.False(t, shouldMirror(nil, req))
+	assert.True(t, shouldMirror(&fv1.MirrorConfig{FunctionName: "shadow", Percentage: 100}, req))
+
+	// upgraded connections are never mirrored
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	assert.False(t, shouldMirror(&fv1.MirrorConfig{FunctionName: "shadow", Percentage: 100}, req))
+}
+
+func TestCopyRequestBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader("hello"))
+	body, ok := copyRequestBody(req)
+	assert.True(t, ok)
+	assert.Equal(t, "hello", string(body))
+
+	// original request still has the body
+	b, err := ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	// body too large to mirror, streamed without content length
+	large := bytes.Repeat([]byte("a"), mirrorMaxBodySize+10)
+	req = httptest.NewRequest(http.MethodPost, "/foo", bytes.NewReader(large))
+	req.ContentLength = -1
+	body, ok = copyRequestBody(req)
+	assert.False(t, ok)
+	assert.Nil(t, body)
+
+	b, err = ioutil.ReadAll(req.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, large, b)
+}
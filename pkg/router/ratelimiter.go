@@ -0,0 +1,288 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// rate limit results of a request, used as metric label
+	rateLimitResultAllowed            = "allowed"
+	rateLimitResultRateLimited        = "rate_limited"
+	rateLimitResultConcurrencyLimited = "concurrency_limited"
+
+	// idle time for a per-key limiter to be evicted
+	rateLimiterKeyExpiry = 10 * time.Minute
+)
+
+type (
+	// triggerRateLimiter enforces the request rate limit and
+	// in-flight quota of an HTTP trigger.
+	triggerRateLimiter struct {
+		spec     fv1.RateLimit
+		inFlight int64
+
+		lock      sync.Mutex
+		limiters  map[string]*keyRateLimiter
+		lastSweep time.Time
+
+		// trustedProxies are the proxies whose X-Forwarded-For hops are trusted.
+		trustedProxies []*net.IPNet
+	}
+
+	// keyRateLimiter is the token bucket of a rate limit key.
+	keyRateLimiter struct {
+		limiter *rate.Limiter
+		atime   time.Time
+	}
+
+	// rateLimiterSet keeps the rate limiters of triggers, so that
+	// the limiter state survives router updates.
+	rateLimiterSet struct {
+		sync.Mutex
+		limiters       map[k8stypes.UID]*triggerRateLimiter
+		trustedProxies []*net.IPNet
+	}
+)
+
+func makeRateLimiterSet(trustedProxies []*net.IPNet) *rateLimiterSet {
+	return &rateLimiterSet{
+		limiters:       make(map[k8stypes.UID]*triggerRateLimiter),
+		trustedProxies: trustedProxies,
+	}
+}
+
+func makeTriggerRateLimiter(spec fv1.RateLimit, trustedProxies []*net.IPNet) *triggerRateLimiter {
+	return &triggerRateLimiter{
+		spec:           spec,
+		limiters:       make(map[string]*keyRateLimiter),
+		lastSweep:      time.Now(),
+		trustedProxies: trustedProxies,
+	}
+}
+
+// parseTrustedProxies parses a comma separated list of IPs and CIDRs of
+// the proxies in front of router.
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if len(s) == 0 {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy IP %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy CIDR %q", s)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
+
+// get returns the rate limiter of the trigger. A new limiter is created
+// if the trigger has no limiter yet or the rate limit spec has changed.
+func (rls *rateLimiterSet) get(trigger *fv1.HTTPTrigger) *triggerRateLimiter {
+	if trigger.Spec.RateLimit == nil {
+		return nil
+	}
+
+	rls.Lock()
+	defer rls.Unlock()
+
+	rl, ok := rls.limiters[trigger.ObjectMeta.UID]
+	if !ok || rl.spec != *trigger.Spec.RateLimit {
+		rl = makeTriggerRateLimiter(*trigger.Spec.RateLimit, rls.trustedProxies)
+		rls.limiters[trigger.ObjectMeta.UID] = rl
+	}
+	return rl
+}
+
+// prune removes limiters of triggers that no longer exist.
+func (rls *rateLimiterSet) prune(triggers []fv1.HTTPTrigger) {
+	active := make(map[k8stypes.UID]struct{}, len(triggers))
+	for _, t := range triggers {
+		active[t.ObjectMeta.UID] = struct{}{}
+	}
+
+	rls.Lock()
+	defer rls.Unlock()
+
+	for uid := range rls.limiters {
+		if _, ok := active[uid]; !ok {
+			delete(rls.limiters, uid)
+		}
+	}
+}
+
+// acquire checks whether the request is allowed. If it is, the caller must
+// call release once the request finishes. Otherwise, it returns the reason
+// for rejection and how long the client should wait before retrying.
+func (rl *triggerRateLimiter) acquire(req *http.Request) (result string, retryAfter time.Duration) {
+	if rl.spec.MaxInFlight > 0 {
+		if atomic.AddInt64(&rl.inFlight, 1) > int64(rl.spec.MaxInFlight) {
+			atomic.AddInt64(&rl.inFlight, -1)
+			return rateLimitResultConcurrencyLimited, time.Second
+		}
+	}
+
+	if rl.spec.RequestsPerSecond > 0 {
+		reservation := rl.getLimiter(rl.key(req)).Reserve()
+		delay := reservation.Delay()
+		if !reservation.OK() || delay > 0 {
+			reservation.Cancel()
+			rl.release()
+			if delay <= 0 || delay == rate.InfDuration {
+				delay = time.Second
+			}
+			return rateLimitResultRateLimited, delay
+		}
+	}
+
+	return rateLimitResultAllowed, 0
+}
+
+// release frees the in-flight slot taken by acquire.
+func (rl *triggerRateLimiter) release() {
+	if rl.spec.MaxInFlight > 0 {
+		atomic.AddInt64(&rl.inFlight, -1)
+	}
+}
+
+func (rl *triggerRateLimiter) getLimiter(key string) *rate.Limiter {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	now := time.Now()
+
+	// evict limiters of keys that have been idle for a while
+	if now.Sub(rl.lastSweep) > rateLimiterKeyExpiry {
+		for k, l := range rl.limiters {
+			if now.Sub(l.atime) > rateLimiterKeyExpiry {
+				delete(rl.limiters, k)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	l, ok := rl.limiters[key]
+	if !ok {
+		burst := rl.spec.Burst
+		if burst <= 0 {
+			burst = rl.spec.RequestsPerSecond
+		}
+		l = &keyRateLimiter{
+			limiter: rate.NewLimiter(rate.Limit(rl.spec.RequestsPerSecond), burst),
+		}
+		rl.limiters[key] = l
+	}
+	l.atime = now
+
+	return l.limiter
+}
+
+// key returns the rate limit bucket key of the request.
+func (rl *triggerRateLimiter) key(req *http.Request) string {
+	switch rl.spec.KeyType {
+	case fv1.RateLimitKeyTypeClientIP:
+		return getClientIP(req, rl.trustedProxies)
+	case fv1.RateLimitKeyTypeHeader:
+		if v := req.Header.Get(rl.spec.KeyHeader); len(v) > 0 {
+			return v
+		}
+		// fallback to client ip if header is absent, so that requests
+		// without the header don't share one bucket.
+		return getClientIP(req, rl.trustedProxies)
+	default:
+		return ""
+	}
+}
+
+// getClientIP returns the ip of the client. X-Forwarded-For is only honored
+// when the request comes from a trusted proxy: the hops are walked from the
+// right-most one and the first hop which is not a trusted proxy is the client,
+// since clients may send any X-Forwarded-For value themselves.
+func getClientIP(req *http.Request, trustedProxies []*net.IPNet) string {
+	client, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		client = req.RemoteAddr
+	}
+	if !isTrustedProxy(client, trustedProxies) {
+		return client
+	}
+
+	hops := strings.Split(strings.Join(req.Header["X-Forwarded-For"], ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if len(hop) == 0 {
+			continue
+		}
+		client = hop
+		if !isTrustedProxy(hop, trustedProxies) {
+			break
+		}
+	}
+	return client
+}
+
+func isTrustedProxy(addr string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTooManyRequests writes 429 with Retry-After header back to the client.
+func writeTooManyRequests(rw http.ResponseWriter, reqID string, retryAfter time.Duration, msg string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	rw.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeErrorResponse(rw, reqID, http.StatusTooManyRequests, msg)
+}
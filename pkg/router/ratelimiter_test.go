@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestTriggerRateLimiterInFlight(t *testing.T) {
+	rl := makeTriggerRateLimiter(fv1.RateLimit{MaxInFlight: 1}, nil)
+	req := httptest.NewRequest("GET", "http://foobar.com", nil)
+
+	result, _ := rl.acquire(req)
+	assert.Equal(t, rateLimitResultAllowed, result)
+
+	result, retryAfter := rl.acquire(req)
+	assert.Equal(t, rateLimitResultConcurrencyLimited, result)
+	assert.True(t, retryAfter > 0)
+
+	rl.release()
+	result, _ = rl.acquire(req)
+	assert.Equal(t, rateLimitResultAllowed, result)
+}
+
+func TestTriggerRateLimiterPerClientIP(t *testing.T) {
+	rl := makeTriggerRateLimiter(fv1.RateLimit{
+		RequestsPerSecond: 1,
+		Burst:             1,
+		KeyType:           fv1.RateLimitKeyTypeClientIP,
+	}, nil)
+
+	req1 := httptest.NewRequest("GET", "http://foobar.com", nil)
+	req1.RemoteAddr = "10.0.0.1:1234"
+	req2 := httptest.NewRequest("GET", "http://foobar.com", nil)
+	req2.Header.Set("X-Forwarded-For", "10.0.0.2, 10.0.0.1")
+
+	result, _ := rl.acquire(req1)
+	assert.Equal(t, rateLimitResultAllowed, result)
+	result, retryAfter := rl.acquire(req1)
+	assert.Equal(t, rateLimitResultRateLimited, result)
+	assert.True(t, retryAfter > 0)
+
+	// another client has its own bucket
+	result, _ = rl.acquire(req2)
+	assert.Equal(t, rateLimitResultAllowed, result)
+}
+
+func TestRateLimiterSet(t *testing.T) {
+	rls := makeRateLimiterSet(nil)
+	trigger := &fv1.HTTPTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "foo-uid"},
+	}
+	assert.Nil(t, rls.get(trigger))
+
+	trigger.Spec.RateLimit = &fv1.RateLimit{RequestsPerSecond: 1}
+	rl := rls.get(trigger)
+	assert.NotNil(t, rl)
+	assert.Equal(t, rl, rls.get(trigger))
+
+	// spec changes reset limiter
+	trigger.Spec.RateLimit = &fv1.RateLimit{RequestsPerSecond: 2}
+	assert.NotEqual(t, rl, rls.get(trigger))
+
+	rls.prune([]fv1.HTTPTrigger{})
+	assert.Empty(t, rls.limiters)
+}
+
+func TestGetClientIP(t *testing.T) {
+	proxies, err := parseTrustedProxies("10.0.0.0/8, 192.168.0.1")
+	assert.NoError(t, err)
+	assert.Len(t, proxies, 2)
+	_, err = parseTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+
+	req := httptest.NewRequest("GET", "http://foobar.com", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	// X-Forwarded-For of clients is ignored
+	assert.Equal(t, "203.0.113.1", getClientIP(req, proxies))
+	assert.Equal(t, "203.0.113.1", getClientIP(req, nil))
+
+	// the right-most hop not a trusted proxy is the client
+	req.RemoteAddr = "10.0.0.2:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.1, 192.168.0.1")
+	assert.Equal(t, "203.0.113.1", getClientIP(req, proxies))
+
+	req.Header.Set("X-Forwarded-For", "10.0.0.3")
+	assert.Equal(t, "10.0.0.3", getClientIP(req, proxies))
+
+	req.Header.Del("X-Forwarded-For")
+	assert.Equal(t, "10.0.0.2", getClientIP(req, proxies))
+}
+
+func TestWriteTooManyRequests(t *testing.T) {
+	rw := httptest.NewRecorder()
+	writeTooManyRequests(rw, "dummy", 0, "too many requests")
+	assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	assert.Equal(t, "1", rw.Header().Get("Retry-After"))
+}
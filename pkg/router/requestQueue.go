@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"sync"
+
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// results of a queued request, used as metric label
+	queueResultServed   = "served"
+	queueResultTimeout  = "timeout"
+	queueResultRejected = "rejected"
+	queueResultCanceled = "canceled"
+)
+
+type (
+	// requestQueueSet holds requests of functions that have no function
+	// service available yet, e.g. pool manager reached the max concurrency
+	// of a function. Instead of failing these requests immediately, router
+	// keeps them in a bounded per-function queue and retries when a slot
+	// may have opened.
+	requestQueueSet struct {
+		sync.Mutex
+		queues map[k8stypes.UID]*functionRequestQueue
+	}
+
+	functionRequestQueue struct {
+		// number of requests waiting in the queue
+		length int
+
+		// notify is closed and replaced every time a request
+		// of the function finishes, to wake up the waiters.
+		notify chan struct{}
+	}
+)
+
+func makeRequestQueueSet() *requestQueueSet {
+	return &requestQueueSet{
+		queues: make(map[k8stypes.UID]*functionRequestQueue),
+	}
+}
+
+// enter puts a request into the queue of the function. It returns false if
+// the queue is already full.
+func (rqs *requestQueueSet) enter(uid k8stypes.UID, maxLength int) (int, bool) {
+	rqs.Lock()
+	defer rqs.Unlock()
+
+	q, ok := rqs.queues[uid]
+	if !ok {
+		q = &functionRequestQueue{
+			notify: make(chan struct{}),
+		}
+		rqs.queues[uid] = q
+	}
+
+	if q.length >= maxLength {
+		return q.length, false
+	}
+	q.length++
+	return q.length, true
+}
+
+// leave removes a request from the queue of the function.
+func (rqs *requestQueueSet) leave(uid k8stypes.UID) int {
+	rqs.Lock()
+	defer rqs.Unlock()
+
+	q, ok := rqs.queues[uid]
+	if !ok {
+		return 0
+	}
+
+	q.length--
+	if q.length <= 0 {
+		delete(rqs.queues, uid)
+		return 0
+	}
+	return q.length
+}
+
+// waitChan returns a channel that is closed once a request of the
+// function finishes.
+func (rqs *requestQueueSet) waitChan(uid k8stypes.UID) <-chan struct{} {
+	rqs.Lock()
+	defer rqs.Unlock()
+
+	q, ok := rqs.queues[uid]
+	if !ok {
+		// nobody is waiting, return a channel that never fires
+		return nil
+	}
+	return q.notify
+}
+
+// signal wakes up requests waiting in the queue of the function.
+func (rqs *requestQueueSet) signal(uid k8stypes.UID) {
+	rqs.Lock()
+	defer rqs.Unlock()
+
+	q, ok := rqs.queues[uid]
+	if !ok {
+		return
+	}
+	close(q.notify)
+	q.notify = make(chan struct{})
+}
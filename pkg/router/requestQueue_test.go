@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestQueueSet(t *testing.T) {
+	rqs := makeRequestQueueSet()
+
+	length, ok := rqs.enter("foo", 2)
+	assert.True(t, ok)
+	assert.Equal(t, 1, length)
+
+	length, ok = rqs.enter("foo", 2)
+	assert.True(t, ok)
+	assert.Equal(t, 2, length)
+
+	// queue is full
+	_, ok = rqs.enter("foo", 2)
+	assert.False(t, ok)
+
+	// other functions have their own queue
+	_, ok = rqs.enter("bar", 2)
+	assert.True(t, ok)
+
+	// waiters are woken up by signal
+	ch := rqs.waitChan("foo")
+	go rqs.signal("foo")
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter is not notified")
+	}
+
+	assert.Equal(t, 1, rqs.leave("foo"))
+	assert.Equal(t, 0, rqs.leave("foo"))
+	assert.Nil(t, rqs.waitChan("foo"))
+
+	// signal without waiters is a no-op
+	rqs.signal("foo")
+}
@@ -19,14 +19,28 @@ package router
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	HEADERS_FISSION_FUNCTION_PREFIX = "Fission-Function"
+
+	// HEADERS_FISSION_AUTH_PREFIX is the prefix of headers carrying the
+	// verified identity of a request to the function.
+	HEADERS_FISSION_AUTH_PREFIX = "Fission-Auth"
+
+	// HEADER_REQUEST_ID is the header carrying the correlation ID of a request.
+	// It is passed to the function pod and returned to the client.
+	HEADER_REQUEST_ID = "X-Request-Id"
+
+	// HEADER_TRACEPARENT is the W3C trace context header, see
+	// https://www.w3.org/TR/trace-context/#traceparent-header
+	HEADER_TRACEPARENT = "traceparent"
 )
 
 // setFunctionMetadataToHeaders set function metadatas to request header
@@ -37,6 +51,42 @@ func setFunctionMetadataToHeader(meta *metav1.ObjectMeta, request *http.Request)
 	request.Header.Set(fmt.Sprintf("X-%s-ResourceVersion", HEADERS_FISSION_FUNCTION_PREFIX), meta.ResourceVersion)
 }
 
+// setAuthInfoToHeader sets the verified identity of a request to request header
+func setAuthInfoToHeader(info *authInfo, request *http.Request) {
+	request.Header.Set(fmt.Sprintf("X-%s-Type", HEADERS_FISSION_AUTH_PREFIX), string(info.authType))
+	if len(info.subject) > 0 {
+		request.Header.Set(fmt.Sprintf("X-%s-Subject", HEADERS_FISSION_AUTH_PREFIX), info.subject)
+	}
+	if len(info.issuer) > 0 {
+		request.Header.Set(fmt.Sprintf("X-%s-Issuer", HEADERS_FISSION_AUTH_PREFIX), info.issuer)
+	}
+	for k, v := range info.claims {
+		request.Header.Set(fmt.Sprintf("X-%s-Claim-%s", HEADERS_FISSION_AUTH_PREFIX, headerSafeName(k)), v)
+	}
+}
+
+// removeAuthInfoFromHeader removes auth headers sent by the client, so that
+// functions only see the identity verified by router.
+func removeAuthInfoFromHeader(request *http.Request) {
+	prefix := fmt.Sprintf("X-%s-", HEADERS_FISSION_AUTH_PREFIX)
+	for k := range request.Header {
+		if strings.HasPrefix(k, prefix) {
+			request.Header.Del(k)
+		}
+	}
+}
+
+// headerSafeName replaces characters not allowed in a header name, e.g. the
+// claim name "https://example.com/roles" becomes "https---example.com-roles".
+func headerSafeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '.' || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+}
+
 // setPathInfoToHeaders set URL path params and full URL path to request header
 func setPathInfoToHeader(request *http.Request) {
 	// retrieve url params and add them to request header
@@ -46,3 +96,27 @@ func setPathInfoToHeader(request *http.Request) {
 	}
 	request.Header.Set("X-Fission-Full-Url", request.URL.String())
 }
+
+// getRequestID returns the correlation ID of the request. An incoming X-Request-Id
+// is reused as-is; otherwise the trace-id of a valid traceparent header is used.
+// If neither exists, a new UUID is generated.
+func getRequestID(request *http.Request) string {
+	if id := strings.TrimSpace(request.Header.Get(HEADER_REQUEST_ID)); len(id) > 0 {
+		return id
+	}
+
+	// traceparent format: {version}-{trace-id}-{parent-id}-{trace-flags}
+	parts := strings.Split(strings.TrimSpace(request.Header.Get(HEADER_TRACEPARENT)), "-")
+	if len(parts) == 4 && len(parts[1]) == 32 && strings.Trim(parts[1], "0") != "" {
+		return parts[1]
+	}
+
+	return uuid.NewV4().String()
+}
+
+// setRequestIDToHeader makes sure the request carries a correlation ID and returns it
+func setRequestIDToHeader(request *http.Request) string {
+	id := getRequestID(request)
+	request.Header.Set(HEADER_REQUEST_ID, id)
+	return id
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRequestID(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://foobar.com", nil)
+	assert.Nil(t, err)
+
+	// generated when no header is given
+	id := getRequestID(req)
+	assert.NotEmpty(t, id)
+	assert.NotEqual(t, id, getRequestID(req))
+
+	// trace id of traceparent is reused
+	req.Header.Set(HEADER_TRACEPARENT, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", getRequestID(req))
+
+	// invalid traceparent is ignored
+	req.Header.Set(HEADER_TRACEPARENT, "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	assert.NotEqual(t, "00000000000000000000000000000000", getRequestID(req))
+
+	// X-Request-Id takes precedence
+	req.Header.Set(HEADER_REQUEST_ID, "foobar")
+	assert.Equal(t, "foobar", getRequestID(req))
+
+	assert.Equal(t, "foobar", setRequestIDToHeader(req))
+	assert.Equal(t, "foobar", req.Header.Get(HEADER_REQUEST_ID))
+}
@@ -0,0 +1,353 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bytes"
+	"container/list"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// HEADER_CACHE tells the client whether the response is served from router cache.
+	HEADER_CACHE = "X-Fission-Cache"
+
+	// response cache results of a request, used as metric label
+	cacheResultHit    = "hit"
+	cacheResultMiss   = "miss"
+	cacheResultBypass = "bypass"
+
+	// a single response larger than this fraction of the cache is not cached
+	responseCacheMaxEntryRatio = 16
+)
+
+type (
+	// responseCache is an in-memory LRU cache of function responses,
+	// bounded by the total size of cached responses.
+	responseCache struct {
+		lock          sync.Mutex
+		maxBytes      int64
+		maxEntryBytes int64
+		size          int64
+		entries       map[string]*list.Element
+		lru           *list.List
+	}
+
+	cachedResponse struct {
+		key        string
+		statusCode int
+		header     http.Header
+		body       []byte
+		ctime      time.Time
+		expiry     time.Time
+	}
+
+	// cacheRecorder passes the response through to the client and
+	// keeps a copy of it to be cached.
+	cacheRecorder struct {
+		http.ResponseWriter
+		maxBytes   int64
+		statusCode int
+		body       bytes.Buffer
+		overflow   bool
+	}
+)
+
+func makeResponseCache(maxBytes int64) *responseCache {
+	return &responseCache{
+		maxBytes:      maxBytes,
+		maxEntryBytes: maxBytes / responseCacheMaxEntryRatio,
+		entries:       make(map[string]*list.Element),
+		lru:           list.New(),
+	}
+}
+
+// get returns the cached response of the key if it's not expired.
+func (rc *responseCache) get(key string) (*cachedResponse, bool) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	elem, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cachedResponse)
+	if time.Now().After(entry.expiry) {
+		rc.remove(elem)
+		return nil, false
+	}
+	rc.lru.MoveToFront(elem)
+	return entry, true
+}
+
+// set caches the response, evicting the least recently used
+// responses if the cache is full.
+func (rc *responseCache) set(entry *cachedResponse) {
+	size := entry.size()
+	if size > rc.maxEntryBytes {
+		return
+	}
+
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	if elem, ok := rc.entries[entry.key]; ok {
+		rc.remove(elem)
+	}
+	for rc.size+size > rc.maxBytes && rc.lru.Len() > 0 {
+		rc.remove(rc.lru.Back())
+	}
+	rc.entries[entry.key] = rc.lru.PushFront(entry)
+	rc.size += size
+}
+
+func (rc *responseCache) remove(elem *list.Element) {
+	entry := rc.lru.Remove(elem).(*cachedResponse)
+	delete(rc.entries, entry.key)
+	rc.size -= entry.size()
+}
+
+func (entry *cachedResponse) size() int64 {
+	size := int64(len(entry.key) + len(entry.body))
+	for k, vs := range entry.header {
+		size += int64(len(k))
+		for _, v := range vs {
+			size += int64(len(v))
+		}
+	}
+	return size
+}
+
+// write sends the cached response to the client.
+func (entry *cachedResponse) write(rw http.ResponseWriter) {
+	for k, vs := range entry.header {
+		rw.Header()[k] = append([]string(nil), vs...)
+	}
+	rw.Header().Set("Age", strconv.Itoa(int(time.Since(entry.ctime).Seconds())))
+	rw.Header().Set(HEADER_CACHE, cacheResultHit)
+	rw.WriteHeader(entry.statusCode)
+	rw.Write(entry.body)
+}
+
+// getCacheKey builds the cache key of a request from the trigger, the function
+// chosen to serve it, request path, selected headers and query parameters. It
+// returns false if the response of the request should not be served from cache.
+func getCacheKey(trigger *fv1.HTTPTrigger, fn *fv1.Function, req *http.Request) (string, bool) {
+	if req.Method != http.MethodGet || isUpgradeRequest(req) {
+		return "", false
+	}
+
+	// client asks for a fresh response
+	cc := parseCacheControl(req.Header.Get("Cache-Control"))
+	if _, ok := cc["no-cache"]; ok {
+		return "", false
+	}
+	if _, ok := cc["no-store"]; ok {
+		return "", false
+	}
+	if maxAge, ok := cc["max-age"]; ok && maxAge == "0" {
+		return "", false
+	}
+
+	// responses of authenticated triggers may be personalized from the
+	// verified identity, which is not part of the key
+	if trigger.Spec.Auth != nil {
+		return "", false
+	}
+
+	config := trigger.Spec.Cache
+
+	// responses of credentialed requests may be personalized for the caller,
+	// they are only shared between callers with the same credentials
+	for _, h := range []string{"Authorization", "Cookie"} {
+		if len(req.Header.Get(h)) > 0 && !isKeyHeader(config, h) {
+			return "", false
+		}
+	}
+
+	var key strings.Builder
+	key.WriteString(string(trigger.ObjectMeta.UID))
+	// canary rules, weights and sticky sessions may pick another function,
+	// and an updated function may respond differently
+	key.WriteByte(0)
+	key.WriteString(string(fn.ObjectMeta.UID))
+	key.WriteByte(0)
+	key.WriteString(fn.ObjectMeta.ResourceVersion)
+	key.WriteByte(0)
+	key.WriteString(req.URL.Path)
+
+	for _, h := range config.Headers {
+		key.WriteByte(0)
+		key.WriteString(strings.Join(req.Header[http.CanonicalHeaderKey(h)], ","))
+	}
+
+	key.WriteByte(0)
+	if len(config.QueryParams) == 0 {
+		key.WriteString(req.URL.RawQuery)
+	} else {
+		query := req.URL.Query()
+		for _, q := range config.QueryParams {
+			key.WriteByte(0)
+			key.WriteString(strings.Join(query[q], ","))
+		}
+	}
+
+	return key.String(), true
+}
+
+// isKeyHeader returns true if the header is part of the cache key.
+func isKeyHeader(config *fv1.ResponseCache, header string) bool {
+	for _, h := range config.Headers {
+		if http.CanonicalHeaderKey(h) == header {
+			return true
+		}
+	}
+	return false
+}
+
+// getCacheTTL returns how long the response can be cached, honoring the
+// Cache-Control header of the response. 0 means the response is not cacheable.
+func getCacheTTL(config *fv1.ResponseCache, statusCode int, header http.Header) time.Duration {
+	if statusCode != http.StatusOK {
+		return 0
+	}
+	// responses personalized for a client are not shared
+	if len(header.Get("Set-Cookie")) > 0 {
+		return 0
+	}
+	// responses varying by request headers are only shared between requests
+	// with the same values of these headers, i.e. if they are part of the key
+	for _, vary := range header["Vary"] {
+		for _, h := range strings.Split(vary, ",") {
+			h = strings.TrimSpace(h)
+			if len(h) > 0 && (h == "*" || !isKeyHeader(config, http.CanonicalHeaderKey(h))) {
+				return 0
+			}
+		}
+	}
+
+	ttl := time.Duration(config.TTL) * time.Second
+	cc := parseCacheControl(header.Get("Cache-Control"))
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := cc[directive]; ok {
+			return 0
+		}
+	}
+	// s-maxage is meant for shared caches and overrides max-age
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		if v, ok := cc[directive]; ok {
+			seconds, err := strconv.Atoi(v)
+			if err != nil || seconds <= 0 {
+				return 0
+			}
+			if d := time.Duration(seconds) * time.Second; d < ttl {
+				ttl = d
+			}
+			break
+		}
+	}
+	return ttl
+}
+
+// parseCacheControl parses Cache-Control header into directives and their values.
+func parseCacheControl(cc string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(cc, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		name := strings.ToLower(strings.TrimSpace(kv[0]))
+		if len(kv) == 2 {
+			directives[name] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		} else {
+			directives[name] = ""
+		}
+	}
+	return directives
+}
+
+func makeCacheRecorder(rw http.ResponseWriter, maxBytes int64) *cacheRecorder {
+	return &cacheRecorder{
+		ResponseWriter: rw,
+		maxBytes:       maxBytes,
+		statusCode:     http.StatusOK,
+	}
+}
+
+func (r *cacheRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *cacheRecorder) Write(p []byte) (int, error) {
+	if !r.overflow {
+		if int64(r.body.Len()+len(p)) > r.maxBytes {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(p)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *cacheRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// response returns the recorded response to be cached, or false if the
+// response cannot be cached.
+func (r *cacheRecorder) response(key string, config *fv1.ResponseCache) (*cachedResponse, bool) {
+	if r.overflow {
+		return nil, false
+	}
+	header := r.Header()
+	ttl := getCacheTTL(config, r.statusCode, header)
+	if ttl <= 0 {
+		return nil, false
+	}
+
+	cachedHeader := make(http.Header, len(header))
+	for k, vs := range header {
+		switch k {
+		case HEADER_REQUEST_ID, HEADER_CACHE:
+			// specific to the request that filled the cache
+			continue
+		}
+		cachedHeader[k] = append([]string(nil), vs...)
+	}
+
+	now := time.Now()
+	return &cachedResponse{
+		key:        key,
+		statusCode: r.statusCode,
+		header:     cachedHeader,
+		body:       append([]byte(nil), r.body.Bytes()...),
+		ctime:      now,
+		expiry:     now.Add(ttl),
+	}, true
+}
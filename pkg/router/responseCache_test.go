@@ -0,0 +1,181 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+func TestResponseCache(t *testing.T) {
+	rc := makeResponseCache(1600)
+	entry := func(key string, body string) *cachedResponse {
+		return &cachedResponse{
+			key:        key,
+			statusCode: http.StatusOK,
+			body:       []byte(body),
+			ctime:      time.Now(),
+			expiry:     time.Now().Add(time.Minute),
+		}
+	}
+
+	rc.set(entry("a", "hello"))
+	e, ok := rc.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "hello", string(e.body))
+
+	// entry larger than the per-entry limit is not cached
+	rc.set(entry("large", string(make([]byte, 200))))
+	_, ok = rc.get("large")
+	assert.False(t, ok)
+
+	// least recently used entry is evicted once the cache is full
+	for _, key := range []string{"b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q"} {
+		rc.set(entry(key, string(make([]byte, 99))))
+		rc.get("a")
+	}
+	_, ok = rc.get("a")
+	assert.True(t, ok)
+	_, ok = rc.get("b")
+	assert.False(t, ok)
+	assert.True(t, rc.size <= rc.maxBytes)
+
+	// expired entry is removed
+	expired := entry("expired", "bye")
+	expired.expiry = time.Now().Add(-time.Second)
+	rc.set(expired)
+	_, ok = rc.get("expired")
+	assert.False(t, ok)
+}
+
+func TestGetCacheKey(t *testing.T) {
+	trigger := &fv1.HTTPTrigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "foo-uid"},
+		Spec: fv1.HTTPTriggerSpec{
+			Cache: &fv1.ResponseCache{TTL: 60, Headers: []string{"accept-language"}},
+		},
+	}
+
+	fn := &fv1.Function{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "foo-fn-uid", ResourceVersion: "1"}}
+
+	req := httptest.NewRequest(http.MethodGet, "/foo?a=1", nil)
+	req.Header.Set("Accept-Language", "en")
+	enKey, ok := getCacheKey(trigger, fn, req)
+	assert.True(t, ok)
+
+	// responses of the canary or an updated function are not shared
+	canary := &fv1.Function{ObjectMeta: metav1.ObjectMeta{Name: "foo-v2", UID: "foo-v2-uid", ResourceVersion: "1"}}
+	canaryKey, _ := getCacheKey(trigger, canary, req)
+	assert.NotEqual(t, enKey, canaryKey)
+	updated := fn.DeepCopy()
+	updated.ObjectMeta.ResourceVersion = "2"
+	updatedKey, _ := getCacheKey(trigger, updated, req)
+	assert.NotEqual(t, enKey, updatedKey)
+
+	req.Header.Set("Accept-Language", "de")
+	deKey, _ := getCacheKey(trigger, fn, req)
+	assert.NotEqual(t, enKey, deKey)
+
+	// only selected query params are part of the key
+	trigger.Spec.Cache.QueryParams = []string{"a"}
+	k1, _ := getCacheKey(trigger, fn, httptest.NewRequest(http.MethodGet, "/foo?a=1&b=1", nil))
+	k2, _ := getCacheKey(trigger, fn, httptest.NewRequest(http.MethodGet, "/foo?b=2&a=1", nil))
+	assert.Equal(t, k1, k2)
+
+	_, ok = getCacheKey(trigger, fn, httptest.NewRequest(http.MethodPost, "/foo", nil))
+	assert.False(t, ok)
+
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("Cache-Control", "no-cache")
+	_, ok = getCacheKey(trigger, fn, req)
+	assert.False(t, ok)
+
+	// credentialed requests are not shared between callers
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("Authorization", "Bearer alice")
+	_, ok = getCacheKey(trigger, fn, req)
+	assert.False(t, ok)
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("Cookie", "session=alice")
+	_, ok = getCacheKey(trigger, fn, req)
+	assert.False(t, ok)
+
+	// unless the credentials are part of the key
+	trigger.Spec.Cache.Headers = append(trigger.Spec.Cache.Headers, "authorization")
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("Authorization", "Bearer alice")
+	aliceKey, ok := getCacheKey(trigger, fn, req)
+	assert.True(t, ok)
+	req.Header.Set("Authorization", "Bearer bob")
+	bobKey, ok := getCacheKey(trigger, fn, req)
+	assert.True(t, ok)
+	assert.NotEqual(t, aliceKey, bobKey)
+
+	// responses of authenticated triggers are never shared
+	trigger.Spec.Auth = &fv1.TriggerAuth{Type: fv1.TriggerAuthTypeAPIKey, SecretName: "keys"}
+	req = httptest.NewRequest(http.MethodGet, "/foo", nil)
+	req.Header.Set("X-Api-Key", "key-a")
+	_, ok = getCacheKey(trigger, fn, req)
+	assert.False(t, ok)
+}
+
+func TestGetCacheTTL(t *testing.T) {
+	config := &fv1.ResponseCache{TTL: 60}
+
+	assert.Equal(t, 60*time.Second, getCacheTTL(config, http.StatusOK, http.Header{}))
+	assert.Equal(t, time.Duration(0), getCacheTTL(config, http.StatusNotFound, http.Header{}))
+	assert.Equal(t, 10*time.Second, getCacheTTL(config, http.StatusOK, http.Header{"Cache-Control": {"public, max-age=10"}}))
+	assert.Equal(t, 5*time.Second, getCacheTTL(config, http.StatusOK, http.Header{"Cache-Control": {"max-age=10, s-maxage=5"}}))
+	assert.Equal(t, 60*time.Second, getCacheTTL(config, http.StatusOK, http.Header{"Cache-Control": {"max-age=3600"}}))
+	assert.Equal(t, time.Duration(0), getCacheTTL(config, http.StatusOK, http.Header{"Cache-Control": {"no-store"}}))
+	assert.Equal(t, time.Duration(0), getCacheTTL(config, http.StatusOK, http.Header{"Set-Cookie": {"session=1"}}))
+	assert.Equal(t, time.Duration(0), getCacheTTL(config, http.StatusOK, http.Header{"Vary": {"*"}}))
+	assert.Equal(t, time.Duration(0), getCacheTTL(config, http.StatusOK, http.Header{"Vary": {"Accept-Encoding"}}))
+
+	// unless the request headers it varies by are part of the key
+	config.Headers = []string{"accept-encoding", "Accept-Language"}
+	assert.Equal(t, 60*time.Second, getCacheTTL(config, http.StatusOK, http.Header{"Vary": {"accept-encoding, Accept-Language"}}))
+}
+
+func TestCacheRecorder(t *testing.T) {
+	rw := httptest.NewRecorder()
+	recorder := makeCacheRecorder(rw, 1024)
+	recorder.Header().Set("Content-Type", "text/plain")
+	recorder.Header().Set(HEADER_REQUEST_ID, "req-1")
+	recorder.WriteHeader(http.StatusOK)
+	recorder.Write([]byte("hello"))
+
+	entry, ok := recorder.response("key", &fv1.ResponseCache{TTL: 60})
+	assert.True(t, ok)
+	assert.Equal(t, "hello", string(entry.body))
+	assert.Equal(t, "text/plain", entry.header.Get("Content-Type"))
+	assert.Empty(t, entry.header.Get(HEADER_REQUEST_ID))
+
+	// cached response is written with cache headers
+	rw = httptest.NewRecorder()
+	entry.write(rw)
+	assert.Equal(t, "hello", rw.Body.String())
+	assert.Equal(t, cacheResultHit, rw.Header().Get(HEADER_CACHE))
+	assert.Equal(t, "0", rw.Header().Get("Age"))
+}
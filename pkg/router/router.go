@@ -53,6 +53,8 @@ import (
 	"go.opencensus.io/plugin/ochttp"
 	"go.opencensus.io/trace"
 	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/fission/fission/pkg/crd"
 	executorClient "github.com/fission/fission/pkg/executor/client"
@@ -83,7 +85,9 @@ func serve(ctx context.Context, logger *zap.Logger, port int, tracingSamplingRat
 	mr := router(ctx, logger, httpTriggerSet, resolver)
 	url := fmt.Sprintf(":%v", port)
 
-	http.ListenAndServe(url, &ochttp.Handler{
+	// h2c handler serves HTTP/2 cleartext requests (e.g. gRPC calls) and
+	// passes through HTTP/1.x requests as-is.
+	http.ListenAndServe(url, h2c.NewHandler(&ochttp.Handler{
 		Handler: mr,
 		GetStartOptions: func(r *http.Request) trace.StartOptions {
 			// do not trace router healthz endpoint
@@ -100,12 +104,15 @@ func serve(ctx context.Context, logger *zap.Logger, port int, tracingSamplingRat
 				Sampler: trace.ProbabilitySampler(tracingSamplingRate),
 			}
 		},
-	})
+	}, &http2.Server{}))
 }
 
-func serveMetric(logger *zap.Logger) {
+func serveMetric(logger *zap.Logger, triggers *HTTPTriggerSet) {
 	// Expose the registered metrics via HTTP.
 	http.Handle("/metrics", promhttp.Handler())
+	// Debug endpoint showing the state of function circuit breakers,
+	// on the internal metrics port rather than the public router port.
+	http.HandleFunc("/router-debug/circuitbreakers", triggers.circuitBreakers.debugHandler)
 	err := http.ListenAndServe(metricAddr, nil)
 
 	logger.Fatal("done listening on metrics endpoint", zap.Error(err))
@@ -200,6 +207,39 @@ func Start(logger *zap.Logger, port int, executorUrl string) {
 			zap.Duration("default", svcAddrUpdateTimeout))
 	}
 
+	// queueMaxLength is the max number of requests of a function waiting for a function service.
+	queueMaxLengthStr := os.Getenv("ROUTER_QUEUE_MAX_LENGTH")
+	queueMaxLength, err := strconv.Atoi(queueMaxLengthStr)
+	if err != nil {
+		queueMaxLength = 0
+		logger.Error("failed to parse request queue max length from 'ROUTER_QUEUE_MAX_LENGTH' - set to the default value",
+			zap.Error(err),
+			zap.String("value", queueMaxLengthStr),
+			zap.Int("default", queueMaxLength))
+	}
+
+	// queueMaxWait is the max time for a request to wait for a function service in the queue.
+	queueMaxWaitStr := os.Getenv("ROUTER_QUEUE_MAX_WAIT")
+	queueMaxWait, err := time.ParseDuration(queueMaxWaitStr)
+	if err != nil {
+		queueMaxWait = 30 * time.Second
+		logger.Error("failed to parse request queue max wait duration from 'ROUTER_QUEUE_MAX_WAIT' - set to the default value",
+			zap.Error(err),
+			zap.String("value", queueMaxWaitStr),
+			zap.Duration("default", queueMaxWait))
+	}
+
+	// responseCacheSize is the max total size in bytes of cached function responses.
+	responseCacheSizeStr := os.Getenv("ROUTER_RESPONSE_CACHE_SIZE")
+	responseCacheSize, err := strconv.ParseInt(responseCacheSizeStr, 10, 64)
+	if err != nil {
+		responseCacheSize = 64 * 1024 * 1024
+		logger.Error("failed to parse response cache size from 'ROUTER_RESPONSE_CACHE_SIZE' - set to the default value",
+			zap.Error(err),
+			zap.String("value", responseCacheSizeStr),
+			zap.Int64("default", responseCacheSize))
+	}
+
 	tracingSamplingRateStr := os.Getenv("TRACING_SAMPLING_RATE")
 	tracingSamplingRate, err := strconv.ParseFloat(tracingSamplingRateStr, 64)
 	if err != nil {
@@ -220,6 +260,17 @@ func Start(logger *zap.Logger, port int, executorUrl string) {
 			zap.Bool("default", displayAccessLog))
 	}
 
+	// trustedProxies are the load balancers in front of router, whose
+	// X-Forwarded-For header is used to find the client IP of requests.
+	trustedProxiesStr := os.Getenv("ROUTER_TRUSTED_PROXIES")
+	trustedProxies, err := parseTrustedProxies(trustedProxiesStr)
+	if err != nil {
+		trustedProxies = nil
+		logger.Error("failed to parse 'ROUTER_TRUSTED_PROXIES' - X-Forwarded-For is ignored",
+			zap.Error(err),
+			zap.String("value", trustedProxiesStr))
+	}
+
 	triggers, _, fnStore := makeHTTPTriggerSet(logger.Named("triggerset"), fmap, fissionClient, kubeClient, executor, fissionClient.CoreV1().RESTClient(), &tsRoundTripperParams{
 		timeout:           timeout,
 		timeoutExponent:   timeoutExponent,
@@ -227,11 +278,13 @@ func Start(logger *zap.Logger, port int, executorUrl string) {
 		keepAliveTime:     keepAliveTime,
 		maxRetries:        maxRetries,
 		svcAddrRetryCount: svcAddrRetryCount,
-	}, isDebugEnv, throttler.MakeThrottler(svcAddrUpdateTimeout))
+		queueMaxLength:    queueMaxLength,
+		queueMaxWait:      queueMaxWait,
+	}, isDebugEnv, throttler.MakeThrottler(svcAddrUpdateTimeout), responseCacheSize, trustedProxies)
 
 	resolver := makeFunctionReferenceResolver(fnStore)
 
-	go serveMetric(logger)
+	go serveMetric(logger, triggers)
 
 	logger.Info("starting router", zap.Int("port", port))
 	ctx, cancel := context.WithCancel(context.Background())
@@ -0,0 +1,364 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	fv1 "github.com/fission/fission/pkg/apis/core/v1"
+)
+
+const (
+	// default interval for router to ping an idle streaming connection
+	defaultStreamingPingInterval = 30 * time.Second
+
+	// interval for router to tap the function service of a streaming session,
+	// so that executor doesn't consider the service idle and recycle it
+	streamingTapInterval = 30 * time.Second
+)
+
+var (
+	// WebSocket ping frame without payload, see https://tools.ietf.org/html/rfc6455#section-5.5.2
+	wsPingFrame = []byte{0x89, 0x00}
+
+	// SSE comment line ignored by clients, see https://html.spec.whatwg.org/multipage/server-sent-events.html
+	ssePingEvent = []byte(": ping\n\n")
+)
+
+type (
+	// streamingSession keeps the function service of a long-lived
+	// connection (WebSocket or SSE) tapped until the connection closes.
+	streamingSession struct {
+		fh         *functionHandler
+		done       chan struct{}
+		serviceUrl *url.URL
+	}
+
+	// streamingResponseWriter sends pings to the client when a
+	// WebSocket or SSE connection is idle, to keep intermediate
+	// proxies and load balancers from closing it.
+	streamingResponseWriter struct {
+		http.ResponseWriter
+		logger       *zap.Logger
+		pingInterval time.Duration
+		done         chan struct{}
+
+		lock      sync.Mutex
+		lastWrite time.Time
+		isSSE     bool
+		sseTail   []byte // last two bytes written to SSE stream
+	}
+
+	// wsPingConn is the hijacked client connection of a WebSocket session.
+	// It tracks the frame boundary of data sent to client, so that ping
+	// frames are never injected into the middle of a frame.
+	wsPingConn struct {
+		net.Conn
+		lock      sync.Mutex
+		tracker   wsFrameTracker
+		lastWrite time.Time
+	}
+
+	// wsFrameTracker parses the byte stream sent from function to client.
+	wsFrameTracker struct {
+		// number of bytes of "\r\n\r\n" matched at the end of HTTP 101 response
+		headerMatched int
+		headerDone    bool
+		frameHeader   []byte
+		remaining     uint64
+	}
+)
+
+func (fh functionHandler) isStreaming() bool {
+	return fh.httpTrigger != nil && fh.httpTrigger.Spec.Streaming != nil
+}
+
+func (fh functionHandler) streamingPingInterval() time.Duration {
+	if fh.httpTrigger.Spec.Streaming.PingInterval > 0 {
+		return time.Duration(fh.httpTrigger.Spec.Streaming.PingInterval) * time.Second
+	}
+	return defaultStreamingPingInterval
+}
+
+// isUpgradeRequest returns true if client asks to switch protocol, e.g. WebSocket.
+func isUpgradeRequest(req *http.Request) bool {
+	if len(req.Header.Get("Upgrade")) == 0 {
+		return false
+	}
+	for _, v := range strings.Split(req.Header.Get("Connection"), ",") {
+		if strings.EqualFold(strings.TrimSpace(v), "upgrade") {
+			return true
+		}
+	}
+	return false
+}
+
+func makeStreamingSession(fh *functionHandler) *streamingSession {
+	return &streamingSession{
+		fh:   fh,
+		done: make(chan struct{}),
+	}
+}
+
+// start keeps the function service tapped until the session ends.
+// It must be called at most once.
+func (s *streamingSession) start(serviceUrl *url.URL) {
+	s.serviceUrl = serviceUrl
+	go func() {
+		ticker := time.NewTicker(streamingTapInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.fh.tapService(s.fh.function, serviceUrl)
+			case <-s.done:
+				if s.fh.function.Spec.InvokeStrategy.ExecutionStrategy.ExecutorType == fv1.ExecutorTypePoolmgr {
+					s.fh.unTapService(s.fh.function, serviceUrl)
+				}
+				return
+			}
+		}
+	}()
+}
+
+// owns returns true if the service is held by the session.
+func (s *streamingSession) owns(serviceUrl *url.URL) bool {
+	return s != nil && s.serviceUrl != nil && s.serviceUrl == serviceUrl
+}
+
+// end releases the function service of the session.
+func (s *streamingSession) end() {
+	close(s.done)
+}
+
+func makeStreamingResponseWriter(logger *zap.Logger, rw http.ResponseWriter, pingInterval time.Duration) *streamingResponseWriter {
+	return &streamingResponseWriter{
+		ResponseWriter: rw,
+		logger:         logger,
+		pingInterval:   pingInterval,
+		done:           make(chan struct{}),
+		lastWrite:      time.Now(),
+	}
+}
+
+func (w *streamingResponseWriter) WriteHeader(statusCode int) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.isSSE = strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+	w.ResponseWriter.WriteHeader(statusCode)
+	if w.isSSE {
+		go w.pingSSE()
+	}
+}
+
+func (w *streamingResponseWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	n, err := w.ResponseWriter.Write(p)
+	w.lastWrite = time.Now()
+	if w.isSSE && n > 0 {
+		w.sseTail = append(w.sseTail, p[:n]...)
+		if len(w.sseTail) > 2 {
+			w.sseTail = w.sseTail[len(w.sseTail)-2:]
+		}
+	}
+	return n, err
+}
+
+func (w *streamingResponseWriter) Flush() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack takes over the client connection of an upgraded request, and
+// starts to ping the client when the WebSocket connection is idle.
+func (w *streamingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pc := &wsPingConn{
+		Conn:      conn,
+		lastWrite: time.Now(),
+	}
+	go pc.ping(w.logger, w.pingInterval, w.done)
+
+	// keep data already buffered from client, but write through
+	// the ping conn to track the frame boundary.
+	return pc, bufio.NewReadWriter(brw.Reader, bufio.NewWriter(pc)), nil
+}
+
+// close stops the pinger.
+func (w *streamingResponseWriter) close() {
+	close(w.done)
+}
+
+// pingSSE writes SSE comments to client when the event stream is idle.
+func (w *streamingResponseWriter) pingSSE() {
+	ticker := time.NewTicker(w.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.lock.Lock()
+			// only ping between two events
+			atBoundary := len(w.sseTail) == 0 || string(w.sseTail) == "\n\n"
+			if atBoundary && time.Since(w.lastWrite) >= w.pingInterval {
+				_, err := w.ResponseWriter.Write(ssePingEvent)
+				if f, ok := w.ResponseWriter.(http.Flusher); ok && err == nil {
+					f.Flush()
+				}
+				w.lastWrite = time.Now()
+				if err != nil {
+					w.lock.Unlock()
+					w.logger.Debug("error pinging event stream", zap.Error(err))
+					return
+				}
+			}
+			w.lock.Unlock()
+		}
+	}
+}
+
+func (c *wsPingConn) Write(p []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	n, err := c.Conn.Write(p)
+	c.tracker.observe(p[:n])
+	c.lastWrite = time.Now()
+	return n, err
+}
+
+// ping writes ping frames to client when the WebSocket connection is idle.
+func (c *wsPingConn) ping(logger *zap.Logger, interval time.Duration, done chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			c.lock.Lock()
+			if c.tracker.atBoundary() && time.Since(c.lastWrite) >= interval {
+				_, err := c.Conn.Write(wsPingFrame)
+				c.lastWrite = time.Now()
+				if err != nil {
+					c.lock.Unlock()
+					logger.Debug("error pinging websocket connection", zap.Error(err))
+					return
+				}
+			}
+			c.lock.Unlock()
+		}
+	}
+}
+
+// observe updates the frame state with bytes sent to client.
+func (t *wsFrameTracker) observe(p []byte) {
+	const headerEnd = "\r\n\r\n"
+
+	for i := 0; i < len(p); {
+		switch {
+		case !t.headerDone:
+			// skip the HTTP 101 response written before the frames
+			if p[i] == headerEnd[t.headerMatched] {
+				t.headerMatched++
+			} else if p[i] == '\r' {
+				t.headerMatched = 1
+			} else {
+				t.headerMatched = 0
+			}
+			t.headerDone = t.headerMatched == len(headerEnd)
+			i++
+		case t.remaining > 0:
+			n := uint64(len(p) - i)
+			if n > t.remaining {
+				n = t.remaining
+			}
+			t.remaining -= n
+			i += int(n)
+		default:
+			t.frameHeader = append(t.frameHeader, p[i])
+			i++
+			if l := wsFrameHeaderLength(t.frameHeader); l > 0 && len(t.frameHeader) == l {
+				t.remaining = wsFramePayloadLength(t.frameHeader)
+				t.frameHeader = t.frameHeader[:0]
+			}
+		}
+	}
+}
+
+// atBoundary returns true if no frame is partially sent.
+func (t *wsFrameTracker) atBoundary() bool {
+	return t.headerDone && t.remaining == 0 && len(t.frameHeader) == 0
+}
+
+// wsFrameHeaderLength returns the length of a frame header, or 0
+// if there are not enough bytes to know it yet.
+func wsFrameHeaderLength(header []byte) int {
+	if len(header) < 2 {
+		return 0
+	}
+	l := 2
+	switch header[1] & 0x7f {
+	case 126:
+		l += 2
+	case 127:
+		l += 8
+	}
+	// masking key
+	if header[1]&0x80 != 0 {
+		l += 4
+	}
+	return l
+}
+
+// wsFramePayloadLength returns the payload length of a complete frame header.
+func wsFramePayloadLength(header []byte) uint64 {
+	switch l := header[1] & 0x7f; l {
+	case 126:
+		return uint64(binary.BigEndian.Uint16(header[2:4]))
+	case 127:
+		return binary.BigEndian.Uint64(header[2:10])
+	default:
+		return uint64(l)
+	}
+}
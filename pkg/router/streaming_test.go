@@ -0,0 +1,91 @@
+/*
+Copyright 2020 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestIsUpgradeRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	assert.False(t, isUpgradeRequest(req))
+
+	req.Header.Set("Upgrade", "websocket")
+	assert.False(t, isUpgradeRequest(req))
+
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	assert.True(t, isUpgradeRequest(req))
+}
+
+func TestWSFrameTracker(t *testing.T) {
+	tracker := &wsFrameTracker{}
+	assert.False(t, tracker.atBoundary())
+
+	// upgrade response written in two parts
+	tracker.observe([]byte("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\n\r"))
+	assert.False(t, tracker.atBoundary())
+	tracker.observe([]byte("\n"))
+	assert.True(t, tracker.atBoundary())
+
+	// text frame "hello" split in the middle of payload
+	tracker.observe([]byte{0x81, 0x05, 'h', 'e'})
+	assert.False(t, tracker.atBoundary())
+	tracker.observe([]byte{'l', 'l', 'o'})
+	assert.True(t, tracker.atBoundary())
+
+	// binary frame with 16-bit extended payload length, header split
+	tracker.observe([]byte{0x82, 126})
+	assert.False(t, tracker.atBoundary())
+	tracker.observe([]byte{0x00, 0x80})
+	tracker.observe(make([]byte, 0x80))
+	assert.True(t, tracker.atBoundary())
+
+	// two frames in one write
+	tracker.observe([]byte{0x81, 0x01, 'a', 0x81, 0x02, 'b'})
+	assert.False(t, tracker.atBoundary())
+	tracker.observe([]byte{'c'})
+	assert.True(t, tracker.atBoundary())
+}
+
+func TestStreamingResponseWriterSSEPing(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := makeStreamingResponseWriter(zap.NewNop(), rec, 50*time.Millisecond)
+	defer w.close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+
+	// pings are not injected into a partially written event
+	w.Write([]byte("data: foo\n"))
+	time.Sleep(200 * time.Millisecond)
+	w.lock.Lock()
+	assert.NotContains(t, rec.Body.String(), string(ssePingEvent))
+	w.lock.Unlock()
+
+	w.Write([]byte("\n"))
+	time.Sleep(200 * time.Millisecond)
+	w.lock.Lock()
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "data: foo\n\n: ping\n\n"))
+	w.lock.Unlock()
+}
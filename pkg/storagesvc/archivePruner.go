@@ -73,6 +73,7 @@ func (pruner *ArchivePruner) insertArchive(archiveID string) {
 
 // A user may have deleted pkgs with kubectl or fission cli. That only deletes crd.Package objects from kubernetes
 // and not the archives that are referenced by them, leaving the archives as orphans.
+// Archives of package revisions dropped by the builder manager become orphans the same way.
 // getOrphanArchives reaps the orphaned archives.
 func (pruner *ArchivePruner) getOrphanArchives() {
 	pruner.logger.Info("getting orphan archives")
@@ -108,6 +109,21 @@ func (pruner *ArchivePruner) getOrphanArchives() {
 			}
 			archivesRefByPkgs = append(archivesRefByPkgs, archiveID)
 		}
+		// archives of retained revisions are kept for functions to be rolled back to
+		for _, revision := range pkg.Status.Revisions {
+			if revision.Deployment.URL == "" {
+				continue
+			}
+			archiveID, err = getQueryParamValue(revision.Deployment.URL, "id")
+			if err != nil {
+				pruner.logger.Error("error extracting value of archiveID from revision deployment url",
+					zap.Error(err),
+					zap.String("url", revision.Deployment.URL),
+					zap.Int("revision", revision.Revision))
+				return
+			}
+			archivesRefByPkgs = append(archivesRefByPkgs, archiveID)
+		}
 	}
 
 	pruner.logger.Debug("archives referenced by packagese", zap.Strings("archives", archivesRefByPkgs))
@@ -160,6 +160,29 @@ func (c *Client) Download(ctx context.Context, id string, filePath string) error
 	return nil
 }
 
+// Exists returns whether the file identified by ID is in the storage service.
+func (c *Client) Exists(ctx context.Context, id string) (bool, error) {
+	req, err := http.NewRequest(http.MethodHead, c.GetUrl(id), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := ctxhttp.Do(ctx, c.httpClient, req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, errors.Errorf("HTTP error %v", resp.StatusCode)
+	}
+}
+
 func (c *Client) Delete(ctx context.Context, id string) error {
 	url := c.GetUrl(id)
 
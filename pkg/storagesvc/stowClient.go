@@ -152,6 +152,18 @@ func (client *StowClient) copyFileToStream(fileId string, w io.Writer) error {
 	return nil
 }
 
+// itemExists returns whether the file exists in storage
+func (client *StowClient) itemExists(fileId string) (bool, error) {
+	_, err := client.container.Item(fileId)
+	if err == stow.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, ErrRetrievingItem
+	}
+	return true, nil
+}
+
 // removeFileByID deletes the file from storage
 func (client *StowClient) removeFileByID(itemID string) error {
 	return client.container.RemoveItem(itemID)